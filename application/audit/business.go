@@ -0,0 +1,194 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/audit"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/audit"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const (
+	// defaultVerifyBatch is the number of events read at once when verifying the audit log without configuration
+	defaultVerifyBatch uint64 = 1000
+	// chainBatch is the number of events chained in each transaction
+	chainBatch uint64 = 500
+)
+
+// Record adds an event to the audit log inside the transaction of the action, so that the event is only
+// kept when the action is committed. The event is chained later by the sequencer, so that the transactions
+// of the actions are not serialized by the hash chain
+func Record(tx *database.Transaction, event *domain.Event) (err error) {
+	return infra.NewAuditRepository(tx).Add(event)
+}
+
+// Sequence is the business logic of the sequencer, which adds the events recorded since the last run
+// to the end of the hash chain, in the order they were recorded
+func Sequence(ctx context.Context) (err error) {
+	for {
+		var chained uint64
+		if chained, err = sequence(ctx); err != nil || chained < chainBatch {
+			return err
+		}
+	}
+}
+
+// sequence chains a batch of events, returning the number of events chained
+func sequence(ctx context.Context) (chained uint64, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return 0, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoAudit := infra.NewAuditRepository(tx)
+
+	var last *domain.Link
+	if last, err = repoAudit.Last(true); err != nil {
+		return 0, oops.Err(err)
+	}
+
+	var events []domain.Event
+	if events, err = repoAudit.Unchained(chainBatch); err != nil {
+		return 0, oops.Err(err)
+	}
+
+	for i := range events {
+		last = events[i].Chain(last)
+		if err = repoAudit.Link(&events[i]); err != nil {
+			return 0, oops.Err(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, oops.Err(err)
+	}
+
+	return uint64(len(events)), nil
+}
+
+// Checkpoint is the business logic to record a checkpoint of the end of the hash chain,
+// signed with the key of the server when configured
+func Checkpoint(ctx context.Context) (err error) {
+	var key ed25519.PrivateKey
+	if key, err = domain.ParseSigningKey(config.Get().Audit.SigningKey); err != nil {
+		return oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoAudit := infra.NewAuditRepository(tx)
+
+	var last *domain.Link
+	if last, err = repoAudit.Last(false); err != nil {
+		return oops.Err(err)
+	}
+
+	if last == nil {
+		return
+	}
+
+	var checkpoints []domain.Checkpoint
+	if checkpoints, err = repoAudit.Checkpoints(); err != nil {
+		return oops.Err(err)
+	}
+
+	if len(checkpoints) > 0 && *checkpoints[len(checkpoints)-1].Seq == last.Seq {
+		return
+	}
+
+	if err = repoAudit.AddCheckpoint(domain.NewCheckpoint(last, key)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Verify is the business logic to walk the hash chain of the audit log and its checkpoints,
+// reporting the first record that was tampered
+func Verify(ctx context.Context) (res *domain.Verification, err error) {
+	var key ed25519.PrivateKey
+	if key, err = domain.ParseSigningKey(config.Get().Audit.SigningKey); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var publicKey ed25519.PublicKey
+	if key != nil {
+		publicKey = key.Public().(ed25519.PublicKey)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoAudit := infra.NewAuditRepository(tx)
+
+	var checkpoints []domain.Checkpoint
+	if checkpoints, err = repoAudit.Checkpoints(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	batch := config.Get().Audit.VerifyBatch
+	if batch == 0 {
+		batch = defaultVerifyBatch
+	}
+
+	verifier := domain.NewVerifier(checkpoints, publicKey)
+	for after := int64(0); ; {
+		var events []domain.Event
+		if events, err = repoAudit.Chain(after, batch); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		for i := range events {
+			if !verifier.Check(&events[i]) {
+				return verifier.Result(), nil
+			}
+		}
+
+		if uint64(len(events)) < batch {
+			break
+		}
+		after = *events[len(events)-1].Seq
+	}
+
+	return verifier.Result(), nil
+}
+
+// List is the business logic to fetch the events of the audit log
+func List(ctx context.Context, params *utils.Params) (res *domain.Events, err error) {
+	if err = domain.ValidateParams(params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	res = new(domain.Events)
+	if res.Events, res.Next, err = infra.NewAuditRepository(tx).List(params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
@@ -6,11 +6,28 @@ package auth
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/outbox"
+	accessPolicy "github.com/isaqueveras/powersso/application/policy"
+	"github.com/isaqueveras/powersso/application/webhook"
+	"github.com/isaqueveras/powersso/config"
 	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/directory"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
 	domain "github.com/isaqueveras/powersso/domain/auth"
+	domainOutbox "github.com/isaqueveras/powersso/domain/outbox"
+	domainPolicy "github.com/isaqueveras/powersso/domain/policy"
+	domainProject "github.com/isaqueveras/powersso/domain/project"
+	domainWebhook "github.com/isaqueveras/powersso/domain/webhook"
+	"github.com/isaqueveras/powersso/i18n"
 	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	infraProject "github.com/isaqueveras/powersso/infrastructure/persistencie/project"
+	"github.com/isaqueveras/powersso/mail"
 	"github.com/isaqueveras/powersso/oops"
 	"github.com/isaqueveras/powersso/tokens"
 	"github.com/isaqueveras/powersso/utils"
@@ -24,32 +41,60 @@ func CreateAccount(ctx context.Context, in *domain.CreateAccount) (url *string,
 	}
 	defer tx.Rollback()
 
-	if err = in.Prepare(); err != nil {
+	var userID *uuid.UUID
+	if userID, url, err = Register(tx, in); err != nil {
 		return nil, oops.Err(err)
 	}
 
-	userRepository := infra.NewUserRepository(tx)
-	if err = userRepository.AccountExists(in.Email); err != nil {
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionCreateAccount).
+		Actor(userID).Target(domainAudit.TargetUser, userID)); err != nil {
 		return nil, oops.Err(err)
 	}
 
-	authRepository := infra.NewAuthRepository(tx)
-	var userID *uuid.UUID
-	if userID, err = authRepository.CreateAccount(in); err != nil {
+	if err = tx.Commit(); err != nil {
 		return nil, oops.Err(err)
 	}
 
+	return
+}
+
+// Register creates the account of the user in the transaction, configuring the 2FA and notifying
+// the creation of the user. It returns the url of the qrcode to enroll the 2FA
+func Register(tx *database.Transaction, in *domain.CreateAccount) (userID *uuid.UUID, url *string, err error) {
+	if err = in.Validate(); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	if err = in.Prepare(); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	if err = infra.NewUserRepository(tx).AccountExists(in.Email); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	if userID, err = infra.NewAuthRepository(tx).CreateAccount(in); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
 	service := domain.NewAuthService(infra.NewFlagRepo(tx), infra.NewOTPRepo(tx))
 	if err = service.Configure2FA(userID); err != nil {
-		return nil, oops.Err(err)
+		return nil, nil, oops.Err(err)
 	}
 
 	if url, err = service.GenerateQrCode2FA(userID); err != nil {
-		return nil, oops.Err(err)
+		return nil, nil, oops.Err(err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, oops.Err(err)
+	// the event is sent to the webhooks of all projects, so it only identifies the user
+	if err = webhook.Enqueue(tx, domainWebhook.NewEvent(domainWebhook.EventUserCreated,
+		&domainWebhook.UserData{UserID: userID})); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewMessage(domainOutbox.TopicUserCreated, domainOutbox.AggregateUser,
+		userID.String(), &domainOutbox.UserData{UserID: userID, Email: in.Email})); err != nil {
+		return nil, nil, oops.Err(err)
 	}
 
 	return
@@ -63,14 +108,11 @@ func Login(ctx context.Context, in *domain.Login) (*domain.Session, error) {
 	}
 	defer tx.Rollback()
 
-	var (
-		repoAuth    = infra.NewAuthRepository(tx)
-		repoUser    = infra.NewUserRepository(tx)
-		repoSession = infra.NewSessionRepository(tx)
-	)
-
 	user := &domain.User{Email: in.Email}
-	if err = repoUser.GetUser(user); err != nil {
+	if err = infra.NewUserRepository(tx).GetUser(user); err != nil {
+		if directory.Enabled() && err.Error() == domain.ErrUserNotExists().Error() {
+			return provision(ctx, tx, in)
+		}
 		return nil, oops.Err(err)
 	}
 
@@ -82,26 +124,130 @@ func Login(ctx context.Context, in *domain.Login) (*domain.Session, error) {
 		return nil, domain.ErrAuthentication2factorNotConfigured()
 	}
 
+	if user.IsLockedPermanently() {
+		return nil, loginFailed(ctx, tx, user, domain.ErrUserLocked())
+	}
+
 	if user.IsBlocked() {
-		return nil, domain.ErrUserBlockedTemporarily()
+		return nil, loginFailed(ctx, tx, user, domain.ErrUserBlockedTemporarily())
 	}
 
-	if err = in.ComparePasswords(user.Password, user.Key); err != nil {
-		if errAttempts := repoAuth.AddAttempts(user.ID); errAttempts != nil {
-			return nil, oops.Err(errAttempts)
+	if err = authenticate(ctx, tx, user, in); err != nil {
+		if err.Error() != domain.ErrEmailOrPasswordIsNotValid().Error() {
+			return nil, oops.Err(err)
 		}
-		if errAttempts := tx.Commit(); errAttempts != nil {
+
+		if errAttempts := addFailedAttempt(tx, user, in); errAttempts != nil {
 			return nil, oops.Err(errAttempts)
 		}
+		return nil, loginFailed(ctx, tx, user, err)
+	}
+
+	in.Method = utils.Pointer(domain.PasswordOTPMethod)
+	if user.IsFederated() {
+		in.Method = utils.Pointer(domain.DirectoryOTPMethod)
+	}
+
+	return SecondFactor(ctx, tx, user, in)
+}
+
+// SecondFactor is the step of the login that checks the code of the 2FA of a user authenticated by the
+// password or by a provider, opening the session when the code is valid
+func SecondFactor(ctx context.Context, tx *database.Transaction, user *domain.User, in *domain.Login) (*domain.Session, error) {
+	if err := utils.ValidateToken(user.OTPToken, in.OTP); err != nil {
+		return nil, loginFailed(ctx, tx, user, domain.ErrOTPTokenInvalid())
+	}
+
+	return OpenSession(ctx, tx, user, in)
+}
+
+// OpenSession opens the session of a user authenticated in the transaction, ending the sessions over
+// the limit of the user, unless it is denied by the access policies of the login. The transaction is
+// committed when the session is opened
+func OpenSession(ctx context.Context, tx *database.Transaction, user *domain.User, in *domain.Login) (_ *domain.Session, err error) {
+	repoSession := infra.NewSessionRepository(tx)
+
+	var decision *domainPolicy.Decision
+	if decision, err = accessPolicy.Evaluate(tx, &domainPolicy.Request{
+		Event:     utils.Pointer(domainPolicy.EventLogin),
+		UserID:    user.ID,
+		Method:    (*string)(in.Method),
+		ClientIP:  in.ClientIP,
+		UserAgent: in.UserAgent,
+	}); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if !decision.Allowed {
+		return nil, accessDenied(ctx, tx, user, decision)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionLogin).Actor(user.ID).Target(domainAudit.TargetUser, user.ID)
+	if user.RequiresPasswordChange(&config.Get().PasswordPolicy) {
+		var token *string
+		if token, err = tokens.NewChangePasswordToken(user); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		event.Reason = utils.Pointer("password change required")
+		if err = audit.Record(tx, event); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		return &domain.Session{
+			Level:                  user.Level,
+			UserID:                 user.ID,
+			Email:                  user.Email,
+			FirstName:              user.FirstName,
+			LastName:               user.LastName,
+			Token:                  token,
+			ChangePasswordRequired: utils.Pointer(true),
+		}, nil
+	}
+
+	var active, evicted []*domain.ActiveSession
+	if active, err = repoSession.List(user.ID); err != nil {
 		return nil, oops.Err(err)
 	}
 
-	if err = utils.ValidateToken(user.OTPToken, in.OTP); err != nil {
-		return nil, domain.ErrOTPTokenInvalid()
+	policy := config.Get().GetSessionPolicy(string(*user.Level))
+	if evicted, err = domain.SessionsToEnd(domain.ConcurrentStrategy(policy.ConcurrentStrategy),
+		user.GetMaxSessions(&policy), active, in.EndSessionID); err != nil {
+		if err.Error() != domain.ErrSessionChoiceRequired().Error() {
+			return nil, oops.Err(err)
+		}
+
+		return &domain.Session{
+			UserID:                user.ID,
+			Email:                 user.Email,
+			FirstName:             user.FirstName,
+			LastName:              user.LastName,
+			SessionChoiceRequired: utils.Pointer(true),
+			ActiveSessions:        active,
+		}, nil
+	}
+
+	for _, session := range evicted {
+		if err = repoSession.Revoke(user.ID, session.ID); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	var notification *mail.Message
+	if notification, err = newDeviceNotification(tx, user, in); err != nil {
+		return nil, oops.Err(err)
 	}
 
-	var sessionID *uuid.UUID
-	if sessionID, err = repoSession.Create(user.ID, in.ClientIP, in.UserAgent); err != nil {
+	var (
+		sessionID *uuid.UUID
+		expiresAt *time.Time
+	)
+
+	if sessionID, expiresAt, err = repoSession.Create(user.ID, user.Level, in.Method, in.ClientIP, in.UserAgent); err != nil {
 		return nil, oops.Err(err)
 	}
 
@@ -110,22 +256,179 @@ func Login(ctx context.Context, in *domain.Login) (*domain.Session, error) {
 		return nil, oops.Err(err)
 	}
 
+	if err = audit.Record(tx, event); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewMessage(domainOutbox.TopicUserLoggedIn, domainOutbox.AggregateUser,
+		user.ID.String(), &domainOutbox.UserData{UserID: user.ID, SessionID: sessionID})); err != nil {
+		return nil, oops.Err(err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, oops.Err(err)
 	}
 
+	if notification != nil {
+		mail.SendAsync(notification)
+	}
+
 	return &domain.Session{
-		SessionID: sessionID,
-		Level:     user.Level,
-		UserID:    user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		CreatedAt: user.CreatedAt,
-		Token:     token,
+		SessionID:       sessionID,
+		Level:           user.Level,
+		UserID:          user.ID,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		CreatedAt:       user.CreatedAt,
+		ExpiresAt:       expiresAt,
+		Token:           token,
+		EvictedSessions: evicted,
+	}, nil
+}
+
+// newDeviceNotification returns the message that tells the user about a login from a new device or network,
+// with a link to secure the account when the login was not made by the user
+func newDeviceNotification(tx *database.Transaction, user *domain.User, in *domain.Login) (*mail.Message, error) {
+	policy := config.Get().LoginNotification
+	if !policy.Enabled || !mail.Enabled() {
+		return nil, nil
+	}
+
+	recent, err := infra.NewSessionRepository(tx).Recent(user.ID, policy.Lookback)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsNewDevice(recent, in.ClientIP, in.UserAgent) {
+		return nil, nil
+	}
+
+	var tokenID *uuid.UUID
+	if tokenID, err = infra.NewTokenRepository(tx).Create(user.ID, domain.SecureAccountToken, policy.SecureTokenDuration); err != nil {
+		return nil, err
+	}
+
+	return &mail.Message{
+		To:      *user.Email,
+		Subject: i18n.Value("mail.new_device.subject"),
+		Body: i18n.Value("mail.new_device.body", *user.FirstName, *in.ClientIP, *in.UserAgent,
+			mail.Link("/secure_account?token="+tokenID.String())),
 	}, nil
 }
 
+// loginFailed records the failed login in the audit log, keeping the changes
+// made in the transaction, and returns the reason of the failure
+func loginFailed(ctx context.Context, tx *database.Transaction, user *domain.User, reason error) error {
+	if err := audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionLogin).
+		Actor(user.ID).Target(domainAudit.TargetUser, user.ID).Fail(reason)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return reason
+}
+
+// ConfirmIdentity checks the password or the token of a new sign in with a provider informed by the user
+// to confirm an action. A wrong password counts as a failed login attempt, which may lock the account, and
+// the failures are recorded in the audit log as a failure of the action. The transaction is committed on
+// failure, so that the attempt is kept
+func ConfirmIdentity(ctx context.Context, tx *database.Transaction, user *domain.User, in *domain.Confirmation, action domainAudit.Action) error {
+	if err := in.Validate(); err != nil {
+		return err
+	}
+
+	event := domainAudit.NewEvent(ctx, action).Target(domainAudit.TargetUser, user.ID)
+
+	var reason error
+	switch {
+	case user.IsLockedPermanently():
+		reason = domain.ErrUserLocked()
+	case user.IsBlocked():
+		reason = domain.ErrUserBlockedTemporarily()
+	case in.ConfirmationToken != nil:
+		userID, err := infra.NewTokenRepository(tx).Use(in.ConfirmationToken, domain.ReauthenticationToken)
+		if err != nil && err.Error() != domain.ErrTokenInvalid().Error() {
+			return oops.Err(err)
+		}
+
+		if reason = err; reason == nil && *userID == *user.ID {
+			return nil
+		}
+
+		if reason == nil {
+			reason = domain.ErrTokenInvalid()
+		}
+	default:
+		login := &domain.Login{Password: in.Password, ClientIP: event.IP, UserAgent: event.UserAgent}
+		if reason = login.ComparePasswords(user.Password, user.Key); reason == nil {
+			return nil
+		}
+
+		if err := addFailedAttempt(tx, user, login); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err := audit.Record(tx, event.Fail(reason)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return reason
+}
+
+// accessDenied records the login denied by a policy in the audit log with the name of the policy,
+// which is not told to the user, and returns the reason of the failure
+func accessDenied(ctx context.Context, tx *database.Transaction, user *domain.User, decision *domainPolicy.Decision) error {
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionLogin).Actor(user.ID).Target(domainAudit.TargetUser, user.ID).
+		Fail(domainPolicy.ErrAccessDenied())
+	event.Reason = utils.Pointer("denied by the policy " + *decision.DeniedBy)
+
+	if err := audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return domainPolicy.ErrAccessDenied()
+}
+
+// addFailedAttempt adds a failed login attempt and locks the account when the lockout policy is reached
+func addFailedAttempt(tx *database.Transaction, user *domain.User, in *domain.Login) (err error) {
+	var attempts *int64
+	if attempts, err = infra.NewAuthRepository(tx).AddAttempts(user.ID); err != nil || attempts == nil {
+		return err
+	}
+
+	lock := domain.NewLock(&config.Get().Lockout, user.ID, *attempts)
+	if lock == nil {
+		return
+	}
+
+	repoLockout := infra.NewLockoutRepository(tx)
+	if err = repoLockout.Lock(lock); err != nil {
+		return err
+	}
+
+	return repoLockout.AddEvent(&domain.LockoutEntry{
+		UserID:      user.ID,
+		Event:       &lock.Event,
+		Attempts:    lock.Attempts,
+		LockedUntil: lock.Until,
+		IP:          in.ClientIP,
+		UserAgent:   in.UserAgent,
+	})
+}
+
 // Logout is the business logic for the user logout
 func Logout(ctx context.Context, sessionID *uuid.UUID) (err error) {
 	var tx *database.Transaction
@@ -138,6 +441,77 @@ func Logout(ctx context.Context, sessionID *uuid.UUID) (err error) {
 		return oops.Err(err)
 	}
 
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionLogout)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Sessions is the business logic to list the active sessions of a user, marking the current one
+func Sessions(ctx context.Context, userID, currentID *uuid.UUID) (res []*domain.ActiveSession, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewSessionRepository(tx).List(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for _, session := range res {
+		session.Current = currentID != nil && *session.ID == *currentID
+	}
+
+	return
+}
+
+// EndSession is the business logic to end an active session of a user
+func EndSession(ctx context.Context, userID, sessionID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewSessionRepository(tx).Revoke(userID, sessionID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionEndSession).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// EndSessions is the business logic to end all active sessions of a user, except the current one if informed
+func EndSessions(ctx context.Context, userID, currentID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewSessionRepository(tx).RevokeAll(userID, currentID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionEndSessions).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return oops.Err(err)
 	}
@@ -145,6 +519,26 @@ func Logout(ctx context.Context, sessionID *uuid.UUID) (err error) {
 	return
 }
 
+// CheckSession is the business logic to validate that a session has not ended or expired,
+// refreshing its idle timeout
+func CheckSession(ctx context.Context, sessionID *uuid.UUID, level *domain.Level) (active bool, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return false, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if active, err = infra.NewSessionRepository(tx).Touch(sessionID, level); err != nil || !active {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, oops.Err(err)
+	}
+
+	return
+}
+
 // LoginSteps is the business logic needed to retrieve needed steps for log a user in
 func LoginSteps(ctx context.Context, email *string) (res *domain.Steps, err error) {
 	var tx *database.Transaction
@@ -174,6 +568,11 @@ func Configure2FA(ctx context.Context, userID *uuid.UUID) (err error) {
 		return oops.Err(err)
 	}
 
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionConfigure2FA).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return oops.Err(err)
 	}
@@ -208,6 +607,11 @@ func Unconfigure2FA(ctx context.Context, userID *uuid.UUID) (err error) {
 		return oops.Err(err)
 	}
 
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionUnconfigure2FA).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return oops.Err(err)
 	}
@@ -245,6 +649,20 @@ func DisableUser(ctx context.Context, userUUID *uuid.UUID) error {
 		return oops.Err(err)
 	}
 
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionDisableUser).
+		Target(domainAudit.TargetUser, userUUID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = webhook.Enqueue(tx, domainWebhook.NewUserEvent(domainWebhook.EventUserDisabled, userUUID,
+		&domainWebhook.UserData{UserID: userUUID})); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserDisabled, userUUID)); err != nil {
+		return oops.Err(err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return oops.Err(err)
 	}
@@ -261,7 +679,6 @@ func ChangePassword(ctx context.Context, in *domain.ChangePassword) (err error)
 	defer tx.Rollback()
 
 	repoUser := infra.NewUserRepository(tx)
-	repoSession := infra.NewSessionRepository(tx)
 
 	user := domain.User{ID: in.UserID}
 	if err = repoUser.GetUser(&user); err != nil {
@@ -277,32 +694,754 @@ func ChangePassword(ctx context.Context, in *domain.ChangePassword) (err error)
 		return oops.New("2-factor authentication code is invalid")
 	}
 
-	// Generate new password crypto
-	gen := &domain.CreateAccount{Password: in.Password}
-	if err = gen.GeneratePassword(); err != nil {
-		return err
+	if err = changePassword(tx, in); err != nil {
+		return oops.Err(err)
 	}
 
-	// Change user password
-	in.Password, in.Key = gen.Password, gen.Key
-	if err = repoUser.ChangePassword(in); err != nil {
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionChangePassword).
+		Target(domainAudit.TargetUser, in.UserID)); err != nil {
 		return oops.Err(err)
 	}
 
-	// Getting all active user sessions
-	var sessions []*uuid.UUID
-	if sessions, err = repoSession.Get(in.UserID); err != nil {
+	if err = tx.Commit(); err != nil {
 		return oops.Err(err)
 	}
 
-	// Disabling all active user sessions
-	if err = repoSession.Delete(sessions...); err != nil {
+	return
+}
+
+// ChangeRequiredPassword is the business logic for the change of an expired or reset password
+func ChangeRequiredPassword(ctx context.Context, in *domain.ChangePassword) (err error) {
+	tx, err := database.NewTransaction(ctx, false)
+	if err != nil {
 		return oops.Err(err)
 	}
+	defer tx.Rollback()
 
-	if err = tx.Commit(); err != nil {
+	user := domain.User{ID: in.UserID}
+	if err = infra.NewUserRepository(tx).GetUser(&user); err != nil {
+		return oops.Err(err)
+	}
+
+	// the change password token is only valid while the change is required, so it cannot be used again
+	if !user.RequiresPasswordChange(&config.Get().PasswordPolicy) {
+		return domain.ErrPasswordChangeNotRequired()
+	}
+
+	login := &domain.Login{Password: utils.Pointer(strings.TrimSpace(*in.Password))}
+	if login.ComparePasswords(user.Password, user.Key) == nil {
+		return domain.ErrPasswordMustBeDifferent()
+	}
+
+	if err = changePassword(tx, in); err != nil {
 		return oops.Err(err)
 	}
 
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionChangePassword).
+		Target(domainAudit.TargetUser, in.UserID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// changePassword changes the user password, ends all active user sessions and notifies the webhooks
+// and the subscribers of the outbox
+func changePassword(tx *database.Transaction, in *domain.ChangePassword) (err error) {
+	var (
+		repoUser    = infra.NewUserRepository(tx)
+		repoSession = infra.NewSessionRepository(tx)
+	)
+
+	// Generate new password crypto
+	gen := &domain.CreateAccount{Password: in.Password}
+	if err = gen.GeneratePassword(); err != nil {
+		return err
+	}
+
+	// Change user password
+	in.Password, in.Key = gen.Password, gen.Key
+	if err = repoUser.ChangePassword(in); err != nil {
+		return oops.Err(err)
+	}
+
+	// Getting all active user sessions
+	var sessions []*uuid.UUID
+	if sessions, err = repoSession.Get(in.UserID); err != nil {
+		return oops.Err(err)
+	}
+
+	// Disabling all active user sessions
+	if err = repoSession.Delete(sessions...); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = webhook.Enqueue(tx, domainWebhook.NewUserEvent(domainWebhook.EventUserPasswordChanged, in.UserID,
+		&domainWebhook.UserData{UserID: in.UserID})); err != nil {
+		return oops.Err(err)
+	}
+
+	return outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserPasswordChanged, in.UserID))
+}
+
+// ForcePasswordChange is the business logic to require a user to change the password on the next login
+func ForcePasswordChange(ctx context.Context, userID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoSession := infra.NewSessionRepository(tx)
+	if err = infra.NewUserRepository(tx).ForcePasswordChange(userID); err != nil {
+		return oops.Err(err)
+	}
+
+	var sessions []*uuid.UUID
+	if sessions, err = repoSession.Get(userID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoSession.Delete(sessions...); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionForcePasswordChange).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// ForcePasswordChangeByLevel is the business logic to require all users of a level to change the password on the next login
+func ForcePasswordChangeByLevel(ctx context.Context, level *domain.Level) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewUserRepository(tx).ForcePasswordChangeByLevel(level); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = infra.NewSessionRepository(tx).DeleteByLevel(level); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionForcePasswordChange).
+		TargetKey(domainAudit.TargetLevel, string(*level))); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// GetLockout is the business logic to fetch the lockout status and history of a user
+func GetLockout(ctx context.Context, userID *uuid.UUID, params *utils.Params) (res *domain.Lockout, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoLockout := infra.NewLockoutRepository(tx)
+	if res, err = repoLockout.Get(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res.Events, res.Next, err = repoLockout.Events(userID, params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Unlock is the business logic to reset the failed attempts and the locks of a user
+func Unlock(ctx context.Context, in *domain.LockoutEntry) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoLockout := infra.NewLockoutRepository(tx)
+
+	var lockout *domain.Lockout
+	if lockout, err = repoLockout.Get(in.UserID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoLockout.Reset(in.UserID); err != nil {
+		return oops.Err(err)
+	}
+
+	in.Event = utils.Pointer(domain.LockoutEventUnlocked)
+	in.Attempts = lockout.Attempts
+	if err = repoLockout.AddEvent(in); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionUnlockUser).
+		Target(domainAudit.TargetUser, in.UserID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserUnlocked, in.UserID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// SetMaxSessions is the business logic to change the number of sessions a user can have open at the same time
+func SetMaxSessions(ctx context.Context, userID *uuid.UUID, maxSessions *int64) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewUserRepository(tx).SetMaxSessions(userID, maxSessions); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionSetSessionLimit).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Users is the business logic to list the users that match the filters
+func Users(ctx context.Context, params *utils.Params) (res *domain.Users, err error) {
+	if err = domain.ValidateUserParams(params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	res = new(domain.Users)
+	if res.Users, res.Next, err = infra.NewUserRepository(tx).List(params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for i := range res.Users {
+		res.Users[i].Prepare()
+	}
+
+	return
+}
+
+// User is the business logic to fetch the data of a user shown to the administrators
+func User(ctx context.Context, userID *uuid.UUID) (res *domain.UserSummary, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewUserRepository(tx).Summary(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res.Prepare()
+	return
+}
+
+// UpdateUser is the business logic for an administrator to change the name and the level of a user.
+// Changing the level ends the sessions of the user, since the level is part of the session token
+func UpdateUser(ctx context.Context, userID *uuid.UUID, in *domain.UpdateUser) (err error) {
+	if err = in.Validate(); err != nil {
+		return oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoUser := infra.NewUserRepository(tx)
+
+	var user *domain.UserSummary
+	if user, err = repoUser.Summary(userID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoUser.Update(userID, in); err != nil {
+		return oops.Err(err)
+	}
+
+	if in.Level != nil && *in.Level != *user.Level {
+		if err = infra.NewSessionRepository(tx).RevokeAll(userID, nil); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionUpdateUser).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserUpdated, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// EnableUser is the business logic to reactivate a disabled user
+func EnableUser(ctx context.Context, userID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewUserRepository(tx).EnableUser(userID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionEnableUser).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserEnabled, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// PurgeExpiredSessions is the business logic to end the sessions that have expired
+func PurgeExpiredSessions(ctx context.Context) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if _, err = infra.NewSessionRepository(tx).DeleteExpired(); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// ClearStaleAttempts is the business logic to remove the failed attempts that have decayed
+func ClearStaleAttempts(ctx context.Context) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if _, err = infra.NewLockoutRepository(tx).ClearStale(); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// ExpireTokens is the business logic to remove the activation and reset tokens that were used or have expired
+func ExpireTokens(ctx context.Context) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if _, err = infra.NewTokenRepository(tx).DeleteExpired(); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// SecureAccount is the business logic to end all sessions and lock the account of a user
+// after a login that was not made by the user, sending a link to reset the password
+func SecureAccount(ctx context.Context, in *domain.SecureAccount) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	var (
+		repoToken   = infra.NewTokenRepository(tx)
+		repoLockout = infra.NewLockoutRepository(tx)
+		user        = new(domain.User)
+	)
+
+	if user.ID, err = repoToken.Use(in.Token, domain.SecureAccountToken); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = infra.NewUserRepository(tx).GetUser(user); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = infra.NewSessionRepository(tx).RevokeAll(user.ID, nil); err != nil {
+		return oops.Err(err)
+	}
+
+	lock := &domain.Lock{UserID: user.ID, Event: domain.LockoutEventSecured, Attempts: user.Attempts, Permanent: true}
+	if err = repoLockout.Lock(lock); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoLockout.AddEvent(&domain.LockoutEntry{
+		UserID:    user.ID,
+		Event:     &lock.Event,
+		Attempts:  lock.Attempts,
+		IP:        in.ClientIP,
+		UserAgent: in.UserAgent,
+	}); err != nil {
+		return oops.Err(err)
+	}
+
+	var tokenID *uuid.UUID
+	if tokenID, err = repoToken.Create(user.ID, domain.ResetPasswordToken, config.Get().LoginNotification.ResetTokenDuration); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionSecureAccount).
+		Actor(user.ID).Target(domainAudit.TargetUser, user.ID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserSecured, user.ID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	mail.SendAsync(&mail.Message{
+		To:      *user.Email,
+		Subject: i18n.Value("mail.reset_password.subject"),
+		Body:    i18n.Value("mail.reset_password.body", *user.FirstName, mail.Link("/reset_password?token="+tokenID.String())),
+	})
+
+	return
+}
+
+// ResetPassword is the business logic to change the password with the token sent by email, unlocking the account
+func ResetPassword(ctx context.Context, in *domain.ResetPassword) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	var userID *uuid.UUID
+	if userID, err = infra.NewTokenRepository(tx).Use(in.Token, domain.ResetPasswordToken); err != nil {
+		return oops.Err(err)
+	}
+
+	repoLockout := infra.NewLockoutRepository(tx)
+	if err = repoLockout.Reset(userID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = changePassword(tx, &domain.ChangePassword{UserID: userID, Password: in.Password}); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoLockout.AddEvent(&domain.LockoutEntry{
+		UserID:   userID,
+		Event:    utils.Pointer(domain.LockoutEventUnlocked),
+		Attempts: utils.Pointer(int64(0)),
+	}); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionResetPassword).
+		Actor(userID).Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Profile is the business logic for users to read their own data
+func Profile(ctx context.Context, userID *uuid.UUID) (res *domain.Profile, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewUserRepository(tx).Profile(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// UpdateProfile is the business logic for users to change their name and preferences
+func UpdateProfile(ctx context.Context, userID *uuid.UUID, in *domain.UpdateProfile) (err error) {
+	if err = in.Validate(); err != nil {
+		return oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewUserRepository(tx).UpdateProfile(userID, in); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionUpdateProfile).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserUpdated, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// RequestEmailChange is the business logic for users to change their email. The current password
+// is required and the email is only changed when the user confirms the link sent to the new address
+func RequestEmailChange(ctx context.Context, in *domain.ChangeEmail) (err error) {
+	if !mail.Enabled() {
+		return domain.ErrEmailChangeUnavailable()
+	}
+
+	in.Prepare()
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repoUser := infra.NewUserRepository(tx)
+
+	user := domain.User{ID: in.UserID}
+	if err = repoUser.GetUser(&user); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = ConfirmIdentity(ctx, tx, &user, &in.Confirmation, domainAudit.ActionRequestEmailChange); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoUser.AccountExists(in.Email); err != nil {
+		return oops.Err(err)
+	}
+
+	var tokenID *uuid.UUID
+	if tokenID, err = infra.NewTokenRepository(tx).CreateWithData(user.ID, domain.ChangeEmailToken,
+		config.Get().Account.EmailChangeTokenDuration, in.Email); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionRequestEmailChange).
+		Target(domainAudit.TargetUser, user.ID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	mail.SendAsync(&mail.Message{
+		To:      *in.Email,
+		Subject: i18n.Value("mail.confirm_email.subject"),
+		Body:    i18n.Value("mail.confirm_email.body", *user.FirstName, mail.Link("/me/email/confirm?token="+tokenID.String())),
+	})
+
+	return
+}
+
+// ConfirmEmailChange is the business logic to replace the email of the user with the address
+// confirmed by the token, notifying the previous address of the change
+func ConfirmEmailChange(ctx context.Context, in *domain.ConfirmEmail) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	var (
+		userID *uuid.UUID
+		email  *string
+	)
+
+	if userID, email, err = infra.NewTokenRepository(tx).UseWithData(in.Token, domain.ChangeEmailToken); err != nil {
+		return oops.Err(err)
+	}
+
+	repoUser := infra.NewUserRepository(tx)
+
+	user := domain.User{ID: userID}
+	if err = repoUser.GetUser(&user); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoUser.AccountExists(email); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repoUser.ChangeEmail(userID, email); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionChangeEmail).
+		Actor(userID).Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewMessage(domainOutbox.TopicUserEmailChanged, domainOutbox.AggregateUser,
+		userID.String(), &domainOutbox.UserData{UserID: userID, Email: email})); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	mail.SendAsync(&mail.Message{
+		To:      *user.Email,
+		Subject: i18n.Value("mail.email_changed.subject"),
+		Body:    i18n.Value("mail.email_changed.body", *user.FirstName, *email),
+	})
+
+	return
+}
+
+// Introspect is the business logic to return the state of a token for an application. The roles of the user
+// in the project of the audience are returned while the user participates in the project, and the token is
+// inactive for the project when it is denied by the access policies of the tokens. The introspection does
+// not refresh the idle timeout of the session, which is only kept alive by the requests of the user
+func Introspect(ctx context.Context, in *domain.Introspect) (res *domain.Introspection, err error) {
+	res = &domain.Introspection{}
+
+	claims := tokens.ParseJWT(*in.Token, config.Get().GetSecrets())
+	if claims == nil || claims["Scope"] != nil {
+		return res, nil
+	}
+
+	var sessionID, userID uuid.UUID
+	if sessionID, err = uuid.Parse(fmt.Sprint(claims["SessionID"])); err != nil {
+		return res, nil
+	}
+
+	if userID, err = uuid.Parse(fmt.Sprint(claims["UserID"])); err != nil {
+		return res, nil
+	}
+
+	level := domain.Level(fmt.Sprint(claims["UserLevel"]))
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res.Active, err = infra.NewSessionRepository(tx).Active(&sessionID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if !res.Active {
+		return res, nil
+	}
+
+	res.SessionID, res.UserID, res.Level = &sessionID, &userID, &level
+	if exp, ok := claims["exp"].(float64); ok {
+		res.ExpiresAt = utils.Pointer(int64(exp))
+	}
+
+	if in.Audience != nil {
+		var access *domainProject.Access
+		if access, err = infraProject.New(tx).Access(in.Audience, &userID); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		var decision *domainPolicy.Decision
+		if decision, err = accessPolicy.Evaluate(tx, &domainPolicy.Request{
+			Event:     utils.Pointer(domainPolicy.EventToken),
+			UserID:    &userID,
+			SessionID: &sessionID,
+			ProjectID: in.Audience,
+		}); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		// the token denied by a policy is inactive for the project
+		if !decision.Allowed {
+			return &domain.Introspection{}, nil
+		}
+
+		res.Audience, res.Roles = in.Audience, access.Roles
+		for _, permission := range access.Permissions {
+			res.Permissions = append(res.Permissions, string(permission))
+		}
+	}
+
 	return
 }
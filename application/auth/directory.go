@@ -0,0 +1,145 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/webhook"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/directory"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domain "github.com/isaqueveras/powersso/domain/auth"
+	domainWebhook "github.com/isaqueveras/powersso/domain/webhook"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// authenticate checks the password of the user, in the directory for the users created by it.
+// The users of the directory receive the names, the level and the projects of the directory
+func authenticate(ctx context.Context, tx *database.Transaction, user *domain.User, in *domain.Login) error {
+	if !user.IsFederated() {
+		return in.ComparePasswords(user.Password, user.Key)
+	}
+
+	entry, err := authenticateDirectory(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	account := entry.Account(&config.Get().LDAP)
+	if err = syncDirectory(ctx, tx, user, account); err != nil {
+		return oops.Err(err)
+	}
+
+	user.FirstName, user.LastName, user.Level, user.DirectoryDN = account.FirstName, account.LastName, account.Level, account.DN
+	return nil
+}
+
+// provision creates in the first login the account of a user of the directory, which is not in the users.
+// The user must configure the 2FA with the url of the session before logging in again
+func provision(ctx context.Context, tx *database.Transaction, in *domain.Login) (*domain.Session, error) {
+	entry, err := authenticateDirectory(ctx, in)
+	if err != nil {
+		if err.Error() == domain.ErrEmailOrPasswordIsNotValid().Error() {
+			return nil, domain.ErrUserNotExists()
+		}
+		return nil, err
+	}
+
+	account := entry.Account(&config.Get().LDAP)
+
+	var (
+		userID *uuid.UUID
+		url    *string
+	)
+
+	if userID, url, err = Register(tx, account.CreateAccount()); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = joinProjects(tx, userID, account.Projects); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionCreateAccount).Actor(userID).Target(domainAudit.TargetUser, userID)
+	event.Reason = utils.Pointer("provisioned by the directory")
+	if err = audit.Record(tx, event); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return &domain.Session{
+		UserID:           userID,
+		Level:            account.Level,
+		Email:            account.Email,
+		FirstName:        account.FirstName,
+		LastName:         account.LastName,
+		OTPSetupRequired: utils.Pointer(true),
+		OTPSetupURL:      url,
+	}, nil
+}
+
+// authenticateDirectory checks the credentials of the login in the directory. The failures of the
+// directory are logged and do not count as failed attempts of the user
+func authenticateDirectory(ctx context.Context, in *domain.Login) (*domain.DirectoryEntry, error) {
+	entry, err := directory.Authenticate(ctx, *in.Email, *in.Password)
+	in.SanitizePassword()
+
+	switch {
+	case err == directory.ErrInvalidCredentials:
+		return nil, domain.ErrEmailOrPasswordIsNotValid()
+	case err != nil && err.Error() != domain.ErrDirectoryUnavailable().Error():
+		log.Println("Error while authenticating with the directory: ", err)
+		return nil, domain.ErrDirectoryUnavailable()
+	}
+
+	return entry, err
+}
+
+// syncDirectory writes the data of the directory on the account of the user, recording the changes of level
+func syncDirectory(ctx context.Context, tx *database.Transaction, user *domain.User, account *domain.DirectoryAccount) (err error) {
+	if err = infra.NewDirectoryRepository(tx).Sync(user.ID, account); err != nil {
+		return err
+	}
+
+	if user.Level != nil && *user.Level != *account.Level {
+		event := domainAudit.NewEvent(ctx, domainAudit.ActionUpdateUser).Actor(user.ID).Target(domainAudit.TargetUser, user.ID)
+		event.Reason = utils.Pointer("level " + string(*account.Level) + " given by the groups of the directory")
+		if err = audit.Record(tx, event); err != nil {
+			return err
+		}
+	}
+
+	return joinProjects(tx, user.ID, account.Projects)
+}
+
+// joinProjects adds the user to the projects of the groups of the directory
+func joinProjects(tx *database.Transaction, userID *uuid.UUID, projects []uuid.UUID) error {
+	joined, err := infra.NewDirectoryRepository(tx).JoinProjects(userID, projects)
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	for i := range joined {
+		if err = webhook.Enqueue(tx, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantJoined, &joined[i],
+			&domainWebhook.ParticipantData{ProjectID: &joined[i], UserID: userID, StartDate: &today})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"context"
+	"io"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/invitation"
+	"github.com/isaqueveras/powersso/application/role"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domainAuth "github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/bulk"
+	domainInvitation "github.com/isaqueveras/powersso/domain/invitation"
+	infraAuth "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/bulk"
+	"github.com/isaqueveras/powersso/mail"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Import is the business logic for an administrator to import the users of a file. Each row is imported
+// on its own, so that the errors of a row are reported in the result without stopping the others.
+// In a dry run the rows are only validated, including the emails that already have an account
+func Import(ctx context.Context, opts *domain.ImportOptions, file io.Reader) (res *domain.ImportResult, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if opts.Invite && !mail.Enabled() {
+		return nil, domainInvitation.ErrInvitationUnavailable()
+	}
+
+	var tx *database.Transaction
+	if opts.DryRun {
+		if tx, err = database.NewTransaction(ctx, true); err != nil {
+			return nil, oops.Err(err)
+		}
+		defer tx.Rollback()
+	}
+
+	res = domain.NewImportResult(opts.DryRun)
+	seen := make(map[string]struct{})
+
+	if err = domain.ReadRows(opts.Format, file, config.Get().Account.ImportMaxRows, func(row int64, data *domain.ImportRow) error {
+		if _, ok := seen[data.Key()]; ok && data.Key() != "" {
+			res.Fail(row, data.Email, domain.ErrDuplicateEmail())
+			return nil
+		}
+		seen[data.Key()] = struct{}{}
+
+		if err := importRow(ctx, tx, opts, data); err != nil {
+			res.Fail(row, data.Email, err)
+			return nil
+		}
+
+		res.Succeed()
+		return nil
+	}); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// importRow imports a row of the file, as an invitation or as an account. The dry run
+// validates the row using the read-only transaction shared by all the rows
+func importRow(ctx context.Context, dryRun *database.Transaction, opts *domain.ImportOptions, data *domain.ImportRow) error {
+	if opts.Invite {
+		in, err := data.Invitation(opts.CreatedBy)
+		if err != nil {
+			return err
+		}
+
+		if dryRun != nil {
+			return validateRow(dryRun, opts, in.Level, in.Email)
+		}
+
+		_, err = invitation.Create(ctx, in)
+		return err
+	}
+
+	account, err := data.Account(opts.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	if dryRun != nil {
+		return validateRow(dryRun, opts, account.Level, account.Email)
+	}
+
+	tx, err := database.NewTransaction(ctx, false)
+	if err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = role.CheckLevel(tx, opts.CreatedBy, account.Level); err != nil {
+		return oops.Err(err)
+	}
+
+	userID, _, err := auth.Register(tx, account)
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionImportUser).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// validateRow checks in the dry run the level and the email of a row, as the import of the row would
+func validateRow(dryRun *database.Transaction, opts *domain.ImportOptions, level *domainAuth.Level, email *string) error {
+	if err := role.CheckLevel(dryRun, opts.CreatedBy, level); err != nil {
+		return err
+	}
+	return infraAuth.NewUserRepository(dryRun).AccountExists(email)
+}
+
+// Export is the business logic for an administrator to export the users and their participations
+// in the projects, writing each user as it is read so that large exports are not kept in memory
+func Export(ctx context.Context, w domain.ExportWriter) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionExportUsers)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = infra.NewBulkRepository(tx).Users(w.Write); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = w.Close(); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
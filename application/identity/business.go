@@ -0,0 +1,398 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domainAuth "github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/identity"
+	"github.com/isaqueveras/powersso/federation"
+	infraAuth "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/identity"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/tokens"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Providers is the business logic to list the providers the users can sign in with
+func Providers(_ context.Context) []domain.Provider {
+	return domain.NewProviders(&config.Get().OIDC)
+}
+
+// Authorize is the business logic to start a sign in with a provider, returning the address the user
+// is sent to and the binding kept by the browser until the callback. The sign in of a user links the identity
+// to the user or confirms an action of the user, by the purpose, instead of opening a session
+func Authorize(ctx context.Context, provider string, purpose domain.Purpose, userID *uuid.UUID) (res *domain.Authorization, err error) {
+	cfg := &config.Get().OIDC
+	if _, err = domain.GetProvider(cfg, provider); err != nil {
+		return nil, err
+	}
+
+	var client federation.Provider
+	if client, err = federation.Get(provider); err != nil {
+		return nil, err
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	state := domain.NewState(provider, purpose, userID, time.Duration(cfg.StateDuration)*time.Second)
+
+	var stateID *uuid.UUID
+	if stateID, err = infra.NewStateRepository(tx).Create(state); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var url string
+	if url, err = client.AuthCodeURL(ctx, stateID.String(), state); err != nil {
+		return nil, providerFailed(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return &domain.Authorization{URL: &url, Binding: state.Binding}, nil
+}
+
+// Callback is the business logic to finish a sign in with a provider. The identity is linked to the user
+// that started the sign in, or the user receives a token that confirms an action, otherwise the user of the identity receives a token to inform the code of the 2FA,
+// as the login with the password requires. The users that sign in for the first time are created when the
+// provider allows it, but are never linked by the email to an existing account, which must link the identity
+// by itself
+func Callback(ctx context.Context, provider string, in *domain.Callback) (res *domain.Result, err error) {
+	var cfg *config.OIDCProviderConfig
+	if cfg, err = domain.GetProvider(&config.Get().OIDC, provider); err != nil {
+		return nil, err
+	}
+
+	var state *domain.State
+	if state, err = useState(ctx, provider, in.State); err != nil {
+		return nil, err
+	}
+
+	// the sign in must be finished by the browser that started it, otherwise anyone could have
+	// the identity of another person linked to the own account or sign the person in to it
+	if !state.BoundTo(in.Binding) {
+		return nil, domain.ErrStateInvalid()
+	}
+
+	if in.Error != nil || in.Code == nil {
+		return nil, domain.ErrAuthorizationFailed()
+	}
+
+	var client federation.Provider
+	if client, err = federation.Get(provider); err != nil {
+		return nil, err
+	}
+
+	var claims *domain.Claims
+	if claims, err = client.Exchange(ctx, *in.Code, state); err != nil {
+		return nil, providerFailed(err)
+	}
+
+	if err = claims.Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	identity := claims.Identity(provider)
+
+	var linked *domain.Identity
+	if linked, err = infra.NewIdentityRepository(tx).Get(identity.Provider, identity.Subject); err != nil {
+		if err.Error() != domain.ErrIdentityNotFound().Error() {
+			return nil, oops.Err(err)
+		}
+	}
+
+	switch {
+	case state.IsLink():
+		return link(ctx, tx, state.UserID, linked, identity)
+	case state.IsConfirm():
+		return confirm(tx, state.UserID, linked)
+	}
+
+	var (
+		user   *domainAuth.User
+		qrcode *string
+	)
+
+	if linked == nil {
+		if !cfg.Provision {
+			return nil, domain.ErrIdentityNotLinked()
+		}
+
+		if user, qrcode, err = provision(ctx, tx, claims, identity); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = infra.NewIdentityRepository(tx).Touch(linked.ID, identity.Email); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		user = &domainAuth.User{ID: linked.UserID}
+		if err = infraAuth.NewUserRepository(tx).GetUser(user); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	switch {
+	case !user.IsActive():
+		return nil, domainAuth.ErrUserNotExists()
+	case user.IsLockedPermanently():
+		return nil, domainAuth.ErrUserLocked()
+	case user.IsBlocked():
+		return nil, domainAuth.ErrUserBlockedTemporarily()
+	case !user.OTPConfigured():
+		return nil, domainAuth.ErrAuthentication2factorNotConfigured()
+	}
+
+	var token *string
+	if token, err = tokens.NewOTPToken(user); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return &domain.Result{Challenge: &domain.Challenge{Token: token, QRCode: qrcode}}, nil
+}
+
+// VerifyOTP is the business logic to finish the sign in with a provider with the code of the 2FA,
+// opening the session of the user in the same step of the login with the password
+func VerifyOTP(ctx context.Context, userID *uuid.UUID, in *domain.OTP) (session *domainAuth.Session, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	user := &domainAuth.User{ID: userID}
+	if err = infraAuth.NewUserRepository(tx).GetUser(user); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	switch {
+	case !user.IsActive():
+		return nil, domainAuth.ErrUserNotExists()
+	case user.IsLockedPermanently():
+		return nil, domainAuth.ErrUserLocked()
+	case user.IsBlocked():
+		return nil, domainAuth.ErrUserBlockedTemporarily()
+	case !user.OTPConfigured():
+		return nil, domainAuth.ErrAuthentication2factorNotConfigured()
+	}
+
+	return auth.SecondFactor(ctx, tx, user, &domainAuth.Login{
+		OTP:          in.Code,
+		Method:       utils.Pointer(domainAuth.ProviderOTPMethod),
+		ClientIP:     in.ClientIP,
+		UserAgent:    in.UserAgent,
+		EndSessionID: in.EndSessionID,
+	})
+}
+
+// useState finishes the state of the sign in, so that it cannot be used again even if the sign in fails
+func useState(ctx context.Context, provider string, stateID *string) (state *domain.State, err error) {
+	if stateID == nil {
+		return nil, domain.ErrStateInvalid()
+	}
+
+	var id uuid.UUID
+	if id, err = uuid.Parse(*stateID); err != nil {
+		return nil, domain.ErrStateInvalid()
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if state, err = infra.NewStateRepository(tx).Use(&id, &provider); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// link links the identity to the user, failing when it is linked to another user
+func link(ctx context.Context, tx *database.Transaction, userID *uuid.UUID, linked, identity *domain.Identity) (*domain.Result, error) {
+	if linked != nil {
+		if *linked.UserID != *userID {
+			return nil, domain.ErrIdentityLinked()
+		}
+		return &domain.Result{Identity: linked}, nil
+	}
+
+	identity.UserID = userID
+	if err := createIdentity(ctx, tx, identity); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return &domain.Result{Identity: identity}, nil
+}
+
+// confirm creates the token that confirms an action of the user, who must have signed in again with an
+// identity linked to the own account
+func confirm(tx *database.Transaction, userID *uuid.UUID, linked *domain.Identity) (*domain.Result, error) {
+	if linked == nil || *linked.UserID != *userID {
+		return nil, domain.ErrIdentityNotLinked()
+	}
+
+	token, err := infraAuth.NewTokenRepository(tx).Create(userID, domainAuth.ReauthenticationToken,
+		config.Get().OIDC.ConfirmationDuration)
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return &domain.Result{Confirmation: &domain.Confirmation{Token: token}}, nil
+}
+
+// provision creates the account of the user that signs in for the first time, linking the identity to it
+// and returning the qrcode to enroll the 2FA. The email must not belong to an account, which could be taken
+// by whoever controls the identity
+func provision(ctx context.Context, tx *database.Transaction, claims *domain.Claims, identity *domain.Identity) (*domainAuth.User, *string, error) {
+	userID, qrcode, err := auth.Register(tx, claims.Account())
+	if err != nil {
+		if err.Error() == domainAuth.ErrUserExists().Error() {
+			return nil, nil, domain.ErrIdentityNotLinked()
+		}
+		return nil, nil, err
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionCreateAccount).Actor(userID).Target(domainAudit.TargetUser, userID)
+	event.Reason = utils.Pointer("provisioned by the provider " + *identity.Provider)
+	if err = audit.Record(tx, event); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	identity.UserID = userID
+	if err = createIdentity(ctx, tx, identity); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	user := &domainAuth.User{ID: userID}
+	if err = infraAuth.NewUserRepository(tx).GetUser(user); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	return user, qrcode, nil
+}
+
+// createIdentity links the identity to its user, recording it in the audit log
+func createIdentity(ctx context.Context, tx *database.Transaction, identity *domain.Identity) (err error) {
+	if identity.ID, err = infra.NewIdentityRepository(tx).Create(identity); err != nil {
+		return err
+	}
+
+	identity.CreatedAt = utils.Pointer(time.Now())
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionLinkIdentity).Actor(identity.UserID).
+		Target(domainAudit.TargetUser, identity.UserID)
+	event.Reason = identity.Provider
+	return audit.Record(tx, event)
+}
+
+// providerFailed maps the failures of the provider, logging the ones that are not caused by the user
+func providerFailed(err error) error {
+	if err == federation.ErrAuthorizationFailed {
+		return domain.ErrAuthorizationFailed()
+	}
+
+	log.Println("Error while signing in with the identity provider: ", err)
+	return domain.ErrProviderUnavailable()
+}
+
+// Identities is the business logic to list the identities linked to the user
+func Identities(ctx context.Context, userID *uuid.UUID) (res []domain.Identity, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewIdentityRepository(tx).List(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Unlink is the business logic to unlink an identity of the user
+func Unlink(ctx context.Context, userID, identityID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	var identity *domain.Identity
+	if identity, err = infra.NewIdentityRepository(tx).Delete(userID, identityID); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionUnlinkIdentity).Actor(userID).Target(domainAudit.TargetUser, userID)
+	event.Reason = identity.Provider
+	if err = audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// ExpireStates is the business logic to remove the states of the sign ins that were not finished in time
+func ExpireStates(ctx context.Context) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if _, err = infra.NewStateRepository(tx).DeleteExpired(); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/role"
+	"github.com/isaqueveras/powersso/application/webhook"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domain "github.com/isaqueveras/powersso/domain/invitation"
+	domainWebhook "github.com/isaqueveras/powersso/domain/webhook"
+	"github.com/isaqueveras/powersso/i18n"
+	infraAuth "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/invitation"
+	"github.com/isaqueveras/powersso/mail"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Create is the business logic for an administrator to invite a user by email. A new invitation
+// to the same email revokes the previous ones, so that only the last link sent can be accepted
+func Create(ctx context.Context, in *domain.CreateInvitation) (invitationID *uuid.UUID, err error) {
+	if !mail.Enabled() {
+		return nil, domain.ErrInvitationUnavailable()
+	}
+
+	in.Prepare(config.Get().Account.InvitationDuration)
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = role.CheckLevel(tx, in.CreatedBy, in.Level); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = infraAuth.NewUserRepository(tx).AccountExists(in.Email); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	repo := infra.NewInvitationRepository(tx)
+	if err = repo.RevokePending(in.Email); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if invitationID, err = repo.Create(in); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for i := range in.Projects {
+		if err = repo.AddProject(invitationID, &in.Projects[i]); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionInviteUser).
+		Target(domainAudit.TargetInvitation, invitationID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	mail.SendAsync(&mail.Message{
+		To:      *in.Email,
+		Subject: i18n.Value("mail.invitation.subject"),
+		Body: i18n.Value("mail.invitation.body", *in.FirstName,
+			mail.Link("/invitation/accept?token="+invitationID.String()), in.ExpiresAt.Format(time.RFC1123)),
+	})
+
+	return
+}
+
+// List is the business logic to list the invitations that match the filters
+func List(ctx context.Context, params *utils.Params) (res *domain.Invitations, err error) {
+	if err = domain.ValidateParams(params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	res = new(domain.Invitations)
+	if res.Invitations, res.Next, err = infra.NewInvitationRepository(tx).List(params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for i := range res.Invitations {
+		res.Invitations[i].Prepare()
+	}
+
+	return
+}
+
+// Revoke is the business logic for an administrator to revoke a pending invitation
+func Revoke(ctx context.Context, invitationID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewInvitationRepository(tx).Revoke(invitationID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionRevokeInvitation).
+		Target(domainAudit.TargetInvitation, invitationID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Accept is the business logic for the invitee to create the account with the invitation link, choosing
+// the password. The account is created by the administrator that sent the invitation, with the level and
+// the projects of the invitation. It returns the url of the qrcode to enroll the 2FA
+func Accept(ctx context.Context, in *domain.AcceptInvitation) (url *string, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewInvitationRepository(tx)
+
+	var invitation *domain.Invitation
+	if invitation, err = repo.Use(in.Token); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var userID *uuid.UUID
+	if userID, url, err = auth.Register(tx, in.Account(invitation)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var joined []domain.Participation
+	if joined, err = repo.Join(invitation.ID, userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for _, participation := range joined {
+		if err = webhook.Enqueue(tx, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantJoined, participation.ProjectID,
+			&domainWebhook.ParticipantData{
+				ProjectID: participation.ProjectID, UserID: userID,
+				StartDate: participation.StartDate, DepartureDate: participation.DepartureDate,
+			})); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionAcceptInvitation).
+		Actor(userID).Target(domainAudit.TargetInvitation, invitation.ID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
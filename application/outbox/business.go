@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/outbox"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/outbox"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/publisher"
+)
+
+const (
+	// defaultBatchSize is the number of messages published on each run without configuration
+	defaultBatchSize uint64 = 100
+	// defaultLease is the time in seconds the relay has to publish the messages without configuration
+	defaultLease int64 = 300
+)
+
+// Add writes the message in the outbox inside the transaction of the change that produced it,
+// so that the message is only published when the change is committed
+func Add(tx *database.Transaction, message *domain.Message) (err error) {
+	if err = message.Encode(); err != nil {
+		return oops.Err(err)
+	}
+
+	return infra.NewOutboxRepository(tx).Add(message)
+}
+
+// Relay is the business logic to publish the messages of the outbox to the sink in the order they
+// were written. The messages are claimed in a transaction committed before publishing them, so that no
+// database lock is held while waiting for the sink. The relay stops on the first failure, so that a message
+// is never published before the ones written earlier, and the message is retried on the next run
+func Relay(ctx context.Context) (err error) {
+	policy := config.Get().Outbox
+
+	batch := policy.BatchSize
+	if batch == 0 {
+		batch = defaultBatchSize
+	}
+
+	lease := policy.Lease
+	if lease <= 0 {
+		lease = defaultLease
+	}
+
+	var messages []*domain.Message
+	if messages, err = claim(ctx, batch, lease); err != nil || len(messages) == 0 {
+		return err
+	}
+
+	var (
+		sent    int
+		failure error
+	)
+
+	for _, message := range messages {
+		if failure = publisher.Publish(ctx, &publisher.Message{
+			ID:        message.ID.String(),
+			Topic:     *message.Topic,
+			Key:       *message.AggregateType + ":" + *message.AggregateID,
+			Payload:   *message.Payload,
+			CreatedAt: *message.CreatedAt,
+		}); failure != nil {
+			break
+		}
+		sent++
+	}
+
+	if err = record(ctx, messages, sent, failure); err != nil {
+		return err
+	}
+
+	if failure != nil {
+		return oops.Err(failure)
+	}
+
+	return
+}
+
+// claim takes the messages to be published for the lease in seconds. If the results are not recorded
+// before the lease ends, the messages are published again on a later run
+func claim(ctx context.Context, batch uint64, lease int64) (messages []*domain.Message, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if messages, err = infra.NewOutboxRepository(tx).Claim(batch, lease); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// record marks the messages sent as published and the failure of the next one, releasing the claim of
+// the messages that were not published
+func record(ctx context.Context, messages []*domain.Message, sent int, failure error) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewOutboxRepository(tx)
+
+	ids := make([]*uuid.UUID, 0, len(messages))
+	for i, message := range messages {
+		ids = append(ids, message.ID)
+
+		switch {
+		case i < sent:
+			err = repo.Published(message.ID)
+		case i == sent && failure != nil:
+			err = repo.Failed(message.ID, failure.Error())
+		}
+
+		if err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = repo.Release(ids); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/isaqueveras/powersso/config"
+	pg "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/publisher"
+)
+
+const (
+	lockQuery      = `SELECT pg_advisory_xact_lock(hashtext('outbox'))`
+	claimQuery     = `SELECT id, topic, aggregate_type, aggregate_id, payload, attempts, created_at FROM outbox WHERE published_at IS NULL AND NOT EXISTS (SELECT 1 FROM outbox WHERE published_at IS NULL AND locked_until > NOW()) ORDER BY created_at, id LIMIT 100`
+	leaseQuery     = `UPDATE outbox SET locked_until = NOW() + ($1 * INTERVAL '1 second') WHERE id IN ($2,$3)`
+	publishedQuery = `UPDATE outbox SET attempts = attempts + 1, last_error = $1, published_at = NOW() WHERE id = $2`
+	failedQuery    = `UPDATE outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2`
+	releaseQuery   = `UPDATE outbox SET locked_until = $1 WHERE id IN ($2,$3)`
+
+	firstID  = "0a4c6e8f-1b3d-4f5a-8c7e-9d1f2a3b4c5d"
+	secondID = "1b5d7f9a-2c4e-4a6b-9d8f-0e2a3b4c5d6e"
+)
+
+func TestRelay(t *testing.T) {
+	suite.Run(t, new(relaySuite))
+}
+
+type relaySuite struct {
+	mock sqlmock.Sqlmock
+
+	suite.Suite
+}
+
+func (s *relaySuite) SetupSuite() {
+	s.T().Setenv("CONFIG_POWER_SSO", "../../app.json")
+	config.LoadConfig()
+}
+
+func (s *relaySuite) SetupTest() {
+	var err error
+	if s.mock, err = pg.OpenConnectionsForTests(); err != nil {
+		s.Assert().FailNow(err.Error())
+	}
+}
+
+func (s *relaySuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+	pg.CloseConnections()
+}
+
+func (s *relaySuite) pending() *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{"id", "topic", "aggregate_type", "aggregate_id", "payload", "attempts", "created_at"}).
+		AddRow(firstID, "user.created", "user", "c4b1c0de-3a6e-4b8d-9f10-2a3b4c5d6e7f", []byte(`{"topic":"user.created"}`), 0, now).
+		AddRow(secondID, "user.disabled", "user", "c4b1c0de-3a6e-4b8d-9f10-2a3b4c5d6e7f", []byte(`{"topic":"user.disabled"}`), 0, now)
+}
+
+func (s *relaySuite) claim() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(lockQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).WillReturnRows(s.pending())
+	s.mock.ExpectExec(regexp.QuoteMeta(leaseQuery)).WithArgs(300, firstID, secondID).WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+}
+
+func (s *relaySuite) TestShouldPublishInOrder() {
+	sink := publisher.NewMemorySink()
+	publisher.SetSink(sink)
+
+	s.claim()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(publishedQuery)).WithArgs(nil, firstID).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(publishedQuery)).WithArgs(nil, secondID).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(releaseQuery)).WithArgs(nil, firstID, secondID).WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(Relay(context.Background()))
+
+	messages := sink.Messages()
+	s.Require().Len(messages, 2)
+	s.Equal(firstID, messages[0].ID)
+	s.Equal("user.created", messages[0].Topic)
+	s.Equal("user:c4b1c0de-3a6e-4b8d-9f10-2a3b4c5d6e7f", messages[0].Key)
+	s.Equal(`{"topic":"user.created"}`, string(messages[0].Payload))
+	s.Equal(secondID, messages[1].ID)
+}
+
+func (s *relaySuite) TestShouldStopOnFailure() {
+	publisher.SetSink(&failingSink{})
+
+	s.claim()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(failedQuery)).WithArgs("broker unavailable", firstID).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(releaseQuery)).WithArgs(nil, firstID, secondID).WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	s.Require().Error(Relay(context.Background()))
+}
+
+func (s *relaySuite) TestShouldNotPublishWhenTheQueryFails() {
+	sink := publisher.NewMemorySink()
+	publisher.SetSink(sink)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(lockQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).WillReturnError(errors.New("connection lost"))
+	s.mock.ExpectRollback()
+
+	s.Require().Error(Relay(context.Background()))
+	s.Empty(sink.Messages())
+}
+
+func (s *relaySuite) TestShouldWaitForTheLeaseOfAnotherRelay() {
+	sink := publisher.NewMemorySink()
+	publisher.SetSink(sink)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(lockQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).WillReturnRows(sqlmock.NewRows([]string{"id", "topic", "aggregate_type", "aggregate_id", "payload", "attempts", "created_at"}))
+	s.mock.ExpectCommit()
+
+	s.Require().NoError(Relay(context.Background()))
+	s.Empty(sink.Messages())
+}
+
+type failingSink struct{}
+
+func (*failingSink) Publish(context.Context, *publisher.Message) error {
+	return errors.New("broker unavailable")
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domainAuth "github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/policy"
+	domainProject "github.com/isaqueveras/powersso/domain/project"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	infraAuth "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/policy"
+	infraProject "github.com/isaqueveras/powersso/infrastructure/persistencie/project"
+	infraRole "github.com/isaqueveras/powersso/infrastructure/persistencie/role"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// List is the business logic to list the policies
+func List(ctx context.Context) (res []domain.Policy, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewPolicyRepository(tx).List(nil); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Get is the business logic to fetch a policy
+func Get(ctx context.Context, policyID *uuid.UUID) (res *domain.Policy, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewPolicyRepository(tx).Get(policyID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Create is the business logic to create a policy
+func Create(ctx context.Context, in *domain.Policy) (err error) {
+	if err = in.Validate(); err != nil {
+		return oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewPolicyRepository(tx).Create(in); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionCreatePolicy).Target(domainAudit.TargetPolicy, in.ID)
+	event.Reason = in.Name
+	if err = audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Update is the business logic to replace a policy
+func Update(ctx context.Context, in *domain.Policy) (err error) {
+	if err = in.Validate(); err != nil {
+		return oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewPolicyRepository(tx).Update(in); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionUpdatePolicy).Target(domainAudit.TargetPolicy, in.ID)
+	event.Reason = in.Name
+	if err = audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Delete is the business logic to delete a policy
+func Delete(ctx context.Context, policyID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewPolicyRepository(tx).Delete(policyID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionDeletePolicy).
+		Target(domainAudit.TargetPolicy, policyID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// DryRun is the business logic to evaluate the policies for a request without enforcing them,
+// explaining the decision with the result of each policy, the inactive ones included, and the attributes
+func DryRun(ctx context.Context, in *domain.Request) (res *domain.Decision, err error) {
+	if err = in.Validate(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	var policies []domain.Policy
+	if policies, err = infra.NewPolicyRepository(tx).List(in.Event); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var attributes domain.Attributes
+	if attributes, err = loadAttributes(tx, in); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res = domain.Evaluate(policies, *in.Event, attributes)
+	res.Attributes = attributes
+
+	return
+}
+
+// Evaluate evaluates the policies of the event for the request in the transaction, the attributes
+// are only loaded when there are policies of the event
+func Evaluate(tx *database.Transaction, in *domain.Request) (_ *domain.Decision, err error) {
+	if err = in.Validate(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var policies []domain.Policy
+	if policies, err = infra.NewPolicyRepository(tx).List(in.Event); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if len(policies) == 0 {
+		return &domain.Decision{Allowed: true, Results: make([]domain.Result, 0)}, nil
+	}
+
+	var attributes domain.Attributes
+	if attributes, err = loadAttributes(tx, in); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return domain.Evaluate(policies, *in.Event, attributes), nil
+}
+
+// loadAttributes loads the attributes of the user, of the sessions and of the project of the request
+func loadAttributes(tx *database.Transaction, in *domain.Request) (domain.Attributes, error) {
+	user := &domainAuth.User{ID: in.UserID}
+	if err := infraAuth.NewUserRepository(tx).GetUser(user); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	roles, err := infraRole.NewRoleRepository(tx).UserRoles(in.UserID)
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var sessions []*domainAuth.ActiveSession
+	if sessions, err = infraAuth.NewSessionRepository(tx).List(in.UserID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	// on the tokens the request is made by the project, so the address and the agent are the ones of the session,
+	// which keeps how the user was authenticated. On the login the method is the one of the session being opened
+	for _, session := range sessions {
+		if in.SessionID == nil || *session.ID != *in.SessionID {
+			continue
+		}
+
+		if in.ClientIP == nil {
+			in.ClientIP, in.UserAgent = session.IP, session.UserAgent
+		}
+		in.Method = (*string)(session.Method)
+	}
+
+	attributes := &domain.UserAttributes{
+		ID:        user.ID,
+		Email:     user.Email,
+		OTP:       (*domainAuth.AuthMethod)(in.Method).HasOTP(),
+		Directory: user.DirectoryDN != nil,
+		Roles:     roleNames(roles),
+	}
+
+	if user.Level != nil {
+		attributes.Level = new(string)
+		*attributes.Level = string(*user.Level)
+	}
+
+	var project *domain.ProjectAttributes
+	if in.ProjectID != nil {
+		var access *domainProject.Access
+		if access, err = infraProject.New(tx).Access(in.ProjectID, in.UserID); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		project = &domain.ProjectAttributes{ID: in.ProjectID, Roles: access.Roles}
+		for _, permission := range access.Permissions {
+			project.Permissions = append(project.Permissions, string(permission))
+		}
+	}
+
+	location := config.Get().AccessPolicy.Location()
+	return in.Attributes(attributes, int64(len(sessions)), project, location), nil
+}
+
+func roleNames(roles []domainRole.Role) (names []string) {
+	for _, role := range roles {
+		names = append(names, *role.Name)
+	}
+	return
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package privacy
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/outbox"
+	"github.com/isaqueveras/powersso/application/webhook"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domainAuth "github.com/isaqueveras/powersso/domain/auth"
+	domainOutbox "github.com/isaqueveras/powersso/domain/outbox"
+	domain "github.com/isaqueveras/powersso/domain/privacy"
+	domainWebhook "github.com/isaqueveras/powersso/domain/webhook"
+	"github.com/isaqueveras/powersso/i18n"
+	infraAuth "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	infraIdentity "github.com/isaqueveras/powersso/infrastructure/persistencie/identity"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/privacy"
+	"github.com/isaqueveras/powersso/mail"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Export is the business logic to build the archive with the personal data of a user,
+// recording in the audit log who downloaded it
+func Export(ctx context.Context, userID *uuid.UUID) (res *domain.Export, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewPrivacyRepository(tx)
+	res = &domain.Export{GeneratedAt: utils.Pointer(time.Now())}
+
+	if res.Profile, err = infraAuth.NewUserRepository(tx).Profile(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res.Deletion, err = repo.Deletion(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res.Sessions, err = readAll(userID, repo.Sessions); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res.Participations, err = readAll(userID, repo.Participations); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res.AuditEvents, err = readAll(userID, repo.AuditEvents); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res.LockoutEvents, err = readAll(userID, infraAuth.NewLockoutRepository(tx).Events); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res.Identities, err = infraIdentity.NewIdentityRepository(tx).List(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionExportData).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// readAll reads all pages of a section of the export
+func readAll[T any](userID *uuid.UUID, page func(*uuid.UUID, *utils.Params) ([]T, *bool, error)) ([]T, error) {
+	var (
+		all    = make([]T, 0)
+		params = &utils.Params{Limit: domain.ExportPageSize}
+	)
+
+	for {
+		items, next, err := page(userID, params)
+		if err != nil {
+			return nil, oops.Err(err)
+		}
+
+		all = append(all, items...)
+		if next == nil || !*next {
+			return all, nil
+		}
+
+		params.Offset += params.Limit
+	}
+}
+
+// RequestDeletion is the business logic for users to delete their own account. The current password, or
+// a new sign in with a provider, is required and the account is disabled until the personal data is erased after the grace period
+func RequestDeletion(ctx context.Context, in *domain.DeleteAccount) (err error) {
+	in.Prepare()
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	user := &domainAuth.User{ID: in.UserID}
+	if err = infraAuth.NewUserRepository(tx).GetUser(user); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = auth.ConfirmIdentity(ctx, tx, user, &in.Confirmation, domainAudit.ActionRequestDeletion); err != nil {
+		return oops.Err(err)
+	}
+
+	deletion := domain.NewDeletion(user.ID, user.ID, user.IsActive(), config.Get().Account.DeletionGracePeriod)
+
+	var message *mail.Message
+	if message, err = scheduleDeletion(ctx, tx, user, deletion); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	mail.SendAsync(message)
+	return
+}
+
+// DeleteUser is the business logic for an administrator to delete the account of a user,
+// with the grace period of the configuration or erasing the personal data immediately
+func DeleteUser(ctx context.Context, userID, adminID *uuid.UUID, in *domain.DeleteUser) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	user := &domainAuth.User{ID: userID}
+	if err = infraAuth.NewUserRepository(tx).GetUser(user); err != nil {
+		return oops.Err(err)
+	}
+
+	gracePeriod := config.Get().Account.DeletionGracePeriod
+	if in.Immediate {
+		gracePeriod = 0
+	}
+
+	var message *mail.Message
+	if message, err = scheduleDeletion(ctx, tx, user, domain.NewDeletion(user.ID, adminID, user.IsActive(), gracePeriod)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	mail.SendAsync(message)
+	return
+}
+
+// scheduleDeletion records the deletion of the account, ending the sessions of the user. Without a grace period
+// the personal data is erased at once, otherwise the user receives a link to cancel the deletion
+func scheduleDeletion(ctx context.Context, tx *database.Transaction, user *domainAuth.User, deletion *domain.Deletion) (*mail.Message, error) {
+	if err := infra.NewPrivacyRepository(tx).ScheduleDeletion(deletion); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err := infraAuth.NewSessionRepository(tx).RevokeAll(user.ID, nil); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err := audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionRequestDeletion).
+		Target(domainAudit.TargetUser, user.ID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if deletion.Immediate() {
+		if err := erase(ctx, tx, user.ID); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		return &mail.Message{
+			To:      *user.Email,
+			Subject: i18n.Value("mail.account_deleted.subject"),
+			Body:    i18n.Value("mail.account_deleted.body", *user.FirstName),
+		}, nil
+	}
+
+	if err := outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserDeletionRequested, user.ID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	// only the users that requested the deletion can cancel it by email,
+	// the deletions of the administrators are only canceled by them
+	if !deletion.RequestedByUser() {
+		return &mail.Message{
+			To:      *user.Email,
+			Subject: i18n.Value("mail.deletion_scheduled.subject"),
+			Body:    i18n.Value("mail.deletion_scheduled.body", *user.FirstName, deletion.ScheduledAt.Format(time.RFC1123)),
+		}, nil
+	}
+
+	tokenID, err := infraAuth.NewTokenRepository(tx).Create(user.ID, domainAuth.CancelDeletionToken,
+		config.Get().Account.DeletionGracePeriod)
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return &mail.Message{
+		To:      *user.Email,
+		Subject: i18n.Value("mail.deletion_requested.subject"),
+		Body: i18n.Value("mail.deletion_requested.body", *user.FirstName, deletion.ScheduledAt.Format(time.RFC1123),
+			mail.Link("/me/deletion/cancel?token="+tokenID.String())),
+	}, nil
+}
+
+// CancelDeletion is the business logic for users to cancel the deletion of the account
+// with the token sent by email, restoring the account during the grace period
+func CancelDeletion(ctx context.Context, in *domain.CancelDeletion) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	var userID *uuid.UUID
+	if userID, err = infraAuth.NewTokenRepository(tx).Use(in.Token, domainAuth.CancelDeletionToken); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = cancelDeletion(ctx, tx, userID, userID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// CancelUserDeletion is the business logic for an administrator to cancel the deletion of the account of a user
+func CancelUserDeletion(ctx context.Context, userID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = cancelDeletion(ctx, tx, userID, nil); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// cancelDeletion removes the pending deletion of the account, recording the actor when it is not in the context
+func cancelDeletion(ctx context.Context, tx *database.Transaction, userID, actorID *uuid.UUID) (err error) {
+	if err = infra.NewPrivacyRepository(tx).CancelDeletion(userID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionCancelDeletion).
+		Actor(actorID).Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserDeletionCancelled, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Erase is the business logic to erase the personal data of the accounts whose grace period has passed
+func Erase(ctx context.Context) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	var users []uuid.UUID
+	if users, err = infra.NewPrivacyRepository(tx).DueDeletions(config.Get().Account.ErasureBatchSize); err != nil {
+		return oops.Err(err)
+	}
+
+	for i := range users {
+		if err = erase(ctx, tx, &users[i]); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// erase replaces the personal data of the user and notifies the webhooks of the projects the user left
+func erase(ctx context.Context, tx *database.Transaction, userID *uuid.UUID) error {
+	ended, err := infra.NewPrivacyRepository(tx).Anonymize(userID, domain.NewAnonymized(userID))
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	for _, participation := range ended {
+		if err = webhook.Enqueue(tx, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantLeft, participation.ProjectID,
+			&domainWebhook.ParticipantData{
+				ProjectID: participation.ProjectID, UserID: participation.UserID, DepartureDate: participation.DepartureDate,
+			})); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionEraseUser).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = outbox.Add(tx, domainOutbox.NewUserMessage(domainOutbox.TopicUserDeleted, userID)); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
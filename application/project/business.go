@@ -6,10 +6,19 @@ package project
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/webhook"
 	"github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
 	domain "github.com/isaqueveras/powersso/domain/project"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	domainWebhook "github.com/isaqueveras/powersso/domain/webhook"
 	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/project"
+	infraRole "github.com/isaqueveras/powersso/infrastructure/persistencie/role"
 	"github.com/isaqueveras/powersso/oops"
 	"github.com/isaqueveras/powersso/utils"
 )
@@ -27,8 +36,358 @@ func Create(ctx context.Context, input *CreateProjectReq) (err error) {
 		return oops.Err(err)
 	}
 
+	var projectID *uuid.UUID
 	repo := infra.New(transaction)
-	if err = repo.Create(data); err != nil {
+	if projectID, err = repo.Create(data); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(transaction, domainAudit.NewEvent(ctx, domainAudit.ActionCreateProject).
+		Target(domainAudit.TargetProject, projectID)); err != nil {
+		return oops.Err(err)
+	}
+
+	for _, role := range domain.NewDefaultRoles(projectID) {
+		if _, err = repo.CreateRole(&role); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	var createdBy uuid.UUID
+	if createdBy, err = uuid.Parse(*data.CreatedByID); err != nil {
+		return oops.Err(err)
+	}
+
+	for _, participant := range data.Participants {
+		var userID uuid.UUID
+		if userID, err = uuid.Parse(*participant.UserID); err != nil {
+			return oops.Err(err)
+		}
+
+		if err = repo.AssignRoles(projectID, &userID, &createdBy, domain.NormalizeRoles(participant.Roles)); err != nil {
+			return oops.Err(err)
+		}
+
+		if err = webhook.Enqueue(transaction, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantJoined, projectID,
+			&domainWebhook.ParticipantData{
+				ProjectID: projectID, UserID: &userID, StartDate: participant.StartDate, DepartureDate: participant.DepartureDate,
+			})); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// EndParticipations is the business logic for removing participants whose departure date has passed
+// and notifying the webhooks of their projects
+func EndParticipations(ctx context.Context) (err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	var ended []domain.EndedParticipation
+	if ended, err = infra.New(transaction).EndParticipations(); err != nil {
+		return oops.Err(err)
+	}
+
+	for _, participation := range ended {
+		if err = webhook.Enqueue(transaction, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantLeft, participation.ProjectID,
+			&domainWebhook.ParticipantData{
+				ProjectID: participation.ProjectID, UserID: participation.UserID, DepartureDate: participation.DepartureDate,
+			})); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// HasPermission is the business logic to check if the user can do the action in the project, which is allowed
+// by a role of the participant in the project or by the permission to manage all projects
+func HasPermission(ctx context.Context, projectID, userID *uuid.UUID, permission domain.Permission) (allowed bool, err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, true); err != nil {
+		return false, oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	if allowed, err = infraRole.NewRoleRepository(transaction).HasPermission(userID, domainRole.PermissionProjectManage); err != nil {
+		return false, oops.Err(err)
+	}
+
+	if allowed {
+		return
+	}
+
+	if allowed, err = infra.New(transaction).HasPermission(projectID, userID, permission); err != nil {
+		return false, oops.Err(err)
+	}
+
+	return
+}
+
+// Roles is the business logic to list the roles of a project
+func Roles(ctx context.Context, projectID *uuid.UUID) (res []domain.Role, err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	if res, err = infra.New(transaction).Roles(projectID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// CreateRole is the business logic to create a role of a project
+func CreateRole(ctx context.Context, in *domain.CreateRole) (roleID *uuid.UUID, err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	if roleID, err = infra.New(transaction).CreateRole(in); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionCreateProjectRole).Target(domainAudit.TargetProject, in.ProjectID)
+	event.Reason = in.Name
+	if err = audit.Record(transaction, event); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// UpdateRole is the business logic to update the description and the permissions of a role of a project
+func UpdateRole(ctx context.Context, projectID, roleID *uuid.UUID, in *domain.UpdateRole) (err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	repo := infra.New(transaction)
+
+	var role *domain.Role
+	if role, err = repo.GetRole(projectID, roleID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repo.UpdateRole(projectID, roleID, in); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionUpdateProjectRole).Target(domainAudit.TargetProject, projectID)
+	event.Reason = role.Name
+	if err = audit.Record(transaction, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// DeleteRole is the business logic to delete a role of a project, which is removed from the participants
+func DeleteRole(ctx context.Context, projectID, roleID *uuid.UUID) (err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	repo := infra.New(transaction)
+
+	var role *domain.Role
+	if role, err = repo.GetRole(projectID, roleID); err != nil {
+		return oops.Err(err)
+	}
+
+	if *role.IsDefault {
+		return domain.ErrDefaultRole()
+	}
+
+	if err = repo.DeleteRole(projectID, roleID); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionDeleteProjectRole).Target(domainAudit.TargetProject, projectID)
+	event.Reason = role.Name
+	if err = audit.Record(transaction, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Participants is the business logic to list the participants of a project with their roles
+func Participants(ctx context.Context, projectID *uuid.UUID) (res []domain.ProjectParticipant, err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	if res, err = infra.New(transaction).Participants(projectID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// AddParticipant is the business logic to add a participant to a project with the roles informed,
+// besides the default role of the project, and notify the webhooks of the project
+func AddParticipant(ctx context.Context, in *domain.AddParticipant) (err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	repo := infra.New(transaction)
+	if err = repo.AddParticipant(in); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repo.AssignRoles(in.ProjectID, in.UserID, in.CreatedBy, in.Roles); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionAddParticipant).Target(domainAudit.TargetProject, in.ProjectID)
+	event.Reason = utils.Pointer("user " + in.UserID.String())
+	if err = audit.Record(transaction, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = webhook.Enqueue(transaction, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantJoined, in.ProjectID,
+		&domainWebhook.ParticipantData{
+			ProjectID: in.ProjectID, UserID: in.UserID, StartDate: in.StartDate, DepartureDate: in.DepartureDate,
+		})); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// RemoveParticipant is the business logic to remove a participant of a project with its roles
+// and notify the webhooks of the project
+func RemoveParticipant(ctx context.Context, projectID, userID *uuid.UUID) (err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	if err = infra.New(transaction).RemoveParticipant(projectID, userID); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionRemoveParticipant).Target(domainAudit.TargetProject, projectID)
+	event.Reason = utils.Pointer("user " + userID.String())
+	if err = audit.Record(transaction, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = webhook.Enqueue(transaction, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantLeft, projectID,
+		&domainWebhook.ParticipantData{ProjectID: projectID, UserID: userID, DepartureDate: utils.Pointer(time.Now())})); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// AssignRole is the business logic to assign a role of a project to a participant.
+// The default role is given to all participants and cannot be assigned
+func AssignRole(ctx context.Context, in *domain.Assignment) (err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	repo := infra.New(transaction)
+
+	var role *domain.Role
+	if role, err = repo.GetRole(in.ProjectID, in.RoleID); err != nil {
+		return oops.Err(err)
+	}
+
+	if *role.IsDefault {
+		return domain.ErrDefaultRole()
+	}
+
+	if err = repo.AssignRole(in); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionAssignProjectRole).Target(domainAudit.TargetProject, in.ProjectID)
+	event.Reason = utils.Pointer("role " + *role.Name + " to user " + in.UserID.String())
+	if err = audit.Record(transaction, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = transaction.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// UnassignRole is the business logic to remove a role of a project assigned to a participant
+func UnassignRole(ctx context.Context, in *domain.Assignment) (err error) {
+	var transaction *postgres.Transaction
+	if transaction, err = postgres.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer transaction.Rollback()
+
+	repo := infra.New(transaction)
+
+	var role *domain.Role
+	if role, err = repo.GetRole(in.ProjectID, in.RoleID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repo.UnassignRole(in); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionUnassignProjectRole).Target(domainAudit.TargetProject, in.ProjectID)
+	event.Reason = utils.Pointer("role " + *role.Name + " of user " + in.UserID.String())
+	if err = audit.Record(transaction, event); err != nil {
 		return oops.Err(err)
 	}
 
@@ -28,6 +28,7 @@ type Participant struct {
 	UserID        *string    `json:"user_id"`
 	StartDate     *time.Time `json:"start_date"`
 	DepartureDate *time.Time `json:"departure_date,omitempty"`
+	Roles         []string   `json:"roles,omitempty"`
 }
 
 // Validate validation of data for registration
@@ -0,0 +1,242 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domain "github.com/isaqueveras/powersso/domain/relation"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/relation"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Namespaces is the business logic to list the namespaces with their relations
+func Namespaces(ctx context.Context) (res []domain.Namespace, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewRelationRepository(tx).Namespaces(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// SaveNamespace is the business logic to create a namespace or replace its relations,
+// the relations used by tuples cannot be removed
+func SaveNamespace(ctx context.Context, in *domain.Namespace) (err error) {
+	if err = in.Validate(); err != nil {
+		return oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewRelationRepository(tx)
+
+	var used []string
+	if used, err = repo.Relations(*in.Name); err != nil {
+		return oops.Err(err)
+	}
+
+	for _, relation := range used {
+		if _, err = in.Relation(relation); err != nil {
+			return oops.Err(domain.ErrNamespaceInUse())
+		}
+	}
+
+	if err = repo.SaveNamespace(in); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionSaveNamespace).
+		TargetKey(domainAudit.TargetNamespace, *in.Name)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// DeleteNamespace is the business logic to delete a namespace that is not used by tuples
+func DeleteNamespace(ctx context.Context, name string) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewRelationRepository(tx).DeleteNamespace(name); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionDeleteNamespace).
+		TargetKey(domainAudit.TargetNamespace, name)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Tuples is the business logic to list the tuples that match the filter
+func Tuples(ctx context.Context, filter *domain.TupleFilter) (res []domain.Tuple, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewRelationRepository(tx).Tuples(filter, config.Get().Relation.MaxObjects); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Write is the business logic to write and delete tuples together. The tuples written must use
+// the relations of the namespaces of the objects and of the subject sets
+func Write(ctx context.Context, in *domain.Write, createdBy *uuid.UUID) (err error) {
+	if err = in.Validate(); err != nil {
+		return oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewRelationRepository(tx)
+
+	namespaces := make(map[string]*domain.Namespace)
+	relation := func(namespace, name string) error {
+		if _, ok := namespaces[namespace]; !ok {
+			var err error
+			if namespaces[namespace], err = repo.GetNamespace(namespace); err != nil {
+				return err
+			}
+		}
+
+		if name == "" {
+			return nil
+		}
+
+		_, err := namespaces[namespace].Relation(name)
+		return err
+	}
+
+	for _, tuple := range in.Writes {
+		if err = relation(*tuple.Object.Type, *tuple.Relation); err != nil {
+			return oops.Err(err)
+		}
+
+		var subjectRelation string
+		if tuple.Subject.IsSet() {
+			subjectRelation = *tuple.Subject.Relation
+		}
+
+		if err = relation(*tuple.Subject.Type, subjectRelation); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = repo.Write(in, createdBy); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionWriteRelations)
+	event.Reason = utils.Pointer(fmt.Sprintf("%d written, %d deleted", len(in.Writes), len(in.Deletes)))
+	if err = audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Check is the business logic to check if the subject has the relation with the object
+func Check(ctx context.Context, in *domain.Check) (res *domain.CheckResult, err error) {
+	if err = in.Validate(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	res = new(domain.CheckResult)
+	engine := domain.NewEngine(infra.NewRelationRepository(tx), config.Get().Relation.MaxDepth)
+	if res.Allowed, err = engine.Check(in); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Expand is the business logic to build the tree of the subjects that have the relation with the object
+func Expand(ctx context.Context, in *domain.Expand) (res *domain.Tree, err error) {
+	if err = in.Validate(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	engine := domain.NewEngine(infra.NewRelationRepository(tx), config.Get().Relation.MaxDepth)
+	if res, err = engine.Expand(in); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// ListObjects is the business logic to list the objects of the type the subject has the relation with
+func ListObjects(ctx context.Context, in *domain.ListObjects) (res *domain.Objects, err error) {
+	if err = in.Validate(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	engine := domain.NewEngine(infra.NewRelationRepository(tx), config.Get().Relation.MaxDepth)
+	if res, err = engine.ListObjects(in, config.Get().Relation.MaxObjects); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domainAuth "github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/role"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/role"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// HasPermission is the business logic to check if a role of the user has the permission
+func HasPermission(ctx context.Context, userID *uuid.UUID, permission domain.Permission) (allowed bool, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return false, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if allowed, err = infra.NewRoleRepository(tx).HasPermission(userID, permission); err != nil {
+		return false, oops.Err(err)
+	}
+
+	return
+}
+
+// CheckLevel checks in the transaction if the user can give the level to another user. The levels above
+// the one of the users require the permission to manage the roles, which could be bypassed otherwise
+func CheckLevel(tx *database.Transaction, userID *uuid.UUID, level *domainAuth.Level) error {
+	if level == nil || !level.IsPrivileged() {
+		return nil
+	}
+
+	allowed, err := infra.NewRoleRepository(tx).HasPermission(userID, domain.PermissionRoleManage)
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if !allowed {
+		return domainAuth.ErrLevelNotAllowed()
+	}
+
+	return nil
+}
+
+// List is the business logic to list the roles with their permissions
+func List(ctx context.Context) (res []domain.Role, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewRoleRepository(tx).List(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Permissions is the business logic to list the permissions that can be given to the roles
+func Permissions(ctx context.Context) (res []domain.PermissionDetail, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewRoleRepository(tx).Permissions(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Create is the business logic to create a role
+func Create(ctx context.Context, in *domain.CreateRole) (roleID *uuid.UUID, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if roleID, err = infra.NewRoleRepository(tx).Create(in); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionCreateRole).Target(domainAudit.TargetRole, roleID)
+	event.Reason = in.Name
+	if err = audit.Record(tx, event); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Update is the business logic to update the description and the permissions of a role. The permissions
+// of the roles of the levels can be changed, but they are still given to all users of the level
+func Update(ctx context.Context, roleID *uuid.UUID, in *domain.UpdateRole) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewRoleRepository(tx).Update(roleID, in); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionUpdateRole).
+		Target(domainAudit.TargetRole, roleID)); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Delete is the business logic to delete a role, which is removed from the users it was assigned to
+func Delete(ctx context.Context, roleID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewRoleRepository(tx)
+
+	var role *domain.Role
+	if role, err = repo.Get(roleID); err != nil {
+		return oops.Err(err)
+	}
+
+	if role.IsLevel() {
+		return domain.ErrLevelRole()
+	}
+
+	if err = repo.Delete(roleID); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionDeleteRole).Target(domainAudit.TargetRole, roleID)
+	event.Reason = role.Name
+	if err = audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// UserRoles is the business logic to list the roles of a user, including the one of the level of the user
+func UserRoles(ctx context.Context, userID *uuid.UUID) (res []domain.Role, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewRoleRepository(tx).UserRoles(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Assign is the business logic to assign a role to a user. The roles of the levels are given
+// by the level of the user and cannot be assigned
+func Assign(ctx context.Context, in *domain.Assignment) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewRoleRepository(tx)
+
+	var role *domain.Role
+	if role, err = repo.Get(in.RoleID); err != nil {
+		return oops.Err(err)
+	}
+
+	if role.IsLevel() {
+		return domain.ErrLevelRole()
+	}
+
+	if err = repo.Assign(in); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionAssignRole).Target(domainAudit.TargetUser, in.UserID)
+	event.Reason = utils.Pointer("role " + *role.Name)
+	if err = audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Unassign is the business logic to remove a role assigned to a user
+func Unassign(ctx context.Context, userID, roleID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewRoleRepository(tx)
+
+	var role *domain.Role
+	if role, err = repo.Get(roleID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = repo.Unassign(userID, roleID); err != nil {
+		return oops.Err(err)
+	}
+
+	event := domainAudit.NewEvent(ctx, domainAudit.ActionUnassignRole).Target(domainAudit.TargetUser, userID)
+	event.Reason = utils.Pointer("role " + *role.Name)
+	if err = audit.Record(tx, event); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
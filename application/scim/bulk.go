@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	domain "github.com/isaqueveras/powersso/domain/scim"
+)
+
+// Bulk is the business logic to run the operations of a bulk request in order. Each operation is
+// independent, so that the errors are reported in its result, and the request stops after the number
+// of errors of failOnErrors. The operations can reference the resources created by the previous ones
+func Bulk(ctx context.Context, in *domain.BulkRequest, actorID *uuid.UUID) (res *domain.BulkResponse, err error) {
+	if len(in.Operations) > config.Get().SCIM.BulkMaxOperations {
+		return nil, domain.ErrTooManyOperations()
+	}
+
+	res = domain.NewBulkResponse()
+	created, failures := make(map[string]string), 0
+
+	for i := range in.Operations {
+		if in.FailOnErrors != nil && *in.FailOnErrors > 0 && failures >= *in.FailOnErrors {
+			break
+		}
+
+		op := &in.Operations[i]
+		op.Resolve(created)
+
+		result := domain.BulkResult{BulkID: op.BulkID}
+
+		resourceID, status, err := bulkOperation(ctx, op, actorID)
+		if err != nil {
+			failures++
+			status, result.Response = domain.NewErrorResponse(err)
+		}
+
+		if resourceID != nil {
+			result.Location = config.Get().SCIM.BaseURL + "/" + op.Endpoint() + "/" + resourceID.String()
+			if op.Method == domain.MethodPost {
+				created[*op.BulkID] = resourceID.String()
+			}
+		}
+
+		result.Method, result.Status = op.Method, strconv.Itoa(status)
+		res.Operations = append(res.Operations, result)
+	}
+
+	return
+}
+
+// bulkOperation runs an operation of a bulk request, returning the resource changed
+func bulkOperation(ctx context.Context, op *domain.BulkOperation, actorID *uuid.UUID) (resourceID *uuid.UUID, status int, err error) {
+	var id *uuid.UUID
+	if id, err = op.Target(); err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		user  domain.User
+		group domain.Group
+		patch domain.PatchRequest
+	)
+
+	switch op.Endpoint() + " " + op.Method {
+	case domain.EndpointUsers + " " + domain.MethodPost:
+		var res *domain.User
+		if err = decode(op, &user); err == nil {
+			if res, err = CreateUser(ctx, &user, actorID); err == nil {
+				return res.ID, http.StatusCreated, nil
+			}
+		}
+
+	case domain.EndpointUsers + " " + domain.MethodPut:
+		if err = decode(op, &user); err == nil {
+			_, err = ReplaceUser(ctx, id, &user)
+		}
+
+	case domain.EndpointUsers + " " + domain.MethodPatch:
+		if err = decode(op, &patch); err == nil {
+			_, err = PatchUser(ctx, id, &patch)
+		}
+
+	case domain.EndpointUsers + " " + domain.MethodDelete:
+		if err = DeleteUser(ctx, id, actorID); err == nil {
+			return nil, http.StatusNoContent, nil
+		}
+
+	case domain.EndpointGroups + " " + domain.MethodPost:
+		var res *domain.Group
+		if err = decode(op, &group); err == nil {
+			if res, err = CreateGroup(ctx, &group, actorID); err == nil {
+				return res.ID, http.StatusCreated, nil
+			}
+		}
+
+	case domain.EndpointGroups + " " + domain.MethodPut:
+		if err = decode(op, &group); err == nil {
+			_, err = ReplaceGroup(ctx, id, &group)
+		}
+
+	case domain.EndpointGroups + " " + domain.MethodPatch:
+		if err = decode(op, &patch); err == nil {
+			_, err = PatchGroup(ctx, id, &patch)
+		}
+
+	case domain.EndpointGroups + " " + domain.MethodDelete:
+		err = domain.ErrGroupDeletion()
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return id, http.StatusOK, nil
+}
+
+// decode decodes the data of a bulk operation, the patches must have operations
+func decode(op *domain.BulkOperation, in interface{}) error {
+	if err := json.Unmarshal(op.Data, in); err != nil {
+		return domain.ErrInvalidValue()
+	}
+
+	if patch, ok := in.(*domain.PatchRequest); ok && len(patch.Operations) == 0 {
+		return domain.ErrInvalidPatch()
+	}
+
+	return nil
+}
@@ -0,0 +1,467 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/outbox"
+	"github.com/isaqueveras/powersso/application/privacy"
+	"github.com/isaqueveras/powersso/application/webhook"
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domainAudit "github.com/isaqueveras/powersso/domain/audit"
+	domainAuth "github.com/isaqueveras/powersso/domain/auth"
+	domainOutbox "github.com/isaqueveras/powersso/domain/outbox"
+	domainPrivacy "github.com/isaqueveras/powersso/domain/privacy"
+	domain "github.com/isaqueveras/powersso/domain/scim"
+	domainWebhook "github.com/isaqueveras/powersso/domain/webhook"
+	infraAuth "github.com/isaqueveras/powersso/infrastructure/persistencie/auth"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/scim"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// generatedPasswordLength is the size of the password of the users provisioned without one,
+// who must reset it to log in
+const generatedPasswordLength = 16
+
+// Users is the business logic to list the users managed by the provisioning that match the filter
+func Users(ctx context.Context, params *domain.ListParams) (res *domain.ListResponse[domain.User], err error) {
+	cfg := config.Get().SCIM
+
+	var filter domain.Filter
+	if filter, err = params.Prepare(cfg.MaxResults); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	users, total, err := infra.NewSCIMRepository(tx).Users(filter, params)
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for i := range users {
+		users[i].Prepare(cfg.BaseURL)
+	}
+
+	return domain.NewListResponse(users, total, params), nil
+}
+
+// User is the business logic to fetch a user managed by the provisioning
+func User(ctx context.Context, userID *uuid.UUID) (res *domain.User, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewSCIMRepository(tx).User(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res.Prepare(config.Get().SCIM.BaseURL)
+	return
+}
+
+// CreateUser is the business logic to provision the account of a user. The users provisioned
+// without a password receive a random one and must reset it to log in
+func CreateUser(ctx context.Context, in *domain.User, createdBy *uuid.UUID) (res *domain.User, err error) {
+	var account *domain.Account
+	if account, err = in.Account(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewSCIMRepository(tx)
+
+	var exists bool
+	if exists, err = repo.EmailExists(account.Email, nil); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if exists {
+		return nil, domain.ErrUserExists()
+	}
+
+	password := account.Password
+	if password == nil {
+		password = utils.Pointer(utils.RandomString(generatedPasswordLength))
+	}
+
+	var userID *uuid.UUID
+	if userID, _, err = auth.Register(tx, &domainAuth.CreateAccount{
+		FirstName: account.FirstName,
+		LastName:  account.LastName,
+		Email:     account.Email,
+		Password:  password,
+		Level:     utils.Pointer(domainAuth.UserLevel),
+		CreatedBy: createdBy,
+	}); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	account.Password = nil
+	if err = repo.UpdateUser(userID, account); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionCreateAccount).
+		Target(domainAudit.TargetUser, userID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res, err = repo.User(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res.Prepare(config.Get().SCIM.BaseURL)
+	return
+}
+
+// ReplaceUser is the business logic to replace the attributes of a user
+func ReplaceUser(ctx context.Context, userID *uuid.UUID, in *domain.User) (res *domain.User, err error) {
+	return updateUser(ctx, userID, func(*domain.User) (*domain.User, error) {
+		return in, nil
+	})
+}
+
+// PatchUser is the business logic to change some attributes of a user with the operations of a patch
+func PatchUser(ctx context.Context, userID *uuid.UUID, in *domain.PatchRequest) (res *domain.User, err error) {
+	return updateUser(ctx, userID, func(current *domain.User) (*domain.User, error) {
+		patched, err := domain.Patch(current, in)
+		if err != nil {
+			return nil, err
+		}
+
+		patched.SyncEmail(current)
+		return patched, nil
+	})
+}
+
+// updateUser writes the account of a user with the attributes of the change. Changing the password or
+// deactivating the user ends the sessions, and the activation is notified like in the other flows
+func updateUser(ctx context.Context, userID *uuid.UUID, change func(*domain.User) (*domain.User, error)) (res *domain.User, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewSCIMRepository(tx)
+
+	var current *domain.User
+	if current, err = repo.User(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+	current.Prepare(config.Get().SCIM.BaseURL)
+
+	var changed *domain.User
+	if changed, err = change(current); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var account *domain.Account
+	if account, err = changed.Account(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if !strings.EqualFold(*account.Email, *current.UserName) {
+		var exists bool
+		if exists, err = repo.EmailExists(account.Email, userID); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		if exists {
+			return nil, domain.ErrUserExists()
+		}
+	}
+
+	if account.Password != nil {
+		hash := &domainAuth.CreateAccount{Password: account.Password}
+		if err = hash.GeneratePassword(); err != nil {
+			return nil, oops.Err(err)
+		}
+		account.Password, account.Key = hash.Password, hash.Key
+	}
+
+	if err = repo.UpdateUser(userID, account); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	deactivated := *current.Active && !*account.Active
+	if deactivated || account.Password != nil {
+		if err = infraAuth.NewSessionRepository(tx).RevokeAll(userID, nil); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	if err = userEvents(ctx, tx, userID, current, account); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res, err = repo.User(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res.Prepare(config.Get().SCIM.BaseURL)
+	return
+}
+
+// userEvents records the change of a user in the audit log and notifies the webhooks and the outbox
+func userEvents(ctx context.Context, tx *database.Transaction, userID *uuid.UUID, current *domain.User, account *domain.Account) (err error) {
+	actions := []domainAudit.Action{domainAudit.ActionUpdateUser}
+	topics := []string{domainOutbox.TopicUserUpdated}
+
+	switch {
+	case *current.Active && !*account.Active:
+		actions, topics = append(actions, domainAudit.ActionDisableUser), append(topics, domainOutbox.TopicUserDisabled)
+		if err = webhook.Enqueue(tx, domainWebhook.NewUserEvent(domainWebhook.EventUserDisabled, userID,
+			&domainWebhook.UserData{UserID: userID})); err != nil {
+			return oops.Err(err)
+		}
+
+	case !*current.Active && *account.Active:
+		actions, topics = append(actions, domainAudit.ActionEnableUser), append(topics, domainOutbox.TopicUserEnabled)
+	}
+
+	if account.Password != nil {
+		topics = append(topics, domainOutbox.TopicUserPasswordChanged)
+		if err = webhook.Enqueue(tx, domainWebhook.NewUserEvent(domainWebhook.EventUserPasswordChanged, userID,
+			&domainWebhook.UserData{UserID: userID})); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	for _, action := range actions {
+		if err = audit.Record(tx, domainAudit.NewEvent(ctx, action).Target(domainAudit.TargetUser, userID)); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	for _, topic := range topics {
+		if err = outbox.Add(tx, domainOutbox.NewUserMessage(topic, userID)); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	return
+}
+
+// DeleteUser is the business logic to deprovision a user, erasing the personal data immediately
+func DeleteUser(ctx context.Context, userID, requestedBy *uuid.UUID) (err error) {
+	if _, err = User(ctx, userID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = privacy.DeleteUser(ctx, userID, requestedBy, &domainPrivacy.DeleteUser{Immediate: true}); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Groups is the business logic to list the groups that match the filter
+func Groups(ctx context.Context, params *domain.ListParams) (res *domain.ListResponse[domain.Group], err error) {
+	cfg := config.Get().SCIM
+
+	var filter domain.Filter
+	if filter, err = params.Prepare(cfg.MaxResults); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	groups, total, err := infra.NewSCIMRepository(tx).Groups(filter, params)
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for i := range groups {
+		groups[i].Prepare(cfg.BaseURL)
+	}
+
+	return domain.NewListResponse(groups, total, params), nil
+}
+
+// Group is the business logic to fetch a group, without the members when they are excluded
+func Group(ctx context.Context, groupID *uuid.UUID, params *domain.ListParams) (res *domain.Group, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if res, err = infra.NewSCIMRepository(tx).Group(groupID, !params.Excluded("members")); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res.Prepare(config.Get().SCIM.BaseURL)
+	return
+}
+
+// CreateGroup is the business logic to provision a group as a project, adding the members as participants
+func CreateGroup(ctx context.Context, in *domain.Group, createdBy *uuid.UUID) (res *domain.Group, err error) {
+	var project *domain.Project
+	if project, err = in.Project(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewSCIMRepository(tx)
+
+	var groupID *uuid.UUID
+	if groupID, err = repo.CreateGroup(project, createdBy); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionCreateProject).
+		Target(domainAudit.TargetProject, groupID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = changeMembers(tx, repo, groupID, project.Members, nil); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res, err = repo.Group(groupID, true); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res.Prepare(config.Get().SCIM.BaseURL)
+	return
+}
+
+// ReplaceGroup is the business logic to replace the name and the members of a group
+func ReplaceGroup(ctx context.Context, groupID *uuid.UUID, in *domain.Group) (res *domain.Group, err error) {
+	return updateGroup(ctx, groupID, func(*domain.Group) (*domain.Group, error) {
+		return in, nil
+	})
+}
+
+// PatchGroup is the business logic to change the name or the members of a group with the operations of a patch
+func PatchGroup(ctx context.Context, groupID *uuid.UUID, in *domain.PatchRequest) (res *domain.Group, err error) {
+	return updateGroup(ctx, groupID, func(current *domain.Group) (*domain.Group, error) {
+		return domain.Patch(current, in)
+	})
+}
+
+// updateGroup writes the project of a group, adding and removing the participants that changed
+func updateGroup(ctx context.Context, groupID *uuid.UUID, change func(*domain.Group) (*domain.Group, error)) (res *domain.Group, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewSCIMRepository(tx)
+
+	var current *domain.Group
+	if current, err = repo.Group(groupID, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	current.Prepare(config.Get().SCIM.BaseURL)
+
+	var changed *domain.Group
+	if changed, err = change(current); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var project *domain.Project
+	if project, err = changed.Project(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = repo.UpdateGroup(groupID, project); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	added, removed := project.Changes(current.Members)
+	if err = changeMembers(tx, repo, groupID, added, removed); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = audit.Record(tx, domainAudit.NewEvent(ctx, domainAudit.ActionUpdateProject).
+		Target(domainAudit.TargetProject, groupID)); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if res, err = repo.Group(groupID, true); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	res.Prepare(config.Get().SCIM.BaseURL)
+	return
+}
+
+// changeMembers adds and removes the participants of the project, notifying the webhooks of the project
+func changeMembers(tx *database.Transaction, repo domain.ISCIM, groupID *uuid.UUID, added, removed []uuid.UUID) (err error) {
+	today := time.Now().Truncate(24 * time.Hour)
+
+	for i := range added {
+		if err = repo.AddMember(groupID, &added[i]); err != nil {
+			return oops.Err(err)
+		}
+
+		if err = webhook.Enqueue(tx, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantJoined, groupID,
+			&domainWebhook.ParticipantData{ProjectID: groupID, UserID: &added[i], StartDate: &today})); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	for i := range removed {
+		if err = repo.RemoveMember(groupID, &removed[i]); err != nil {
+			return oops.Err(err)
+		}
+
+		if err = webhook.Enqueue(tx, domainWebhook.NewProjectEvent(domainWebhook.EventParticipantLeft, groupID,
+			&domainWebhook.ParticipantData{ProjectID: groupID, UserID: &removed[i], DepartureDate: &today})); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/webhook"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/webhook"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const (
+	// defaultBatchSize is the number of deliveries sent on each run without configuration
+	defaultBatchSize uint64 = 50
+	// defaultTimeout is the time in seconds to wait for the response of a webhook without configuration
+	defaultTimeout int64 = 10
+	// maxErrorLength is the number of characters of the error of an attempt that are kept
+	maxErrorLength int = 500
+)
+
+// Enqueue schedules the deliveries of an event to the subscribed webhooks inside the transaction
+// of the action, so that the event is only sent when the action is committed
+func Enqueue(tx *database.Transaction, event *domain.Event) (err error) {
+	var payload []byte
+	if payload, err = event.Payload(); err != nil {
+		return oops.Err(err)
+	}
+
+	return infra.NewWebhookRepository(tx).Enqueue(event, payload)
+}
+
+// Create is the business logic to subscribe a webhook to the events of a project
+func Create(ctx context.Context, webhook *domain.Webhook) (_ *domain.Webhook, err error) {
+	if err = webhook.Prepare(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if webhook.ID, err = infra.NewWebhookRepository(tx).Create(webhook); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	webhook.Active = utils.Pointer(true)
+	return webhook, nil
+}
+
+// List is the business logic to fetch the webhooks of a project
+func List(ctx context.Context, projectID *uuid.UUID) (webhooks []domain.Webhook, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if webhooks, err = infra.NewWebhookRepository(tx).List(projectID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Delete is the business logic to remove a webhook of a project
+func Delete(ctx context.Context, projectID, webhookID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewWebhookRepository(tx).Delete(projectID, webhookID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Deliveries is the business logic to fetch the deliveries of a webhook
+func Deliveries(ctx context.Context, projectID, webhookID *uuid.UUID, params *utils.Params) (res *domain.Deliveries, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, true); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	res = new(domain.Deliveries)
+	if res.Deliveries, res.Next, err = infra.NewWebhookRepository(tx).Deliveries(projectID, webhookID, params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Redeliver is the business logic to send a delivery again, including the ones in the dead-letter state
+func Redeliver(ctx context.Context, projectID, webhookID, deliveryID *uuid.UUID) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = infra.NewWebhookRepository(tx).Redeliver(projectID, webhookID, deliveryID); err != nil {
+		return oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Deliver is the business logic to send the pending deliveries whose next attempt has come,
+// scheduling a retry with backoff for the ones that fail. The deliveries are claimed in a transaction
+// committed before sending them, so that no database lock is held while waiting for the webhooks
+func Deliver(ctx context.Context) (err error) {
+	policy := config.Get().Webhook
+
+	batch := policy.BatchSize
+	if batch == 0 {
+		batch = defaultBatchSize
+	}
+
+	var deliveries []*domain.Delivery
+	timeout := policy.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	if deliveries, err = claim(ctx, batch, int64(batch+1)*timeout); err != nil || len(deliveries) == 0 {
+		return err
+	}
+
+	client := newClient(time.Duration(timeout) * time.Second)
+	results := make([]*domain.Result, len(deliveries))
+	for i, delivery := range deliveries {
+		results[i] = send(ctx, client, delivery)
+	}
+
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	repo := infra.NewWebhookRepository(tx)
+	for i, delivery := range deliveries {
+		if results[i].Succeeded() {
+			if err = repo.Delivered(delivery.ID, results[i]); err != nil {
+				return oops.Err(err)
+			}
+			continue
+		}
+
+		delivery.Fail(&policy, results[i])
+		if err = repo.Failed(delivery); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// claim marks the deliveries to be sent as in flight for the lease in seconds. If the results are not
+// recorded before the lease ends, the deliveries are sent again on a later run
+func claim(ctx context.Context, batch uint64, lease int64) (deliveries []*domain.Delivery, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return nil, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if deliveries, err = infra.NewWebhookRepository(tx).Claim(batch, lease); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// newClient creates the client used to send the deliveries. The addresses are checked when connecting,
+// so that neither a change in the DNS records nor a redirect makes a webhook reach an internal network
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || domain.IsInternalIP(ip) {
+				return domain.ErrInternalAddress()
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+	}
+}
+
+// send posts the payload of the delivery to the webhook, signed with its secret
+func send(ctx context.Context, client *http.Client, delivery *domain.Delivery) *domain.Result {
+	failure := func(err error) *domain.Result {
+		msg := []rune(err.Error())
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		return &domain.Result{Error: utils.Pointer(string(msg))}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *delivery.URL, bytes.NewReader(*delivery.Payload))
+	if err != nil {
+		return failure(err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(domain.HeaderEvent, string(*delivery.Event))
+	req.Header.Set(domain.HeaderDelivery, delivery.ID.String())
+	req.Header.Set(domain.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(domain.HeaderSignature, domain.Sign(*delivery.Secret, timestamp, *delivery.Payload))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return failure(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	result := &domain.Result{StatusCode: utils.Pointer(int64(res.StatusCode))}
+	if !result.Succeeded() {
+		result.Error = utils.Pointer(res.Status)
+	}
+
+	return result
+}
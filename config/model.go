@@ -11,6 +11,9 @@ const (
 	modeDevelopment string = "dev"
 	// modeDevelopment represents the production environment mode
 	modeProduction string = "prod"
+
+	// defaultSessionIdleTimeout is the idle timeout in seconds of sessions without policy
+	defaultSessionIdleTimeout int64 = 900
 )
 
 // Config type represents the application settings
@@ -18,9 +21,45 @@ type Config struct {
 	ProjectName string         `json:"project_name"`
 	Server      ServerConfig   `json:"server"`
 	Database    DatabaseConfig `json:"database"`
+	Redis       RedisConfig    `json:"redis"`
+	Mail        MailConfig     `json:"mail"`
 
 	SecretsDuration int64   `json:"secrets_duration"`
 	SecretsTokens   Secrets `json:"secrets_tokens"`
+
+	PasswordPolicy PasswordPolicyConfig `json:"password_policy"`
+	Lockout        LockoutConfig        `json:"lockout"`
+	RateLimit      RateLimitConfig      `json:"rate_limit"`
+	Scheduler      SchedulerConfig      `json:"scheduler"`
+
+	LoginNotification LoginNotificationConfig `json:"login_notification"`
+	Audit             AuditConfig             `json:"audit"`
+	Webhook           WebhookConfig           `json:"webhook"`
+	Outbox            OutboxConfig            `json:"outbox"`
+	Account           AccountConfig           `json:"account"`
+	SCIM              SCIMConfig              `json:"scim"`
+	LDAP              LDAPConfig              `json:"ldap"`
+	OIDC              OIDCConfig              `json:"oidc"`
+	Relation          RelationConfig          `json:"relation"`
+	AccessPolicy      AccessPolicyConfig      `json:"access_policy"`
+
+	// SessionPolicy is the session policy by user level
+	SessionPolicy map[string]SessionPolicyConfig `json:"session_policy"`
+}
+
+// GetSessionPolicy returns the session policy of the user level, filling the values not configured
+func (c *Config) GetSessionPolicy(level string) SessionPolicyConfig {
+	policy := c.SessionPolicy[level]
+	if policy.IdleTimeout <= 0 {
+		policy.IdleTimeout = defaultSessionIdleTimeout
+	}
+	if policy.MaxLifetime <= 0 {
+		policy.MaxLifetime = c.SecretsDuration
+	}
+	if policy.MaxSessions <= 0 {
+		policy.MaxSessions = c.Server.OpenSessionsPerUser
+	}
+	return policy
 }
 
 // GetSecrets returns a list of tokens
@@ -65,6 +104,36 @@ type DatabaseConfig struct {
 	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
 }
 
+// RedisConfig models redis configuration data
+type RedisConfig struct {
+	Enabled         bool   `json:"enabled"`
+	RedisAddr       string `json:"redis_addr"`
+	Password        string `json:"password"`
+	DB              int    `json:"db"`
+	PoolSize        int    `json:"pool_size"`
+	MinIdleConns    int    `json:"min_idle_conns"`
+	MaxIdleConns    int    `json:"max_idle_conns"`
+	PoolTimeout     int64  `json:"pool_timeout"`
+	ConnMaxIdleTime int64  `json:"conn_max_idle_time"`
+	DialTimeout     int64  `json:"dial_timeout"`
+	ReadTimeout     int64  `json:"read_timeout"`
+	WriteTimeout    int64  `json:"write_timeout"`
+}
+
+// MailConfig models the settings to send emails to the users
+type MailConfig struct {
+	Enabled bool `json:"enabled"`
+	// Backend is how the messages are delivered: "smtp" or "log"
+	Backend  string `json:"backend"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	// BaseURL is the public address of the pages that handle the links of the messages
+	BaseURL string `json:"base_url"`
+}
+
 // Secrets models the data for the token configuration
 type Secrets struct {
 	User        string `json:"user"`
@@ -72,6 +141,234 @@ type Secrets struct {
 	Integration string `json:"integration"`
 }
 
+// PasswordPolicyConfig models the password rotation settings
+type PasswordPolicyConfig struct {
+	// ExpirationDays is the number of days a password is valid, by user level.
+	// Levels without a value or with zero never expire
+	ExpirationDays map[string]int64 `json:"expiration_days"`
+	// ChangeTokenDuration is the duration in seconds of the token
+	// issued to users that must change their password
+	ChangeTokenDuration int64 `json:"change_token_duration"`
+}
+
+// LockoutConfig models the account lockout policy after failed login attempts
+type LockoutConfig struct {
+	// MaxAttempts is the number of failed attempts that locks the account
+	MaxAttempts int64 `json:"max_attempts"`
+	// Window is the time in seconds in which failed attempts are counted
+	Window int64 `json:"window"`
+	// Duration is the time in seconds of the first lock
+	Duration int64 `json:"duration"`
+	// BackoffMultiplier multiplies the lock duration for each failed attempt after the lock
+	BackoffMultiplier float64 `json:"backoff_multiplier"`
+	// PermanentAfter is the number of failed attempts that locks the account
+	// until an administrator unlocks it. Zero disables the permanent lock
+	PermanentAfter int64 `json:"permanent_after"`
+}
+
+// SessionPolicyConfig models the lifetime settings of the sessions
+type SessionPolicyConfig struct {
+	// IdleTimeout is the time in seconds without requests that ends the session
+	IdleTimeout int64 `json:"idle_timeout"`
+	// MaxLifetime is the maximum time in seconds of the session, even with requests
+	MaxLifetime int64 `json:"max_lifetime"`
+	// MaxSessions is the number of sessions a user can have open at the same time
+	MaxSessions int64 `json:"max_sessions"`
+	// ConcurrentStrategy is what happens on login when the user reaches the maximum
+	// number of sessions: "evict_oldest", "reject" or "choose"
+	ConcurrentStrategy string `json:"concurrent_strategy"`
+}
+
+// RateLimitConfig models the rate limit settings of the authentication endpoints
+type RateLimitConfig struct {
+	Enabled bool `json:"enabled"`
+	// Backend is where the limits are stored: "memory", "postgres" or "redis"
+	Backend string        `json:"backend"`
+	IP      RateLimitRule `json:"ip"`
+	Email   RateLimitRule `json:"email"`
+}
+
+// RateLimitRule models the token bucket of a rate limit
+type RateLimitRule struct {
+	// Requests is the number of requests allowed by period
+	Requests int64 `json:"requests"`
+	// Period is the time in seconds to refill the requests
+	Period int64 `json:"period"`
+	// Burst is the maximum number of requests allowed at once
+	Burst int64 `json:"burst"`
+}
+
+// SchedulerConfig models the settings of the background jobs
+type SchedulerConfig struct {
+	Enabled bool `json:"enabled"`
+	// Tick is the time in seconds between the checks for jobs to run
+	Tick int64 `json:"tick"`
+	// Jobs is the interval in seconds of each job by name.
+	// Jobs without a value or with zero are not run
+	Jobs map[string]int64 `json:"jobs"`
+}
+
+// LoginNotificationConfig models the settings of the notifications of logins from new devices
+type LoginNotificationConfig struct {
+	Enabled bool `json:"enabled"`
+	// Lookback is the number of days of sessions compared with a new login
+	Lookback int64 `json:"lookback"`
+	// SecureTokenDuration is the duration in seconds of the "this wasn't me" link
+	SecureTokenDuration int64 `json:"secure_token_duration"`
+	// ResetTokenDuration is the duration in seconds of the link to reset the password
+	ResetTokenDuration int64 `json:"reset_token_duration"`
+}
+
+// AuditConfig models the settings of the audit log
+type AuditConfig struct {
+	// SigningKey is the base64 ed25519 seed used to sign the checkpoints of the audit log.
+	// Without a key the checkpoints are not signed
+	SigningKey string `json:"signing_key"`
+	// VerifyBatch is the number of events read at once when verifying the audit log
+	VerifyBatch uint64 `json:"verify_batch"`
+}
+
+// WebhookConfig models the delivery settings of the webhooks
+type WebhookConfig struct {
+	// MaxAttempts is the number of attempts before a delivery moves to the dead-letter state
+	MaxAttempts int64 `json:"max_attempts"`
+	// BackoffBase is the time in seconds before the first retry, doubled on each attempt
+	BackoffBase int64 `json:"backoff_base"`
+	// BackoffMax is the maximum time in seconds between attempts
+	BackoffMax int64 `json:"backoff_max"`
+	// Timeout is the time in seconds to wait for the response of the webhook
+	Timeout int64 `json:"timeout"`
+	// BatchSize is the number of deliveries sent on each run of the job
+	BatchSize uint64 `json:"batch_size"`
+}
+
+// OutboxConfig models the settings of the relay of the events written in the outbox
+type OutboxConfig struct {
+	// Sink is where the events are published: "memory" or "log"
+	Sink string `json:"sink"`
+	// BatchSize is the number of events published on each run of the relay
+	BatchSize uint64 `json:"batch_size"`
+	// Lease is the time in seconds the relay has to publish the events it claimed before they are claimed again
+	Lease int64 `json:"lease"`
+}
+
+// AccountConfig models the settings of the self-service of the accounts
+type AccountConfig struct {
+	// EmailChangeTokenDuration is the duration in seconds of the link to confirm a new email
+	EmailChangeTokenDuration int64 `json:"email_change_token_duration"`
+	// DeletionGracePeriod is the time in seconds users have to cancel the deletion of the account
+	// before the personal data is erased. Zero erases the data at the time of the request
+	DeletionGracePeriod int64 `json:"deletion_grace_period"`
+	// ErasureBatchSize is the number of accounts erased on each run of the job
+	ErasureBatchSize uint64 `json:"erasure_batch_size"`
+	// InvitationDuration is the duration in seconds of the link sent to the users invited by the administrators
+	InvitationDuration int64 `json:"invitation_duration"`
+	// ImportMaxRows is the maximum number of users in a file imported by the administrators
+	ImportMaxRows int64 `json:"import_max_rows"`
+}
+
+// SCIMConfig models the settings of the SCIM 2.0 provisioning
+type SCIMConfig struct {
+	// BaseURL is the public address of the endpoints, used in the location of the resources
+	BaseURL string `json:"base_url"`
+	// MaxResults is the maximum number of resources returned by the lists
+	MaxResults int64 `json:"max_results"`
+	// BulkMaxOperations is the maximum number of operations of a bulk request
+	BulkMaxOperations int `json:"bulk_max_operations"`
+	// BulkMaxPayloadSize is the maximum size in bytes of a bulk request
+	BulkMaxPayloadSize int64 `json:"bulk_max_payload_size"`
+}
+
+// LDAPConfig models the settings of the authentication of the users with the accounts of a LDAP directory
+type LDAPConfig struct {
+	Enabled bool `json:"enabled"`
+	// URL is the address of the directory, like ldap://localhost:389 or ldaps://localhost:636
+	URL                string `json:"url"`
+	StartTLS           bool   `json:"start_tls"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	// Timeout is the time in seconds to connect and to wait for the responses of the directory
+	Timeout int64 `json:"timeout"`
+	// BindDN and BindPassword are the account used to search the users, the search is anonymous when empty
+	BindDN       string `json:"bind_dn"`
+	BindPassword string `json:"bind_password"`
+	BaseDN       string `json:"base_dn"`
+	// UserFilter finds the entry of a user, the %s is replaced by the email informed in the login
+	UserFilter string         `json:"user_filter"`
+	Attributes LDAPAttributes `json:"attributes"`
+	// Groups maps the groups of the directory to a level and to projects. The level is the one
+	// of the first group of the user with a level, the users without it have the user level
+	Groups []LDAPGroupConfig `json:"groups"`
+}
+
+// LDAPAttributes models the names of the attributes of the entries of the users
+type LDAPAttributes struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Groups    string `json:"groups"`
+}
+
+// LDAPGroupConfig models the level and the projects of the members of a group of the directory
+type LDAPGroupConfig struct {
+	// DN is the distinguished name of the group, compared ignoring the case
+	DN       string   `json:"dn"`
+	Level    string   `json:"level"`
+	Projects []string `json:"projects"`
+}
+
+// OIDCConfig models the settings of the sign in of the users with external OpenID Connect providers
+type OIDCConfig struct {
+	// RedirectURL is the address of the callback registered in the providers, the {provider}
+	// is replaced by the name of the provider
+	RedirectURL string `json:"redirect_url"`
+	// StateDuration is the time in seconds the user has to sign in with the provider
+	StateDuration int64 `json:"state_duration"`
+	// ConfirmationDuration is the time in seconds the user has to confirm an action after signing in again
+	ConfirmationDuration int64                `json:"confirmation_duration"`
+	Providers            []OIDCProviderConfig `json:"providers"`
+}
+
+// OIDCProviderConfig models an OpenID Connect provider the users can sign in with
+type OIDCProviderConfig struct {
+	// Name identifies the provider in the addresses and in the identities of the users
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	// Issuer is the address of the provider, where the discovery document is published
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+	// AllowedDomains are the domains of the emails that can sign in, any domain when empty
+	AllowedDomains []string `json:"allowed_domains"`
+	// TrustEmail accepts the emails of the provider without the email_verified claim,
+	// for providers that only give verified emails and do not send it
+	TrustEmail bool `json:"trust_email"`
+	// Provision creates the account of the users that sign in for the first time
+	Provision bool `json:"provision"`
+}
+
+// RelationConfig models the limits of the queries of the relationship-based authorization
+type RelationConfig struct {
+	// MaxDepth is the number of relations followed by a query before it fails, protecting from cycles
+	MaxDepth int64 `json:"max_depth"`
+	// MaxObjects is the number of objects of the type checked when listing the objects of a subject
+	MaxObjects int64 `json:"max_objects"`
+}
+
+// AccessPolicyConfig models the evaluation of the conditional access policies
+type AccessPolicyConfig struct {
+	// Timezone is the location of the time of the requests, like the business hours, the UTC when empty
+	Timezone string `json:"timezone"`
+}
+
+// Location returns the location of the time of the requests
+func (c *AccessPolicyConfig) Location() *time.Location {
+	if location, err := time.LoadLocation(c.Timezone); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
 // IsModeDevelopment returns if in development mode
 func (sc *ServerConfig) IsModeDevelopment() bool {
 	return sc.Mode == modeDevelopment
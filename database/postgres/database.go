@@ -24,6 +24,8 @@ type database interface {
 	close()
 	// openConnectionsForTests opens connections to the mocked database
 	openConnectionsForTests() (sqlmock.Sqlmock, error)
+	// ping checks if the connection with database is alive
+	ping(ctx context.Context) error
 }
 
 var connection database
@@ -61,6 +63,11 @@ func CloseConnections() {
 	connection.close()
 }
 
+// Ping checks if the connection with database is alive
+func Ping(ctx context.Context) error {
+	return connection.ping(ctx)
+}
+
 // NewTransaction uses a transaction from a connection already opened in the database
 func NewTransaction(ctx context.Context, readOnly bool) (*Transaction, error) {
 	tx := &Transaction{}
@@ -70,6 +70,11 @@ func (p *postgres) close() {
 	}
 }
 
+// ping checks if the connection with database is alive
+func (p *postgres) ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
 // openConnectionsForTests opens connections to the mocked database
 func (p *postgres) openConnectionsForTests() (mock sqlmock.Sqlmock, err error) {
 	db, mock, err := sqlmock.New()
@@ -4,18 +4,71 @@
 
 package redis
 
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/isaqueveras/powersso/config"
+)
+
+var client *redis.Client
+
 // NewRedisClient returns new redis client
-// func NewRedisClient(cfg *config.Config) *redis.Client {
-// 	if cfg.Redis.RedisAddr == "" {
-// 		cfg.Redis.RedisAddr = ":6379"
-// 	}
-
-// 	return redis.NewClient(&redis.Options{
-// 		Addr:         cfg.Redis.RedisAddr,
-// 		MinIdleConns: cfg.Redis.MinIdleConns,
-// 		PoolSize:     cfg.Redis.PoolSize,
-// 		PoolTimeout:  time.Duration(cfg.Redis.PoolTimeout) * time.Second,
-// 		Password:     cfg.Redis.Password,
-// 		DB:           cfg.Redis.DB,
-// 	})
-// }
+func NewRedisClient(cfg *config.Config) *redis.Client {
+	addr := cfg.Redis.RedisAddr
+	if addr == "" {
+		addr = ":6379"
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:            addr,
+		MinIdleConns:    cfg.Redis.MinIdleConns,
+		MaxIdleConns:    cfg.Redis.MaxIdleConns,
+		PoolSize:        cfg.Redis.PoolSize,
+		PoolTimeout:     time.Duration(cfg.Redis.PoolTimeout) * time.Second,
+		ConnMaxIdleTime: time.Duration(cfg.Redis.ConnMaxIdleTime) * time.Second,
+		DialTimeout:     time.Duration(cfg.Redis.DialTimeout) * time.Second,
+		ReadTimeout:     time.Duration(cfg.Redis.ReadTimeout) * time.Second,
+		WriteTimeout:    time.Duration(cfg.Redis.WriteTimeout) * time.Second,
+		Password:        cfg.Redis.Password,
+		DB:              cfg.Redis.DB,
+	})
+}
+
+// OpenConnection open connection with redis when it is enabled
+func OpenConnection(cfg *config.Config) {
+	if !cfg.Redis.Enabled || client != nil {
+		return
+	}
+
+	client = NewRedisClient(cfg)
+	if err := Ping(context.Background()); err != nil {
+		log.Fatal("Unable to open connection to redis: ", err)
+	}
+}
+
+// CloseConnection close connection with redis
+func CloseConnection() {
+	if client != nil {
+		_ = client.Close()
+		client = nil
+	}
+}
+
+// Enabled returns if the connection with redis is open
+func Enabled() bool {
+	return client != nil
+}
+
+// Client returns the redis client, or nil if redis is not enabled
+func Client() *redis.Client {
+	return client
+}
+
+// Ping checks if the connection with redis is alive
+func Ping(ctx context.Context) error {
+	return client.Ping(ctx).Err()
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"context"
+
+	app "github.com/isaqueveras/powersso/application/relation"
+	domain "github.com/isaqueveras/powersso/domain/relation"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// permission is the permission of the roles required to call the service
+const permission = "relation:check"
+
+// Server implements proto interface
+type Server struct {
+	UnimplementedRelationServer
+}
+
+// Check checks if the subject has the relation with the object
+func (s *Server) Check(ctx context.Context, in *CheckRequest) (_ *CheckResponse, err error) {
+	if err = middleware.AuthorizeGRPC(ctx, permission); err != nil {
+		return nil, err
+	}
+
+	res, err := app.Check(ctx, &domain.Check{
+		Object:   toObject(in.Object),
+		Relation: utils.Pointer(in.Relation),
+		Subject:  toSubject(in.Subject),
+	})
+	if err != nil {
+		return nil, oops.HandlingGRPC(err)
+	}
+
+	return &CheckResponse{Allowed: res.Allowed}, nil
+}
+
+// Expand builds the tree of the subjects that have the relation with the object
+func (s *Server) Expand(ctx context.Context, in *ExpandRequest) (_ *Tree, err error) {
+	if err = middleware.AuthorizeGRPC(ctx, permission); err != nil {
+		return nil, err
+	}
+
+	res, err := app.Expand(ctx, &domain.Expand{Object: toObject(in.Object), Relation: utils.Pointer(in.Relation)})
+	if err != nil {
+		return nil, oops.HandlingGRPC(err)
+	}
+
+	return fromTree(res), nil
+}
+
+// ListObjects lists the objects of the type the subject has the relation with
+func (s *Server) ListObjects(ctx context.Context, in *ListObjectsRequest) (_ *ListObjectsResponse, err error) {
+	if err = middleware.AuthorizeGRPC(ctx, permission); err != nil {
+		return nil, err
+	}
+
+	res, err := app.ListObjects(ctx, &domain.ListObjects{
+		ObjectType: utils.Pointer(in.ObjectType),
+		Relation:   utils.Pointer(in.Relation),
+		Subject:    toSubject(in.Subject),
+	})
+	if err != nil {
+		return nil, oops.HandlingGRPC(err)
+	}
+
+	return &ListObjectsResponse{ObjectType: *res.ObjectType, ObjectIDs: res.ObjectIDs}, nil
+}
+
+func toObject(in *Object) *domain.Object {
+	if in == nil {
+		return nil
+	}
+	return &domain.Object{Type: utils.Pointer(in.Type), ID: utils.Pointer(in.ID)}
+}
+
+func toSubject(in *Subject) *domain.Subject {
+	if in == nil {
+		return nil
+	}
+
+	subject := &domain.Subject{Type: utils.Pointer(in.Type), ID: utils.Pointer(in.ID)}
+	if in.Relation != "" {
+		subject.Relation = utils.Pointer(in.Relation)
+	}
+
+	return subject
+}
+
+func fromObject(in *domain.Object) *Object {
+	return &Object{Type: *in.Type, ID: *in.ID}
+}
+
+func fromSubject(in *domain.Subject) *Subject {
+	subject := &Subject{Type: *in.Type, ID: *in.ID}
+	if in.Relation != nil {
+		subject.Relation = *in.Relation
+	}
+	return subject
+}
+
+func fromTree(in *domain.Tree) *Tree {
+	tree := &Tree{Operation: in.Operation, Object: fromObject(in.Object), Relation: *in.Relation}
+
+	for i := range in.Subjects {
+		tree.Subjects = append(tree.Subjects, fromSubject(&in.Subjects[i]))
+	}
+
+	for i := range in.Children {
+		tree.Children = append(tree.Children, fromTree(&in.Children[i]))
+	}
+
+	return tree
+}
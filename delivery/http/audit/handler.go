@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	app "github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/audit [GET]
+func list(ctx *gin.Context) {
+	params, err := utils.ParseParams(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.List(ctx, &params)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/audit/verify [GET]
+func verify(ctx *gin.Context) {
+	res, err := app.Verify(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,17 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// RouterAuthorization is the router for the audit module.
+func RouterAuthorization(r *gin.RouterGroup) {
+	r.GET("", middleware.RequirePermission("audit:read"), list)
+	r.GET("verify", middleware.RequirePermission("audit:read"), verify)
+}
@@ -10,7 +10,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	app "github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/bulk"
+	"github.com/isaqueveras/powersso/application/privacy"
 	domain "github.com/isaqueveras/powersso/domain/auth"
+	domainBulk "github.com/isaqueveras/powersso/domain/bulk"
+	domainPrivacy "github.com/isaqueveras/powersso/domain/privacy"
 	"github.com/isaqueveras/powersso/i18n"
 	"github.com/isaqueveras/powersso/middleware"
 	"github.com/isaqueveras/powersso/oops"
@@ -56,6 +60,11 @@ func login(ctx *gin.Context) {
 		return
 	}
 
+	if output.SessionChoiceRequired != nil && *output.SessionChoiceRequired {
+		ctx.JSON(http.StatusConflict, output)
+		return
+	}
+
 	ctx.JSON(http.StatusOK, output)
 }
 
@@ -80,6 +89,73 @@ func changePassword(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, nil)
 }
 
+// @Router /v1/auth/change_password/required [PUT]
+func changeRequiredPassword(ctx *gin.Context) {
+	in := &domain.ChangePassword{}
+	if err := ctx.ShouldBindJSON(in); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if in.Password == nil || in.ConfirmPassword == nil || !in.ValidatePassword() {
+		oops.Handling(ctx, oops.New("Invalid passwords"))
+		return
+	}
+
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	in.UserID = &userID
+	if err = app.ChangeRequiredPassword(ctx, in); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
+
+// @Router /v1/auth/secure_account [POST]
+func secureAccount(ctx *gin.Context) {
+	in := &domain.SecureAccount{}
+	if err := ctx.ShouldBindJSON(in); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	in.ClientIP = utils.Pointer(ctx.ClientIP())
+	in.UserAgent = utils.Pointer(ctx.Request.UserAgent())
+	if err := app.SecureAccount(ctx, in); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/reset_password [PUT]
+func resetPassword(ctx *gin.Context) {
+	in := &domain.ResetPassword{}
+	if err := ctx.ShouldBindJSON(in); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if !in.ValidatePassword() {
+		oops.Handling(ctx, oops.New("Invalid passwords"))
+		return
+	}
+
+	if err := app.ResetPassword(ctx, in); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
 // @Router /v1/auth/logout [DELETE]
 func logout(ctx *gin.Context) {
 	sessionID, err := uuid.Parse(middleware.GetSession(ctx).SessionID)
@@ -96,6 +172,171 @@ func logout(ctx *gin.Context) {
 	ctx.JSON(http.StatusNoContent, utils.NoContent{})
 }
 
+// @Router /v1/auth/sessions [GET]
+func sessions(ctx *gin.Context) {
+	session := middleware.GetSession(ctx)
+
+	userID, err := uuid.Parse(session.UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	sessionID, err := uuid.Parse(session.SessionID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Sessions(ctx, &userID, &sessionID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/introspect [POST]
+func introspect(ctx *gin.Context) {
+	input := new(domain.Introspect)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Introspect(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/sessions/{session_id} [DELETE]
+func endSession(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	sessionID, err := uuid.Parse(ctx.Param("session_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.EndSession(ctx, &userID, &sessionID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/sessions [DELETE]
+func endOtherSessions(ctx *gin.Context) {
+	session := middleware.GetSession(ctx)
+
+	userID, err := uuid.Parse(session.UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	sessionID, err := uuid.Parse(session.SessionID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.EndSessions(ctx, &userID, &sessionID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id}/sessions [GET]
+func userSessions(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Sessions(ctx, &userID, nil)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/user/{user_id}/sessions/{session_id} [DELETE]
+func endUserSession(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	sessionID, err := uuid.Parse(ctx.Param("session_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.EndSession(ctx, &userID, &sessionID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id}/sessions/limit [PUT]
+func setUserSessionLimit(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	var input domain.SessionLimit
+	if err = ctx.ShouldBindJSON(&input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.SetMaxSessions(ctx, &userID, input.MaxSessions); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id}/sessions [DELETE]
+func endUserSessions(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.EndSessions(ctx, &userID, nil); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
 // @Router /v1/auth/login/steps [GET]
 func loginSteps(ctx *gin.Context) {
 	res, err := app.LoginSteps(ctx, utils.Pointer(ctx.Query("email")))
@@ -123,6 +364,284 @@ func disable(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, utils.NoContent{})
 }
 
+// @Router /v1/auth/user/{user_id}/enable [PUT]
+func enable(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.EnableUser(ctx, &userID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id} [DELETE]
+func deleteUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	var adminID uuid.UUID
+	if adminID, err = uuid.Parse(middleware.GetSession(ctx).UserID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domainPrivacy.DeleteUser)
+	if err = ctx.ShouldBindQuery(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = privacy.DeleteUser(ctx, &userID, &adminID, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id}/deletion [DELETE]
+func cancelUserDeletion(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = privacy.CancelUserDeletion(ctx, &userID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id}/export [GET]
+func exportUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := privacy.Export(ctx, &userID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="`+res.FileName()+`"`)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/user/import [POST]
+func importUsers(ctx *gin.Context) {
+	input := new(domainBulk.ImportOptions)
+	if err := ctx.ShouldBindQuery(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if input.Format == "" {
+		input.Format = domainBulk.FormatOf(ctx.ContentType())
+	}
+
+	adminID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+	input.CreatedBy = &adminID
+
+	res, err := bulk.Import(ctx, input, ctx.Request.Body)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/user/export [GET]
+func exportUsers(ctx *gin.Context) {
+	input := new(domainBulk.ExportOptions)
+	if err := ctx.ShouldBindQuery(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", input.Format.ContentType())
+	ctx.Header("Content-Disposition", `attachment; filename="powersso-users.`+string(input.Format)+`"`)
+
+	if err := bulk.Export(ctx, domainBulk.NewExportWriter(input.Format, ctx.Writer)); err != nil {
+		// the status and part of the file may already have been sent
+		if ctx.Writer.Written() {
+			_ = ctx.Error(err)
+			return
+		}
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		oops.Handling(ctx, err)
+		return
+	}
+}
+
+// @Router /v1/auth/user [GET]
+func users(ctx *gin.Context) {
+	params, err := utils.ParseParams(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Users(ctx, &params)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/user/{user_id} [GET]
+func getUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.User(ctx, &userID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/user/{user_id} [PATCH]
+func updateUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.UpdateUser)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.UpdateUser(ctx, &userID, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id}/password/expire [PUT]
+func expirePassword(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.ForcePasswordChange(ctx, &userID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, utils.NoContent{})
+}
+
+// @Router /v1/auth/level/{level}/password/expire [PUT]
+func expirePasswordByLevel(ctx *gin.Context) {
+	level := domain.Level(ctx.Param("level"))
+	if !level.IsValid() {
+		oops.Handling(ctx, domain.ErrLevelIsNotValid())
+		return
+	}
+
+	if err := app.ForcePasswordChangeByLevel(ctx, &level); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, utils.NoContent{})
+}
+
+// @Router /v1/auth/user/{user_id}/lockout [GET]
+func lockout(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	params, err := utils.ParseParams(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.GetLockout(ctx, &userID, &params)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/auth/user/{user_id}/lockout [DELETE]
+func unlock(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	adminID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Unlock(ctx, &domain.LockoutEntry{
+		UserID:    &userID,
+		CreatedBy: &adminID,
+		IP:        utils.Pointer(ctx.ClientIP()),
+		UserAgent: utils.Pointer(ctx.Request.UserAgent()),
+	}); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
 // @Router /v1/auth/user/{user_id}/otp/configure [POST]
 func configure(ctx *gin.Context) {
 	userID, err := uuid.Parse(ctx.Param("user_id"))
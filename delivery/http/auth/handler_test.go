@@ -8,8 +8,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"bou.ke/monkey"
@@ -19,7 +21,13 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/bulk"
+	"github.com/isaqueveras/powersso/application/privacy"
+	"github.com/isaqueveras/powersso/application/role"
 	domain "github.com/isaqueveras/powersso/domain/auth"
+	domainBulk "github.com/isaqueveras/powersso/domain/bulk"
+	domainPrivacy "github.com/isaqueveras/powersso/domain/privacy"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
 	"github.com/isaqueveras/powersso/middleware"
 	"github.com/isaqueveras/powersso/oops"
 	"github.com/isaqueveras/powersso/utils"
@@ -50,6 +58,10 @@ func (a *testSuite) SetupSuite() {
 		}
 	}
 
+	monkey.Patch(role.HasPermission, func(_ context.Context, _ *uuid.UUID, _ domainRole.Permission) (bool, error) {
+		return true, nil
+	})
+
 	a.router = gin.New()
 	a.router.Use(middleware.RequestIdentifier(), handleUserLog())
 	Router(a.router.Group("v1/auth"))
@@ -57,6 +69,10 @@ func (a *testSuite) SetupSuite() {
 	RouterAuthorization(a.router.Group("v1/auth/user/:user_id/otp"))
 }
 
+func (a *testSuite) TearDownSuite() {
+	monkey.Unpatch(role.HasPermission)
+}
+
 func (a *testSuite) TestShouldCreateUser() {
 	monkey.Patch(auth.CreateAccount, func(_ context.Context, _ *domain.CreateAccount) (*string, error) {
 		return utils.Pointer(""), nil
@@ -165,3 +181,341 @@ func (t *testSuite) TestShouldUnconfigure() {
 		t.Assert().Equal(http.StatusForbidden, w.Code)
 	})
 }
+
+func (t *testSuite) TestShouldExpirePasswordByLevel() {
+	t.Run("Success", func() {
+		monkey.Patch(auth.ForcePasswordChangeByLevel, func(_ context.Context, _ *domain.Level) error {
+			return nil
+		})
+		defer monkey.Unpatch(auth.ForcePasswordChangeByLevel)
+
+		var (
+			req = httptest.NewRequest(http.MethodPut, "/v1/auth/level/admin/password/expire", nil)
+			w   = httptest.NewRecorder()
+		)
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+	})
+
+	t.Run("Error::InvalidLevel", func() {
+		var (
+			req = httptest.NewRequest(http.MethodPut, "/v1/auth/level/root/password/expire", nil)
+			w   = httptest.NewRecorder()
+		)
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldEndUserSession() {
+	t.Run("Success", func() {
+		monkey.Patch(auth.EndSession, func(_ context.Context, _, _ *uuid.UUID) error {
+			return nil
+		})
+		defer monkey.Unpatch(auth.EndSession)
+
+		var (
+			req = httptest.NewRequest(http.MethodDelete, "/v1/auth/user/"+uuid.New().String()+"/sessions/"+uuid.New().String(), nil)
+			w   = httptest.NewRecorder()
+		)
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::InvalidSessionID", func() {
+		var (
+			req = httptest.NewRequest(http.MethodDelete, "/v1/auth/user/"+uuid.New().String()+"/sessions/invalid", nil)
+			w   = httptest.NewRecorder()
+		)
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldResetPassword() {
+	t.Run("Success", func() {
+		monkey.Patch(auth.ResetPassword, func(_ context.Context, _ *domain.ResetPassword) error {
+			return nil
+		})
+		defer monkey.Unpatch(auth.ResetPassword)
+
+		data, err := json.Marshal(map[string]interface{}{
+			"token":            uuid.New(),
+			"password":         "any_password",
+			"confirm_password": "any_password",
+		})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/auth/reset_password", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::PasswordsDoNotMatch", func() {
+		data, err := json.Marshal(map[string]interface{}{
+			"token":            uuid.New(),
+			"password":         "any_password",
+			"confirm_password": "other_password",
+		})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/auth/reset_password", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldListUsers() {
+	t.Run("Success", func() {
+		monkey.Patch(auth.Users, func(_ context.Context, params *utils.Params) (*domain.Users, error) {
+			t.Assert().Equal([]string{"admin"}, params.Filters[domain.FilterUserLevel])
+			t.Assert().Equal([]string{"true"}, params.Filters[domain.FilterUserBlocked])
+			return &domain.Users{Users: []domain.UserSummary{}, Next: utils.Pointer(false)}, nil
+		})
+		defer monkey.Unpatch(auth.Users)
+
+		var (
+			req = httptest.NewRequest(http.MethodGet, "/v1/auth/user?level=admin&blocked=true&limit=10", nil)
+			w   = httptest.NewRecorder()
+		)
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldUpdateUser() {
+	t.Run("Success", func() {
+		monkey.Patch(auth.UpdateUser, func(_ context.Context, _ *uuid.UUID, _ *domain.UpdateUser) error {
+			return nil
+		})
+		defer monkey.Unpatch(auth.UpdateUser)
+
+		data, err := json.Marshal(map[string]interface{}{"first_name": "Ayrton", "level": "admin"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/auth/user/"+uuid.New().String(), bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::InvalidLevel", func() {
+		data, err := json.Marshal(map[string]interface{}{"level": "root"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/auth/user/"+uuid.New().String(), bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Error::NothingToUpdate", func() {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/auth/user/"+uuid.New().String(), bytes.NewBufferString("{}"))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldDisableUser() {
+	monkey.Patch(auth.DisableUser, func(_ context.Context, _ *uuid.UUID) error {
+		return nil
+	})
+	defer monkey.Unpatch(auth.DisableUser)
+
+	t.Run("Success", func() {
+		req := httptest.NewRequest(http.MethodPut, "/v1/auth/user/"+uuid.New().String()+"/disable", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+	})
+
+	t.Run("Error::WithoutPermission", func() {
+		monkey.Patch(role.HasPermission, func(_ context.Context, _ *uuid.UUID, _ domainRole.Permission) (bool, error) {
+			return false, nil
+		})
+		defer monkey.Patch(role.HasPermission, func(_ context.Context, _ *uuid.UUID, _ domainRole.Permission) (bool, error) {
+			return true, nil
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/auth/user/"+uuid.New().String()+"/disable", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusForbidden, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldEnableUser() {
+	monkey.Patch(auth.EnableUser, func(_ context.Context, _ *uuid.UUID) error {
+		return nil
+	})
+	defer monkey.Unpatch(auth.EnableUser)
+
+	var (
+		req = httptest.NewRequest(http.MethodPut, "/v1/auth/user/"+uuid.New().String()+"/enable", nil)
+		w   = httptest.NewRecorder()
+	)
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusCreated, w.Code)
+}
+
+func (t *testSuite) TestShouldDeleteUser() {
+	t.Run("WithGracePeriod", func() {
+		monkey.Patch(privacy.DeleteUser, func(_ context.Context, _, adminID *uuid.UUID, in *domainPrivacy.DeleteUser) error {
+			t.Assert().Equal(sucessUserID, adminID.String())
+			t.Assert().False(in.Immediate)
+			return nil
+		})
+		defer monkey.Unpatch(privacy.DeleteUser)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/auth/user/"+uuid.New().String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Immediate", func() {
+		monkey.Patch(privacy.DeleteUser, func(_ context.Context, _, _ *uuid.UUID, in *domainPrivacy.DeleteUser) error {
+			t.Assert().True(in.Immediate)
+			return nil
+		})
+		defer monkey.Unpatch(privacy.DeleteUser)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/auth/user/"+uuid.New().String()+"?immediate=true", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldExportUser() {
+	userID := uuid.New()
+	monkey.Patch(privacy.Export, func(_ context.Context, id *uuid.UUID) (*domainPrivacy.Export, error) {
+		return &domainPrivacy.Export{Profile: &domain.Profile{ID: id}}, nil
+	})
+	defer monkey.Unpatch(privacy.Export)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/user/"+userID.String()+"/export", nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Contains(w.Header().Get("Content-Disposition"), "powersso-export-"+userID.String()+".json")
+}
+
+func (t *testSuite) TestShouldImportUsers() {
+	t.Run("DryRun", func() {
+		monkey.Patch(bulk.Import, func(_ context.Context, opts *domainBulk.ImportOptions, file io.Reader) (*domainBulk.ImportResult, error) {
+			t.Assert().Equal(domainBulk.FormatCSV, opts.Format)
+			t.Assert().True(opts.DryRun)
+			t.Assert().False(opts.Invite)
+			t.Assert().Equal(sucessUserID, opts.CreatedBy.String())
+
+			content, err := io.ReadAll(file)
+			t.Assert().Nil(err)
+			t.Assert().Equal("email\njanekin@powersso.io\n", string(content))
+
+			res := domainBulk.NewImportResult(opts.DryRun)
+			res.Fail(1, utils.Pointer("janekin@powersso.io"), domain.ErrUserExists())
+			return res, nil
+		})
+		defer monkey.Unpatch(bulk.Import)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/user/import?dry_run=true", bytes.NewBufferString("email\njanekin@powersso.io\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"failed":1`)
+	})
+
+	t.Run("FormatFromQuery", func() {
+		monkey.Patch(bulk.Import, func(_ context.Context, opts *domainBulk.ImportOptions, _ io.Reader) (*domainBulk.ImportResult, error) {
+			t.Assert().Equal(domainBulk.FormatJSON, opts.Format)
+			t.Assert().True(opts.Invite)
+			return domainBulk.NewImportResult(opts.DryRun), nil
+		})
+		defer monkey.Unpatch(bulk.Import)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/user/import?format=json&invite=true", bytes.NewBufferString("[]"))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldExportUsers() {
+	t.Run("CSV", func() {
+		monkey.Patch(bulk.Export, func(_ context.Context, w domainBulk.ExportWriter) error {
+			return w.Close()
+		})
+		defer monkey.Unpatch(bulk.Export)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/user/export?format=csv", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Equal("text/csv", w.Header().Get("Content-Type"))
+		t.Assert().Equal(`attachment; filename="powersso-users.csv"`, w.Header().Get("Content-Disposition"))
+		t.Assert().True(strings.HasPrefix(w.Body.String(), "id,email,"))
+	})
+
+	t.Run("Error::FormatNotSupported", func() {
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/user/export?format=xml", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusUnsupportedMediaType, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldIntrospect() {
+	t.Run("Success", func() {
+		audience := uuid.New()
+		monkey.Patch(auth.Introspect, func(_ context.Context, in *domain.Introspect) (*domain.Introspection, error) {
+			t.Assert().Equal("token", *in.Token)
+			t.Assert().Equal(audience, *in.Audience)
+			return &domain.Introspection{Active: true, Audience: in.Audience, Roles: []string{"owner", "viewer"}}, nil
+		})
+		defer monkey.Unpatch(auth.Introspect)
+
+		data, err := json.Marshal(map[string]interface{}{"token": "token", "audience": audience})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/introspect", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"active":true`)
+		t.Assert().Contains(w.Body.String(), `"roles":["owner","viewer"]`)
+	})
+
+	t.Run("Error::WithoutToken", func() {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/introspect", bytes.NewBufferString(`{}`))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
@@ -11,18 +11,45 @@ import (
 
 // Router is the router for the auth module.
 func Router(r *gin.RouterGroup) {
-	r.POST("create_account", createAccount)
-	r.POST("login", login)
-	r.GET("login/steps", loginSteps)
-	r.PUT("change_password", changePassword)
+	r.POST("create_account", middleware.RateLimit(), createAccount)
+	r.POST("login", middleware.RateLimit(), login)
+	r.GET("login/steps", middleware.RateLimit(), loginSteps)
+	r.PUT("change_password", middleware.RateLimit(), changePassword)
+	r.POST("secure_account", middleware.RateLimit(), secureAccount)
+	r.PUT("reset_password", middleware.RateLimit(), resetPassword)
 }
 
 // RouterAuthorization is the router for the auth module.
 func RouterAuthorization(r *gin.RouterGroup) {
 	r.DELETE("logout", logout)
 
+	r.GET("sessions", sessions)
+	r.DELETE("sessions", endOtherSessions)
+	r.DELETE("sessions/:session_id", endSession)
+
+	r.POST("introspect", middleware.RequirePermission("token:introspect"), introspect)
+
+	r.GET("user", middleware.RequirePermission("user:read"), users)
+	r.POST("user/import", middleware.RequirePermission("user:import"), importUsers)
+	r.GET("user/export", middleware.RequirePermission("user:export"), exportUsers)
+
 	user := r.Group("user/:user_id")
-	user.PUT("disable", disable)
+	user.GET("", middleware.RequirePermission("user:read"), getUser)
+	user.PATCH("", middleware.RequirePermission("user:update"), updateUser)
+	user.DELETE("", middleware.RequirePermission("user:delete"), deleteUser)
+	user.DELETE("deletion", middleware.RequirePermission("user:delete"), cancelUserDeletion)
+	user.GET("export", middleware.RequirePermission("user:export"), exportUser)
+	user.PUT("enable", middleware.RequirePermission("user:update"), enable)
+	user.PUT("disable", middleware.RequirePermission("user:update"), disable)
+	user.GET("sessions", middleware.RequirePermission("user:read"), userSessions)
+	user.DELETE("sessions", middleware.RequirePermission("user:update"), endUserSessions)
+	user.DELETE("sessions/:session_id", middleware.RequirePermission("user:update"), endUserSession)
+	user.PUT("sessions/limit", middleware.RequirePermission("user:update"), setUserSessionLimit)
+	user.PUT("password/expire", middleware.RequirePermission("user:update"), expirePassword)
+	user.GET("lockout", middleware.RequirePermission("user:read"), lockout)
+	user.DELETE("lockout", middleware.RequirePermission("user:update"), unlock)
+
+	r.PUT("level/:level/password/expire", middleware.RequirePermission("user:update"), expirePasswordByLevel)
 
 	otp := user.Group("otp")
 	otp.Use(middleware.Yourself())
@@ -31,3 +58,8 @@ func RouterAuthorization(r *gin.RouterGroup) {
 	otp.POST("configure", configure)
 	otp.PUT("unconfigure", unconfigure)
 }
+
+// RouterChangePassword is the router for users who must change their password
+func RouterChangePassword(r *gin.RouterGroup) {
+	r.PUT("change_password/required", changeRequiredPassword)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/database/redis"
+)
+
+const (
+	statusUp       = "up"
+	statusDown     = "down"
+	statusDisabled = "disabled"
+
+	// timeout is the maximum time to check each dependency
+	timeout = 2 * time.Second
+)
+
+// @Router /health [GET]
+func health(ctx *gin.Context) {
+	var (
+		code   = http.StatusOK
+		status = map[string]string{"postgres": statusUp, "redis": statusDisabled}
+	)
+
+	if err := check(ctx, postgres.Ping); err != nil {
+		status["postgres"], code = statusDown, http.StatusServiceUnavailable
+	}
+
+	if redis.Enabled() {
+		status["redis"] = statusUp
+		if err := check(ctx, redis.Ping); err != nil {
+			status["redis"], code = statusDown, http.StatusServiceUnavailable
+		}
+	}
+
+	ctx.JSON(code, status)
+}
+
+// check runs the check of a dependency with timeout
+func check(ctx context.Context, fn func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx)
+}
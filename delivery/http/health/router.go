@@ -0,0 +1,12 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package health
+
+import "github.com/gin-gonic/gin"
+
+// Router is the router for the health module.
+func Router(r *gin.RouterGroup) {
+	r.GET("", health)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/identity"
+	domain "github.com/isaqueveras/powersso/domain/identity"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/auth/oidc/providers [GET]
+func providers(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, app.Providers(ctx))
+}
+
+// @Router /v1/auth/oidc/{provider}/login [GET]
+func login(ctx *gin.Context) {
+	res, err := app.Authorize(ctx, ctx.Param("provider"), domain.PurposeLogin, nil)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	middleware.BindOIDCState(ctx, res.Binding)
+	ctx.Redirect(http.StatusFound, *res.URL)
+}
+
+// @Router /v1/auth/oidc/{provider}/callback [GET]
+func callback(ctx *gin.Context) {
+	input := new(domain.Callback)
+	if err := ctx.ShouldBindQuery(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.ClientIP = utils.Pointer(ctx.ClientIP())
+	input.UserAgent = utils.Pointer(ctx.Request.UserAgent())
+	input.Binding = middleware.OIDCStateBinding(ctx)
+
+	res, err := app.Callback(ctx, ctx.Param("provider"), input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	switch {
+	case res.Identity != nil:
+		ctx.JSON(http.StatusCreated, res.Identity)
+	case res.Confirmation != nil:
+		ctx.JSON(http.StatusOK, res.Confirmation)
+	default:
+		ctx.JSON(http.StatusOK, res.Challenge)
+	}
+}
+
+// @Router /v1/auth/oidc/{provider}/otp [POST]
+func otp(ctx *gin.Context) {
+	input := new(domain.OTP)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.ClientIP = utils.Pointer(ctx.ClientIP())
+	input.UserAgent = utils.Pointer(ctx.Request.UserAgent())
+
+	res, err := app.VerifyOTP(ctx, &userID, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if res.SessionChoiceRequired != nil && *res.SessionChoiceRequired {
+		ctx.JSON(http.StatusConflict, res)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
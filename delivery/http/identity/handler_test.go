@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	app "github.com/isaqueveras/powersso/application/identity"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/identity"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestHandlerIdentity(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier())
+	Router(t.router.Group("v1/auth/oidc"))
+}
+
+func (t *testSuite) TestShouldRedirectToProvider() {
+	t.Run("Success", func() {
+		monkey.Patch(app.Authorize, func(_ context.Context, provider string, purpose domain.Purpose, userID *uuid.UUID) (*domain.Authorization, error) {
+			t.Assert().Equal("google", provider)
+			t.Assert().Equal(domain.PurposeLogin, purpose)
+			t.Assert().Nil(userID)
+			return &domain.Authorization{
+				URL:     utils.Pointer("https://accounts.google.com/o/oauth2/v2/auth?state=1"),
+				Binding: utils.Pointer("binding"),
+			}, nil
+		})
+		defer monkey.Unpatch(app.Authorize)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/login", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusFound, w.Code)
+		t.Assert().Equal("https://accounts.google.com/o/oauth2/v2/auth?state=1", w.Header().Get("Location"))
+		t.Assert().Contains(w.Header().Get("Set-Cookie"), "powersso_oidc_binding=binding")
+		t.Assert().Contains(w.Header().Get("Set-Cookie"), "HttpOnly")
+	})
+
+	t.Run("Error::ProviderUnavailable", func() {
+		monkey.Patch(app.Authorize, func(_ context.Context, _ string, _ domain.Purpose, _ *uuid.UUID) (*domain.Authorization, error) {
+			return nil, domain.ErrProviderUnavailable()
+		})
+		defer monkey.Unpatch(app.Authorize)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/login", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldFinishSignIn() {
+	t.Run("Challenge", func() {
+		monkey.Patch(app.Callback, func(_ context.Context, provider string, in *domain.Callback) (*domain.Result, error) {
+			t.Assert().Equal("google", provider)
+			t.Assert().Equal("code", *in.Code)
+			t.Assert().Equal("state", *in.State)
+			t.Assert().Equal("binding", *in.Binding)
+			t.Assert().Nil(in.Error)
+			return &domain.Result{Challenge: &domain.Challenge{Token: utils.Pointer("token")}}, nil
+		})
+		defer monkey.Unpatch(app.Callback)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/callback?code=code&state=state", nil)
+		req.AddCookie(&http.Cookie{Name: "powersso_oidc_binding", Value: "binding"})
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"otp_token":"token"`)
+		t.Assert().NotContains(w.Body.String(), `"session"`)
+	})
+
+	t.Run("Identity", func() {
+		monkey.Patch(app.Callback, func(_ context.Context, _ string, _ *domain.Callback) (*domain.Result, error) {
+			return &domain.Result{Identity: &domain.Identity{Provider: utils.Pointer("google"), Subject: utils.Pointer("248289761001")}}, nil
+		})
+		defer monkey.Unpatch(app.Callback)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+		t.Assert().Contains(w.Body.String(), `"subject":"248289761001"`)
+	})
+
+	t.Run("Confirmation", func() {
+		token := uuid.New()
+		monkey.Patch(app.Callback, func(_ context.Context, _ string, _ *domain.Callback) (*domain.Result, error) {
+			return &domain.Result{Confirmation: &domain.Confirmation{Token: &token}}, nil
+		})
+		defer monkey.Unpatch(app.Callback)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"confirmation_token":"`+token.String()+`"`)
+	})
+
+	t.Run("Error::AccessDenied", func() {
+		monkey.Patch(app.Callback, func(_ context.Context, _ string, in *domain.Callback) (*domain.Result, error) {
+			t.Assert().Equal("access_denied", *in.Error)
+			return nil, domain.ErrAuthorizationFailed()
+		})
+		defer monkey.Unpatch(app.Callback)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/callback?error=access_denied&state=state", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusUnauthorized, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldVerifyOTP() {
+	userID := uuid.New()
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("SESSION", jwt.MapClaims{"UserID": userID.String(), "UserLevel": string(auth.UserLevel), "FirstName": "Jane"})
+	})
+	router.POST("v1/auth/oidc/:provider/otp", otp)
+
+	t.Run("Session", func() {
+		monkey.Patch(app.VerifyOTP, func(_ context.Context, id *uuid.UUID, in *domain.OTP) (*auth.Session, error) {
+			t.Assert().Equal(userID, *id)
+			t.Assert().Equal("123456", *in.Code)
+			return &auth.Session{Token: utils.Pointer("token")}, nil
+		})
+		defer monkey.Unpatch(app.VerifyOTP)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/oidc/google/otp", strings.NewReader(`{"otp":"123456"}`))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"token":"token"`)
+	})
+
+	t.Run("SessionChoiceRequired", func() {
+		monkey.Patch(app.VerifyOTP, func(_ context.Context, _ *uuid.UUID, _ *domain.OTP) (*auth.Session, error) {
+			return &auth.Session{SessionChoiceRequired: utils.Pointer(true)}, nil
+		})
+		defer monkey.Unpatch(app.VerifyOTP)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/oidc/google/otp", strings.NewReader(`{"otp":"123456"}`))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusConflict, w.Code)
+	})
+
+	t.Run("Error::WithoutToken", func() {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/oidc/google/otp", strings.NewReader(`{"otp":"123456"}`))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusUnauthorized, w.Code)
+	})
+}
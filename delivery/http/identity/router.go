@@ -0,0 +1,19 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// Router is the router for the sign in with external providers
+func Router(r *gin.RouterGroup) {
+	r.GET("providers", providers)
+	r.GET(":provider/login", middleware.RateLimit(), login)
+	r.GET(":provider/callback", middleware.RateLimit(), callback)
+	r.POST(":provider/otp", middleware.RateLimit(), middleware.AuthOTP(), otp)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/invitation"
+	domain "github.com/isaqueveras/powersso/domain/invitation"
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/invitation [POST]
+func create(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.CreateInvitation)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.CreatedBy = &userID
+	invitationID, err := app.Create(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, map[string]string{"id": invitationID.String()})
+}
+
+// @Router /v1/invitation [GET]
+func list(ctx *gin.Context) {
+	params, err := utils.ParseParams(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.List(ctx, &params)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/invitation/{invitation_id} [DELETE]
+func revoke(ctx *gin.Context) {
+	invitationID, err := uuid.Parse(ctx.Param("invitation_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Revoke(ctx, &invitationID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/invitation/accept [PUT]
+func accept(ctx *gin.Context) {
+	input := new(domain.AcceptInvitation)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	url, err := app.Accept(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, map[string]string{
+		"url":          *url,
+		"message":      i18n.Value("create_account.message"),
+		"instructions": i18n.Value("create_account.instructions"),
+	})
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	app "github.com/isaqueveras/powersso/application/invitation"
+	"github.com/isaqueveras/powersso/application/role"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/invitation"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const sucessUserID = "9ec1b2a7-665c-47a7-b180-54f11f8a6122"
+
+func TestHandlerInvitation(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	var handleUserLog = func() gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set("UID", sucessUserID)
+			ctx.Set("SESSION", jwt.MapClaims{
+				"SessionID": "",
+				"UserID":    sucessUserID,
+				"UserLevel": string(auth.AdminLevel),
+				"FirstName": "Janekin",
+			})
+		}
+	}
+
+	monkey.Patch(role.HasPermission, func(_ context.Context, _ *uuid.UUID, _ domainRole.Permission) (bool, error) {
+		return true, nil
+	})
+
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier(), handleUserLog())
+	Router(t.router.Group("v1/invitation"))
+	RouterAuthorization(t.router.Group("v1/invitation"))
+}
+
+func (t *testSuite) TearDownSuite() {
+	monkey.Unpatch(role.HasPermission)
+}
+
+func (t *testSuite) TestShouldCreateInvitation() {
+	t.Run("Success", func() {
+		invitationID := uuid.New()
+		monkey.Patch(app.Create, func(_ context.Context, in *domain.CreateInvitation) (*uuid.UUID, error) {
+			t.Assert().Equal(sucessUserID, in.CreatedBy.String())
+			t.Assert().Len(in.Projects, 1)
+			return &invitationID, nil
+		})
+		defer monkey.Unpatch(app.Create)
+
+		data, err := json.Marshal(map[string]interface{}{
+			"email":      "jane@powersso.io",
+			"first_name": "Jane",
+			"last_name":  "Doe",
+			"level":      "admin",
+			"projects":   []map[string]interface{}{{"project_id": uuid.New(), "start_date": "2023-01-02T00:00:00Z"}},
+		})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/invitation", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+		t.Assert().Contains(w.Body.String(), invitationID.String())
+	})
+
+	t.Run("Error::ProjectWithoutStartDate", func() {
+		data, err := json.Marshal(map[string]interface{}{
+			"email":      "jane@powersso.io",
+			"first_name": "Jane",
+			"last_name":  "Doe",
+			"projects":   []map[string]interface{}{{"project_id": uuid.New()}},
+		})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/invitation", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldRevokeInvitation() {
+	monkey.Patch(app.Revoke, func(_ context.Context, _ *uuid.UUID) error {
+		return nil
+	})
+	defer monkey.Unpatch(app.Revoke)
+
+	req := httptest.NewRequest(http.MethodDelete, "/v1/invitation/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusNoContent, w.Code)
+}
+
+func (t *testSuite) TestShouldAcceptInvitation() {
+	t.Run("Success", func() {
+		monkey.Patch(app.Accept, func(_ context.Context, _ *domain.AcceptInvitation) (*string, error) {
+			return utils.Pointer("otpauth://totp/PowerSSO"), nil
+		})
+		defer monkey.Unpatch(app.Accept)
+
+		data, err := json.Marshal(map[string]interface{}{"token": uuid.New(), "password": "any_password"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/invitation/accept", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+		t.Assert().Contains(w.Body.String(), "otpauth://totp/PowerSSO")
+	})
+
+	t.Run("Error::ShortPassword", func() {
+		data, err := json.Marshal(map[string]interface{}{"token": uuid.New(), "password": "123"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/invitation/accept", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// Router is the router for the invitees, used by the link sent by email
+func Router(r *gin.RouterGroup) {
+	r.PUT("accept", middleware.RateLimit(), accept)
+}
+
+// RouterAuthorization is the router for the administrators to manage the invitations
+func RouterAuthorization(r *gin.RouterGroup) {
+	r.POST("", middleware.RequirePermission("invitation:manage"), create)
+	r.GET("", middleware.RequirePermission("invitation:manage"), list)
+	r.DELETE(":invitation_id", middleware.RequirePermission("invitation:manage"), revoke)
+}
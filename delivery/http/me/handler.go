@@ -0,0 +1,240 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package me
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/identity"
+	"github.com/isaqueveras/powersso/application/privacy"
+	domain "github.com/isaqueveras/powersso/domain/auth"
+	domainIdentity "github.com/isaqueveras/powersso/domain/identity"
+	domainPrivacy "github.com/isaqueveras/powersso/domain/privacy"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/me [GET]
+func profile(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Profile(ctx, &userID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/me [PATCH]
+func updateProfile(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.UpdateProfile)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.UpdateProfile(ctx, &userID, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/me/email [POST]
+func changeEmail(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.ChangeEmail)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.UserID = &userID
+	if err = app.RequestEmailChange(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/me/email/confirm [PUT]
+func confirmEmail(ctx *gin.Context) {
+	input := new(domain.ConfirmEmail)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err := app.ConfirmEmailChange(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/me/export [GET]
+func export(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := privacy.Export(ctx, &userID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="`+res.FileName()+`"`)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/me [DELETE]
+func deleteAccount(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domainPrivacy.DeleteAccount)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.UserID = &userID
+	if err = privacy.RequestDeletion(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/me/deletion/cancel [PUT]
+func cancelDeletion(ctx *gin.Context) {
+	input := new(domainPrivacy.CancelDeletion)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err := privacy.CancelDeletion(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/me/identities [GET]
+func identities(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := identity.Identities(ctx, &userID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/me/identities/{provider} [POST]
+func linkIdentity(ctx *gin.Context) {
+	authorize(ctx, domainIdentity.PurposeLink)
+}
+
+// @Router /v1/me/identities/{provider}/confirm [POST]
+func confirmIdentity(ctx *gin.Context) {
+	authorize(ctx, domainIdentity.PurposeConfirm)
+}
+
+// authorize starts a sign in with the provider for the user of the session
+func authorize(ctx *gin.Context, purpose domainIdentity.Purpose) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := identity.Authorize(ctx, ctx.Param("provider"), purpose, &userID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	middleware.BindOIDCState(ctx, res.Binding)
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/me/identities/{identity_id} [DELETE]
+func unlinkIdentity(ctx *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	identityID, err := uuid.Parse(ctx.Param("identity_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = identity.Unlink(ctx, &userID, &identityID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
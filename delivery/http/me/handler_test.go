@@ -0,0 +1,337 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package me
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/identity"
+	"github.com/isaqueveras/powersso/application/privacy"
+	domain "github.com/isaqueveras/powersso/domain/auth"
+	domainIdentity "github.com/isaqueveras/powersso/domain/identity"
+	domainPrivacy "github.com/isaqueveras/powersso/domain/privacy"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const sucessUserID = "9ec1b2a7-665c-47a7-b180-54f11f8a6122"
+
+func TestHandlerMe(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	var handleUserLog = func() gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set("UID", sucessUserID)
+			ctx.Set("SESSION", jwt.MapClaims{
+				"SessionID": "",
+				"UserID":    sucessUserID,
+				"UserLevel": string(domain.UserLevel),
+				"FirstName": "Janekin",
+			})
+		}
+	}
+
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier(), handleUserLog())
+	Router(t.router.Group("v1/me"))
+	RouterAuthorization(t.router.Group("v1/me"))
+}
+
+func (t *testSuite) TestShouldGetProfile() {
+	monkey.Patch(auth.Profile, func(_ context.Context, userID *uuid.UUID) (*domain.Profile, error) {
+		t.Assert().Equal(sucessUserID, userID.String())
+		return &domain.Profile{ID: userID, Email: utils.Pointer("janekin@powersso.io")}, nil
+	})
+	defer monkey.Unpatch(auth.Profile)
+
+	var (
+		req = httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+		w   = httptest.NewRecorder()
+	)
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Contains(w.Body.String(), "janekin@powersso.io")
+}
+
+func (t *testSuite) TestShouldUpdateProfile() {
+	t.Run("Success", func() {
+		monkey.Patch(auth.UpdateProfile, func(_ context.Context, _ *uuid.UUID, _ *domain.UpdateProfile) error {
+			return nil
+		})
+		defer monkey.Unpatch(auth.UpdateProfile)
+
+		data, err := json.Marshal(map[string]interface{}{"first_name": "Ayrton", "locale": "pt_BR"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/me", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::LocaleNotSupported", func() {
+		data, err := json.Marshal(map[string]interface{}{"locale": "fr_FR"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/me", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldChangeEmail() {
+	t.Run("Success", func() {
+		monkey.Patch(auth.RequestEmailChange, func(_ context.Context, in *domain.ChangeEmail) error {
+			t.Assert().Equal(sucessUserID, in.UserID.String())
+			return nil
+		})
+		defer monkey.Unpatch(auth.RequestEmailChange)
+
+		data, err := json.Marshal(map[string]interface{}{"email": "new@powersso.io", "password": "any_password"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/email", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::ConfirmationRequired", func() {
+		data, err := json.Marshal(map[string]interface{}{"email": "new@powersso.io"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/email", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Error::InvalidEmail", func() {
+		data, err := json.Marshal(map[string]interface{}{"email": "new", "password": "any_password"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/email", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldConfirmEmail() {
+	monkey.Patch(auth.ConfirmEmailChange, func(_ context.Context, _ *domain.ConfirmEmail) error {
+		return nil
+	})
+	defer monkey.Unpatch(auth.ConfirmEmailChange)
+
+	data, err := json.Marshal(map[string]interface{}{"token": uuid.New()})
+	t.Assert().Nil(err, oops.Err(err))
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/me/email/confirm", bytes.NewBuffer(data))
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusNoContent, w.Code)
+}
+
+func (t *testSuite) TestShouldExport() {
+	monkey.Patch(privacy.Export, func(_ context.Context, userID *uuid.UUID) (*domainPrivacy.Export, error) {
+		return &domainPrivacy.Export{Profile: &domain.Profile{ID: userID}}, nil
+	})
+	defer monkey.Unpatch(privacy.Export)
+
+	var (
+		req = httptest.NewRequest(http.MethodGet, "/v1/me/export", nil)
+		w   = httptest.NewRecorder()
+	)
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Equal(`attachment; filename="powersso-export-`+sucessUserID+`.json"`, w.Header().Get("Content-Disposition"))
+}
+
+func (t *testSuite) TestShouldDeleteAccount() {
+	t.Run("Success", func() {
+		monkey.Patch(privacy.RequestDeletion, func(_ context.Context, in *domainPrivacy.DeleteAccount) error {
+			t.Assert().Equal(sucessUserID, in.UserID.String())
+			return nil
+		})
+		defer monkey.Unpatch(privacy.RequestDeletion)
+
+		data, err := json.Marshal(map[string]interface{}{"password": "any_password"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/me", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Success::ConfirmationToken", func() {
+		token := uuid.New()
+		monkey.Patch(privacy.RequestDeletion, func(_ context.Context, in *domainPrivacy.DeleteAccount) error {
+			t.Assert().Equal(token, *in.ConfirmationToken)
+			t.Assert().Nil(in.Password)
+			return nil
+		})
+		defer monkey.Unpatch(privacy.RequestDeletion)
+
+		data, err := json.Marshal(map[string]interface{}{"confirmation_token": token})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/me", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::ConfirmationRequired", func() {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/me", bytes.NewBufferString("{}"))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldCancelDeletion() {
+	monkey.Patch(privacy.CancelDeletion, func(_ context.Context, _ *domainPrivacy.CancelDeletion) error {
+		return nil
+	})
+	defer monkey.Unpatch(privacy.CancelDeletion)
+
+	data, err := json.Marshal(map[string]interface{}{"token": uuid.New()})
+	t.Assert().Nil(err, oops.Err(err))
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/me/deletion/cancel", bytes.NewBuffer(data))
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusNoContent, w.Code)
+}
+
+func (t *testSuite) TestShouldListIdentities() {
+	monkey.Patch(identity.Identities, func(_ context.Context, userID *uuid.UUID) ([]domainIdentity.Identity, error) {
+		t.Assert().Equal(sucessUserID, userID.String())
+		return []domainIdentity.Identity{{Provider: utils.Pointer("google"), Subject: utils.Pointer("248289761001")}}, nil
+	})
+	defer monkey.Unpatch(identity.Identities)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/me/identities", nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Contains(w.Body.String(), `"provider":"google"`)
+}
+
+func (t *testSuite) TestShouldLinkIdentity() {
+	t.Run("Success", func() {
+		monkey.Patch(identity.Authorize, func(_ context.Context, provider string, purpose domainIdentity.Purpose, userID *uuid.UUID) (*domainIdentity.Authorization, error) {
+			t.Assert().Equal("google", provider)
+			t.Assert().Equal(domainIdentity.PurposeLink, purpose)
+			t.Assert().Equal(sucessUserID, userID.String())
+			return &domainIdentity.Authorization{URL: utils.Pointer("https://accounts.google.com/o/oauth2/v2/auth")}, nil
+		})
+		defer monkey.Unpatch(identity.Authorize)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/identities/google", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"authorization_url":"https://accounts.google.com/o/oauth2/v2/auth"`)
+	})
+
+	t.Run("Error::ProviderNotFound", func() {
+		monkey.Patch(identity.Authorize, func(_ context.Context, _ string, _ domainIdentity.Purpose, _ *uuid.UUID) (*domainIdentity.Authorization, error) {
+			return nil, domainIdentity.ErrProviderNotFound()
+		})
+		defer monkey.Unpatch(identity.Authorize)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/identities/unknown", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldConfirmWithIdentity() {
+	monkey.Patch(identity.Authorize, func(_ context.Context, provider string, purpose domainIdentity.Purpose, userID *uuid.UUID) (*domainIdentity.Authorization, error) {
+		t.Assert().Equal("google", provider)
+		t.Assert().Equal(domainIdentity.PurposeConfirm, purpose)
+		t.Assert().Equal(sucessUserID, userID.String())
+		return &domainIdentity.Authorization{URL: utils.Pointer("https://accounts.google.com/o/oauth2/v2/auth")}, nil
+	})
+	defer monkey.Unpatch(identity.Authorize)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/me/identities/google/confirm", nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Contains(w.Body.String(), `"authorization_url":"https://accounts.google.com/o/oauth2/v2/auth"`)
+}
+
+func (t *testSuite) TestShouldUnlinkIdentity() {
+	t.Run("Success", func() {
+		identityID := uuid.New()
+		monkey.Patch(identity.Unlink, func(_ context.Context, userID, id *uuid.UUID) error {
+			t.Assert().Equal(sucessUserID, userID.String())
+			t.Assert().Equal(identityID, *id)
+			return nil
+		})
+		defer monkey.Unpatch(identity.Unlink)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/me/identities/"+identityID.String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::IdentityNotFound", func() {
+		monkey.Patch(identity.Unlink, func(_ context.Context, _, _ *uuid.UUID) error {
+			return domainIdentity.ErrIdentityNotFound()
+		})
+		defer monkey.Unpatch(identity.Unlink)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/me/identities/"+uuid.New().String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNotFound, w.Code)
+	})
+}
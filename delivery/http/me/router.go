@@ -0,0 +1,32 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package me
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// Router is the router for the profile of the user that does not require a session,
+// used by the links sent by email
+func Router(r *gin.RouterGroup) {
+	r.PUT("email/confirm", middleware.RateLimit(), confirmEmail)
+	r.PUT("deletion/cancel", middleware.RateLimit(), cancelDeletion)
+}
+
+// RouterAuthorization is the router for the profile of the logged in user.
+func RouterAuthorization(r *gin.RouterGroup) {
+	r.GET("", profile)
+	r.PATCH("", updateProfile)
+	r.DELETE("", middleware.RateLimit(), deleteAccount)
+	r.GET("export", export)
+	r.POST("email", middleware.RateLimit(), changeEmail)
+
+	r.GET("identities", identities)
+	r.POST("identities/:provider", middleware.RateLimit(), linkIdentity)
+	r.POST("identities/:provider/confirm", middleware.RateLimit(), confirmIdentity)
+	r.DELETE("identities/:identity_id", unlinkIdentity)
+}
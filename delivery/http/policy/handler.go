@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/policy"
+	domain "github.com/isaqueveras/powersso/domain/policy"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/policy [GET]
+func list(ctx *gin.Context) {
+	res, err := app.List(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/policy/{policy_id} [GET]
+func get(ctx *gin.Context) {
+	policyID, err := uuid.Parse(ctx.Param("policy_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Get(ctx, &policyID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/policy [POST]
+func create(ctx *gin.Context) {
+	createdBy, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.Policy)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.CreatedBy = &createdBy
+	if err = app.Create(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, input)
+}
+
+// @Router /v1/policy/{policy_id} [PUT]
+func update(ctx *gin.Context) {
+	policyID, err := uuid.Parse(ctx.Param("policy_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.Policy)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.ID = &policyID
+	if err = app.Update(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, input)
+}
+
+// @Router /v1/policy/{policy_id} [DELETE]
+func remove(ctx *gin.Context) {
+	policyID, err := uuid.Parse(ctx.Param("policy_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Delete(ctx, &policyID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/policy/dry_run [POST]
+func dryRun(ctx *gin.Context) {
+	input := new(domain.Request)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.DryRun(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	app "github.com/isaqueveras/powersso/application/policy"
+	"github.com/isaqueveras/powersso/application/role"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/policy"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const sucessUserID = "9ec1b2a7-665c-47a7-b180-54f11f8a6122"
+
+func TestHandlerPolicy(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	var handleUserLog = func() gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set("UID", sucessUserID)
+			ctx.Set("SESSION", jwt.MapClaims{
+				"SessionID": "",
+				"UserID":    sucessUserID,
+				"UserLevel": string(auth.AdminLevel),
+				"FirstName": "Janekin",
+			})
+		}
+	}
+
+	// only the user of the session can manage the policies
+	monkey.Patch(role.HasPermission, func(_ context.Context, userID *uuid.UUID, permission domainRole.Permission) (bool, error) {
+		return userID.String() == sucessUserID && permission == domainRole.PermissionPolicyManage, nil
+	})
+
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier(), handleUserLog())
+	RouterAuthorization(t.router.Group("v1/policy"))
+}
+
+func (t *testSuite) TearDownSuite() {
+	monkey.Unpatch(role.HasPermission)
+}
+
+func (t *testSuite) TestShouldCreatePolicy() {
+	t.Run("Success", func() {
+		monkey.Patch(app.Create, func(_ context.Context, in *domain.Policy) error {
+			t.Assert().Equal("Admins from the office", *in.Name)
+			t.Assert().Equal(sucessUserID, in.CreatedBy.String())
+			t.Assert().True(in.IsActive())
+			in.ID = utils.Pointer(uuid.New())
+			return nil
+		})
+		defer monkey.Unpatch(app.Create)
+
+		data := `{"name":"Admins from the office","events":["login"],"target":"user.level eq \"admin\"",` +
+			`"condition":"request.ip in [\"10.0.0.0/8\"]"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/policy", bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+		t.Assert().Contains(w.Body.String(), `"active":true`)
+	})
+
+	t.Run("Error::ExpressionIsNotValid", func() {
+		data := `{"name":"Admins from the office","events":["login"],"condition":"request.ip in"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/policy", bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Error::PolicyExists", func() {
+		monkey.Patch(app.Create, func(_ context.Context, _ *domain.Policy) error {
+			return domain.ErrPolicyExists()
+		})
+		defer monkey.Unpatch(app.Create)
+
+		data := `{"name":"Admins from the office","events":["login"],"condition":"user.otp eq true"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/policy", bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusConflict, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldDryRun() {
+	t.Run("Success", func() {
+		monkey.Patch(app.DryRun, func(_ context.Context, in *domain.Request) (*domain.Decision, error) {
+			t.Assert().Equal(domain.EventToken, *in.Event)
+			t.Assert().Equal("192.168.0.10", *in.ClientIP)
+			t.Assert().NotNil(in.Time)
+			return &domain.Decision{DeniedBy: utils.Pointer("Project requires 2FA"), Results: []domain.Result{
+				{Name: utils.Pointer("Project requires 2FA"), Active: true, Applies: true},
+			}}, nil
+		})
+		defer monkey.Unpatch(app.DryRun)
+
+		data := `{"event":"token","user_id":"` + uuid.New().String() + `","project_id":"` + uuid.New().String() + `","ip":"192.168.0.10"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/policy/dry_run", bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"allowed":false`)
+		t.Assert().Contains(w.Body.String(), `"denied_by":"Project requires 2FA"`)
+	})
+
+	t.Run("Error::EventIsNotValid", func() {
+		data := `{"event":"logout","user_id":"` + uuid.New().String() + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/policy/dry_run", bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldDeletePolicy() {
+	t.Run("Success", func() {
+		policyID := uuid.New()
+		monkey.Patch(app.Delete, func(_ context.Context, id *uuid.UUID) error {
+			t.Assert().Equal(policyID, *id)
+			return nil
+		})
+		defer monkey.Unpatch(app.Delete)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/policy/"+policyID.String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::PolicyNotFound", func() {
+		monkey.Patch(app.Delete, func(_ context.Context, _ *uuid.UUID) error {
+			return domain.ErrPolicyNotFound()
+		})
+		defer monkey.Unpatch(app.Delete)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/policy/"+uuid.New().String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldOnlyAllowPermission() {
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("SESSION", jwt.MapClaims{"UserID": uuid.New().String(), "UserLevel": string(auth.UserLevel), "FirstName": "Jane"})
+	})
+	RouterAuthorization(router.Group("v1/policy"))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/policy", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusForbidden, w.Code)
+}
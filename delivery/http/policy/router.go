@@ -0,0 +1,23 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// RouterAuthorization is the router for the administrators to manage the conditional access policies and run them in dry run
+func RouterAuthorization(r *gin.RouterGroup) {
+	r.Use(middleware.RequirePermission("policy:manage"))
+
+	r.GET("", list)
+	r.POST("", create)
+	r.POST("dry_run", dryRun)
+	r.GET(":policy_id", get)
+	r.PUT(":policy_id", update)
+	r.DELETE(":policy_id", remove)
+}
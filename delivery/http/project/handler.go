@@ -8,7 +8,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/isaqueveras/powersso/application/project"
+	domain "github.com/isaqueveras/powersso/domain/project"
 	"github.com/isaqueveras/powersso/middleware"
 	"github.com/isaqueveras/powersso/oops"
 	"github.com/isaqueveras/powersso/utils"
@@ -40,3 +42,236 @@ func create(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusCreated, utils.NoContent{})
 }
+
+// @Router /v1/project/{project_id}/roles [get]
+func roles(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := project.Roles(ctx, &projectID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/project/{project_id}/roles [post]
+func createRole(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.CreateRole)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.ProjectID = &projectID
+	roleID, err := project.CreateRole(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, map[string]string{"id": roleID.String()})
+}
+
+// @Router /v1/project/{project_id}/roles/{role_id} [patch]
+func updateRole(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.UpdateRole)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = project.UpdateRole(ctx, &projectID, &roleID, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/project/{project_id}/roles/{role_id} [delete]
+func deleteRole(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = project.DeleteRole(ctx, &projectID, &roleID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/project/{project_id}/participants [get]
+func participants(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := project.Participants(ctx, &projectID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/project/{project_id}/participants [post]
+func addParticipant(ctx *gin.Context) {
+	createdBy, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.AddParticipant)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.ProjectID, input.CreatedBy = &projectID, &createdBy
+	if err = project.AddParticipant(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, utils.NoContent{})
+}
+
+// @Router /v1/project/{project_id}/participants/{user_id} [delete]
+func removeParticipant(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = project.RemoveParticipant(ctx, &projectID, &userID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// assignment parses the role of the project and the participant of the route
+func assignment(ctx *gin.Context) (*domain.Assignment, error) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Assignment{ProjectID: &projectID, RoleID: &roleID, UserID: &userID}, nil
+}
+
+// @Router /v1/project/{project_id}/participants/{user_id}/roles/{role_id} [put]
+func assignRole(ctx *gin.Context) {
+	createdBy, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input, err := assignment(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.CreatedBy = &createdBy
+	if err = project.AssignRole(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/project/{project_id}/participants/{user_id}/roles/{role_id} [delete]
+func unassignRole(ctx *gin.Context) {
+	input, err := assignment(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = project.UnassignRole(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
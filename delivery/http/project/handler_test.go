@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/isaqueveras/powersso/application/project"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/project"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const sucessUserID = "9ec1b2a7-665c-47a7-b180-54f11f8a6122"
+
+// ownedProjectID is the project the user of the session owns
+var ownedProjectID = uuid.New()
+
+func TestHandlerProject(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	var handleUserLog = func() gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set("UID", sucessUserID)
+			ctx.Set("SESSION", jwt.MapClaims{
+				"SessionID": "",
+				"UserID":    sucessUserID,
+				"UserLevel": string(auth.UserLevel),
+				"FirstName": "Janekin",
+			})
+		}
+	}
+
+	// the user of the session has all permissions only in the project it owns
+	monkey.Patch(project.HasPermission, func(_ context.Context, projectID, userID *uuid.UUID, _ domain.Permission) (bool, error) {
+		return *projectID == ownedProjectID && userID.String() == sucessUserID, nil
+	})
+
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier(), handleUserLog())
+	RouterAuthorization(t.router.Group("v1/project"))
+}
+
+func (t *testSuite) TearDownSuite() {
+	monkey.Unpatch(project.HasPermission)
+}
+
+func (t *testSuite) TestShouldCreateRole() {
+	t.Run("Success", func() {
+		roleID := uuid.New()
+		monkey.Patch(project.CreateRole, func(_ context.Context, in *domain.CreateRole) (*uuid.UUID, error) {
+			t.Assert().Equal(ownedProjectID, *in.ProjectID)
+			t.Assert().Equal("reviewer", *in.Name)
+			return &roleID, nil
+		})
+		defer monkey.Unpatch(project.CreateRole)
+
+		data, err := json.Marshal(map[string]interface{}{"name": "Reviewer", "permissions": []string{"participant:read"}})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/project/"+ownedProjectID.String()+"/roles", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+		t.Assert().Contains(w.Body.String(), roleID.String())
+	})
+
+	t.Run("Error::AnotherProject", func() {
+		data, err := json.Marshal(map[string]interface{}{"name": "reviewer"})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/project/"+uuid.New().String()+"/roles", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusForbidden, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldListParticipants() {
+	monkey.Patch(project.Participants, func(_ context.Context, _ *uuid.UUID) ([]domain.ProjectParticipant, error) {
+		return []domain.ProjectParticipant{{FirstName: utils.Pointer("Jane"), Roles: []string{domain.RoleOwner, domain.RoleViewer}}}, nil
+	})
+	defer monkey.Unpatch(project.Participants)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/project/"+ownedProjectID.String()+"/participants", nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Contains(w.Body.String(), `"roles":["owner","viewer"]`)
+}
+
+func (t *testSuite) TestShouldAddParticipant() {
+	t.Run("Success", func() {
+		userID := uuid.New()
+		monkey.Patch(project.AddParticipant, func(_ context.Context, in *domain.AddParticipant) error {
+			t.Assert().Equal(userID, *in.UserID)
+			t.Assert().Equal(sucessUserID, in.CreatedBy.String())
+			t.Assert().Equal([]string{domain.RoleMaintainer}, in.Roles)
+			return nil
+		})
+		defer monkey.Unpatch(project.AddParticipant)
+
+		data, err := json.Marshal(map[string]interface{}{
+			"user_id":    userID,
+			"start_date": "2023-01-02T00:00:00Z",
+			"roles":      []string{"Maintainer"},
+		})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/project/"+ownedProjectID.String()+"/participants", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+	})
+
+	t.Run("Error::WithoutStartDate", func() {
+		data, err := json.Marshal(map[string]interface{}{"user_id": uuid.New()})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/project/"+ownedProjectID.String()+"/participants", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldAssignRole() {
+	t.Run("Success", func() {
+		userID, roleID := uuid.New(), uuid.New()
+		monkey.Patch(project.AssignRole, func(_ context.Context, in *domain.Assignment) error {
+			t.Assert().Equal(ownedProjectID, *in.ProjectID)
+			t.Assert().Equal(roleID, *in.RoleID)
+			t.Assert().Equal(userID, *in.UserID)
+			return nil
+		})
+		defer monkey.Unpatch(project.AssignRole)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/project/"+ownedProjectID.String()+"/participants/"+
+			userID.String()+"/roles/"+roleID.String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::DefaultRole", func() {
+		monkey.Patch(project.AssignRole, func(_ context.Context, _ *domain.Assignment) error {
+			return domain.ErrDefaultRole()
+		})
+		defer monkey.Unpatch(project.AssignRole)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/project/"+ownedProjectID.String()+"/participants/"+
+			uuid.New().String()+"/roles/"+uuid.New().String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
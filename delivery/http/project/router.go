@@ -11,5 +11,17 @@ import (
 
 // RouterAuthorization is the router for the project module.
 func RouterAuthorization(r *gin.RouterGroup) {
-	r.POST("create", middleware.OnlyAdmin(), create)
+	r.POST("create", middleware.RequirePermission("project:create"), create)
+
+	project := r.Group(":project_id")
+	project.GET("roles", middleware.RequireProjectPermission("participant:read"), roles)
+	project.POST("roles", middleware.RequireProjectPermission("role:manage"), createRole)
+	project.PATCH("roles/:role_id", middleware.RequireProjectPermission("role:manage"), updateRole)
+	project.DELETE("roles/:role_id", middleware.RequireProjectPermission("role:manage"), deleteRole)
+
+	project.GET("participants", middleware.RequireProjectPermission("participant:read"), participants)
+	project.POST("participants", middleware.RequireProjectPermission("participant:manage"), addParticipant)
+	project.DELETE("participants/:user_id", middleware.RequireProjectPermission("participant:manage"), removeParticipant)
+	project.PUT("participants/:user_id/roles/:role_id", middleware.RequireProjectPermission("role:manage"), assignRole)
+	project.DELETE("participants/:user_id/roles/:role_id", middleware.RequireProjectPermission("role:manage"), unassignRole)
 }
@@ -0,0 +1,167 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/relation"
+	domain "github.com/isaqueveras/powersso/domain/relation"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/relation/namespaces [GET]
+func namespaces(ctx *gin.Context) {
+	res, err := app.Namespaces(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/relation/namespaces/{namespace} [PUT]
+func saveNamespace(ctx *gin.Context) {
+	input := new(domain.Namespace)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.Name = utils.Pointer(ctx.Param("namespace"))
+	if err := app.SaveNamespace(ctx, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, input)
+}
+
+// @Router /v1/relation/namespaces/{namespace} [DELETE]
+func deleteNamespace(ctx *gin.Context) {
+	if err := app.DeleteNamespace(ctx, ctx.Param("namespace")); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/relation/tuples [GET]
+func tuples(ctx *gin.Context) {
+	filter := new(domain.TupleFilter)
+
+	if value := ctx.Query("object_type"); value != "" {
+		filter.Object = &domain.Object{Type: &value}
+		if id := ctx.Query("object_id"); id != "" {
+			filter.Object.ID = &id
+		}
+	}
+
+	if value := ctx.Query("relation"); value != "" {
+		filter.Relation = &value
+	}
+
+	if value := ctx.Query("subject"); value != "" {
+		subject, err := domain.ParseSubject(value)
+		if err != nil {
+			oops.Handling(ctx, err)
+			return
+		}
+		filter.Subject = subject
+	}
+
+	res, err := app.Tuples(ctx, filter)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/relation/write [POST]
+func write(ctx *gin.Context) {
+	createdBy, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.Write)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Write(ctx, input, &createdBy); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/relation/check [POST]
+func checkRelation(ctx *gin.Context) {
+	input := new(domain.Check)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Check(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/relation/expand [POST]
+func expand(ctx *gin.Context) {
+	input := new(domain.Expand)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Expand(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/relation/list_objects [POST]
+func listObjects(ctx *gin.Context) {
+	input := new(domain.ListObjects)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.ListObjects(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
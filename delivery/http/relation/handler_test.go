@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	app "github.com/isaqueveras/powersso/application/relation"
+	"github.com/isaqueveras/powersso/application/role"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/relation"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+const sucessUserID = "9ec1b2a7-665c-47a7-b180-54f11f8a6122"
+
+func TestHandlerRelation(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	var handleUserLog = func() gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set("UID", sucessUserID)
+			ctx.Set("SESSION", jwt.MapClaims{
+				"SessionID": "",
+				"UserID":    sucessUserID,
+				"UserLevel": string(auth.IntegrationLevel),
+				"FirstName": "Docs",
+			})
+		}
+	}
+
+	// the integration of the session can check the relations, but not manage them
+	monkey.Patch(role.HasPermission, func(_ context.Context, userID *uuid.UUID, permission domainRole.Permission) (bool, error) {
+		return userID.String() == sucessUserID && permission == domainRole.PermissionRelationCheck, nil
+	})
+
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier(), handleUserLog())
+	RouterAuthorization(t.router.Group("v1/relation"))
+}
+
+func (t *testSuite) TearDownSuite() {
+	monkey.Unpatch(role.HasPermission)
+}
+
+func (t *testSuite) TestShouldCheckRelation() {
+	t.Run("Success", func() {
+		monkey.Patch(app.Check, func(_ context.Context, in *domain.Check) (*domain.CheckResult, error) {
+			t.Assert().Equal("project:powersso", in.Object.String())
+			t.Assert().Equal("viewer", *in.Relation)
+			t.Assert().Equal("user:"+sucessUserID, in.Subject.String())
+			return &domain.CheckResult{Allowed: true}, nil
+		})
+		defer monkey.Unpatch(app.Check)
+
+		data := `{"object":{"type":"project","id":"powersso"},"relation":"viewer","subject":{"type":"user","id":"` + sucessUserID + `"}}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/relation/check", bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().JSONEq(`{"allowed":true}`, w.Body.String())
+	})
+
+	t.Run("Error::ObjectIsNotValid", func() {
+		monkey.Patch(app.Check, func(_ context.Context, _ *domain.Check) (*domain.CheckResult, error) {
+			return nil, domain.ErrObjectIsNotValid()
+		})
+		defer monkey.Unpatch(app.Check)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/relation/check", bytes.NewBufferString(`{"relation":"viewer"}`))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldListTuples() {
+	t.Run("Success", func() {
+		monkey.Patch(app.Tuples, func(_ context.Context, filter *domain.TupleFilter) ([]domain.Tuple, error) {
+			t.Assert().Equal("doc", *filter.Object.Type)
+			t.Assert().Nil(filter.Object.ID)
+			t.Assert().Equal("group:staff#member", filter.Subject.String())
+			return []domain.Tuple{}, nil
+		})
+		defer monkey.Unpatch(app.Tuples)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/relation/tuples?object_type=doc&subject=group:staff%23member", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+	})
+
+	t.Run("Error::SubjectIsNotValid", func() {
+		req := httptest.NewRequest(http.MethodGet, "/v1/relation/tuples?subject=staff", nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldNotWriteWithoutPermission() {
+	req := httptest.NewRequest(http.MethodPost, "/v1/relation/write",
+		bytes.NewBufferString(`{"writes":[{"object":{"type":"doc","id":"readme"},"relation":"viewer","subject":{"type":"user","id":"jane"}}]}`))
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// RouterAuthorization is the router for the applications to write the relationship tuples and check them
+func RouterAuthorization(r *gin.RouterGroup) {
+	check := r.Group("", middleware.RequirePermission("relation:check"))
+	check.GET("namespaces", namespaces)
+	check.GET("tuples", tuples)
+	check.POST("check", checkRelation)
+	check.POST("expand", expand)
+	check.POST("list_objects", listObjects)
+
+	manage := r.Group("", middleware.RequirePermission("relation:manage"))
+	manage.PUT("namespaces/:namespace", saveNamespace)
+	manage.DELETE("namespaces/:namespace", deleteNamespace)
+	manage.POST("write", write)
+}
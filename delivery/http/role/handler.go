@@ -0,0 +1,172 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/role"
+	domain "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/role [GET]
+func list(ctx *gin.Context) {
+	res, err := app.List(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/role/permissions [GET]
+func permissions(ctx *gin.Context) {
+	res, err := app.Permissions(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/role [POST]
+func create(ctx *gin.Context) {
+	input := new(domain.CreateRole)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	roleID, err := app.Create(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, map[string]string{"id": roleID.String()})
+}
+
+// @Router /v1/role/{role_id} [PATCH]
+func update(ctx *gin.Context) {
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.UpdateRole)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = input.Validate(); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Update(ctx, &roleID, input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/role/{role_id} [DELETE]
+func remove(ctx *gin.Context) {
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Delete(ctx, &roleID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/role/users/{user_id} [GET]
+func userRoles(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.UserRoles(ctx, &userID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/role/{role_id}/users/{user_id} [PUT]
+func assign(ctx *gin.Context) {
+	createdBy, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Assign(ctx, &domain.Assignment{UserID: &userID, RoleID: &roleID, CreatedBy: &createdBy}); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/role/{role_id}/users/{user_id} [DELETE]
+func unassign(ctx *gin.Context) {
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Unassign(ctx, &userID, &roleID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
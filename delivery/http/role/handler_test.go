@@ -0,0 +1,177 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	app "github.com/isaqueveras/powersso/application/role"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const sucessUserID = "9ec1b2a7-665c-47a7-b180-54f11f8a6122"
+
+func TestHandlerRole(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	var handleUserLog = func() gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set("UID", sucessUserID)
+			ctx.Set("SESSION", jwt.MapClaims{
+				"SessionID": "",
+				"UserID":    sucessUserID,
+				"UserLevel": string(auth.AdminLevel),
+				"FirstName": "Janekin",
+			})
+		}
+	}
+
+	// only the user of the session can manage the roles
+	monkey.Patch(app.HasPermission, func(_ context.Context, userID *uuid.UUID, permission domain.Permission) (bool, error) {
+		return userID.String() == sucessUserID && permission == domain.PermissionRoleManage, nil
+	})
+
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier(), handleUserLog())
+	RouterAuthorization(t.router.Group("v1/role"))
+}
+
+func (t *testSuite) TearDownSuite() {
+	monkey.Unpatch(app.HasPermission)
+}
+
+func (t *testSuite) TestShouldCreateRole() {
+	t.Run("Success", func() {
+		roleID := uuid.New()
+		monkey.Patch(app.Create, func(_ context.Context, in *domain.CreateRole) (*uuid.UUID, error) {
+			t.Assert().Equal("support", *in.Name)
+			t.Assert().Equal([]domain.Permission{domain.PermissionUserRead}, in.Permissions)
+			return &roleID, nil
+		})
+		defer monkey.Unpatch(app.Create)
+
+		data, err := json.Marshal(map[string]interface{}{
+			"name":        "Support",
+			"description": "Support team",
+			"permissions": []string{"user:read", "user:read"},
+		})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/role", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+		t.Assert().Contains(w.Body.String(), roleID.String())
+	})
+
+	t.Run("Error::UnknownPermission", func() {
+		data, err := json.Marshal(map[string]interface{}{"name": "support", "permissions": []string{"user:fly"}})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/role", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldListPermissions() {
+	monkey.Patch(app.Permissions, func(_ context.Context) ([]domain.PermissionDetail, error) {
+		return []domain.PermissionDetail{{Name: utils.Pointer(domain.PermissionProjectCreate)}}, nil
+	})
+	defer monkey.Unpatch(app.Permissions)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/role/permissions", nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Contains(w.Body.String(), "project:create")
+}
+
+func (t *testSuite) TestShouldAssignRole() {
+	t.Run("Success", func() {
+		userID, roleID := uuid.New(), uuid.New()
+		monkey.Patch(app.Assign, func(_ context.Context, in *domain.Assignment) error {
+			t.Assert().Equal(userID, *in.UserID)
+			t.Assert().Equal(roleID, *in.RoleID)
+			t.Assert().Equal(sucessUserID, in.CreatedBy.String())
+			return nil
+		})
+		defer monkey.Unpatch(app.Assign)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/role/"+roleID.String()+"/users/"+userID.String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Error::LevelRole", func() {
+		monkey.Patch(app.Assign, func(_ context.Context, _ *domain.Assignment) error {
+			return domain.ErrLevelRole()
+		})
+		defer monkey.Unpatch(app.Assign)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/role/"+uuid.New().String()+"/users/"+uuid.New().String(), nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldListUserRoles() {
+	monkey.Patch(app.UserRoles, func(_ context.Context, _ *uuid.UUID) ([]domain.Role, error) {
+		return []domain.Role{{Name: utils.Pointer("admin"), Level: utils.Pointer(auth.AdminLevel)}}, nil
+	})
+	defer monkey.Unpatch(app.UserRoles)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/role/users/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Contains(w.Body.String(), `"level":"admin"`)
+}
+
+func (t *testSuite) TestShouldRequirePermission() {
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("SESSION", jwt.MapClaims{"UserID": uuid.New().String(), "UserLevel": string(auth.UserLevel), "FirstName": "Jane"})
+	})
+	RouterAuthorization(router.Group("v1/role"))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/role", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusForbidden, w.Code)
+}
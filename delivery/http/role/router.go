@@ -0,0 +1,25 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// RouterAuthorization is the router for the administrators to manage the roles and assign them to the users
+func RouterAuthorization(r *gin.RouterGroup) {
+	r.Use(middleware.RequirePermission("role:manage"))
+
+	r.GET("", list)
+	r.POST("", create)
+	r.GET("permissions", permissions)
+	r.GET("users/:user_id", userRoles)
+	r.PATCH(":role_id", update)
+	r.DELETE(":role_id", remove)
+	r.PUT(":role_id/users/:user_id", assign)
+	r.DELETE(":role_id/users/:user_id", unassign)
+}
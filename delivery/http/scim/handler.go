@@ -0,0 +1,312 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/scim"
+	"github.com/isaqueveras/powersso/config"
+	domain "github.com/isaqueveras/powersso/domain/scim"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// handling responds the error in the format of the errors of the RFC 7644
+func handling(ctx *gin.Context, err error) {
+	status, res := domain.NewErrorResponse(err)
+	ctx.AbortWithStatusJSON(status, res)
+	ctx.Set("error", oops.Err(err))
+}
+
+// actor returns the integration that made the request
+func actor(ctx *gin.Context) (*uuid.UUID, error) {
+	actorID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &actorID, nil
+}
+
+// @Router /scim/v2/ServiceProviderConfig [GET]
+func serviceProviderConfig(ctx *gin.Context) {
+	cfg := config.Get().SCIM
+	ctx.JSON(http.StatusOK, domain.NewServiceProviderConfig(&cfg))
+}
+
+// @Router /scim/v2/Users [GET]
+func users(ctx *gin.Context) {
+	params := new(domain.ListParams)
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.Users(ctx, params)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Users [POST]
+func createUser(ctx *gin.Context) {
+	input := new(domain.User)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	actorID, err := actor(ctx)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.CreateUser(ctx, input, actorID)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", res.Meta.Location)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// @Router /scim/v2/Users/{user_id} [GET]
+func user(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.User(ctx, &userID)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Users/{user_id} [PUT]
+func replaceUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	input := new(domain.User)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.ReplaceUser(ctx, &userID, input)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Users/{user_id} [PATCH]
+func patchUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	input := new(domain.PatchRequest)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.PatchUser(ctx, &userID, input)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Users/{user_id} [DELETE]
+func deleteUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	actorID, err := actor(ctx)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	if err = app.DeleteUser(ctx, &userID, actorID); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /scim/v2/Groups [GET]
+func groups(ctx *gin.Context) {
+	params := new(domain.ListParams)
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.Groups(ctx, params)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Groups [POST]
+func createGroup(ctx *gin.Context) {
+	input := new(domain.Group)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	actorID, err := actor(ctx)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.CreateGroup(ctx, input, actorID)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", res.Meta.Location)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// @Router /scim/v2/Groups/{group_id} [GET]
+func group(ctx *gin.Context) {
+	groupID, err := uuid.Parse(ctx.Param("group_id"))
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	params := new(domain.ListParams)
+	if err = ctx.ShouldBindQuery(params); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.Group(ctx, &groupID, params)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Groups/{group_id} [PUT]
+func replaceGroup(ctx *gin.Context) {
+	groupID, err := uuid.Parse(ctx.Param("group_id"))
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	input := new(domain.Group)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.ReplaceGroup(ctx, &groupID, input)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Groups/{group_id} [PATCH]
+func patchGroup(ctx *gin.Context) {
+	groupID, err := uuid.Parse(ctx.Param("group_id"))
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	input := new(domain.PatchRequest)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.PatchGroup(ctx, &groupID, input)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /scim/v2/Groups/{group_id} [DELETE]
+func deleteGroup(ctx *gin.Context) {
+	handling(ctx, domain.ErrGroupDeletion())
+}
+
+// @Router /scim/v2/Bulk [POST]
+func bulk(ctx *gin.Context) {
+	maxPayloadSize := config.Get().SCIM.BulkMaxPayloadSize
+	if ctx.Request.ContentLength > maxPayloadSize {
+		handling(ctx, domain.ErrTooManyOperations())
+		return
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxPayloadSize)
+
+	input := new(domain.BulkRequest)
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	actorID, err := actor(ctx)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	res, err := app.Bulk(ctx, input, actorID)
+	if err != nil {
+		handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
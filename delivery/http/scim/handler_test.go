@@ -0,0 +1,240 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/isaqueveras/powersso/application/role"
+	app "github.com/isaqueveras/powersso/application/scim"
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	domain "github.com/isaqueveras/powersso/domain/scim"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const sucessUserID = "9ec1b2a7-665c-47a7-b180-54f11f8a6122"
+
+func TestHandlerSCIM(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
+
+type testSuite struct {
+	router *gin.Engine
+
+	suite.Suite
+}
+
+func (t *testSuite) SetupSuite() {
+	var handleUserLog = func() gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.Set("UID", sucessUserID)
+			ctx.Set("SESSION", jwt.MapClaims{
+				"SessionID": "",
+				"UserID":    sucessUserID,
+				"UserLevel": string(auth.IntegrationLevel),
+				"FirstName": "Okta",
+			})
+		}
+	}
+
+	os.Setenv("CONFIG_POWER_SSO", "../../../app.json")
+	config.LoadConfig()
+
+	// only the integration of the session has the permission to provision
+	monkey.Patch(role.HasPermission, func(_ context.Context, userID *uuid.UUID, permission domainRole.Permission) (bool, error) {
+		return userID.String() == sucessUserID && permission == domainRole.PermissionSCIMProvision, nil
+	})
+
+	t.router = gin.New()
+	t.router.Use(middleware.RequestIdentifier(), handleUserLog())
+	RouterAuthorization(t.router.Group("scim/v2", middleware.RequirePermission("scim:provision")))
+}
+
+func (t *testSuite) TearDownSuite() {
+	monkey.Unpatch(role.HasPermission)
+}
+
+func (t *testSuite) TestShouldCreateUser() {
+	t.Run("Success", func() {
+		userID := uuid.New()
+		monkey.Patch(app.CreateUser, func(_ context.Context, in *domain.User, createdBy *uuid.UUID) (*domain.User, error) {
+			t.Assert().Equal(sucessUserID, createdBy.String())
+			t.Assert().Equal("jane@powersso.io", *in.UserName)
+			user := &domain.User{ID: &userID, UserName: in.UserName, Name: in.Name, Active: utils.Pointer(true)}
+			user.Prepare("http://localhost:5000/scim/v2")
+			return user, nil
+		})
+		defer monkey.Unpatch(app.CreateUser)
+
+		data, err := json.Marshal(map[string]interface{}{
+			"schemas":  []string{domain.SchemaUser},
+			"userName": "jane@powersso.io",
+			"name":     map[string]interface{}{"givenName": "Jane", "familyName": "Doe"},
+		})
+		t.Assert().Nil(err, oops.Err(err))
+
+		req := httptest.NewRequest(http.MethodPost, "/scim/v2/Users", bytes.NewBuffer(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusCreated, w.Code)
+		t.Assert().Equal("application/scim+json", w.Header().Get("Content-Type"))
+		t.Assert().Equal("http://localhost:5000/scim/v2/Users/"+userID.String(), w.Header().Get("Location"))
+	})
+
+	t.Run("Error::UserExists", func() {
+		monkey.Patch(app.CreateUser, func(_ context.Context, _ *domain.User, _ *uuid.UUID) (*domain.User, error) {
+			return nil, domain.ErrUserExists()
+		})
+		defer monkey.Unpatch(app.CreateUser)
+
+		req := httptest.NewRequest(http.MethodPost, "/scim/v2/Users", bytes.NewBufferString(`{"userName":"jane@powersso.io"}`))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusConflict, w.Code)
+		t.Assert().Contains(w.Body.String(), `"scimType":"uniqueness"`)
+		t.Assert().Contains(w.Body.String(), domain.SchemaError)
+	})
+}
+
+func (t *testSuite) TestShouldListUsers() {
+	t.Run("Success", func() {
+		monkey.Patch(app.Users, func(_ context.Context, params *domain.ListParams) (*domain.ListResponse[domain.User], error) {
+			t.Assert().Equal(`userName eq "jane@powersso.io"`, *params.Filter)
+			t.Assert().Equal(int64(1), params.StartIndex)
+			return domain.NewListResponse([]domain.User{}, 0, params), nil
+		})
+		defer monkey.Unpatch(app.Users)
+
+		req := httptest.NewRequest(http.MethodGet, `/scim/v2/Users?startIndex=1&filter=userName+eq+%22jane@powersso.io%22`, nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"totalResults":0`)
+	})
+
+	t.Run("Error::InvalidFilter", func() {
+		monkey.Patch(app.Users, func(_ context.Context, _ *domain.ListParams) (*domain.ListResponse[domain.User], error) {
+			return nil, domain.ErrInvalidFilter()
+		})
+		defer monkey.Unpatch(app.Users)
+
+		req := httptest.NewRequest(http.MethodGet, `/scim/v2/Users?filter=userName`, nil)
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+		t.Assert().Contains(w.Body.String(), `"scimType":"invalidFilter"`)
+	})
+}
+
+func (t *testSuite) TestShouldPatchUser() {
+	t.Run("Success", func() {
+		monkey.Patch(app.PatchUser, func(_ context.Context, _ *uuid.UUID, in *domain.PatchRequest) (*domain.User, error) {
+			t.Assert().Len(in.Operations, 1)
+			return &domain.User{Active: utils.Pointer(false)}, nil
+		})
+		defer monkey.Unpatch(app.PatchUser)
+
+		data := `{"schemas":["` + domain.SchemaPatchOp + `"],"Operations":[{"op":"Replace","path":"active","value":"False"}]}`
+		req := httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/"+uuid.New().String(), bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+	})
+
+	t.Run("Error::WithoutOperations", func() {
+		req := httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/"+uuid.New().String(), bytes.NewBufferString(`{"Operations":[]}`))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (t *testSuite) TestShouldDeleteUser() {
+	monkey.Patch(app.DeleteUser, func(_ context.Context, _, requestedBy *uuid.UUID) error {
+		t.Assert().Equal(sucessUserID, requestedBy.String())
+		return nil
+	})
+	defer monkey.Unpatch(app.DeleteUser)
+
+	req := httptest.NewRequest(http.MethodDelete, "/scim/v2/Users/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusNoContent, w.Code)
+}
+
+func (t *testSuite) TestShouldNotDeleteGroup() {
+	req := httptest.NewRequest(http.MethodDelete, "/scim/v2/Groups/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+
+	t.router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusNotImplemented, w.Code)
+}
+
+func (t *testSuite) TestShouldRunBulk() {
+	t.Run("Success", func() {
+		monkey.Patch(app.Bulk, func(_ context.Context, in *domain.BulkRequest, actorID *uuid.UUID) (*domain.BulkResponse, error) {
+			t.Assert().Equal(sucessUserID, actorID.String())
+			t.Assert().Len(in.Operations, 1)
+			res := domain.NewBulkResponse()
+			res.Operations = append(res.Operations, domain.BulkResult{Method: domain.MethodPost, BulkID: in.Operations[0].BulkID, Status: "201"})
+			return res, nil
+		})
+		defer monkey.Unpatch(app.Bulk)
+
+		data := `{"Operations":[{"method":"POST","bulkId":"jane","path":"/Users","data":{"userName":"jane@powersso.io"}}]}`
+		req := httptest.NewRequest(http.MethodPost, "/scim/v2/Bulk", bytes.NewBufferString(data))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusOK, w.Code)
+		t.Assert().Contains(w.Body.String(), `"bulkId":"jane"`)
+	})
+
+	t.Run("Error::PayloadTooLarge", func() {
+		req := httptest.NewRequest(http.MethodPost, "/scim/v2/Bulk", bytes.NewBuffer(make([]byte, config.Get().SCIM.BulkMaxPayloadSize+1)))
+		w := httptest.NewRecorder()
+
+		t.router.ServeHTTP(w, req)
+		t.Assert().Equal(http.StatusRequestEntityTooLarge, w.Code)
+		t.Assert().Contains(w.Body.String(), `"scimType":"tooMany"`)
+	})
+}
+
+func (t *testSuite) TestShouldOnlyAllowIntegrations() {
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("SESSION", jwt.MapClaims{"UserID": uuid.New().String(), "UserLevel": string(auth.AdminLevel), "FirstName": "Jane"})
+	})
+	RouterAuthorization(router.Group("scim/v2", middleware.RequirePermission("scim:provision")))
+
+	req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+	t.Assert().Equal(http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// contentTypeSCIM is the media type of the requests and responses of the RFC 7644
+const contentTypeSCIM = "application/scim+json"
+
+// RouterAuthorization is the router for the SCIM 2.0 provisioning, used by the integrations.
+// The groups are the projects, and cannot be deleted
+func RouterAuthorization(r *gin.RouterGroup) {
+	r.Use(func(ctx *gin.Context) { ctx.Header("Content-Type", contentTypeSCIM) })
+
+	r.GET("ServiceProviderConfig", serviceProviderConfig)
+
+	r.GET("Users", users)
+	r.POST("Users", createUser)
+	r.GET("Users/:user_id", user)
+	r.PUT("Users/:user_id", replaceUser)
+	r.PATCH("Users/:user_id", patchUser)
+	r.DELETE("Users/:user_id", deleteUser)
+
+	r.GET("Groups", groups)
+	r.POST("Groups", createGroup)
+	r.GET("Groups/:group_id", group)
+	r.PUT("Groups/:group_id", replaceGroup)
+	r.PATCH("Groups/:group_id", patchGroup)
+	r.DELETE("Groups/:group_id", deleteGroup)
+
+	r.POST("Bulk", bulk)
+}
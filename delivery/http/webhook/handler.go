@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	app "github.com/isaqueveras/powersso/application/webhook"
+	domain "github.com/isaqueveras/powersso/domain/webhook"
+	"github.com/isaqueveras/powersso/middleware"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// @Router /v1/project/{project_id}/webhooks [POST]
+func create(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	userID, err := uuid.Parse(middleware.GetSession(ctx).UserID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input := new(domain.Webhook)
+	if err = ctx.ShouldBindJSON(input); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	input.ProjectID, input.CreatedBy = &projectID, &userID
+	res, err := app.Create(ctx, input)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// @Router /v1/project/{project_id}/webhooks [GET]
+func list(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.List(ctx, &projectID)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/project/{project_id}/webhooks/{webhook_id} [DELETE]
+func remove(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	webhookID, err := uuid.Parse(ctx.Param("webhook_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Delete(ctx, &projectID, &webhookID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
+
+// @Router /v1/project/{project_id}/webhooks/{webhook_id}/deliveries [GET]
+func deliveries(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	webhookID, err := uuid.Parse(ctx.Param("webhook_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	params, err := utils.ParseParams(ctx)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	res, err := app.Deliveries(ctx, &projectID, &webhookID, &params)
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Router /v1/project/{project_id}/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver [POST]
+func redeliver(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("project_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	webhookID, err := uuid.Parse(ctx.Param("webhook_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	deliveryID, err := uuid.Parse(ctx.Param("delivery_id"))
+	if err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	if err = app.Redeliver(ctx, &projectID, &webhookID, &deliveryID); err != nil {
+		oops.Handling(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, utils.NoContent{})
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/isaqueveras/powersso/middleware"
+)
+
+// RouterAuthorization is the router for the webhook module.
+func RouterAuthorization(r *gin.RouterGroup) {
+	r.POST("", middleware.RequirePermission("webhook:manage"), create)
+	r.GET("", middleware.RequirePermission("webhook:manage"), list)
+	r.DELETE(":webhook_id", middleware.RequirePermission("webhook:manage"), remove)
+	r.GET(":webhook_id/deliveries", middleware.RequirePermission("webhook:manage"), deliveries)
+	r.POST(":webhook_id/deliveries/:delivery_id/redeliver", middleware.RequirePermission("webhook:manage"), redeliver)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package directory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/auth"
+)
+
+// ErrInvalidCredentials is returned when the user is not in the directory or the password is wrong
+var ErrInvalidCredentials = errors.New("directory: invalid credentials")
+
+// Authenticator defines an interface for the directories that authenticate the users
+type Authenticator interface {
+	Authenticate(ctx context.Context, email, password string) (*auth.DirectoryEntry, error)
+}
+
+var authenticator Authenticator
+
+// Setup initializes the directory used to authenticate the users
+func Setup(cfg *config.Config) {
+	if !cfg.LDAP.Enabled {
+		authenticator = nil
+		return
+	}
+	authenticator = NewLDAPAuthenticator(&cfg.LDAP)
+}
+
+// SetAuthenticator replaces the directory used to authenticate the users
+func SetAuthenticator(a Authenticator) {
+	authenticator = a
+}
+
+// Enabled returns if the users are authenticated by a directory
+func Enabled() bool {
+	return authenticator != nil
+}
+
+// Authenticate checks the password of the user in the directory, returning the entry of the user
+func Authenticate(ctx context.Context, email, password string) (*auth.DirectoryEntry, error) {
+	if authenticator == nil {
+		return nil, auth.ErrDirectoryUnavailable()
+	}
+	return authenticator.Authenticate(ctx, email, password)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// LDAPAuthenticator authenticates the users with a search and a bind in a LDAP directory
+type LDAPAuthenticator struct {
+	cfg     *config.LDAPConfig
+	timeout time.Duration
+}
+
+// NewLDAPAuthenticator creates a new authenticator for the directory of the configuration
+func NewLDAPAuthenticator(cfg *config.LDAPConfig) *LDAPAuthenticator {
+	return &LDAPAuthenticator{cfg: cfg, timeout: time.Duration(cfg.Timeout) * time.Second}
+}
+
+// Authenticate finds the entry of the user with the account of the configuration and binds
+// with the entry and the password, the connection is closed at the end of the authentication
+func (l *LDAPAuthenticator) Authenticate(_ context.Context, email, password string) (*auth.DirectoryEntry, error) {
+	// a bind without password is an anonymous bind, which would succeed for any user
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := l.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if l.cfg.BindDN != "" {
+		if err = conn.Bind(l.cfg.BindDN, l.cfg.BindPassword); err != nil {
+			return nil, err
+		}
+	}
+
+	attributes := l.cfg.Attributes
+	result, err := conn.Search(ldap.NewSearchRequest(l.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(l.cfg.Timeout), false, fmt.Sprintf(l.cfg.UserFilter, ldap.EscapeFilter(email)),
+		[]string{attributes.Email, attributes.FirstName, attributes.LastName, attributes.Groups}, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	// more than one entry means the filter does not identify the users
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	entry := result.Entries[0]
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	user := &auth.DirectoryEntry{
+		DN:        utils.Pointer(entry.DN),
+		Email:     utils.Pointer(email),
+		FirstName: value(entry, attributes.FirstName),
+		LastName:  value(entry, attributes.LastName),
+		Groups:    entry.GetAttributeValues(attributes.Groups),
+	}
+
+	if mail := value(entry, attributes.Email); mail != nil {
+		user.Email = mail
+	}
+
+	return user, nil
+}
+
+// dial connects to the directory, with TLS when the address is ldaps or when StartTLS is enabled
+func (l *LDAPAuthenticator) dial() (*ldap.Conn, error) {
+	address, err := url.Parse(l.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	// the verification is only skipped when configured, for the directories with private certificates
+	tlsConfig := &tls.Config{ServerName: address.Hostname(), InsecureSkipVerify: l.cfg.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(l.cfg.URL, ldap.DialWithDialer(&net.Dialer{Timeout: l.timeout}), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(l.timeout)
+
+	if l.cfg.StartTLS {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func value(entry *ldap.Entry, attribute string) *string {
+	if value := entry.GetAttributeValue(attribute); value != "" {
+		return &value
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package directory
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/isaqueveras/powersso/config"
+)
+
+const (
+	serviceDN       = "cn=powersso,dc=powersso,dc=io"
+	servicePassword = "service_password"
+)
+
+// fakeEntry models an entry of the fake directory
+type fakeEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// fakeDirectory is an in-process LDAP server that answers the bind and the search requests
+type fakeDirectory struct {
+	listener net.Listener
+	entries  []fakeEntry
+}
+
+func newFakeDirectory(entries ...fakeEntry) (*fakeDirectory, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	directory := &fakeDirectory{listener: listener, entries: entries}
+	go directory.serve()
+
+	return directory, nil
+}
+
+func (f *fakeDirectory) url() string {
+	return "ldap://" + f.listener.Addr().String()
+}
+
+func (f *fakeDirectory) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeDirectory) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageID, request := packet.Children[0].Value, packet.Children[1]
+
+		var responses []*ber.Packet
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			code := ldap.LDAPResultInvalidCredentials
+			if f.bind(request.Children[1].Data.String(), request.Children[2].Data.String()) {
+				code = ldap.LDAPResultSuccess
+			}
+			responses = append(responses, result(ldap.ApplicationBindResponse, code))
+
+		case ldap.ApplicationSearchRequest:
+			filter, _ := ldap.DecompileFilter(request.Children[6])
+			for _, entry := range f.search(filter) {
+				responses = append(responses, entry)
+			}
+			responses = append(responses, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+
+		default:
+			return
+		}
+
+		for _, response := range responses {
+			message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, ""))
+			message.AppendChild(response)
+			if _, err = conn.Write(message.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeDirectory) bind(dn, password string) bool {
+	if dn == serviceDN {
+		return password == servicePassword
+	}
+
+	for _, entry := range f.entries {
+		if strings.EqualFold(entry.dn, dn) {
+			return password != "" && password == entry.password
+		}
+	}
+
+	return false
+}
+
+// search returns the entries with the email of the filter
+func (f *fakeDirectory) search(filter string) (entries []*ber.Packet) {
+	for _, entry := range f.entries {
+		for _, email := range entry.attributes["mail"] {
+			if !strings.Contains(filter, "(mail="+email+")") {
+				continue
+			}
+
+			packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+			packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, ""))
+
+			attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			for name, values := range entry.attributes {
+				attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+
+				set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+				for _, value := range values {
+					set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+				}
+
+				attribute.AppendChild(set)
+				attributes.AppendChild(attribute)
+			}
+
+			packet.AppendChild(attributes)
+			entries = append(entries, packet)
+		}
+	}
+
+	return
+}
+
+func result(tag ber.Tag, code int) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	return packet
+}
+
+func TestLDAPAuthenticator(t *testing.T) {
+	suite.Run(t, new(ldapSuite))
+}
+
+type ldapSuite struct {
+	directory *fakeDirectory
+	cfg       *config.LDAPConfig
+
+	suite.Suite
+}
+
+func (s *ldapSuite) SetupSuite() {
+	var err error
+	s.directory, err = newFakeDirectory(
+		fakeEntry{
+			dn:       "uid=jane,ou=people,dc=powersso,dc=io",
+			password: "jane_password",
+			attributes: map[string][]string{
+				"mail":      {"Jane@PowerSSO.io"},
+				"givenName": {"Jane"},
+				"sn":        {"Doe"},
+				"memberOf":  {"cn=admins,ou=groups,dc=powersso,dc=io", "cn=developers,ou=groups,dc=powersso,dc=io"},
+			},
+		},
+		fakeEntry{
+			dn:         "uid=john,ou=people,dc=powersso,dc=io",
+			password:   "john_password",
+			attributes: map[string][]string{"mail": {"john@powersso.io"}, "givenName": {"John"}},
+		},
+	)
+	s.Require().NoError(err)
+}
+
+func (s *ldapSuite) TearDownSuite() {
+	s.directory.listener.Close()
+}
+
+func (s *ldapSuite) SetupTest() {
+	s.cfg = &config.LDAPConfig{
+		Enabled:      true,
+		URL:          s.directory.url(),
+		Timeout:      5,
+		BindDN:       serviceDN,
+		BindPassword: servicePassword,
+		BaseDN:       "ou=people,dc=powersso,dc=io",
+		UserFilter:   "(&(objectClass=inetOrgPerson)(mail=%s))",
+		Attributes:   config.LDAPAttributes{Email: "mail", FirstName: "givenName", LastName: "sn", Groups: "memberOf"},
+	}
+}
+
+func (s *ldapSuite) TestShouldAuthenticate() {
+	entry, err := NewLDAPAuthenticator(s.cfg).Authenticate(context.Background(), "Jane@PowerSSO.io", "jane_password")
+	s.Require().NoError(err)
+
+	s.Equal("uid=jane,ou=people,dc=powersso,dc=io", *entry.DN)
+	s.Equal("Jane@PowerSSO.io", *entry.Email)
+	s.Equal("Jane", *entry.FirstName)
+	s.Equal("Doe", *entry.LastName)
+	s.Len(entry.Groups, 2)
+}
+
+func (s *ldapSuite) TestShouldKeepMissingAttributesEmpty() {
+	entry, err := NewLDAPAuthenticator(s.cfg).Authenticate(context.Background(), "john@powersso.io", "john_password")
+	s.Require().NoError(err)
+
+	s.Equal("john@powersso.io", *entry.Email)
+	s.Nil(entry.LastName)
+	s.Empty(entry.Groups)
+}
+
+func (s *ldapSuite) TestShouldNotAuthenticateInvalidCredentials() {
+	authenticator := NewLDAPAuthenticator(s.cfg)
+
+	for email, password := range map[string]string{
+		"Jane@PowerSSO.io":    "wrong_password",
+		"Jane@PowerSSO.io ":   "",
+		"unknown@powersso.io": "jane_password",
+		"*)(mail=*":           "jane_password",
+		"john@powersso.io":    "jane_password",
+	} {
+		_, err := authenticator.Authenticate(context.Background(), email, password)
+		s.Equal(ErrInvalidCredentials, err, email)
+	}
+}
+
+func (s *ldapSuite) TestShouldFailWithWrongServiceAccount() {
+	s.cfg.BindPassword = "wrong_password"
+
+	_, err := NewLDAPAuthenticator(s.cfg).Authenticate(context.Background(), "Jane@PowerSSO.io", "jane_password")
+	s.Error(err)
+	s.NotEqual(ErrInvalidCredentials, err)
+}
+
+func (s *ldapSuite) TestShouldFailWithUnavailableDirectory() {
+	s.cfg.URL = "ldap://127.0.0.1:1"
+
+	_, err := NewLDAPAuthenticator(s.cfg).Authenticate(context.Background(), "Jane@PowerSSO.io", "jane_password")
+	s.Error(err)
+	s.NotEqual(ErrInvalidCredentials, err)
+}
@@ -0,0 +1,22 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrInvalidFilter creates and returns an error when a filter of the audit log has an invalid format
+func ErrInvalidFilter() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invalid_filter"), http.StatusBadRequest)
+}
+
+// ErrInvalidSigningKey creates and returns an error when the key to sign the audit checkpoints is not valid
+func ErrInvalidSigningKey() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invalid_signing_key"), http.StatusInternalServerError)
+}
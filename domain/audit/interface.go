@@ -0,0 +1,19 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import "github.com/isaqueveras/powersso/utils"
+
+// IAudit define an interface for data layer access methods
+type IAudit interface {
+	Add(*Event) error
+	List(params *utils.Params) ([]Event, *bool, error)
+	Last(lock bool) (*Link, error)
+	Chain(afterSeq int64, limit uint64) ([]Event, error)
+	Unchained(limit uint64) ([]Event, error)
+	Link(*Event) error
+	AddCheckpoint(*Checkpoint) error
+	Checkpoints() ([]Checkpoint, error)
+}
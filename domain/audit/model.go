@@ -0,0 +1,400 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Action set data type to the action recorded in the audit log
+type Action string
+
+// Actions recorded in the audit log
+const (
+	ActionCreateAccount       Action = "create_account"
+	ActionLogin               Action = "login"
+	ActionLogout              Action = "logout"
+	ActionChangePassword      Action = "change_password"
+	ActionResetPassword       Action = "reset_password"
+	ActionForcePasswordChange Action = "force_password_change"
+	ActionConfigure2FA        Action = "configure_2fa"
+	ActionUnconfigure2FA      Action = "unconfigure_2fa"
+	ActionDisableUser         Action = "disable_user"
+	ActionEnableUser          Action = "enable_user"
+	ActionUpdateUser          Action = "update_user"
+	ActionUpdateProfile       Action = "update_profile"
+	ActionRequestEmailChange  Action = "request_email_change"
+	ActionChangeEmail         Action = "change_email"
+	ActionUnlockUser          Action = "unlock_user"
+	ActionSecureAccount       Action = "secure_account"
+	ActionEndSession          Action = "end_session"
+	ActionEndSessions         Action = "end_sessions"
+	ActionSetSessionLimit     Action = "set_session_limit"
+	ActionCreateProject       Action = "create_project"
+	ActionExportData          Action = "export_data"
+	ActionRequestDeletion     Action = "request_deletion"
+	ActionCancelDeletion      Action = "cancel_deletion"
+	ActionEraseUser           Action = "erase_user"
+	ActionInviteUser          Action = "invite_user"
+	ActionRevokeInvitation    Action = "revoke_invitation"
+	ActionAcceptInvitation    Action = "accept_invitation"
+	ActionImportUser          Action = "import_user"
+	ActionExportUsers         Action = "export_users"
+	ActionUpdateProject       Action = "update_project"
+	ActionLinkIdentity        Action = "link_identity"
+	ActionUnlinkIdentity      Action = "unlink_identity"
+	ActionCreateRole          Action = "create_role"
+	ActionUpdateRole          Action = "update_role"
+	ActionDeleteRole          Action = "delete_role"
+	ActionAssignRole          Action = "assign_role"
+	ActionUnassignRole        Action = "unassign_role"
+	ActionCreateProjectRole   Action = "create_project_role"
+	ActionUpdateProjectRole   Action = "update_project_role"
+	ActionDeleteProjectRole   Action = "delete_project_role"
+	ActionAddParticipant      Action = "add_participant"
+	ActionRemoveParticipant   Action = "remove_participant"
+	ActionAssignProjectRole   Action = "assign_project_role"
+	ActionUnassignProjectRole Action = "unassign_project_role"
+	ActionSaveNamespace       Action = "save_namespace"
+	ActionDeleteNamespace     Action = "delete_namespace"
+	ActionWriteRelations      Action = "write_relations"
+	ActionCreatePolicy        Action = "create_policy"
+	ActionUpdatePolicy        Action = "update_policy"
+	ActionDeletePolicy        Action = "delete_policy"
+)
+
+// Outcome set data type to the result of the action recorded in the audit log
+type Outcome string
+
+const (
+	// OutcomeSuccess is the outcome of an action that succeeded
+	OutcomeSuccess Outcome = "success"
+	// OutcomeFailure is the outcome of an action that failed
+	OutcomeFailure Outcome = "failure"
+)
+
+// TargetType set data type to the kind of resource affected by the action
+type TargetType string
+
+const (
+	// TargetUser is the target of the actions on users
+	TargetUser TargetType = "user"
+	// TargetProject is the target of the actions on projects
+	TargetProject TargetType = "project"
+	// TargetLevel is the target of the actions on all users of a level
+	TargetLevel TargetType = "level"
+	// TargetInvitation is the target of the actions on the invitations of users
+	TargetInvitation TargetType = "invitation"
+	// TargetRole is the target of the actions on roles
+	TargetRole TargetType = "role"
+	// TargetNamespace is the target of the actions on the namespaces of the relations
+	TargetNamespace TargetType = "namespace"
+	// TargetPolicy is the target of the actions on the conditional access policies
+	TargetPolicy TargetType = "policy"
+)
+
+// Context keys with the data of the request that made the action
+const (
+	contextUserID    string = "UID"
+	contextRequestID string = "RID"
+	contextClientIP  string = "CLIENT_IP"
+	contextUserAgent string = "USER_AGENT"
+)
+
+// Event models an entry of the audit log
+type Event struct {
+	ID         *uuid.UUID  `json:"id" sql:"id"`
+	Action     *Action     `json:"action" sql:"action"`
+	Outcome    *Outcome    `json:"outcome" sql:"outcome"`
+	ActorID    *uuid.UUID  `json:"actor_id,omitempty" sql:"actor_id"`
+	TargetType *TargetType `json:"target_type,omitempty" sql:"target_type"`
+	TargetID   *string     `json:"target_id,omitempty" sql:"target_id"`
+	Reason     *string     `json:"reason,omitempty" sql:"reason"`
+	IP         *string     `json:"ip,omitempty" sql:"ip"`
+	UserAgent  *string     `json:"user_agent,omitempty" sql:"user_agent"`
+	RequestID  *string     `json:"request_id,omitempty" sql:"request_id"`
+	CreatedAt  *time.Time  `json:"created_at" sql:"created_at"`
+	Seq        *int64      `json:"seq,omitempty" sql:"seq"`
+	PrevHash   *string     `json:"prev_hash,omitempty" sql:"prev_hash"`
+	Hash       *string     `json:"hash,omitempty" sql:"hash"`
+}
+
+// NewEvent creates a successful event of the action with the data of the request in the context
+func NewEvent(ctx context.Context, action Action) *Event {
+	event := &Event{Action: &action, Outcome: utils.Pointer(OutcomeSuccess)}
+
+	if value, ok := ctx.Value(contextUserID).(string); ok {
+		if actorID, err := uuid.Parse(value); err == nil {
+			event.ActorID = &actorID
+		}
+	}
+
+	for key, field := range map[string]**string{
+		contextRequestID: &event.RequestID,
+		contextClientIP:  &event.IP,
+		contextUserAgent: &event.UserAgent,
+	} {
+		if value, ok := ctx.Value(key).(string); ok && value != "" {
+			*field = utils.Pointer(value)
+		}
+	}
+
+	return event
+}
+
+// Target sets the resource affected by the action
+func (e *Event) Target(kind TargetType, id *uuid.UUID) *Event {
+	e.TargetType = &kind
+	if id != nil {
+		e.TargetID = utils.Pointer(id.String())
+	}
+	return e
+}
+
+// TargetKey sets the resource affected by the action when it is not identified by an id
+func (e *Event) TargetKey(kind TargetType, key string) *Event {
+	e.TargetType, e.TargetID = &kind, &key
+	return e
+}
+
+// Actor sets who made the action when it is not the user of the session, like on login
+func (e *Event) Actor(actorID *uuid.UUID) *Event {
+	if e.ActorID == nil {
+		e.ActorID = actorID
+	}
+	return e
+}
+
+// Fail marks the action as failed with the reason of the failure
+func (e *Event) Fail(reason error) *Event {
+	e.Outcome = utils.Pointer(OutcomeFailure)
+	if reason != nil {
+		e.Reason = utils.Pointer(reason.Error())
+	}
+	return e
+}
+
+// Events models a page of the audit log
+type Events struct {
+	Events []Event `json:"events"`
+	Next   *bool   `json:"next"`
+}
+
+// Filters accepted in the query of the audit log
+const (
+	FilterActorID   string = "actor_id"
+	FilterTargetID  string = "target_id"
+	FilterAction    string = "action"
+	FilterOutcome   string = "outcome"
+	FilterRequestID string = "request_id"
+	FilterFrom      string = "from"
+	FilterTo        string = "to"
+)
+
+// ValidateParams checks the format of the filters of the audit log
+func ValidateParams(p *utils.Params) error {
+	for _, value := range p.Filters[FilterActorID] {
+		if _, err := uuid.Parse(value); err != nil {
+			return ErrInvalidFilter()
+		}
+	}
+
+	for _, filter := range []string{FilterFrom, FilterTo} {
+		for _, value := range p.Filters[filter] {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return ErrInvalidFilter()
+			}
+		}
+	}
+
+	return nil
+}
+
+// Link models the position of an event in the hash chain of the audit log
+type Link struct {
+	Seq  int64
+	Hash string
+}
+
+// Chain links the event to the previous one, setting its position and its hash. The first event of the
+// log has no previous link. The events are chained as read from the database, so that the hash is computed
+// with the values kept by it
+func (e *Event) Chain(prev *Link) *Link {
+	e.Seq, e.PrevHash = utils.Pointer(int64(1)), utils.Pointer("")
+	if prev != nil {
+		e.Seq, e.PrevHash = utils.Pointer(prev.Seq+1), utils.Pointer(prev.Hash)
+	}
+
+	e.Hash = utils.Pointer(e.ComputeHash())
+	return &Link{Seq: *e.Seq, Hash: *e.Hash}
+}
+
+// ComputeHash returns the hash of the content of the event and the hash of the previous event
+func (e *Event) ComputeHash() string {
+	var createdAt string
+	if e.CreatedAt != nil {
+		createdAt = e.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	content, _ := json.Marshal([]interface{}{
+		e.Seq, e.PrevHash, e.Action, e.Outcome, e.ActorID, e.TargetType, e.TargetID,
+		e.Reason, e.IP, e.UserAgent, e.RequestID, createdAt,
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Checkpoint models a point of the hash chain, signed with the key of the server when configured
+type Checkpoint struct {
+	ID        *uuid.UUID `json:"id" sql:"id"`
+	Seq       *int64     `json:"seq" sql:"seq"`
+	Hash      *string    `json:"hash" sql:"hash"`
+	Signature *string    `json:"signature,omitempty" sql:"signature"`
+	CreatedAt *time.Time `json:"created_at" sql:"created_at"`
+}
+
+// NewCheckpoint creates a checkpoint of the link, signed when a key is informed
+func NewCheckpoint(link *Link, key ed25519.PrivateKey) *Checkpoint {
+	checkpoint := &Checkpoint{Seq: &link.Seq, Hash: &link.Hash}
+	if key != nil {
+		checkpoint.Signature = utils.Pointer(base64.StdEncoding.EncodeToString(ed25519.Sign(key, checkpoint.message())))
+	}
+	return checkpoint
+}
+
+// VerifySignature checks the signature of the checkpoint with the public key of the server
+func (c *Checkpoint) VerifySignature(key ed25519.PublicKey) bool {
+	if c.Signature == nil {
+		return key == nil
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(*c.Signature)
+	if err != nil || key == nil {
+		return false
+	}
+
+	return ed25519.Verify(key, c.message(), signature)
+}
+
+// message returns the content signed in the checkpoint
+func (c *Checkpoint) message() []byte {
+	return []byte(fmt.Sprintf("%d:%s", *c.Seq, *c.Hash))
+}
+
+// ParseSigningKey decodes the base64 seed of the key used to sign the checkpoints,
+// returning nil when no key is configured
+func ParseSigningKey(seed string) (ed25519.PrivateKey, error) {
+	if seed == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil || len(raw) != ed25519.SeedSize {
+		return nil, ErrInvalidSigningKey()
+	}
+
+	return ed25519.NewKeyFromSeed(raw), nil
+}
+
+// Reasons for a tampered record of the audit log
+const (
+	ReasonHashMismatch        string = "the content of the event does not match its hash"
+	ReasonBrokenLink          string = "the previous hash does not match the hash of the previous event"
+	ReasonMissingEvents       string = "events before this one were removed"
+	ReasonCheckpointMismatch  string = "the checkpoint does not match the hash of the event"
+	ReasonCheckpointSignature string = "the signature of the checkpoint is not valid"
+	ReasonTruncated           string = "events after the last event were removed"
+)
+
+// Verification models the result of the verification of the audit log
+type Verification struct {
+	Valid       bool   `json:"valid"`
+	Events      int64  `json:"events"`
+	Checkpoints int64  `json:"checkpoints"`
+	Reason      string `json:"reason,omitempty"`
+	// FirstTampered is the first event that was changed or follows removed events
+	FirstTampered *Event `json:"first_tampered,omitempty"`
+	// Checkpoint is the checkpoint that failed the verification
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+}
+
+// Verifier walks the hash chain of the audit log in order, stopping at the first tampered record
+type Verifier struct {
+	prev        *Link
+	key         ed25519.PublicKey
+	checkpoints map[int64][]*Checkpoint
+	result      Verification
+}
+
+// NewVerifier creates a verifier of the events against the checkpoints and the public key of the server
+func NewVerifier(checkpoints []Checkpoint, key ed25519.PublicKey) *Verifier {
+	v := &Verifier{key: key, checkpoints: map[int64][]*Checkpoint{}, result: Verification{Valid: true}}
+	for i := range checkpoints {
+		v.checkpoints[*checkpoints[i].Seq] = append(v.checkpoints[*checkpoints[i].Seq], &checkpoints[i])
+	}
+	return v
+}
+
+// Check verifies the next event of the chain, returning false when it was tampered
+func (v *Verifier) Check(event *Event) bool {
+	expectedSeq, expectedPrev := int64(1), ""
+	if v.prev != nil {
+		expectedSeq, expectedPrev = v.prev.Seq+1, v.prev.Hash
+	}
+
+	switch {
+	case event.Seq == nil || *event.Seq != expectedSeq:
+		return v.fail(event, nil, ReasonMissingEvents)
+	case event.PrevHash == nil || *event.PrevHash != expectedPrev:
+		return v.fail(event, nil, ReasonBrokenLink)
+	case event.Hash == nil || *event.Hash != event.ComputeHash():
+		return v.fail(event, nil, ReasonHashMismatch)
+	}
+
+	for _, checkpoint := range v.checkpoints[*event.Seq] {
+		if !checkpoint.VerifySignature(v.key) {
+			return v.fail(event, checkpoint, ReasonCheckpointSignature)
+		}
+		if *checkpoint.Hash != *event.Hash {
+			return v.fail(event, checkpoint, ReasonCheckpointMismatch)
+		}
+		v.result.Checkpoints++
+	}
+
+	delete(v.checkpoints, *event.Seq)
+	v.prev = &Link{Seq: *event.Seq, Hash: *event.Hash}
+	v.result.Events++
+	return true
+}
+
+// Result returns the verification, failing when there are checkpoints after the last event
+func (v *Verifier) Result() *Verification {
+	if v.result.Valid {
+		for _, checkpoints := range v.checkpoints {
+			v.fail(nil, checkpoints[0], ReasonTruncated)
+			break
+		}
+	}
+	return &v.result
+}
+
+// fail records the first tampered record of the chain
+func (v *Verifier) fail(event *Event, checkpoint *Checkpoint, reason string) bool {
+	v.result.Valid, v.result.Reason = false, reason
+	v.result.FirstTampered, v.result.Checkpoint = event, checkpoint
+	return false
+}
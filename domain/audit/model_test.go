@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestNewEvent(t *testing.T) {
+	t.Run("RequestMetadata", func(t *testing.T) {
+		actorID, targetID := uuid.New(), uuid.New()
+
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Set(contextUserID, actorID.String())
+		ctx.Set(contextRequestID, "request")
+		ctx.Set(contextClientIP, "10.0.0.1")
+		ctx.Set(contextUserAgent, "firefox")
+
+		event := NewEvent(ctx, ActionDisableUser).Target(TargetUser, &targetID)
+		if *event.ActorID != actorID || *event.TargetID != targetID.String() || *event.TargetType != TargetUser {
+			t.Error("expected the actor and the target of the event")
+		}
+
+		if *event.RequestID != "request" || *event.IP != "10.0.0.1" || *event.UserAgent != "firefox" {
+			t.Error("expected the metadata of the request in the event")
+		}
+
+		if *event.Outcome != OutcomeSuccess {
+			t.Errorf("expected success outcome, got %s", *event.Outcome)
+		}
+	})
+
+	t.Run("WithoutRequest", func(t *testing.T) {
+		userID := uuid.New()
+
+		event := NewEvent(context.Background(), ActionLogin).Actor(&userID).Fail(errors.New("invalid password"))
+		if *event.ActorID != userID || event.IP != nil || event.RequestID != nil {
+			t.Error("expected only the informed actor in the event")
+		}
+
+		if *event.Outcome != OutcomeFailure || *event.Reason != "invalid password" {
+			t.Error("expected the failure and its reason in the event")
+		}
+	})
+}
+
+func TestValidateParams(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filter, value string
+		valid         bool
+	}{
+		"ValidActor":   {filter: FilterActorID, value: uuid.New().String(), valid: true},
+		"InvalidActor": {filter: FilterActorID, value: "admin", valid: false},
+		"ValidFrom":    {filter: FilterFrom, value: "2023-01-02T15:04:05Z", valid: true},
+		"InvalidTo":    {filter: FilterTo, value: "yesterday", valid: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			params := utils.NewParams()
+			params.AddFilter(tc.filter, tc.value)
+
+			if err := ValidateParams(&params); (err == nil) != tc.valid {
+				t.Errorf("expected valid %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	publicKey := key.Public().(ed25519.PublicKey)
+
+	chain := func() (events []Event) {
+		var prev *Link
+		for _, action := range []Action{ActionLogin, ActionChangePassword, ActionLogout} {
+			event := NewEvent(context.Background(), action)
+			prev = event.Chain(prev)
+			events = append(events, *event)
+		}
+		return
+	}
+
+	verify := func(events []Event, checkpoints []Checkpoint) *Verification {
+		verifier := NewVerifier(checkpoints, publicKey)
+		for i := range events {
+			if !verifier.Check(&events[i]) {
+				break
+			}
+		}
+		return verifier.Result()
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		events := chain()
+		checkpoint := NewCheckpoint(&Link{Seq: *events[2].Seq, Hash: *events[2].Hash}, key)
+
+		if res := verify(events, []Checkpoint{*checkpoint}); !res.Valid || res.Events != 3 || res.Checkpoints != 1 {
+			t.Errorf("expected a valid chain, got %+v", res)
+		}
+	})
+
+	t.Run("ChangedContent", func(t *testing.T) {
+		events := chain()
+		events[1].Action = utils.Pointer(ActionDisableUser)
+
+		if res := verify(events, nil); res.Valid || *res.FirstTampered.Seq != 2 || res.Reason != ReasonHashMismatch {
+			t.Errorf("expected the second event to be tampered, got %+v", res)
+		}
+	})
+
+	t.Run("RemovedEvent", func(t *testing.T) {
+		events := chain()
+		events = append(events[:1], events[2:]...)
+
+		if res := verify(events, nil); res.Valid || *res.FirstTampered.Seq != 3 || res.Reason != ReasonMissingEvents {
+			t.Errorf("expected the event after the removed one to be reported, got %+v", res)
+		}
+	})
+
+	t.Run("RehashedEvent", func(t *testing.T) {
+		events := chain()
+		events[1].Action = utils.Pointer(ActionDisableUser)
+		events[1].Hash = utils.Pointer(events[1].ComputeHash())
+
+		if res := verify(events, nil); res.Valid || *res.FirstTampered.Seq != 3 || res.Reason != ReasonBrokenLink {
+			t.Errorf("expected the link of the next event to be broken, got %+v", res)
+		}
+	})
+
+	t.Run("TruncatedChain", func(t *testing.T) {
+		events := chain()
+		checkpoint := NewCheckpoint(&Link{Seq: *events[2].Seq, Hash: *events[2].Hash}, key)
+
+		if res := verify(events[:2], []Checkpoint{*checkpoint}); res.Valid || res.Reason != ReasonTruncated {
+			t.Errorf("expected the removal of the last event to be reported, got %+v", res)
+		}
+	})
+
+	t.Run("ForgedCheckpoint", func(t *testing.T) {
+		events := chain()
+		checkpoint := NewCheckpoint(&Link{Seq: *events[2].Seq, Hash: *events[2].Hash}, nil)
+
+		if res := verify(events, []Checkpoint{*checkpoint}); res.Valid || res.Reason != ReasonCheckpointSignature {
+			t.Errorf("expected the unsigned checkpoint to be reported, got %+v", res)
+		}
+	})
+}
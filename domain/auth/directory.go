@@ -0,0 +1,124 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// directoryPasswordLength is the size of the local password of the accounts created by the directory,
+// which is never used since these users are always authenticated by the directory
+const directoryPasswordLength = 16
+
+// DirectoryEntry models the entry of a user in the LDAP directory
+type DirectoryEntry struct {
+	DN        *string
+	Email     *string
+	FirstName *string
+	LastName  *string
+	Groups    []string
+}
+
+// DirectoryAccount models the data of the account of a user authenticated by the directory
+type DirectoryAccount struct {
+	DN        *string
+	Email     *string
+	FirstName *string
+	LastName  *string
+	Level     *Level
+	Projects  []uuid.UUID
+}
+
+// Account maps the entry to the account of the user. The level and the projects come from the groups of
+// the configuration, and the names are cut to the size of the names of the accounts
+func (e *DirectoryEntry) Account(cfg *config.LDAPConfig) *DirectoryAccount {
+	account := &DirectoryAccount{
+		DN:        e.DN,
+		FirstName: truncate(e.FirstName, maxFirstNameLength),
+		LastName:  truncate(e.LastName, maxLastNameLength),
+		Level:     utils.Pointer(UserLevel),
+	}
+
+	if e.Email != nil {
+		account.Email = utils.Pointer(strings.ToLower(strings.TrimSpace(*e.Email)))
+	}
+
+	groups := make(map[string]bool, len(e.Groups))
+	for _, group := range e.Groups {
+		groups[strings.ToLower(group)] = true
+	}
+
+	var (
+		leveled  bool
+		projects = make(map[uuid.UUID]bool)
+	)
+
+	for _, group := range cfg.Groups {
+		if !groups[strings.ToLower(group.DN)] {
+			continue
+		}
+
+		// the integration level is only given by the administrators, never by the directory
+		if level := Level(group.Level); !leveled && (level == UserLevel || level == AdminLevel) {
+			account.Level, leveled = &level, true
+		}
+
+		for _, project := range group.Projects {
+			if projectID, err := uuid.Parse(project); err == nil && !projects[projectID] {
+				projects[projectID] = true
+				account.Projects = append(account.Projects, projectID)
+			}
+		}
+	}
+
+	return account
+}
+
+// CreateAccount returns the data to register the account, with a random local password
+func (a *DirectoryAccount) CreateAccount() *CreateAccount {
+	return &CreateAccount{
+		FirstName:   a.FirstName,
+		LastName:    a.LastName,
+		Email:       a.Email,
+		Password:    utils.Pointer(utils.RandomString(directoryPasswordLength)),
+		Level:       a.Level,
+		DirectoryDN: a.DN,
+	}
+}
+
+// NewExternalAccount returns the data to register the account of a user authenticated by an external
+// provider, with the names cut to the size of the names of the accounts and a random local password
+func NewExternalAccount(email, firstName, lastName *string) *CreateAccount {
+	account := &CreateAccount{
+		FirstName: truncate(firstName, maxFirstNameLength),
+		LastName:  truncate(lastName, maxLastNameLength),
+		Password:  utils.Pointer(utils.RandomString(directoryPasswordLength)),
+		Level:     utils.Pointer(UserLevel),
+	}
+
+	if email != nil {
+		account.Email = utils.Pointer(strings.ToLower(strings.TrimSpace(*email)))
+	}
+
+	return account
+}
+
+func truncate(value *string, length int) *string {
+	if value == nil {
+		return nil
+	}
+
+	runes := []rune(strings.TrimSpace(*value))
+	if len(runes) > length {
+		runes = runes[:length]
+	}
+
+	return utils.Pointer(strings.TrimSpace(string(runes)))
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestDirectoryEntryAccount(t *testing.T) {
+	var (
+		engineering, support = uuid.New(), uuid.New()
+		cfg                  = &config.LDAPConfig{Groups: []config.LDAPGroupConfig{
+			{DN: "cn=integrations,ou=groups,dc=powersso,dc=io", Level: string(IntegrationLevel)},
+			{DN: "cn=developers,ou=groups,dc=powersso,dc=io", Projects: []string{engineering.String()}},
+			{DN: "cn=Admins,ou=groups,dc=powersso,dc=io", Level: string(AdminLevel), Projects: []string{engineering.String(), support.String()}},
+			{DN: "cn=users,ou=groups,dc=powersso,dc=io", Level: string(UserLevel)},
+		}}
+	)
+
+	t.Run("GroupsMapped", func(t *testing.T) {
+		entry := &DirectoryEntry{
+			DN:        utils.Pointer("uid=jane,ou=people,dc=powersso,dc=io"),
+			Email:     utils.Pointer(" Jane@PowerSSO.io "),
+			FirstName: utils.Pointer("Jane"),
+			LastName:  utils.Pointer("Doe"),
+			Groups: []string{
+				"cn=integrations,ou=groups,dc=powersso,dc=io",
+				"cn=developers,ou=groups,dc=powersso,dc=io",
+				"cn=admins,ou=groups,dc=powersso,dc=io",
+				"cn=users,ou=groups,dc=powersso,dc=io",
+			},
+		}
+
+		account := entry.Account(cfg)
+		if *account.Level != AdminLevel {
+			t.Errorf("expected the level of the first group with a valid level, got %s", *account.Level)
+		}
+
+		if *account.Email != "jane@powersso.io" {
+			t.Errorf("expected the email in lower case, got %s", *account.Email)
+		}
+
+		if len(account.Projects) != 2 || account.Projects[0] != engineering || account.Projects[1] != support {
+			t.Errorf("expected the projects of the groups without repetition, got %v", account.Projects)
+		}
+	})
+
+	t.Run("WithoutGroups", func(t *testing.T) {
+		entry := &DirectoryEntry{
+			FirstName: utils.Pointer("Maria Eduarda Gonçalves"),
+			LastName:  utils.Pointer("Doe"),
+		}
+
+		account := entry.Account(cfg)
+		if *account.Level != UserLevel || len(account.Projects) != 0 {
+			t.Errorf("expected the user level without projects, got %s %v", *account.Level, account.Projects)
+		}
+
+		if *account.FirstName != "Maria Eduarda Gonçal" {
+			t.Errorf("expected the first name cut to the size of the accounts, got %s", *account.FirstName)
+		}
+	})
+
+	t.Run("CreateAccount", func(t *testing.T) {
+		entry := &DirectoryEntry{DN: utils.Pointer("uid=jane,ou=people,dc=powersso,dc=io"), Email: utils.Pointer("jane@powersso.io"),
+			FirstName: utils.Pointer("Jane"), LastName: utils.Pointer("Doe")}
+
+		in := entry.Account(cfg).CreateAccount()
+		if err := in.Validate(); err != nil {
+			t.Errorf("expected a valid account, got %v", err)
+		}
+
+		if *in.DirectoryDN != *entry.DN || in.Password == nil || *in.Level != UserLevel {
+			t.Errorf("unexpected account %+v", in)
+		}
+	})
+}
+
+func TestUserFederatedPasswordChange(t *testing.T) {
+	user := &User{MustChangePassword: utils.Pointer(true), DirectoryDN: utils.Pointer("uid=jane,ou=people,dc=powersso,dc=io")}
+	if user.RequiresPasswordChange(&config.PasswordPolicyConfig{}) {
+		t.Error("expected the users of the directory to never change the password locally")
+	}
+}
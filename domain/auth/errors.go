@@ -36,6 +36,11 @@ func ErrUserBlockedTemporarily() *oops.Error {
 	return oops.NewError(i18n.Value("errors.handling.err_user_blocked_temporarily"), http.StatusForbidden)
 }
 
+// ErrUserLocked creates and returns an error when the user is locked until an administrator unlocks it
+func ErrUserLocked() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_user_locked"), http.StatusForbidden)
+}
+
 // ErrOTPTokenInvalid creates and returns an error when validate token OTP
 func ErrOTPTokenInvalid() *oops.Error {
 	return oops.NewError(i18n.Value("errors.handling.err_a2f_invalid"), http.StatusForbidden)
@@ -45,3 +50,100 @@ func ErrOTPTokenInvalid() *oops.Error {
 func ErrAuthentication2factorNotConfigured() *oops.Error {
 	return oops.NewError(i18n.Value("errors.handling.err_otp_token_2_factor_authentication_not_configured"), http.StatusForbidden)
 }
+
+// ErrPasswordChangeNotRequired creates and returns an error when the password change token is used after the password was changed
+func ErrPasswordChangeNotRequired() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_password_change_not_required"), http.StatusUnauthorized)
+}
+
+// ErrPasswordMustBeDifferent creates and returns an error when the new password is the same as the current one
+func ErrPasswordMustBeDifferent() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_password_must_be_different"), http.StatusBadRequest)
+}
+
+// ErrLevelNotAllowed creates and returns an error when the user is not allowed to give the level to another user
+func ErrLevelNotAllowed() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_level_not_allowed"), http.StatusForbidden)
+}
+
+// ErrLevelIsNotValid creates and returns an error when the user level is not valid
+func ErrLevelIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_level_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrSessionNotFound creates and returns an error when the session does not exists or has already ended
+func ErrSessionNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_session_not_found"), http.StatusNotFound)
+}
+
+// ErrSessionLimitReached creates and returns an error when the user has reached the maximum number of sessions
+func ErrSessionLimitReached() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_session_limit_reached"), http.StatusConflict)
+}
+
+// ErrSessionChoiceRequired creates and returns an error when the user must choose a session to end
+func ErrSessionChoiceRequired() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_session_choice_required"), http.StatusConflict)
+}
+
+// ErrConfirmationRequired creates and returns an error when an action is not confirmed by the password
+// or by signing in again with a provider
+func ErrConfirmationRequired() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_confirmation_required"), http.StatusBadRequest)
+}
+
+// ErrTokenInvalid creates and returns an error when the token was used, has expired or does not exist
+func ErrTokenInvalid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_token_invalid"), http.StatusBadRequest)
+}
+
+// ErrInvalidFilter creates and returns an error when a filter of the query has an invalid format
+func ErrInvalidFilter() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invalid_filter"), http.StatusBadRequest)
+}
+
+// ErrNothingToUpdate creates and returns an error when the request does not change any data
+func ErrNothingToUpdate() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_nothing_to_update"), http.StatusBadRequest)
+}
+
+// ErrLocaleNotSupported creates and returns an error when there are no translations for the locale
+func ErrLocaleNotSupported() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_locale_not_supported"), http.StatusBadRequest)
+}
+
+// ErrEmailChangeUnavailable creates and returns an error when the new email cannot be verified
+// because the server does not send emails
+func ErrEmailChangeUnavailable() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_email_change_unavailable"), http.StatusServiceUnavailable)
+}
+
+// ErrFirstNameIsNotValid creates and returns an error when the first name is empty or too long
+func ErrFirstNameIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_first_name_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrLastNameIsNotValid creates and returns an error when the last name is empty or too long
+func ErrLastNameIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_last_name_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrEmailIsNotValid creates and returns an error when the email is not a valid address
+func ErrEmailIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_email_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrPasswordIsNotValid creates and returns an error when the password is too short
+func ErrPasswordIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_password_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrPasswordHashIsNotValid creates and returns an error when the password hash is not a bcrypt hash
+func ErrPasswordHashIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_password_hash_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrDirectoryUnavailable creates and returns an error when the LDAP directory cannot authenticate the users
+func ErrDirectoryUnavailable() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_directory_unavailable"), http.StatusServiceUnavailable)
+}
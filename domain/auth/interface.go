@@ -4,7 +4,13 @@
 
 package auth
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/utils"
+)
 
 // IAuthService defines an interface for service methods to access the data layer
 type IAuthService interface {
@@ -15,15 +21,33 @@ type IAuthService interface {
 // IAuth define an interface for data layer access methods
 type IAuth interface {
 	CreateAccount(*CreateAccount) (userID *uuid.UUID, err error)
-	AddAttempts(userID *uuid.UUID) error
+	AddAttempts(userID *uuid.UUID) (attempts *int64, err error)
 	LoginSteps(email *string) (*Steps, error)
 }
 
+// ILockout define an interface for data layer access methods
+type ILockout interface {
+	Get(userID *uuid.UUID) (*Lockout, error)
+	Events(userID *uuid.UUID, params *utils.Params) ([]LockoutEntry, *bool, error)
+	Lock(*Lock) error
+	Reset(userID *uuid.UUID) error
+	AddEvent(*LockoutEntry) error
+	ClearStale() (int64, error)
+}
+
 // ISession define an interface for data layer access methods
 type ISession interface {
-	Create(userID *uuid.UUID, clientIP, userAgent *string) (*uuid.UUID, error)
+	Create(userID *uuid.UUID, level *Level, method *AuthMethod, clientIP, userAgent *string) (*uuid.UUID, *time.Time, error)
 	Delete(ids ...*uuid.UUID) error
 	Get(userID *uuid.UUID) ([]*uuid.UUID, error)
+	DeleteByLevel(level *Level) error
+	List(userID *uuid.UUID) ([]*ActiveSession, error)
+	Revoke(userID, sessionID *uuid.UUID) error
+	RevokeAll(userID *uuid.UUID, except *uuid.UUID) error
+	Touch(sessionID *uuid.UUID, level *Level) (bool, error)
+	Active(sessionID *uuid.UUID) (bool, error)
+	DeleteExpired() (int64, error)
+	Recent(userID *uuid.UUID, days int64) ([]*ActiveSession, error)
 }
 
 // IFlag define an interface for data layer access methods
@@ -44,4 +68,29 @@ type IUser interface {
 	ChangePassword(*ChangePassword) error
 	AccountExists(email *string) error
 	DisableUser(userUUID *uuid.UUID) error
+	ForcePasswordChange(userID *uuid.UUID) error
+	ForcePasswordChangeByLevel(level *Level) error
+	SetMaxSessions(userID *uuid.UUID, maxSessions *int64) error
+	List(params *utils.Params) ([]UserSummary, *bool, error)
+	Summary(userID *uuid.UUID) (*UserSummary, error)
+	Update(userID *uuid.UUID, in *UpdateUser) error
+	EnableUser(userID *uuid.UUID) error
+	Profile(userID *uuid.UUID) (*Profile, error)
+	UpdateProfile(userID *uuid.UUID, in *UpdateProfile) error
+	ChangeEmail(userID *uuid.UUID, email *string) error
+}
+
+// IDirectory define an interface for data layer access methods of the users of the LDAP directory
+type IDirectory interface {
+	Sync(userID *uuid.UUID, in *DirectoryAccount) error
+	JoinProjects(userID *uuid.UUID, projects []uuid.UUID) ([]uuid.UUID, error)
+}
+
+// IToken define an interface for data layer access methods
+type IToken interface {
+	Create(userID *uuid.UUID, kind TokenKind, duration int64) (*uuid.UUID, error)
+	Use(tokenID *uuid.UUID, kind TokenKind) (userID *uuid.UUID, err error)
+	CreateWithData(userID *uuid.UUID, kind TokenKind, duration int64, data *string) (*uuid.UUID, error)
+	UseWithData(tokenID *uuid.UUID, kind TokenKind) (userID *uuid.UUID, data *string, err error)
+	DeleteExpired() (int64, error)
 }
@@ -5,11 +5,18 @@
 package auth
 
 import (
+	"math"
+	"net"
+	"net/mail"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/i18n"
 	"github.com/isaqueveras/powersso/utils"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -38,6 +45,17 @@ const (
 	IntegrationLevel Level = "integration"
 )
 
+// IsValid checks if the level is one of the known levels
+func (l Level) IsValid() bool {
+	return l == UserLevel || l == AdminLevel || l == IntegrationLevel
+}
+
+// IsPrivileged returns if the level gives more access than the level of the users, so that it can
+// only be given by the users allowed to manage the roles
+func (l Level) IsPrivileged() bool {
+	return l != UserLevel
+}
+
 const (
 	// CostHashPasswordProduction is the cost of hashing password in production
 	CostHashPasswordProduction int = 14
@@ -53,12 +71,92 @@ type CreateAccount struct {
 	Password  *string `sql:"password" json:"password"`
 	Key       *string `sql:"key" json:"-"`
 	Level     *Level  `sql:"level" json:"-"`
+	// CreatedBy is the administrator that invited the user, empty when users register themselves
+	CreatedBy *uuid.UUID `sql:"created_by" json:"-"`
+	// DirectoryDN is the entry of the users created by the LDAP directory, who are always authenticated by it
+	DirectoryDN *string `sql:"ldap_dn" json:"-"`
+
+	// hashed is true when the password is a hash generated by another system
+	hashed bool
+}
+
+// Limits of the data of an account
+const (
+	maxFirstNameLength int = 20
+	maxLastNameLength  int = 32
+	maxEmailLength     int = 60
+	minPasswordLength  int = 6
+)
+
+// Validate checks the data to create an account
+func (rr *CreateAccount) Validate() error {
+	if err := rr.ValidateProfile(); err != nil {
+		return err
+	}
+
+	if !rr.hashed && (rr.Password == nil || utf8.RuneCountInString(strings.TrimSpace(*rr.Password)) < minPasswordLength) {
+		return ErrPasswordIsNotValid()
+	}
+
+	return nil
+}
+
+// ValidateProfile checks the name, the email and the level of the account, without the password
+func (rr *CreateAccount) ValidateProfile() error {
+	if !validLength(rr.FirstName, maxFirstNameLength) {
+		return ErrFirstNameIsNotValid()
+	}
+
+	if !validLength(rr.LastName, maxLastNameLength) {
+		return ErrLastNameIsNotValid()
+	}
+
+	if rr.Email == nil || !IsValidEmail(*rr.Email) {
+		return ErrEmailIsNotValid()
+	}
+
+	if rr.Level != nil && !rr.Level.IsValid() {
+		return ErrLevelIsNotValid()
+	}
+
+	return nil
+}
+
+// validLength checks that the value is filled and has at most max characters
+func validLength(value *string, max int) bool {
+	if value == nil {
+		return false
+	}
+	length := utf8.RuneCountInString(strings.TrimSpace(*value))
+	return length > 0 && length <= max
+}
+
+// IsValidEmail checks if the value is a single email address, without the name
+func IsValidEmail(value string) bool {
+	value = strings.TrimSpace(value)
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value && len(value) <= maxEmailLength
+}
+
+// UsePasswordHash sets a bcrypt hash generated by another system as the password, so that the users
+// keep their passwords. The key is empty because the hash was not generated with it
+func (rr *CreateAccount) UsePasswordHash(hash string) error {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return ErrPasswordHashIsNotValid()
+	}
+
+	rr.Password, rr.Key, rr.hashed = &hash, utils.Pointer(""), true
+	return nil
 }
 
 // Prepare prepare data for registration
 func (rr *CreateAccount) Prepare() (err error) {
 	rr.Email = utils.Pointer(strings.ToLower(strings.TrimSpace(*rr.Email)))
 
+	if rr.hashed {
+		return
+	}
+
 	if err = rr.GeneratePassword(); err != nil {
 		return err
 	}
@@ -97,6 +195,43 @@ func (rr *CreateAccount) SanitizePassword() {
 	rr.Password = nil
 }
 
+// TokenKind set data type to the kind of single-use token sent to the user
+type TokenKind string
+
+const (
+	// ActivateAccountToken is the token to activate the user account
+	ActivateAccountToken TokenKind = "activate_account"
+	// ResetPasswordToken is the token to reset the user password
+	ResetPasswordToken TokenKind = "reset_password"
+	// SecureAccountToken is the token sent in the new device notification to end all sessions of the user
+	SecureAccountToken TokenKind = "secure_account"
+	// ChangeEmailToken is the token sent to the new email of the user to confirm the change
+	ChangeEmailToken TokenKind = "change_email"
+	// CancelDeletionToken is the token sent to the user to cancel the deletion of the account during the grace period
+	CancelDeletionToken TokenKind = "cancel_deletion"
+	// ReauthenticationToken is the token of a user that signed in again with a provider to confirm an action
+	ReauthenticationToken TokenKind = "reauthentication"
+)
+
+// SecureAccount models the data to secure an account after a login that was not made by the user
+type SecureAccount struct {
+	Token     *uuid.UUID `json:"token" binding:"required"`
+	ClientIP  *string    `json:"-"`
+	UserAgent *string    `json:"-"`
+}
+
+// ResetPassword models the data to reset the password with the token sent by email
+type ResetPassword struct {
+	Token           *uuid.UUID `json:"token" binding:"required"`
+	Password        *string    `json:"password" binding:"required"`
+	ConfirmPassword *string    `json:"confirm_password" binding:"required"`
+}
+
+// ValidatePassword validate passwords for reset password
+func (r *ResetPassword) ValidatePassword() bool {
+	return strings.TrimSpace(*r.Password) == strings.TrimSpace(*r.ConfirmPassword)
+}
+
 // ActivateAccount model the data to activate user account
 type ActivateAccount struct {
 	ID        *uuid.UUID `sql:"id"`
@@ -135,6 +270,19 @@ type User struct {
 	CreatedBy *uuid.UUID
 	CreatedAt *time.Time
 	LastLogin *time.Time
+
+	PasswordChangedAt  *time.Time
+	MustChangePassword *bool
+
+	Attempts          *int64
+	LastFailure       *time.Time
+	LockedUntil       *time.Time
+	LockedPermanently *bool
+
+	MaxSessions *int64
+
+	// DirectoryDN is the entry of the user in the LDAP directory, which authenticates the user
+	DirectoryDN *string
 }
 
 // HasFlag return 'true' if has flag
@@ -152,6 +300,11 @@ func (u *User) IsBlocked() bool {
 	return u.Blocked != nil && *u.Blocked
 }
 
+// IsLockedPermanently check if the user has the account blocked until an administrator unlocks it
+func (u *User) IsLockedPermanently() bool {
+	return u.LockedPermanently != nil && *u.LockedPermanently
+}
+
 // OTPConfigured checks if the user has the OTP token configured
 func (u *User) OTPConfigured() bool {
 	enabled := u.Flag != nil && *u.Flag&FlagOTPEnable != 0
@@ -159,6 +312,42 @@ func (u *User) OTPConfigured() bool {
 	return enabled && setup
 }
 
+// PasswordExpired checks if the user password is older than the expiration days of its level
+func (u *User) PasswordExpired(policy *config.PasswordPolicyConfig) bool {
+	if u.Level == nil || u.PasswordChangedAt == nil {
+		return false
+	}
+
+	days := policy.ExpirationDays[string(*u.Level)]
+	if days <= 0 {
+		return false
+	}
+
+	return time.Since(*u.PasswordChangedAt) >= time.Duration(days)*24*time.Hour
+}
+
+// IsFederated check if the user is authenticated by the LDAP directory
+func (u *User) IsFederated() bool {
+	return u.DirectoryDN != nil
+}
+
+// RequiresPasswordChange checks if the user must change the password before opening a session.
+// The password of the users of the directory is managed by the directory
+func (u *User) RequiresPasswordChange(policy *config.PasswordPolicyConfig) bool {
+	if u.IsFederated() {
+		return false
+	}
+	return (u.MustChangePassword != nil && *u.MustChangePassword) || u.PasswordExpired(policy)
+}
+
+// GetMaxSessions returns the number of sessions the user can have open at the same time
+func (u *User) GetMaxSessions(policy *config.SessionPolicyConfig) int64 {
+	if u.MaxSessions != nil {
+		return *u.MaxSessions
+	}
+	return policy.MaxSessions
+}
+
 // GetUserLevel returns the authentication token and duration by user level
 func (u *User) GetUserLevel(s *config.Secrets) string {
 	keys := map[Level]string{
@@ -169,6 +358,98 @@ func (u *User) GetUserLevel(s *config.Secrets) string {
 	return keys[*u.Level]
 }
 
+// LockoutEvent set data type to the events of the account lockout
+type LockoutEvent string
+
+const (
+	// LockoutEventLocked is the event of an account locked temporarily
+	LockoutEventLocked LockoutEvent = "locked"
+	// LockoutEventLockedPermanently is the event of an account locked until an administrator unlocks it
+	LockoutEventLockedPermanently LockoutEvent = "locked_permanently"
+	// LockoutEventUnlocked is the event of an account unlocked by an administrator
+	LockoutEventUnlocked LockoutEvent = "unlocked"
+	// LockoutEventSecured is the event of an account locked by the user until the password is reset
+	LockoutEventSecured LockoutEvent = "secured"
+)
+
+// Lock models the data of a lock applied to the account after failed attempts
+type Lock struct {
+	UserID    *uuid.UUID
+	Event     LockoutEvent
+	Attempts  *int64
+	Until     *time.Time
+	Permanent bool
+}
+
+// NewLock returns the lock to be applied to an account after the number of failed attempts,
+// or nil if the attempts don't reach the policy limits
+func NewLock(policy *config.LockoutConfig, userID *uuid.UUID, attempts int64) *Lock {
+	if policy.MaxAttempts <= 0 || attempts < policy.MaxAttempts {
+		return nil
+	}
+
+	lock := &Lock{UserID: userID, Attempts: utils.Pointer(attempts)}
+	if policy.PermanentAfter > 0 && attempts >= policy.PermanentAfter {
+		lock.Event, lock.Permanent = LockoutEventLockedPermanently, true
+		return lock
+	}
+
+	lock.Event = LockoutEventLocked
+	lock.Until = utils.Pointer(time.Now().Add(time.Duration(lockDuration(policy, attempts) * float64(time.Second))))
+	return lock
+}
+
+// lockDuration returns the time in seconds of the temporary lock after the number of failed attempts
+func lockDuration(policy *config.LockoutConfig, attempts int64) float64 {
+	seconds := float64(policy.Duration)
+	if policy.BackoffMultiplier > 1 {
+		seconds *= math.Pow(policy.BackoffMultiplier, float64(attempts-policy.MaxAttempts))
+	}
+	return seconds
+}
+
+// AttemptsDecay returns the time in seconds after the last failure in which the failed attempts are cleared,
+// or false when they are kept until a login succeeds or an administrator unlocks the account. The attempts
+// below the limit decay after the window, the ones that locked the account only after the longest temporary
+// lock, so that the backoff keeps growing until the permanent lock instead of restarting after each lock
+func AttemptsDecay(policy *config.LockoutConfig, attempts int64) (int64, bool) {
+	if policy.MaxAttempts <= 0 || attempts < policy.MaxAttempts {
+		return policy.Window, true
+	}
+
+	if policy.PermanentAfter <= 0 {
+		return 0, false
+	}
+
+	longest := lockDuration(policy, policy.PermanentAfter-1)
+	return int64(math.Ceil(longest)) + policy.Window, true
+}
+
+// LockoutEntry models the data of an event recorded in the account lockout history
+type LockoutEntry struct {
+	ID          *uuid.UUID    `json:"id" sql:"id"`
+	UserID      *uuid.UUID    `json:"user_id" sql:"user_id"`
+	Event       *LockoutEvent `json:"event" sql:"event"`
+	Attempts    *int64        `json:"attempts" sql:"attempts"`
+	LockedUntil *time.Time    `json:"locked_until,omitempty" sql:"locked_until"`
+	IP          *string       `json:"ip,omitempty" sql:"ip"`
+	UserAgent   *string       `json:"user_agent,omitempty" sql:"user_agent"`
+	CreatedBy   *uuid.UUID    `json:"created_by,omitempty" sql:"created_by"`
+	CreatedAt   *time.Time    `json:"created_at" sql:"created_at"`
+}
+
+// Lockout models the lockout status of an account
+type Lockout struct {
+	UserID            *uuid.UUID     `json:"user_id"`
+	Attempts          *int64         `json:"attempts"`
+	LastFailure       *time.Time     `json:"last_failure,omitempty"`
+	LockedUntil       *time.Time     `json:"locked_until,omitempty"`
+	LockedPermanently *bool          `json:"locked_permanently"`
+	Blocked           *bool          `json:"blocked"`
+	Events            []LockoutEntry `json:"events"`
+	Next              *bool          `json:"next,omitempty"`
+}
+
 // Login models the data for the user to log in with their account
 type Login struct {
 	Email     *string `json:"email" binding:"required,lte=60,email"`
@@ -176,6 +457,38 @@ type Login struct {
 	OTP       *string `json:"otp,omitempty"`
 	ClientIP  *string `json:"-"`
 	UserAgent *string `json:"-"`
+
+	// EndSessionID is the session chosen to be ended when the user reaches the maximum number of sessions
+	EndSessionID *uuid.UUID `json:"end_session_id,omitempty"`
+	// Method is how the user was authenticated, recorded in the session
+	Method *AuthMethod `json:"-"`
+}
+
+// AuthMethod set data type to how the session of a user was authenticated
+type AuthMethod string
+
+const (
+	// PasswordOTPMethod authenticates the user by the password of the account and the code of the 2FA
+	PasswordOTPMethod AuthMethod = "password_otp"
+	// DirectoryOTPMethod authenticates the user by the password of the LDAP directory and the code of the 2FA
+	DirectoryOTPMethod AuthMethod = "ldap_otp"
+	// ProviderOTPMethod authenticates the user by a sign in with a provider and the code of the 2FA
+	ProviderOTPMethod AuthMethod = "oidc_otp"
+)
+
+// HasOTP returns if the method checks the code of the 2FA. The sessions opened before the
+// method was recorded have no method and are not taken as authenticated by the 2FA
+func (m *AuthMethod) HasOTP() bool {
+	if m == nil {
+		return false
+	}
+
+	switch *m {
+	case PasswordOTPMethod, DirectoryOTPMethod, ProviderOTPMethod:
+		return true
+	}
+
+	return false
 }
 
 type ChangePassword struct {
@@ -227,4 +540,309 @@ type Session struct {
 	Token     *string    `json:"token,omitempty"`
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// ChangePasswordRequired indicates that the token can only be used to change the password
+	ChangePasswordRequired *bool `json:"change_password_required,omitempty"`
+	// SessionChoiceRequired indicates that the user must choose a session to end before logging in
+	SessionChoiceRequired *bool `json:"session_choice_required,omitempty"`
+	// ActiveSessions are the sessions the user can choose to end
+	ActiveSessions []*ActiveSession `json:"active_sessions,omitempty"`
+	// EvictedSessions are the sessions ended to open this session
+	EvictedSessions []*ActiveSession `json:"evicted_sessions,omitempty"`
+	// OTPSetupRequired indicates that the account was created by the directory in this login,
+	// and the user must configure the 2FA with the OTPSetupURL before logging in again
+	OTPSetupRequired *bool   `json:"otp_setup_required,omitempty"`
+	OTPSetupURL      *string `json:"otp_setup_url,omitempty"`
+}
+
+// SessionLimit models the data to change the number of sessions a user can have open,
+// a nil value restores the limit of the session policy of the level
+type SessionLimit struct {
+	MaxSessions *int64 `json:"max_sessions" binding:"omitempty,min=0"`
+}
+
+// ActiveSession models the data of an active session of the user
+type ActiveSession struct {
+	ID         *uuid.UUID  `json:"id"`
+	IP         *string     `json:"ip"`
+	UserAgent  *string     `json:"user_agent"`
+	Method     *AuthMethod `json:"method"`
+	CreatedAt  *time.Time  `json:"created_at"`
+	LastSeenAt *time.Time  `json:"last_seen_at"`
+	ExpiresAt  *time.Time  `json:"expires_at"`
+	Current    bool        `json:"current"`
+}
+
+// ConcurrentStrategy set data type to the strategy applied when the user reaches the maximum number of sessions
+type ConcurrentStrategy string
+
+const (
+	// EvictOldestStrategy ends the oldest sessions to open the new one
+	EvictOldestStrategy ConcurrentStrategy = "evict_oldest"
+	// RejectStrategy rejects the new login
+	RejectStrategy ConcurrentStrategy = "reject"
+	// ChooseStrategy requires the user to choose a session to end
+	ChooseStrategy ConcurrentStrategy = "choose"
+)
+
+// SessionsToEnd returns the active sessions that must be ended to open a new session under the limit.
+// It returns ErrSessionLimitReached when the strategy rejects the login and ErrSessionChoiceRequired
+// when the user must choose one of the active sessions to end
+func SessionsToEnd(strategy ConcurrentStrategy, limit int64, active []*ActiveSession, chosen *uuid.UUID) ([]*ActiveSession, error) {
+	if limit <= 0 || int64(len(active)) < limit {
+		return nil, nil
+	}
+
+	oldest := make([]*ActiveSession, len(active))
+	copy(oldest, active)
+	sort.SliceStable(oldest, func(i, j int) bool {
+		return oldest[i].CreatedAt.Before(*oldest[j].CreatedAt)
+	})
+
+	excess := int64(len(active)) - limit + 1
+	switch strategy {
+	case RejectStrategy:
+		return nil, ErrSessionLimitReached()
+
+	case ChooseStrategy:
+		var end []*ActiveSession
+		for _, session := range oldest {
+			if chosen != nil && *session.ID == *chosen {
+				end = append(end, session)
+			}
+		}
+
+		if len(end) == 0 {
+			return nil, ErrSessionChoiceRequired()
+		}
+
+		for i := 0; int64(len(end)) < excess && i < len(oldest); i++ {
+			if *oldest[i].ID != *chosen {
+				end = append(end, oldest[i])
+			}
+		}
+
+		return end, nil
+	}
+
+	return oldest[:excess], nil
+}
+
+// IsNewDevice checks if a login comes from a device or a network not seen in the recent sessions of the user.
+// The first login of the user is not considered new, as there is nothing to compare with
+func IsNewDevice(recent []*ActiveSession, clientIP, userAgent *string) bool {
+	if len(recent) == 0 {
+		return false
+	}
+
+	var knownDevice, knownNetwork bool
+	for _, session := range recent {
+		if session.UserAgent != nil && userAgent != nil && *session.UserAgent == *userAgent {
+			knownDevice = true
+		}
+		if session.IP != nil && clientIP != nil && sameNetwork(*session.IP, *clientIP) {
+			knownNetwork = true
+		}
+	}
+
+	return !knownDevice || !knownNetwork
+}
+
+// sameNetwork checks if two addresses are in the same /24 IPv4 or /64 IPv6 network
+func sameNetwork(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a == b
+	}
+
+	mask := net.CIDRMask(64, 128)
+	if ipA.To4() != nil && ipB.To4() != nil {
+		ipA, ipB, mask = ipA.To4(), ipB.To4(), net.CIDRMask(24, 32)
+	}
+
+	return ipA.Mask(mask).Equal(ipB.Mask(mask))
+}
+
+// Filters accepted in the query of the users
+const (
+	FilterUserLevel       string = "level"
+	FilterUserActive      string = "active"
+	FilterUserBlocked     string = "blocked"
+	FilterUserCreatedFrom string = "created_from"
+	FilterUserCreatedTo   string = "created_to"
+	// FilterUserSearch searches the text in the email and in the name of the users
+	FilterUserSearch string = "q"
+)
+
+// ValidateUserParams checks the format of the filters of the users
+func ValidateUserParams(p *utils.Params) error {
+	for _, value := range p.Filters[FilterUserLevel] {
+		if !Level(value).IsValid() {
+			return ErrLevelIsNotValid()
+		}
+	}
+
+	for _, filter := range []string{FilterUserActive, FilterUserBlocked} {
+		for _, value := range p.Filters[filter] {
+			if _, err := strconv.ParseBool(value); err != nil {
+				return ErrInvalidFilter()
+			}
+		}
+	}
+
+	for _, filter := range []string{FilterUserCreatedFrom, FilterUserCreatedTo} {
+		for _, value := range p.Filters[filter] {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return ErrInvalidFilter()
+			}
+		}
+	}
+
+	return nil
+}
+
+// UserSummary models the data of a user shown to the administrators
+type UserSummary struct {
+	ID                 *uuid.UUID `json:"id" sql:"id"`
+	Email              *string    `json:"email" sql:"email"`
+	FirstName          *string    `json:"first_name" sql:"first_name"`
+	LastName           *string    `json:"last_name" sql:"last_name"`
+	Level              *Level     `json:"level" sql:"level"`
+	Active             *bool      `json:"active" sql:"active"`
+	MustChangePassword *bool      `json:"must_change_password" sql:"must_change_password"`
+	PasswordChangedAt  *time.Time `json:"password_changed_at" sql:"password_changed_at"`
+	LockedUntil        *time.Time `json:"locked_until,omitempty" sql:"locked_until"`
+	LockedPermanently  *bool      `json:"locked_permanently" sql:"locked_permanently"`
+	MaxSessions        *int64     `json:"max_sessions,omitempty" sql:"max_sessions"`
+	CreatedAt          *time.Time `json:"created_at" sql:"created_at"`
+	UpdatedAt          *time.Time `json:"updated_at,omitempty" sql:"updated_at"`
+
+	// Blocked indicates that the user cannot log in because of failed attempts
+	Blocked *bool `json:"blocked" ignore:"true"`
+}
+
+// Prepare fills the data computed from the stored data of the user
+func (u *UserSummary) Prepare() {
+	blocked := (u.LockedPermanently != nil && *u.LockedPermanently) ||
+		(u.LockedUntil != nil && u.LockedUntil.After(time.Now()))
+	u.Blocked = &blocked
+}
+
+// Users models a page of the users
+type Users struct {
+	Users []UserSummary `json:"users"`
+	Next  *bool         `json:"next"`
+}
+
+// UpdateUser models the data an administrator can change in a user, the fields without value are kept
+type UpdateUser struct {
+	FirstName *string `json:"first_name" binding:"omitempty,min=1,max=20"`
+	LastName  *string `json:"last_name" binding:"omitempty,min=1,max=32"`
+	Level     *Level  `json:"level"`
+}
+
+// Validate checks that there is something to change and that the level is valid
+func (u *UpdateUser) Validate() error {
+	if u.FirstName == nil && u.LastName == nil && u.Level == nil {
+		return ErrNothingToUpdate()
+	}
+
+	if u.Level != nil && !u.Level.IsValid() {
+		return ErrLevelIsNotValid()
+	}
+
+	return nil
+}
+
+// Profile models the data users see and manage about themselves
+type Profile struct {
+	ID                *uuid.UUID `json:"id"`
+	Email             *string    `json:"email"`
+	FirstName         *string    `json:"first_name"`
+	LastName          *string    `json:"last_name"`
+	Level             *Level     `json:"level"`
+	Locale            *string    `json:"locale,omitempty"`
+	PasswordChangedAt *time.Time `json:"password_changed_at"`
+	CreatedAt         *time.Time `json:"created_at"`
+}
+
+// UpdateProfile models the data users can change about themselves, the fields without value are kept
+type UpdateProfile struct {
+	FirstName *string `json:"first_name" binding:"omitempty,min=1,max=20"`
+	LastName  *string `json:"last_name" binding:"omitempty,min=1,max=32"`
+	Locale    *string `json:"locale"`
+}
+
+// Validate checks that there is something to change and that the locale has translations
+func (u *UpdateProfile) Validate() error {
+	if u.FirstName == nil && u.LastName == nil && u.Locale == nil {
+		return ErrNothingToUpdate()
+	}
+
+	if u.Locale != nil && !i18n.IsSupported(*u.Locale) {
+		return ErrLocaleNotSupported()
+	}
+
+	return nil
+}
+
+// ChangeEmail models the data to request the change of the email of the user,
+// confirmed with the current password
+type ChangeEmail struct {
+	UserID *uuid.UUID `json:"-"`
+	Email  *string    `json:"email" binding:"required,lte=60,email"`
+	Confirmation
+}
+
+// Prepare normalizes the new email
+func (c *ChangeEmail) Prepare() {
+	c.Email = utils.Pointer(strings.ToLower(strings.TrimSpace(*c.Email)))
+	c.Confirmation.Prepare()
+}
+
+// Confirmation models how the user confirms a sensitive action: with the password or, for the users
+// that sign in with a provider, with the token received after signing in again with the provider
+type Confirmation struct {
+	Password          *string    `json:"password,omitempty"`
+	ConfirmationToken *uuid.UUID `json:"confirmation_token,omitempty"`
+}
+
+// Prepare removes the spaces around the password
+func (c *Confirmation) Prepare() {
+	if c.Password != nil {
+		c.Password = utils.Pointer(strings.TrimSpace(*c.Password))
+	}
+}
+
+// Validate checks that the action is confirmed by one of the ways
+func (c *Confirmation) Validate() error {
+	if (c.Password == nil || *c.Password == "") && c.ConfirmationToken == nil {
+		return ErrConfirmationRequired()
+	}
+	return nil
+}
+
+// ConfirmEmail models the data to confirm the new email with the token sent to it
+type ConfirmEmail struct {
+	Token *uuid.UUID `json:"token" binding:"required"`
+}
+
+// Introspect models the request of an application to introspect a token of a user. The audience is
+// the project of the application, whose roles of the user are returned in the introspection
+type Introspect struct {
+	Token    *string    `json:"token" binding:"required"`
+	Audience *uuid.UUID `json:"audience"`
+}
+
+// Introspection models the state of a token, with the roles and the permissions of the user in the project
+// of the audience. The inactive tokens only inform that they are not active, as in the RFC 7662
+type Introspection struct {
+	Active      bool       `json:"active"`
+	SessionID   *uuid.UUID `json:"session_id,omitempty"`
+	UserID      *uuid.UUID `json:"sub,omitempty"`
+	Level       *Level     `json:"level,omitempty"`
+	ExpiresAt   *int64     `json:"exp,omitempty"`
+	Audience    *uuid.UUID `json:"aud,omitempty"`
+	Roles       []string   `json:"roles,omitempty"`
+	Permissions []string   `json:"permissions,omitempty"`
 }
@@ -0,0 +1,328 @@
+// Copyright (c) 2022 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestNewLock(t *testing.T) {
+	var (
+		userID = uuid.New()
+		policy = &config.LockoutConfig{
+			MaxAttempts:       3,
+			Window:            300,
+			Duration:          60,
+			BackoffMultiplier: 2,
+			PermanentAfter:    6,
+		}
+	)
+
+	t.Run("BelowMaxAttempts", func(t *testing.T) {
+		if lock := NewLock(policy, &userID, 2); lock != nil {
+			t.Errorf("expected no lock, got %v", lock.Event)
+		}
+	})
+
+	t.Run("Temporary", func(t *testing.T) {
+		for attempts, expected := range map[int64]time.Duration{
+			3: time.Minute,
+			4: 2 * time.Minute,
+			5: 4 * time.Minute,
+		} {
+			lock := NewLock(policy, &userID, attempts)
+			if lock == nil || lock.Event != LockoutEventLocked || lock.Permanent {
+				t.Fatalf("expected temporary lock for %d attempts", attempts)
+			}
+
+			if remaining := time.Until(*lock.Until); remaining > expected || remaining < expected-time.Second {
+				t.Errorf("expected lock of %v for %d attempts, got %v", expected, attempts, remaining)
+			}
+		}
+	})
+
+	t.Run("Permanent", func(t *testing.T) {
+		lock := NewLock(policy, &userID, 6)
+		if lock == nil || lock.Event != LockoutEventLockedPermanently || !lock.Permanent || lock.Until != nil {
+			t.Fatal("expected permanent lock")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		if lock := NewLock(&config.LockoutConfig{}, &userID, 100); lock != nil {
+			t.Error("expected no lock when the policy is disabled")
+		}
+	})
+}
+
+func TestAttemptsDecay(t *testing.T) {
+	policy := &config.LockoutConfig{
+		MaxAttempts:       3,
+		Window:            300,
+		Duration:          60,
+		BackoffMultiplier: 2,
+		PermanentAfter:    6,
+	}
+
+	t.Run("BelowMaxAttempts", func(t *testing.T) {
+		if decay, ok := AttemptsDecay(policy, 2); !ok || decay != 300 {
+			t.Errorf("expected the attempts to decay after the window, got %d (%v)", decay, ok)
+		}
+	})
+
+	t.Run("AfterLongestLock", func(t *testing.T) {
+		if decay, ok := AttemptsDecay(policy, 4); !ok || decay != 4*60+300 {
+			t.Errorf("expected the attempts to decay after the longest lock, got %d (%v)", decay, ok)
+		}
+	})
+
+	t.Run("NeverPermanent", func(t *testing.T) {
+		withoutPermanent := *policy
+		withoutPermanent.PermanentAfter = 0
+		if _, ok := AttemptsDecay(&withoutPermanent, 3); ok {
+			t.Error("expected the attempts that locked the account to be kept")
+		}
+	})
+
+	t.Run("BackoffAfterClearStale", func(t *testing.T) {
+		userID := uuid.New()
+		attempts := policy.MaxAttempts
+
+		first := NewLock(policy, &userID, attempts)
+		if first == nil || first.Until == nil {
+			t.Fatal("expected temporary lock")
+		}
+
+		// the lock and the window have passed when the clean up job runs
+		elapsed := time.Until(*first.Until) + time.Duration(policy.Window)*time.Second + time.Second
+		if decay, ok := AttemptsDecay(policy, attempts); ok && elapsed > time.Duration(decay)*time.Second {
+			attempts = 0
+		}
+
+		if attempts != policy.MaxAttempts {
+			t.Fatalf("expected the attempts to be kept, got %d", attempts)
+		}
+
+		second := NewLock(policy, &userID, attempts+1)
+		if second == nil || second.Until == nil || !second.Until.After(first.Until.Add(time.Duration(policy.Duration)*time.Second/2)) {
+			t.Error("expected the backoff to keep growing after the clean up")
+		}
+	})
+}
+
+func TestSessionsToEnd(t *testing.T) {
+	now := time.Now()
+	active := make([]*ActiveSession, 3)
+	for i := range active {
+		active[i] = &ActiveSession{
+			ID:        utils.Pointer(uuid.New()),
+			CreatedAt: utils.Pointer(now.Add(time.Duration(-i) * time.Hour)),
+		}
+	}
+
+	t.Run("BelowLimit", func(t *testing.T) {
+		end, err := SessionsToEnd(EvictOldestStrategy, 4, active, nil)
+		if err != nil || len(end) != 0 {
+			t.Errorf("expected no sessions to end, got %d (%v)", len(end), err)
+		}
+	})
+
+	t.Run("EvictOldest", func(t *testing.T) {
+		end, err := SessionsToEnd(EvictOldestStrategy, 2, active, nil)
+		if err != nil || len(end) != 2 || end[0] != active[2] || end[1] != active[1] {
+			t.Errorf("expected the two oldest sessions to end, got %d (%v)", len(end), err)
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		if _, err := SessionsToEnd(RejectStrategy, 3, active, nil); err == nil {
+			t.Error("expected the login to be rejected")
+		}
+	})
+
+	t.Run("Choose", func(t *testing.T) {
+		if _, err := SessionsToEnd(ChooseStrategy, 3, active, nil); err == nil {
+			t.Error("expected a session choice to be required")
+		}
+
+		end, err := SessionsToEnd(ChooseStrategy, 3, active, active[0].ID)
+		if err != nil || len(end) != 1 || end[0] != active[0] {
+			t.Errorf("expected the chosen session to end, got %d (%v)", len(end), err)
+		}
+	})
+}
+
+func TestIsNewDevice(t *testing.T) {
+	recent := []*ActiveSession{
+		{IP: utils.Pointer("192.168.0.10"), UserAgent: utils.Pointer("firefox")},
+		{IP: utils.Pointer("2001:db8::1"), UserAgent: utils.Pointer("chrome")},
+	}
+
+	for name, tc := range map[string]struct {
+		recent    []*ActiveSession
+		ip, agent string
+		expected  bool
+	}{
+		"FirstLogin":     {recent: nil, ip: "10.0.0.1", agent: "firefox", expected: false},
+		"KnownDevice":    {recent: recent, ip: "192.168.0.20", agent: "firefox", expected: false},
+		"KnownIPv6":      {recent: recent, ip: "2001:db8::ffff", agent: "chrome", expected: false},
+		"NewDevice":      {recent: recent, ip: "192.168.0.10", agent: "safari", expected: true},
+		"NewNetwork":     {recent: recent, ip: "192.168.1.10", agent: "firefox", expected: true},
+		"UnknownAddress": {recent: recent, ip: "unknown", agent: "firefox", expected: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := IsNewDevice(tc.recent, &tc.ip, &tc.agent); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateUserParams(t *testing.T) {
+	testCases := map[string]struct {
+		filters map[string][]string
+		valid   bool
+	}{
+		"NoFilters":        {filters: map[string][]string{}, valid: true},
+		"AllFilters":       {filters: map[string][]string{"level": {"admin", "user"}, "active": {"true"}, "blocked": {"false"}, "created_from": {"2023-01-01T00:00:00Z"}, "q": {"senna"}}, valid: true},
+		"InvalidLevel":     {filters: map[string][]string{"level": {"root"}}},
+		"InvalidActive":    {filters: map[string][]string{"active": {"yes"}}},
+		"InvalidCreatedTo": {filters: map[string][]string{"created_to": {"yesterday"}}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := ValidateUserParams(&utils.Params{Filters: tc.filters}); (err == nil) != tc.valid {
+				t.Errorf("expected valid to be %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestUserSummaryPrepare(t *testing.T) {
+	testCases := map[string]struct {
+		user     UserSummary
+		expected bool
+	}{
+		"NotLocked":         {user: UserSummary{LockedPermanently: utils.Pointer(false)}},
+		"LockExpired":       {user: UserSummary{LockedPermanently: utils.Pointer(false), LockedUntil: utils.Pointer(time.Now().Add(-time.Minute))}},
+		"LockedTemporarily": {user: UserSummary{LockedPermanently: utils.Pointer(false), LockedUntil: utils.Pointer(time.Now().Add(time.Minute))}, expected: true},
+		"LockedPermanently": {user: UserSummary{LockedPermanently: utils.Pointer(true)}, expected: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tc.user.Prepare()
+			if *tc.user.Blocked != tc.expected {
+				t.Errorf("expected blocked to be %v, got %v", tc.expected, *tc.user.Blocked)
+			}
+		})
+	}
+}
+
+func TestCreateAccountValidate(t *testing.T) {
+	valid := func() *CreateAccount {
+		return &CreateAccount{
+			FirstName: utils.Pointer("Janekin"),
+			LastName:  utils.Pointer("Skywalker"),
+			Email:     utils.Pointer("janekin@powersso.io"),
+			Password:  utils.Pointer("any_password"),
+		}
+	}
+
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("expected valid account, got %v", err)
+	}
+
+	for name, change := range map[string]func(*CreateAccount){
+		"WithoutFirstName": func(in *CreateAccount) { in.FirstName = nil },
+		"LongLastName":     func(in *CreateAccount) { in.LastName = utils.Pointer("Skywalker Skywalker Skywalker Sky") },
+		"InvalidEmail":     func(in *CreateAccount) { in.Email = utils.Pointer("Janekin <janekin@powersso.io>") },
+		"ShortPassword":    func(in *CreateAccount) { in.Password = utils.Pointer("12345") },
+		"InvalidLevel":     func(in *CreateAccount) { in.Level = utils.Pointer(Level("root")) },
+	} {
+		in := valid()
+		change(in)
+		if err := in.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCreateAccountUsePasswordHash(t *testing.T) {
+	t.Run("Bcrypt", func(t *testing.T) {
+		in := &CreateAccount{
+			FirstName: utils.Pointer("Janekin"),
+			LastName:  utils.Pointer("Skywalker"),
+			Email:     utils.Pointer("janekin@powersso.io"),
+		}
+
+		hash := "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+		if err := in.UsePasswordHash(hash); err != nil {
+			t.Fatalf("expected the hash to be accepted, got %v", err)
+		}
+
+		if err := in.Validate(); err != nil {
+			t.Fatalf("expected valid account, got %v", err)
+		}
+
+		if err := in.Prepare(); err != nil || *in.Password != hash || *in.Key != "" {
+			t.Errorf("expected the hash to be kept, got %v", err)
+		}
+	})
+
+	t.Run("NotBcrypt", func(t *testing.T) {
+		if err := new(CreateAccount).UsePasswordHash("5f4dcc3b5aa765d61d8327deb882cf99"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestConfirmationValidate(t *testing.T) {
+	testCases := map[string]struct {
+		in       Confirmation
+		expected bool
+	}{
+		"Password":      {in: Confirmation{Password: utils.Pointer("any_password")}, expected: true},
+		"Token":         {in: Confirmation{ConfirmationToken: utils.Pointer(uuid.New())}, expected: true},
+		"Empty":         {in: Confirmation{}},
+		"BlankPassword": {in: Confirmation{Password: utils.Pointer("")}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if valid := tc.in.Validate() == nil; valid != tc.expected {
+				t.Errorf("expected valid to be %v, got %v", tc.expected, valid)
+			}
+		})
+	}
+}
+
+func TestAuthMethodHasOTP(t *testing.T) {
+	testCases := map[string]struct {
+		method   *AuthMethod
+		expected bool
+	}{
+		"Password":  {method: utils.Pointer(PasswordOTPMethod), expected: true},
+		"Directory": {method: utils.Pointer(DirectoryOTPMethod), expected: true},
+		"Provider":  {method: utils.Pointer(ProviderOTPMethod), expected: true},
+		"Unknown":   {method: utils.Pointer(AuthMethod("password"))},
+		"Missing":   {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if otp := tc.method.HasOTP(); otp != tc.expected {
+				t.Errorf("expected otp to be %v, got %v", tc.expected, otp)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrFormatNotSupported creates and returns an error when the format of the file is not csv or json
+func ErrFormatNotSupported() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_bulk_format_not_supported"), http.StatusUnsupportedMediaType)
+}
+
+// ErrMalformedFile creates and returns an error when the file cannot be read in its format
+func ErrMalformedFile() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_bulk_malformed_file"), http.StatusBadRequest)
+}
+
+// ErrTooManyRows creates and returns an error when the file has more rows than allowed in an import
+func ErrTooManyRows() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_bulk_too_many_rows"), http.StatusRequestEntityTooLarge)
+}
+
+// ErrDuplicateEmail creates and returns an error when the email appears in another row of the file
+func ErrDuplicateEmail() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_bulk_duplicate_email"), http.StatusBadRequest)
+}
+
+// ErrPasswordAndHash creates and returns an error when a row has the password and its hash
+func ErrPasswordAndHash() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_bulk_password_and_hash"), http.StatusBadRequest)
+}
+
+// ErrPasswordNotAllowed creates and returns an error when a row of an invitation has a password
+func ErrPasswordNotAllowed() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_bulk_password_not_allowed"), http.StatusBadRequest)
+}
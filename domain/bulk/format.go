@@ -0,0 +1,227 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Columns of the csv files
+const (
+	columnID           string = "id"
+	columnEmail        string = "email"
+	columnFirstName    string = "first_name"
+	columnLastName     string = "last_name"
+	columnLevel        string = "level"
+	columnPassword     string = "password"
+	columnPasswordHash string = "password_hash"
+	columnActive       string = "active"
+	columnCreatedBy    string = "created_by"
+	columnCreatedAt    string = "created_at"
+	columnProjects     string = "projects"
+)
+
+// exportColumns are the columns of the csv files of export, the projects are a json array
+var exportColumns = []string{
+	columnID, columnEmail, columnFirstName, columnLastName, columnLevel,
+	columnActive, columnCreatedBy, columnCreatedAt, columnProjects,
+}
+
+// ReadRows reads the users of the file in the format, calling read for each row in the order of the file.
+// It stops with an error when the file is malformed or has more than maxRows rows
+func ReadRows(format Format, r io.Reader, maxRows int64, read func(row int64, data *ImportRow) error) error {
+	if format == FormatCSV {
+		return readCSV(r, maxRows, read)
+	}
+	return readJSON(r, maxRows, read)
+}
+
+// readCSV reads a csv file with a header, the columns are found by name and the empty cells are not informed
+func readCSV(r io.Reader, maxRows int64, read func(row int64, data *ImportRow) error) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return ErrMalformedFile()
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns[columnEmail]; !ok {
+		return ErrMalformedFile()
+	}
+
+	cell := func(record []string, column string) *string {
+		if i, ok := columns[column]; ok && strings.TrimSpace(record[i]) != "" {
+			return utils.Pointer(strings.TrimSpace(record[i]))
+		}
+		return nil
+	}
+
+	for row := int64(1); ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return ErrMalformedFile()
+		}
+
+		if row > maxRows {
+			return ErrTooManyRows()
+		}
+
+		data := &ImportRow{
+			Email:        cell(record, columnEmail),
+			FirstName:    cell(record, columnFirstName),
+			LastName:     cell(record, columnLastName),
+			Password:     cell(record, columnPassword),
+			PasswordHash: cell(record, columnPasswordHash),
+		}
+
+		if level := cell(record, columnLevel); level != nil {
+			data.Level = utils.Pointer(auth.Level(*level))
+		}
+
+		if err = read(row, data); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSON reads a json array of users, decoding one user at a time
+func readJSON(r io.Reader, maxRows int64, read func(row int64, data *ImportRow) error) error {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return ErrMalformedFile()
+	}
+
+	for row := int64(1); decoder.More(); row++ {
+		if row > maxRows {
+			return ErrTooManyRows()
+		}
+
+		data := new(ImportRow)
+		if err := decoder.Decode(data); err != nil {
+			return ErrMalformedFile()
+		}
+
+		if err := read(row, data); err != nil {
+			return err
+		}
+	}
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim(']') {
+		return ErrMalformedFile()
+	}
+
+	return nil
+}
+
+// ExportWriter writes the users in a format as they are read, without keeping them in memory
+type ExportWriter interface {
+	Write(*ExportedUser) error
+	Close() error
+}
+
+// NewExportWriter creates the writer of the users in the format
+func NewExportWriter(format Format, w io.Writer) ExportWriter {
+	if format == FormatCSV {
+		return &csvWriter{writer: csv.NewWriter(w)}
+	}
+	return &jsonWriter{writer: w}
+}
+
+// csvWriter writes the users as the lines of a csv file with a header
+type csvWriter struct {
+	writer  *csv.Writer
+	started bool
+}
+
+// Write writes a user as a line of the file, writing the header before the first user
+func (c *csvWriter) Write(user *ExportedUser) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+
+	projects, err := json.Marshal(user.Projects)
+	if err != nil {
+		return err
+	}
+
+	var createdBy string
+	if user.CreatedBy != nil {
+		createdBy = user.CreatedBy.String()
+	}
+
+	return c.writer.Write([]string{
+		user.ID.String(), *user.Email, *user.FirstName, *user.LastName, string(*user.Level),
+		strconv.FormatBool(*user.Active), createdBy, user.CreatedAt.Format(time.RFC3339), string(projects),
+	})
+}
+
+// Close writes the header when there are no users and flushes the file
+func (c *csvWriter) Close() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvWriter) header() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	return c.writer.Write(exportColumns)
+}
+
+// jsonWriter writes the users as the items of a json array
+type jsonWriter struct {
+	writer  io.Writer
+	started bool
+}
+
+// Write writes a user as an item of the array, opening the array before the first user
+func (j *jsonWriter) Write(user *ExportedUser) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if !j.started {
+		separator, j.started = "[\n", true
+	}
+
+	_, err = io.WriteString(j.writer, separator+string(data))
+	return err
+}
+
+// Close closes the array, writing an empty array when there are no users
+func (j *jsonWriter) Close() (err error) {
+	if !j.started {
+		_, err = io.WriteString(j.writer, "[]\n")
+		return
+	}
+
+	_, err = io.WriteString(j.writer, "\n]\n")
+	return
+}
@@ -0,0 +1,10 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+// IBulk define an interface for data layer access methods
+type IBulk interface {
+	Users(each func(*ExportedUser) error) error
+}
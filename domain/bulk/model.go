@@ -0,0 +1,216 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"errors"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/domain/invitation"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Format set data type to the format of the files of import and export of users
+type Format string
+
+const (
+	// FormatCSV is the format of the files with a header and a user by line
+	FormatCSV Format = "csv"
+	// FormatJSON is the format of the files with an array of users
+	FormatJSON Format = "json"
+)
+
+// IsValid returns if the format is supported
+func (f Format) IsValid() bool {
+	return f == FormatCSV || f == FormatJSON
+}
+
+// ContentType returns the media type of the files of the format
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv"
+	}
+	return "application/json"
+}
+
+// FormatOf returns the format of the media type of a request, empty when it is not supported
+func FormatOf(contentType string) Format {
+	media, _, _ := mime.ParseMediaType(contentType)
+	switch media {
+	case "text/csv":
+		return FormatCSV
+	case "application/json":
+		return FormatJSON
+	}
+	return ""
+}
+
+// ImportOptions models the options of an administrator to import users
+type ImportOptions struct {
+	Format Format `form:"format"`
+	// DryRun validates the file without creating the users
+	DryRun bool `form:"dry_run"`
+	// Invite sends an invitation to the users instead of creating the accounts, so that they choose the password
+	Invite    bool       `form:"invite"`
+	CreatedBy *uuid.UUID `form:"-"`
+}
+
+// Validate checks the options of the import
+func (o *ImportOptions) Validate() error {
+	if !o.Format.IsValid() {
+		return ErrFormatNotSupported()
+	}
+	return nil
+}
+
+// ExportOptions models the options of an administrator to export users
+type ExportOptions struct {
+	Format Format `form:"format"`
+}
+
+// Validate checks the options of the export, using json when the format is not informed
+func (o *ExportOptions) Validate() error {
+	if o.Format == "" {
+		o.Format = FormatJSON
+	}
+
+	if !o.Format.IsValid() {
+		return ErrFormatNotSupported()
+	}
+
+	return nil
+}
+
+// ImportRow models a user in the file of import. The password can be informed in plain text or as a bcrypt
+// hash generated by another system, and is not informed when the user is invited
+type ImportRow struct {
+	Email        *string     `json:"email"`
+	FirstName    *string     `json:"first_name"`
+	LastName     *string     `json:"last_name"`
+	Level        *auth.Level `json:"level"`
+	Password     *string     `json:"password"`
+	PasswordHash *string     `json:"password_hash"`
+}
+
+// Account creates the account of the row, with the same validation of the accounts created by the users
+func (r *ImportRow) Account(createdBy *uuid.UUID) (*auth.CreateAccount, error) {
+	account := &auth.CreateAccount{
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Email:     r.Email,
+		Password:  r.Password,
+		Level:     r.Level,
+		CreatedBy: createdBy,
+	}
+
+	if r.PasswordHash != nil {
+		if r.Password != nil {
+			return nil, ErrPasswordAndHash()
+		}
+
+		if err := account.UsePasswordHash(*r.PasswordHash); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// Invitation creates the invitation of the row, validating the data of the account without the password
+func (r *ImportRow) Invitation(createdBy *uuid.UUID) (*invitation.CreateInvitation, error) {
+	if r.Password != nil || r.PasswordHash != nil {
+		return nil, ErrPasswordNotAllowed()
+	}
+
+	account := &auth.CreateAccount{FirstName: r.FirstName, LastName: r.LastName, Email: r.Email, Level: r.Level}
+	if err := account.ValidateProfile(); err != nil {
+		return nil, err
+	}
+
+	return &invitation.CreateInvitation{
+		Email:     r.Email,
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Level:     r.Level,
+		CreatedBy: createdBy,
+	}, nil
+}
+
+// Key returns the normalized email of the row, used to find the rows with the same email
+func (r *ImportRow) Key() string {
+	if r.Email == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(*r.Email))
+}
+
+// ImportResult models the result of an import, with the errors of the rows that were not imported
+type ImportResult struct {
+	DryRun   bool       `json:"dry_run"`
+	Total    int64      `json:"total"`
+	Imported int64      `json:"imported"`
+	Failed   int64      `json:"failed"`
+	Errors   []RowError `json:"errors"`
+}
+
+// RowError models the error of a row of the import. The rows are numbered from one, without the header
+type RowError struct {
+	Row   int64   `json:"row"`
+	Email *string `json:"email,omitempty"`
+	Error string  `json:"error"`
+}
+
+// NewImportResult creates the result of an import
+func NewImportResult(dryRun bool) *ImportResult {
+	return &ImportResult{DryRun: dryRun, Errors: make([]RowError, 0)}
+}
+
+// Succeed counts a row imported, or that would be imported in a dry run
+func (r *ImportResult) Succeed() {
+	r.Total++
+	r.Imported++
+}
+
+// Fail counts a row that was not imported with the message of the error
+func (r *ImportResult) Fail(row int64, email *string, err error) {
+	r.Total++
+	r.Failed++
+
+	var handled *oops.Error
+	if !errors.As(oops.Err(err), &handled) {
+		handled = &oops.Error{Message: err.Error()}
+	}
+
+	r.Errors = append(r.Errors, RowError{Row: row, Email: email, Error: handled.Message})
+}
+
+// ExportedUser models a user in the export, without the credentials
+type ExportedUser struct {
+	ID        *uuid.UUID        `json:"id"`
+	Email     *string           `json:"email"`
+	FirstName *string           `json:"first_name"`
+	LastName  *string           `json:"last_name"`
+	Level     *auth.Level       `json:"level"`
+	Active    *bool             `json:"active"`
+	CreatedBy *uuid.UUID        `json:"created_by,omitempty"`
+	CreatedAt *time.Time        `json:"created_at"`
+	Projects  []ExportedProject `json:"projects"`
+}
+
+// ExportedProject models a current participation of the user in a project in the export
+type ExportedProject struct {
+	ProjectID     *uuid.UUID `json:"project_id"`
+	StartDate     *time.Time `json:"start_date"`
+	DepartureDate *time.Time `json:"departure_date,omitempty"`
+}
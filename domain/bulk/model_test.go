@@ -0,0 +1,219 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestFormatOf(t *testing.T) {
+	for contentType, expected := range map[string]Format{
+		"text/csv":                        FormatCSV,
+		"application/json; charset=utf-8": FormatJSON,
+		"application/xml":                 "",
+		"":                                "",
+	} {
+		if format := FormatOf(contentType); format != expected {
+			t.Errorf("expected %q for %q, got %q", expected, contentType, format)
+		}
+	}
+}
+
+func TestReadRows(t *testing.T) {
+	read := func(format Format, file string, maxRows int64) (rows []ImportRow, err error) {
+		err = ReadRows(format, strings.NewReader(file), maxRows, func(row int64, data *ImportRow) error {
+			if row != int64(len(rows)+1) {
+				t.Errorf("expected row %d, got %d", len(rows)+1, row)
+			}
+			rows = append(rows, *data)
+			return nil
+		})
+		return
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		rows, err := read(FormatCSV, "Email,first_name,last_name,level,password\n"+
+			"janekin@powersso.io,Janekin,Skywalker,admin,any_password\n"+
+			"ayrton@powersso.io, Ayrton,Senna,,\n", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(rows) != 2 || *rows[0].Email != "janekin@powersso.io" || *rows[0].Level != auth.AdminLevel ||
+			*rows[1].FirstName != "Ayrton" || rows[1].Level != nil || rows[1].Password != nil || rows[1].PasswordHash != nil {
+			t.Errorf("unexpected rows %+v", rows)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		rows, err := read(FormatJSON, `[{"email":"janekin@powersso.io","password_hash":"hash"},{"email":"ayrton@powersso.io"}]`, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(rows) != 2 || *rows[0].PasswordHash != "hash" || *rows[1].Email != "ayrton@powersso.io" {
+			t.Errorf("unexpected rows %+v", rows)
+		}
+	})
+
+	for name, file := range map[string]struct {
+		format  Format
+		content string
+	}{
+		"CSVWithoutEmail":     {FormatCSV, "first_name\nJanekin\n"},
+		"CSVWrongColumns":     {FormatCSV, "email,first_name\njanekin@powersso.io\n"},
+		"JSONNotArray":        {FormatJSON, `{"email":"janekin@powersso.io"}`},
+		"JSONInvalidItem":     {FormatJSON, `[{"email":1}]`},
+		"JSONUnterminated":    {FormatJSON, `[{"email":"janekin@powersso.io"}`},
+		"CSVEmpty":            {FormatCSV, ""},
+		"JSONTrailingGarbage": {FormatJSON, `[{"email":"janekin@powersso.io"},]`},
+	} {
+		if _, err := read(file.format, file.content, 10); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	t.Run("TooManyRows", func(t *testing.T) {
+		if _, err := read(FormatJSON, `[{},{},{}]`, 2); err == nil {
+			t.Error("expected an error")
+		}
+
+		if _, err := read(FormatCSV, "email\na@powersso.io\nb@powersso.io\n", 2); err != nil {
+			t.Errorf("expected no error at the limit, got %v", err)
+		}
+	})
+}
+
+func TestImportRow(t *testing.T) {
+	row := func() *ImportRow {
+		return &ImportRow{
+			Email:     utils.Pointer("janekin@powersso.io"),
+			FirstName: utils.Pointer("Janekin"),
+			LastName:  utils.Pointer("Skywalker"),
+			Password:  utils.Pointer("any_password"),
+		}
+	}
+
+	t.Run("Account", func(t *testing.T) {
+		createdBy := uuid.New()
+		account, err := row().Account(&createdBy)
+		if err != nil || *account.CreatedBy != createdBy {
+			t.Fatalf("expected a valid account, got %v", err)
+		}
+
+		data := row()
+		data.Password = nil
+		if _, err = data.Account(nil); err == nil {
+			t.Error("expected an error without password")
+		}
+
+		data.PasswordHash = utils.Pointer("$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy")
+		if _, err = data.Account(nil); err != nil {
+			t.Errorf("expected the hash to be accepted, got %v", err)
+		}
+
+		data.Password = utils.Pointer("any_password")
+		if _, err = data.Account(nil); err == nil {
+			t.Error("expected an error with the password and its hash")
+		}
+	})
+
+	t.Run("Invitation", func(t *testing.T) {
+		if _, err := row().Invitation(nil); err == nil {
+			t.Error("expected an error with password")
+		}
+
+		data := row()
+		data.Password = nil
+		if in, err := data.Invitation(nil); err != nil || *in.Email != "janekin@powersso.io" {
+			t.Errorf("expected a valid invitation, got %v", err)
+		}
+
+		data.Email = utils.Pointer("janekin")
+		if _, err := data.Invitation(nil); err == nil {
+			t.Error("expected an error with an invalid email")
+		}
+	})
+}
+
+func TestImportResult(t *testing.T) {
+	res := NewImportResult(true)
+	res.Succeed()
+	res.Fail(2, utils.Pointer("janekin@powersso.io"), ErrDuplicateEmail())
+
+	if res.Total != 2 || res.Imported != 1 || res.Failed != 1 || len(res.Errors) != 1 || res.Errors[0].Row != 2 {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestExportWriter(t *testing.T) {
+	var (
+		userID    = uuid.New()
+		projectID = uuid.New()
+		createdAt = time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+		user      = &ExportedUser{
+			ID:        &userID,
+			Email:     utils.Pointer("janekin@powersso.io"),
+			FirstName: utils.Pointer("Janekin"),
+			LastName:  utils.Pointer("Skywalker"),
+			Level:     utils.Pointer(auth.UserLevel),
+			Active:    utils.Pointer(true),
+			CreatedAt: &createdAt,
+			Projects:  []ExportedProject{{ProjectID: &projectID, StartDate: &createdAt}},
+		}
+	)
+
+	write := func(format Format, users ...*ExportedUser) string {
+		var buf bytes.Buffer
+		writer := NewExportWriter(format, &buf)
+		for _, user := range users {
+			if err := writer.Write(user); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(write(FormatCSV, user, user)), "\n")
+		if len(lines) != 3 || lines[0] != strings.Join(exportColumns, ",") ||
+			!strings.HasPrefix(lines[1], userID.String()+",janekin@powersso.io,Janekin,Skywalker,user,true,,2023-01-02T03:04:05Z,") ||
+			!strings.Contains(lines[1], projectID.String()) {
+			t.Errorf("unexpected file %q", lines)
+		}
+
+		if file := write(FormatCSV); file != strings.Join(exportColumns, ",")+"\n" {
+			t.Errorf("expected only the header, got %q", file)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var users []ExportedUser
+		if err := json.Unmarshal([]byte(write(FormatJSON, user, user)), &users); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(users) != 2 || *users[1].ID != userID || *users[1].Projects[0].ProjectID != projectID {
+			t.Errorf("unexpected users %+v", users)
+		}
+
+		if file := write(FormatJSON); file != "[]\n" {
+			t.Errorf("expected an empty array, got %q", file)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrProviderNotFound creates and returns an error when the provider is not configured
+func ErrProviderNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_provider_not_found"), http.StatusNotFound)
+}
+
+// ErrProviderUnavailable creates and returns an error when the provider cannot be reached
+func ErrProviderUnavailable() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_provider_unavailable"), http.StatusServiceUnavailable)
+}
+
+// ErrStateInvalid creates and returns an error when the sign in was not started here, has expired or was already finished
+func ErrStateInvalid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_state_invalid"), http.StatusBadRequest)
+}
+
+// ErrAuthorizationFailed creates and returns an error when the provider does not authenticate the user
+func ErrAuthorizationFailed() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_authorization_failed"), http.StatusUnauthorized)
+}
+
+// ErrEmailNotVerified creates and returns an error when the provider does not give a verified email
+func ErrEmailNotVerified() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_email_not_verified"), http.StatusForbidden)
+}
+
+// ErrDomainNotAllowed creates and returns an error when the domain of the email cannot sign in with the provider
+func ErrDomainNotAllowed() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_domain_not_allowed"), http.StatusForbidden)
+}
+
+// ErrIdentityNotLinked creates and returns an error when the identity is not linked to a user and cannot create one
+func ErrIdentityNotLinked() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_not_linked"), http.StatusForbidden)
+}
+
+// ErrIdentityLinked creates and returns an error when the identity is already linked to another user
+func ErrIdentityLinked() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_linked"), http.StatusConflict)
+}
+
+// ErrIdentityNotFound creates and returns an error when the identity is not linked to the user
+func ErrIdentityNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_identity_not_found"), http.StatusNotFound)
+}
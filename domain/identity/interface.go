@@ -0,0 +1,23 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import "github.com/google/uuid"
+
+// IIdentity define an interface for data layer access methods
+type IIdentity interface {
+	Get(provider, subject *string) (*Identity, error)
+	Create(*Identity) (*uuid.UUID, error)
+	Touch(identityID *uuid.UUID, email *string) error
+	List(userID *uuid.UUID) ([]Identity, error)
+	Delete(userID, identityID *uuid.UUID) (*Identity, error)
+}
+
+// IState define an interface for data layer access methods
+type IState interface {
+	Create(*State) (*uuid.UUID, error)
+	Use(stateID *uuid.UUID, provider *string) (*State, error)
+	DeleteExpired() (int64, error)
+}
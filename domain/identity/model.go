@@ -0,0 +1,245 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const (
+	// nonceLength is the size of the nonce the provider returns in the id token
+	nonceLength = 32
+	// verifierLength is the size of the PKCE code verifier, between the 43 and 128 characters of the RFC 7636
+	verifierLength = 64
+	// bindingLength is the size of the value that binds the sign in to the browser that started it
+	bindingLength = 32
+)
+
+// Purpose set data type to what a sign in with a provider is made for
+type Purpose string
+
+const (
+	// PurposeLogin opens a session for the user of the identity
+	PurposeLogin Purpose = "login"
+	// PurposeLink links the identity to the account of the user that started the sign in
+	PurposeLink Purpose = "link"
+	// PurposeConfirm confirms a sensitive action of the user that started the sign in, for the users
+	// that sign in with the provider and do not know the password of the account
+	PurposeConfirm Purpose = "confirm"
+)
+
+// Provider models an external provider the users can sign in with
+type Provider struct {
+	Name        *string `json:"name"`
+	DisplayName *string `json:"display_name"`
+}
+
+// NewProviders returns the providers of the configuration
+func NewProviders(cfg *config.OIDCConfig) []Provider {
+	providers := make([]Provider, 0, len(cfg.Providers))
+	for i := range cfg.Providers {
+		providers = append(providers, Provider{
+			Name:        &cfg.Providers[i].Name,
+			DisplayName: &cfg.Providers[i].DisplayName,
+		})
+	}
+	return providers
+}
+
+// GetProvider returns the configuration of the provider with the name
+func GetProvider(cfg *config.OIDCConfig, name string) (*config.OIDCProviderConfig, error) {
+	for i := range cfg.Providers {
+		if cfg.Providers[i].Name == name {
+			return &cfg.Providers[i], nil
+		}
+	}
+	return nil, ErrProviderNotFound()
+}
+
+// RedirectURL returns the address of the callback of the provider
+func RedirectURL(cfg *config.OIDCConfig, provider string) string {
+	return strings.ReplaceAll(cfg.RedirectURL, "{provider}", provider)
+}
+
+// Identity models an identity of an external provider linked to a user
+type Identity struct {
+	ID          *uuid.UUID `sql:"id" json:"id"`
+	UserID      *uuid.UUID `sql:"user_id" json:"-"`
+	Provider    *string    `sql:"provider" json:"provider"`
+	Subject     *string    `sql:"subject" json:"subject"`
+	Email       *string    `sql:"email" json:"email,omitempty"`
+	CreatedAt   *time.Time `sql:"created_at" json:"created_at"`
+	LastLoginAt *time.Time `sql:"last_login_at" json:"last_login_at,omitempty"`
+}
+
+// State models a sign in started with a provider, which can be finished only once before it expires.
+// The user is filled when the sign in links the identity to the account of the user or confirms an action
+// of the user. The binding is kept in a cookie of the browser that started the sign in and must be sent back
+// in the callback
+type State struct {
+	ID        *uuid.UUID
+	Provider  *string
+	Purpose   *Purpose
+	UserID    *uuid.UUID
+	Nonce     *string
+	Verifier  *string
+	Binding   *string
+	ExpiresAt *time.Time
+}
+
+// NewState creates the state of a sign in with the provider, with a random nonce and PKCE code verifier
+func NewState(provider string, purpose Purpose, userID *uuid.UUID, duration time.Duration) *State {
+	return &State{
+		Provider:  &provider,
+		Purpose:   &purpose,
+		UserID:    userID,
+		Nonce:     utils.Pointer(utils.RandomString(nonceLength)),
+		Verifier:  utils.Pointer(utils.RandomString(verifierLength)),
+		Binding:   utils.Pointer(utils.RandomString(bindingLength)),
+		ExpiresAt: utils.Pointer(time.Now().Add(duration)),
+	}
+}
+
+// IsLink returns if the sign in links the identity to the account of a user
+func (s *State) IsLink() bool {
+	return s.is(PurposeLink)
+}
+
+// IsConfirm returns if the sign in confirms an action of a user
+func (s *State) IsConfirm() bool {
+	return s.is(PurposeConfirm)
+}
+
+// is checks the purpose of a sign in started by a user
+func (s *State) is(purpose Purpose) bool {
+	return s.UserID != nil && s.Purpose != nil && *s.Purpose == purpose
+}
+
+// BoundTo checks if the sign in was started by the browser that sent the binding
+func (s *State) BoundTo(binding *string) bool {
+	return s.Binding != nil && binding != nil && subtle.ConstantTimeCompare([]byte(*s.Binding), []byte(*binding)) == 1
+}
+
+// Challenge returns the S256 PKCE code challenge of the code verifier
+func (s *State) Challenge() string {
+	sum := sha256.Sum256([]byte(*s.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Claims models the claims of the id token returned by the provider
+type Claims struct {
+	Subject       *string `json:"sub"`
+	Email         *string `json:"email"`
+	EmailVerified *bool   `json:"email_verified"`
+	Name          *string `json:"name"`
+	GivenName     *string `json:"given_name"`
+	FamilyName    *string `json:"family_name"`
+}
+
+// Identity returns the identity of the claims in the provider
+func (c *Claims) Identity(provider string) *Identity {
+	identity := &Identity{Provider: &provider, Subject: c.Subject}
+	if c.Email != nil {
+		identity.Email = utils.Pointer(strings.ToLower(strings.TrimSpace(*c.Email)))
+	}
+	return identity
+}
+
+// Validate checks that the email of the claims is verified and has a domain allowed by the provider
+func (c *Claims) Validate(cfg *config.OIDCProviderConfig) error {
+	if c.Email == nil || !auth.IsValidEmail(*c.Email) {
+		return ErrEmailNotVerified()
+	}
+
+	if !cfg.TrustEmail && (c.EmailVerified == nil || !*c.EmailVerified) {
+		return ErrEmailNotVerified()
+	}
+
+	if len(cfg.AllowedDomains) == 0 {
+		return nil
+	}
+
+	domain := (*c.Email)[strings.LastIndex(*c.Email, "@")+1:]
+	for _, allowed := range cfg.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return nil
+		}
+	}
+
+	return ErrDomainNotAllowed()
+}
+
+// Account returns the data to register the account of the user of the claims. The names are taken
+// from the full name when the provider does not give the given and the family names
+func (c *Claims) Account() *auth.CreateAccount {
+	firstName, lastName := c.GivenName, c.FamilyName
+	if (firstName == nil || lastName == nil) && c.Name != nil {
+		if names := strings.Fields(*c.Name); len(names) > 1 {
+			firstName = utils.Pointer(names[0])
+			lastName = utils.Pointer(strings.Join(names[1:], " "))
+		}
+	}
+	return auth.NewExternalAccount(c.Email, firstName, lastName)
+}
+
+// Callback models the response of the provider at the end of the sign in
+type Callback struct {
+	Code  *string `form:"code"`
+	State *string `form:"state"`
+	// Error is filled when the user refuses to sign in or the provider fails
+	Error     *string `form:"error"`
+	ClientIP  *string `form:"-"`
+	UserAgent *string `form:"-"`
+	// Binding is the value of the cookie of the browser that started the sign in
+	Binding *string `form:"-"`
+}
+
+// Result models the result of a sign in with a provider, the session opened
+// for the user or the identity linked to the account of the user
+type Result struct {
+	Session      *auth.Session `json:"session,omitempty"`
+	Identity     *Identity     `json:"identity,omitempty"`
+	Challenge    *Challenge    `json:"challenge,omitempty"`
+	Confirmation *Confirmation `json:"confirmation,omitempty"`
+}
+
+// Confirmation models the token received by the user that signed in again with the provider to confirm
+// an action, which is informed instead of the password
+type Confirmation struct {
+	Token *uuid.UUID `json:"confirmation_token"`
+}
+
+// Challenge models the 2FA step of a sign in with a provider. The session is only opened when the code
+// of the 2FA is informed with the token. The users created by the sign in receive the qrcode to enroll the 2FA
+type Challenge struct {
+	Token  *string `json:"otp_token"`
+	QRCode *string `json:"qrcode_url,omitempty"`
+}
+
+// OTP models the code of the 2FA informed to finish a sign in with a provider
+type OTP struct {
+	Code *string `json:"otp" binding:"required"`
+	// EndSessionID is the session chosen to be ended when the user reaches the maximum number of sessions
+	EndSessionID *uuid.UUID `json:"end_session_id,omitempty"`
+	ClientIP     *string    `json:"-"`
+	UserAgent    *string    `json:"-"`
+}
+
+// Authorization models the address the user is sent to sign in with the provider
+type Authorization struct {
+	URL *string `json:"authorization_url"`
+	// Binding is kept in a cookie of the browser, sent back in the callback
+	Binding *string `json:"-"`
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func isError(err error, expected *oops.Error) bool {
+	var e *oops.Error
+	return errors.As(err, &e) && e.Message == expected.Message && e.Code == expected.Code
+}
+
+func TestState(t *testing.T) {
+	state := NewState("google", PurposeLogin, nil, time.Minute)
+	if state.IsLink() {
+		t.Error("expected a state of a login")
+	}
+
+	if len(*state.Nonce) != nonceLength || len(*state.Verifier) != verifierLength {
+		t.Errorf("unexpected nonce or verifier: %s %s", *state.Nonce, *state.Verifier)
+	}
+
+	if !state.ExpiresAt.After(time.Now()) {
+		t.Error("expected a state that has not expired")
+	}
+
+	if !NewState("google", PurposeLink, utils.Pointer(uuid.New()), time.Minute).IsLink() {
+		t.Error("expected a state of a link")
+	}
+
+	if confirm := NewState("google", PurposeConfirm, utils.Pointer(uuid.New()), time.Minute); !confirm.IsConfirm() || confirm.IsLink() {
+		t.Error("expected a state of a confirmation")
+	}
+
+	if !state.BoundTo(utils.Pointer(*state.Binding)) || state.BoundTo(utils.Pointer("another browser")) || state.BoundTo(nil) {
+		t.Error("expected the state to be bound only to the browser that started it")
+	}
+
+	// example of the appendix B of the RFC 7636
+	state.Verifier = utils.Pointer("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if challenge := state.Challenge(); challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("unexpected challenge: %s", challenge)
+	}
+}
+
+func TestProviders(t *testing.T) {
+	cfg := &config.OIDCConfig{
+		RedirectURL: "http://localhost:5000/v1/auth/oidc/{provider}/callback",
+		Providers:   []config.OIDCProviderConfig{{Name: "google", DisplayName: "Google"}, {Name: "gitlab", DisplayName: "GitLab"}},
+	}
+
+	if providers := NewProviders(cfg); len(providers) != 2 || *providers[1].Name != "gitlab" {
+		t.Errorf("unexpected providers: %v", providers)
+	}
+
+	if provider, err := GetProvider(cfg, "gitlab"); err != nil || provider.DisplayName != "GitLab" {
+		t.Errorf("unexpected provider: %v %v", provider, err)
+	}
+
+	if _, err := GetProvider(cfg, "azure"); !isError(err, ErrProviderNotFound()) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if url := RedirectURL(cfg, "google"); url != "http://localhost:5000/v1/auth/oidc/google/callback" {
+		t.Errorf("unexpected redirect url: %s", url)
+	}
+}
+
+func TestClaimsValidate(t *testing.T) {
+	cfg := &config.OIDCProviderConfig{AllowedDomains: []string{"powersso.io"}}
+
+	for _, test := range []struct {
+		claims   Claims
+		trust    bool
+		expected *oops.Error
+	}{
+		{claims: Claims{Email: utils.Pointer("jane@PowerSSO.io"), EmailVerified: utils.Pointer(true)}},
+		{claims: Claims{Email: utils.Pointer("jane@powersso.io")}, trust: true},
+		{claims: Claims{Email: utils.Pointer("jane@powersso.io")}, expected: ErrEmailNotVerified()},
+		{claims: Claims{Email: utils.Pointer("jane@powersso.io"), EmailVerified: utils.Pointer(false)}, expected: ErrEmailNotVerified()},
+		{claims: Claims{EmailVerified: utils.Pointer(true)}, expected: ErrEmailNotVerified()},
+		{claims: Claims{Email: utils.Pointer("jane@example.com"), EmailVerified: utils.Pointer(true)}, expected: ErrDomainNotAllowed()},
+		{claims: Claims{Email: utils.Pointer("jane@evil.io@powersso.io"), EmailVerified: utils.Pointer(true)}, expected: ErrEmailNotVerified()},
+	} {
+		cfg.TrustEmail = test.trust
+
+		err := test.claims.Validate(cfg)
+		if (test.expected == nil && err != nil) || (test.expected != nil && !isError(err, test.expected)) {
+			t.Errorf("unexpected error of %v: %v", test.claims.Email, err)
+		}
+	}
+
+	cfg.AllowedDomains = nil
+	if err := (&Claims{Email: utils.Pointer("jane@example.com"), EmailVerified: utils.Pointer(true)}).Validate(cfg); err != nil {
+		t.Errorf("unexpected error without allowed domains: %v", err)
+	}
+}
+
+func TestClaimsAccount(t *testing.T) {
+	claims := &Claims{
+		Subject:   utils.Pointer("248289761001"),
+		Email:     utils.Pointer(" Jane@PowerSSO.io"),
+		Name:      utils.Pointer("Jane van der Doe"),
+		GivenName: utils.Pointer("Jane"),
+	}
+
+	account := claims.Account()
+	if *account.Email != "jane@powersso.io" || *account.FirstName != "Jane" || *account.LastName != "van der Doe" {
+		t.Errorf("unexpected account: %s %s %s", *account.Email, *account.FirstName, *account.LastName)
+	}
+
+	if err := account.Validate(); err != nil {
+		t.Errorf("unexpected error validating the account: %v", err)
+	}
+
+	claims.GivenName, claims.FamilyName = utils.Pointer("Maximiliana Alexandrina"), utils.Pointer("Doe")
+	if account = claims.Account(); *account.FirstName != "Maximiliana Alexandr" || *account.LastName != "Doe" {
+		t.Errorf("unexpected names: %s %s", *account.FirstName, *account.LastName)
+	}
+
+	identity := claims.Identity("google")
+	if *identity.Provider != "google" || *identity.Subject != "248289761001" || *identity.Email != "jane@powersso.io" {
+		t.Errorf("unexpected identity: %v", identity)
+	}
+}
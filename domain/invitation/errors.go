@@ -0,0 +1,37 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrInvitationNotFound creates and returns an error when the invitation does not exist or is no longer pending
+func ErrInvitationNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invitation_not_found"), http.StatusNotFound)
+}
+
+// ErrInvitationUnavailable creates and returns an error when the server cannot send the invitation
+func ErrInvitationUnavailable() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invitation_unavailable"), http.StatusServiceUnavailable)
+}
+
+// ErrProjectNotFound creates and returns an error when a project of the invitation does not exist
+func ErrProjectNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invitation_project_not_found"), http.StatusNotFound)
+}
+
+// ErrDuplicateProject creates and returns an error when a project is informed more than once
+func ErrDuplicateProject() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invitation_duplicate_project"), http.StatusBadRequest)
+}
+
+// ErrInvalidDepartureDate creates and returns an error when the departure date is before the start date
+func ErrInvalidDepartureDate() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_invitation_invalid_departure_date"), http.StatusBadRequest)
+}
@@ -0,0 +1,22 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// IInvitation define an interface for data layer access methods
+type IInvitation interface {
+	Create(*CreateInvitation) (*uuid.UUID, error)
+	AddProject(invitationID *uuid.UUID, project *Project) error
+	RevokePending(email *string) error
+	List(params *utils.Params) ([]Invitation, *bool, error)
+	Revoke(invitationID *uuid.UUID) error
+	Use(invitationID *uuid.UUID) (*Invitation, error)
+	Join(invitationID, userID *uuid.UUID) ([]Participation, error)
+}
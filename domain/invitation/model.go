@@ -0,0 +1,165 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Status set data type to the state of an invitation
+type Status string
+
+const (
+	// StatusPending is the status of the invitations waiting to be accepted
+	StatusPending Status = "pending"
+	// StatusAccepted is the status of the invitations accepted by the invitee
+	StatusAccepted Status = "accepted"
+	// StatusRevoked is the status of the invitations revoked by an administrator
+	StatusRevoked Status = "revoked"
+	// StatusExpired is the status of the invitations not accepted in time
+	StatusExpired Status = "expired"
+)
+
+// IsValid returns if the status exists
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusPending, StatusAccepted, StatusRevoked, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// Filters accepted in the query of the invitations
+const (
+	FilterStatus string = "status"
+	FilterEmail  string = "email"
+)
+
+// ValidateParams checks the format of the filters of the invitations
+func ValidateParams(p *utils.Params) error {
+	for _, value := range p.Filters[FilterStatus] {
+		if !Status(value).IsValid() {
+			return auth.ErrInvalidFilter()
+		}
+	}
+
+	return nil
+}
+
+// Invitation models an invitation sent by an administrator to create the account of a user
+type Invitation struct {
+	ID         *uuid.UUID  `json:"id" sql:"id"`
+	Email      *string     `json:"email" sql:"email"`
+	FirstName  *string     `json:"first_name" sql:"first_name"`
+	LastName   *string     `json:"last_name" sql:"last_name"`
+	Level      *auth.Level `json:"level" sql:"level"`
+	CreatedBy  *uuid.UUID  `json:"created_by" sql:"created_by"`
+	UserID     *uuid.UUID  `json:"user_id,omitempty" sql:"user_id"`
+	ExpiresAt  *time.Time  `json:"expires_at" sql:"expires_at"`
+	AcceptedAt *time.Time  `json:"accepted_at,omitempty" sql:"accepted_at"`
+	RevokedAt  *time.Time  `json:"revoked_at,omitempty" sql:"revoked_at"`
+	CreatedAt  *time.Time  `json:"created_at" sql:"created_at"`
+
+	Status *Status `json:"status" ignore:"true"`
+}
+
+// Prepare computes the status of the invitation
+func (i *Invitation) Prepare() {
+	switch {
+	case i.AcceptedAt != nil:
+		i.Status = utils.Pointer(StatusAccepted)
+	case i.RevokedAt != nil:
+		i.Status = utils.Pointer(StatusRevoked)
+	case !i.ExpiresAt.After(time.Now()):
+		i.Status = utils.Pointer(StatusExpired)
+	default:
+		i.Status = utils.Pointer(StatusPending)
+	}
+}
+
+// Invitations models a page of the invitations
+type Invitations struct {
+	Invitations []Invitation `json:"invitations"`
+	Next        *bool        `json:"next"`
+}
+
+// Project models a project the invitee joins when accepting the invitation
+type Project struct {
+	ProjectID     *uuid.UUID `json:"project_id" binding:"required"`
+	StartDate     *time.Time `json:"start_date" binding:"required"`
+	DepartureDate *time.Time `json:"departure_date,omitempty"`
+}
+
+// CreateInvitation models the data of an administrator to invite a user by email
+type CreateInvitation struct {
+	Email     *string     `json:"email" binding:"required,lte=60,email"`
+	FirstName *string     `json:"first_name" binding:"required,min=1,max=20"`
+	LastName  *string     `json:"last_name" binding:"required,min=1,max=32"`
+	Level     *auth.Level `json:"level"`
+	Projects  []Project   `json:"projects" binding:"dive"`
+	CreatedBy *uuid.UUID  `json:"-"`
+	ExpiresAt *time.Time  `json:"-"`
+}
+
+// Prepare prepares the data of the invitation, valid for the duration in seconds
+func (c *CreateInvitation) Prepare(duration int64) {
+	c.Email = utils.Pointer(strings.ToLower(strings.TrimSpace(*c.Email)))
+	c.ExpiresAt = utils.Pointer(time.Now().Add(time.Duration(duration) * time.Second))
+	if c.Level == nil {
+		c.Level = utils.Pointer(auth.UserLevel)
+	}
+}
+
+// Validate checks the level and the projects of the invitation
+func (c *CreateInvitation) Validate() error {
+	if c.Level != nil && !c.Level.IsValid() {
+		return auth.ErrLevelIsNotValid()
+	}
+
+	projects := make(map[uuid.UUID]bool, len(c.Projects))
+	for _, project := range c.Projects {
+		if projects[*project.ProjectID] {
+			return ErrDuplicateProject()
+		}
+		projects[*project.ProjectID] = true
+
+		if project.DepartureDate != nil && project.DepartureDate.Before(*project.StartDate) {
+			return ErrInvalidDepartureDate()
+		}
+	}
+
+	return nil
+}
+
+// AcceptInvitation models the data of the invitee to create the account with the invitation link
+type AcceptInvitation struct {
+	Token    *uuid.UUID `json:"token" binding:"required"`
+	Password *string    `json:"password" binding:"required,gte=6"`
+}
+
+// Account creates the account of the invitee with the data chosen by the administrator
+func (a *AcceptInvitation) Account(invitation *Invitation) *auth.CreateAccount {
+	return &auth.CreateAccount{
+		FirstName: invitation.FirstName,
+		LastName:  invitation.LastName,
+		Email:     invitation.Email,
+		Password:  a.Password,
+		Level:     invitation.Level,
+		CreatedBy: invitation.CreatedBy,
+	}
+}
+
+// Participation models a participation created when the invitation is accepted
+type Participation struct {
+	ProjectID     *uuid.UUID
+	StartDate     *time.Time
+	DepartureDate *time.Time
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestInvitationStatus(t *testing.T) {
+	var (
+		past   = time.Now().Add(-time.Hour)
+		future = time.Now().Add(time.Hour)
+	)
+
+	for expected, invitation := range map[Status]*Invitation{
+		StatusPending:  {ExpiresAt: &future},
+		StatusExpired:  {ExpiresAt: &past},
+		StatusAccepted: {ExpiresAt: &past, AcceptedAt: &past},
+		StatusRevoked:  {ExpiresAt: &future, RevokedAt: &past},
+	} {
+		if invitation.Prepare(); *invitation.Status != expected {
+			t.Errorf("expected the status %s, got %s", expected, *invitation.Status)
+		}
+	}
+}
+
+func TestCreateInvitationPrepare(t *testing.T) {
+	in := &CreateInvitation{Email: utils.Pointer(" Jane@PowerSSO.io ")}
+	in.Prepare(3600)
+
+	if *in.Email != "jane@powersso.io" {
+		t.Errorf("expected the email in lower case, got %s", *in.Email)
+	}
+
+	if *in.Level != auth.UserLevel {
+		t.Error("expected the user level when the level is not informed")
+	}
+
+	if !in.ExpiresAt.After(time.Now()) {
+		t.Error("expected the invitation to expire in the future")
+	}
+}
+
+func TestCreateInvitationValidate(t *testing.T) {
+	var (
+		projectID = uuid.New()
+		start     = time.Now()
+		before    = start.AddDate(0, 0, -1)
+	)
+
+	in := &CreateInvitation{Level: utils.Pointer(auth.Level("root"))}
+	if err := in.Validate(); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+
+	in = &CreateInvitation{Projects: []Project{
+		{ProjectID: &projectID, StartDate: &start},
+		{ProjectID: &projectID, StartDate: &start},
+	}}
+	if err := in.Validate(); err == nil {
+		t.Error("expected an error for a duplicate project")
+	}
+
+	in = &CreateInvitation{Projects: []Project{{ProjectID: &projectID, StartDate: &start, DepartureDate: &before}}}
+	if err := in.Validate(); err == nil {
+		t.Error("expected an error for a departure date before the start date")
+	}
+
+	in = &CreateInvitation{Projects: []Project{{ProjectID: &projectID, StartDate: &start}}}
+	if err := in.Validate(); err != nil {
+		t.Errorf("expected a valid invitation, got %v", err)
+	}
+}
+
+func TestAcceptInvitationAccount(t *testing.T) {
+	inviter := uuid.New()
+	invitation := &Invitation{
+		Email:     utils.Pointer("jane@powersso.io"),
+		FirstName: utils.Pointer("Jane"),
+		LastName:  utils.Pointer("Doe"),
+		Level:     utils.Pointer(auth.AdminLevel),
+		CreatedBy: &inviter,
+	}
+
+	account := (&AcceptInvitation{Password: utils.Pointer("any_password")}).Account(invitation)
+	if *account.CreatedBy != inviter || *account.Level != auth.AdminLevel || *account.Email != *invitation.Email {
+		t.Errorf("expected the account with the data of the invitation, got %+v", account)
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import "github.com/google/uuid"
+
+// IOutbox define an interface for data layer access methods
+type IOutbox interface {
+	Add(*Message) error
+	Claim(limit uint64, lease int64) ([]*Message, error)
+	Published(messageID *uuid.UUID) error
+	Failed(messageID *uuid.UUID, reason string) error
+	Release(messageIDs []*uuid.UUID) error
+}
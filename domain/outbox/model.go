@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Topics of the messages about the users
+const (
+	TopicUserCreated           string = "user.created"
+	TopicUserLoggedIn          string = "user.logged_in"
+	TopicUserPasswordChanged   string = "user.password_changed"
+	TopicUserDisabled          string = "user.disabled"
+	TopicUserEnabled           string = "user.enabled"
+	TopicUserUpdated           string = "user.updated"
+	TopicUserEmailChanged      string = "user.email_changed"
+	TopicUserUnlocked          string = "user.unlocked"
+	TopicUserSecured           string = "user.secured"
+	TopicUserDeletionRequested string = "user.deletion_requested"
+	TopicUserDeletionCancelled string = "user.deletion_cancelled"
+	TopicUserDeleted           string = "user.deleted"
+)
+
+// AggregateUser is the aggregate of the messages about the users
+const AggregateUser string = "user"
+
+// Message models an event written in the outbox in the same transaction of the change
+// that produced it, to be published by the relay after the commit
+type Message struct {
+	ID            *uuid.UUID       `json:"id" sql:"id"`
+	Topic         *string          `json:"topic" sql:"topic"`
+	AggregateType *string          `json:"aggregate_type" sql:"aggregate_type"`
+	AggregateID   *string          `json:"aggregate_id" sql:"aggregate_id"`
+	Payload       *json.RawMessage `json:"payload" sql:"payload"`
+	Attempts      *int64           `json:"attempts,omitempty" sql:"attempts"`
+	LastError     *string          `json:"last_error,omitempty" sql:"last_error"`
+	CreatedAt     *time.Time       `json:"created_at" sql:"created_at"`
+
+	// Data is the content of the event, encoded in the payload when the message is written
+	Data interface{} `json:"-" ignore:"true"`
+}
+
+// NewMessage creates a message of the topic about the aggregate
+func NewMessage(topic, aggregateType, aggregateID string, data interface{}) *Message {
+	id, now := uuid.New(), time.Now()
+	return &Message{
+		ID:            &id,
+		Topic:         &topic,
+		AggregateType: &aggregateType,
+		AggregateID:   &aggregateID,
+		CreatedAt:     &now,
+		Data:          data,
+	}
+}
+
+// NewUserMessage creates a message of the topic about the user
+func NewUserMessage(topic string, userID *uuid.UUID) *Message {
+	return NewMessage(topic, AggregateUser, userID.String(), &UserData{UserID: userID})
+}
+
+// Encode writes the envelope of the event in the payload of the message
+func (m *Message) Encode() error {
+	payload, err := json.Marshal(&envelope{
+		ID:         m.ID,
+		Topic:      m.Topic,
+		OccurredAt: m.CreatedAt,
+		Data:       m.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.Payload = (*json.RawMessage)(&payload)
+	return nil
+}
+
+// envelope models the payload published for each message
+type envelope struct {
+	ID         *uuid.UUID  `json:"id"`
+	Topic      *string     `json:"topic"`
+	OccurredAt *time.Time  `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// UserData models the data of the messages about a user
+type UserData struct {
+	UserID    *uuid.UUID `json:"user_id"`
+	Email     *string    `json:"email,omitempty"`
+	SessionID *uuid.UUID `json:"session_id,omitempty"`
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMessageEncode(t *testing.T) {
+	userID := uuid.New()
+
+	message := NewUserMessage(TopicUserDisabled, &userID)
+	if err := message.Encode(); err != nil {
+		t.Fatal(err)
+	}
+
+	if *message.AggregateType != AggregateUser || *message.AggregateID != userID.String() {
+		t.Error("expected the user as the aggregate of the message")
+	}
+
+	var payload struct {
+		ID    uuid.UUID `json:"id"`
+		Topic string    `json:"topic"`
+		Data  UserData  `json:"data"`
+	}
+	if err := json.Unmarshal(*message.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.ID != *message.ID || payload.Topic != TopicUserDisabled || *payload.Data.UserID != userID {
+		t.Errorf("expected the envelope of the message, got %+v", payload)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrPolicyNotFound creates and returns an error when the policy does not exist
+func ErrPolicyNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_policy_not_found"), http.StatusNotFound)
+}
+
+// ErrPolicyExists creates and returns an error when a policy with the name already exists
+func ErrPolicyExists() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_policy_exists"), http.StatusConflict)
+}
+
+// ErrPolicyIsNotValid creates and returns an error when the name or the events of the policy are not valid
+func ErrPolicyIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_policy_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrExpressionIsNotValid creates and returns an error when an expression of the policy cannot be parsed
+func ErrExpressionIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_policy_expression_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrRequestIsNotValid creates and returns an error when the event or the user of the dry run are not valid
+func ErrRequestIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_policy_request_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrAccessDenied creates and returns an error when a policy denies the request
+func ErrAccessDenied() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_access_denied_by_policy"), http.StatusForbidden)
+}
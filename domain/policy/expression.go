@@ -0,0 +1,483 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Operator set data type to the operators of the expressions
+type Operator string
+
+const (
+	OperatorEq      Operator = "eq"
+	OperatorNe      Operator = "ne"
+	OperatorCo      Operator = "co"
+	OperatorSw      Operator = "sw"
+	OperatorEw      Operator = "ew"
+	OperatorGt      Operator = "gt"
+	OperatorGe      Operator = "ge"
+	OperatorLt      Operator = "lt"
+	OperatorLe      Operator = "le"
+	OperatorIn      Operator = "in"
+	OperatorPresent Operator = "pr"
+	OperatorAnd     Operator = "and"
+	OperatorOr      Operator = "or"
+)
+
+// isComparison returns if the operator compares an attribute with a value
+func (o Operator) isComparison() bool {
+	switch o {
+	case OperatorEq, OperatorNe, OperatorCo, OperatorSw, OperatorEw, OperatorGt, OperatorGe, OperatorLt, OperatorLe, OperatorIn:
+		return true
+	}
+	return false
+}
+
+// scopes are the groups of the attributes the expressions can read
+var scopes = []string{ScopeUser, ScopeSession, ScopeRequest, ScopeProject}
+
+// Expression is a condition over the attributes of a request, in the syntax of the filters of SCIM,
+// like user.level eq "admin" and not (request.ip in ["10.0.0.0/8", "192.168.0.1"])
+type Expression interface {
+	// Matches returns if the attributes of the request match the expression
+	Matches(attributes Attributes) bool
+}
+
+// Comparison models the comparison of an attribute with a value, the value is nil on the presence
+// operator and a list of values on the in operator
+type Comparison struct {
+	Path     string
+	Operator Operator
+	Value    interface{}
+}
+
+// Logical models the combination of two expressions with and or or
+type Logical struct {
+	Operator    Operator
+	Left, Right Expression
+}
+
+// Not models the negation of an expression
+type Not struct {
+	Expression Expression
+}
+
+// ParseExpression parses an expression, whose attributes must be of the scopes of the requests
+func ParseExpression(expression string) (Expression, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.done() {
+		return nil, ErrExpressionIsNotValid()
+	}
+
+	return expr, nil
+}
+
+// token models a word, a string or a bracket of the expression
+type token struct {
+	value  string
+	quoted bool
+}
+
+// tokenize splits the expression in words, strings, brackets and commas
+func tokenize(expression string) (tokens []token, err error) {
+	for i := 0; i < len(expression); {
+		switch c := expression[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.IndexByte("()[],", c) >= 0:
+			tokens = append(tokens, token{value: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(expression) && expression[end] != '"'; end++ {
+				if expression[end] == '\\' {
+					end++
+				}
+			}
+
+			if end >= len(expression) {
+				return nil, ErrExpressionIsNotValid()
+			}
+
+			var value string
+			if err = json.Unmarshal([]byte(expression[i:end+1]), &value); err != nil {
+				return nil, ErrExpressionIsNotValid()
+			}
+
+			tokens = append(tokens, token{value: value, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(expression) && strings.IndexByte(" \t\n\r()[],\"", expression[end]) < 0 {
+				end++
+			}
+			tokens = append(tokens, token{value: expression[i:end]})
+			i = end
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, ErrExpressionIsNotValid()
+	}
+
+	return
+}
+
+// parser reads the tokens with the precedence of the operators, from the lowest: or, and, not
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.position++
+	return t
+}
+
+// keyword returns if the next token is the keyword, consuming it
+func (p *parser) keyword(value string) bool {
+	if t := p.peek(); !t.quoted && strings.EqualFold(t.value, value) {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (Expression, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword(string(OperatorOr)) {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Operator: OperatorOr, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) and() (Expression, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword(string(OperatorAnd)) {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Operator: OperatorAnd, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) unary() (Expression, error) {
+	if p.keyword("not") {
+		if !p.keyword("(") {
+			return nil, ErrExpressionIsNotValid()
+		}
+		return p.group(func(expr Expression) Expression { return &Not{Expression: expr} })
+	}
+
+	if p.keyword("(") {
+		return p.group(func(expr Expression) Expression { return expr })
+	}
+
+	return p.comparison()
+}
+
+// group reads the expression until the closing parenthesis
+func (p *parser) group(wrap func(Expression) Expression) (Expression, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.keyword(")") {
+		return nil, ErrExpressionIsNotValid()
+	}
+
+	return wrap(expr), nil
+}
+
+// comparison reads the comparison of an attribute with a value or a list of values
+func (p *parser) comparison() (Expression, error) {
+	name := p.next()
+	if name.quoted || !validPath(name.value) {
+		return nil, ErrExpressionIsNotValid()
+	}
+	path := strings.ToLower(name.value)
+
+	operator := Operator(strings.ToLower(p.next().value))
+	if operator == OperatorPresent {
+		return &Comparison{Path: path, Operator: operator}, nil
+	}
+
+	if !operator.isComparison() || p.done() {
+		return nil, ErrExpressionIsNotValid()
+	}
+
+	if operator == OperatorIn {
+		values, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		return &Comparison{Path: path, Operator: operator, Value: values}, nil
+	}
+
+	value, err := literal(p.next())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comparison{Path: path, Operator: operator, Value: value}, nil
+}
+
+// list reads a list of values between brackets, or a single value as a list of one value
+func (p *parser) list() (values []interface{}, err error) {
+	if !p.keyword("[") {
+		var value interface{}
+		if value, err = literal(p.next()); err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	}
+
+	for {
+		if p.done() {
+			return nil, ErrExpressionIsNotValid()
+		}
+
+		var value interface{}
+		if value, err = literal(p.next()); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		if p.keyword("]") {
+			return values, nil
+		}
+
+		if !p.keyword(",") {
+			return nil, ErrExpressionIsNotValid()
+		}
+	}
+}
+
+// literal converts the token of a value to a string, a boolean, a number or nil
+func literal(t token) (interface{}, error) {
+	if t.quoted {
+		return t.value, nil
+	}
+
+	switch strings.ToLower(t.value) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	number, err := strconv.ParseFloat(t.value, 64)
+	if err != nil {
+		return nil, ErrExpressionIsNotValid()
+	}
+
+	return number, nil
+}
+
+// validPath returns if the value is the name of an attribute of one of the scopes, like request.ip
+func validPath(value string) bool {
+	scope, name, found := strings.Cut(strings.ToLower(value), ".")
+	if !found || name == "" {
+		return false
+	}
+
+	valid := false
+	for _, s := range scopes {
+		valid = valid || scope == s
+	}
+
+	for _, c := range name {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && strings.IndexRune("_.", c) < 0 {
+			return false
+		}
+	}
+
+	return valid
+}
+
+// Matches returns if the attribute, or any value of a multi-valued attribute, matches the comparison
+func (c *Comparison) Matches(attributes Attributes) bool {
+	values := attributes.lookup(c.Path)
+
+	if c.Operator == OperatorPresent {
+		for _, value := range values {
+			if !empty(value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if c.Operator == OperatorNe {
+		return !(&Comparison{Path: c.Path, Operator: OperatorEq, Value: c.Value}).Matches(attributes)
+	}
+
+	if len(values) == 0 {
+		values = []interface{}{nil}
+	}
+
+	for _, value := range values {
+		if compare(value, c.Operator, c.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches returns if the expressions match with and or or
+func (l *Logical) Matches(attributes Attributes) bool {
+	if l.Operator == OperatorAnd {
+		return l.Left.Matches(attributes) && l.Right.Matches(attributes)
+	}
+	return l.Left.Matches(attributes) || l.Right.Matches(attributes)
+}
+
+// Matches returns if the expression does not match
+func (n *Not) Matches(attributes Attributes) bool {
+	return !n.Expression.Matches(attributes)
+}
+
+func empty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	}
+	return false
+}
+
+// compare compares a value of the attributes with the value of the expression, the strings ignoring the case.
+// On the in operator, an address matches a network of the list written in the CIDR notation
+func compare(value interface{}, operator Operator, expected interface{}) bool {
+	if operator == OperatorIn {
+		for _, item := range expected.([]interface{}) {
+			if network, ok := item.(string); ok && strings.Contains(network, "/") {
+				if address, ok := value.(string); ok && contains(network, address) {
+					return true
+				}
+				continue
+			}
+
+			if compare(value, OperatorEq, item) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch v := value.(type) {
+	case string:
+		text, ok := expected.(string)
+		if !ok {
+			return false
+		}
+
+		v, text = strings.ToLower(v), strings.ToLower(text)
+		switch operator {
+		case OperatorEq:
+			return v == text
+		case OperatorCo:
+			return strings.Contains(v, text)
+		case OperatorSw:
+			return strings.HasPrefix(v, text)
+		case OperatorEw:
+			return strings.HasSuffix(v, text)
+		case OperatorGt:
+			return v > text
+		case OperatorGe:
+			return v >= text
+		case OperatorLt:
+			return v < text
+		case OperatorLe:
+			return v <= text
+		}
+
+	case float64:
+		number, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+
+		switch operator {
+		case OperatorEq:
+			return v == number
+		case OperatorGt:
+			return v > number
+		case OperatorGe:
+			return v >= number
+		case OperatorLt:
+			return v < number
+		case OperatorLe:
+			return v <= number
+		}
+
+	case bool:
+		return operator == OperatorEq && v == expected
+
+	case nil:
+		return operator == OperatorEq && expected == nil
+	}
+
+	return false
+}
+
+// contains returns if the address is in the network written in the CIDR notation
+func contains(network, address string) bool {
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(address)
+	return ip != nil && ipNet.Contains(ip)
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import "github.com/google/uuid"
+
+// IPolicy define an interface for data layer access methods
+type IPolicy interface {
+	List(event *Event) ([]Policy, error)
+	Get(policyID *uuid.UUID) (*Policy, error)
+	Create(*Policy) error
+	Update(*Policy) error
+	Delete(policyID *uuid.UUID) error
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxNameLength is the size of the names of the policies
+const maxNameLength = 64
+
+// Event set data type to the moments the policies are evaluated
+type Event string
+
+const (
+	// EventLogin is evaluated when a user logs in, with a password, the directory or an identity provider
+	EventLogin Event = "login"
+	// EventToken is evaluated when the token of a user is introspected for a project, the moment the
+	// project receives the roles of the user
+	EventToken Event = "token"
+)
+
+// IsValid checks if the event is one of the moments the policies are evaluated
+func (e Event) IsValid() bool {
+	return e == EventLogin || e == EventToken
+}
+
+// Scopes of the attributes of the requests
+const (
+	// ScopeUser has the attributes of the user: id, email, level, otp, when the session is authenticated
+	// by the 2FA, directory and roles
+	ScopeUser string = "user"
+	// ScopeSession has the attributes of the sessions of the user: id, on tokens, method, how the session
+	// is authenticated, and active
+	ScopeSession string = "session"
+	// ScopeRequest has the attributes of the request: ip, user_agent, time, hour, weekday and date
+	ScopeRequest string = "request"
+	// ScopeProject has the attributes of the project of the token: id, participant, roles and permissions
+	ScopeProject string = "project"
+)
+
+// Policy models a conditional access policy. The requests of the events the target matches, all of them
+// when the target is empty, are denied when they do not match the condition
+type Policy struct {
+	ID          *uuid.UUID `json:"id"`
+	Name        *string    `json:"name" binding:"required"`
+	Description *string    `json:"description,omitempty"`
+	Events      []Event    `json:"events" binding:"required,min=1"`
+	Target      *string    `json:"target,omitempty"`
+	Condition   *string    `json:"condition" binding:"required"`
+	Active      *bool      `json:"active"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the name, the events and the expressions of the policy, which is active when not informed
+func (p *Policy) Validate() error {
+	if p.Name == nil || strings.TrimSpace(*p.Name) == "" || len(strings.TrimSpace(*p.Name)) > maxNameLength {
+		return ErrPolicyIsNotValid()
+	}
+	*p.Name = strings.TrimSpace(*p.Name)
+
+	if len(p.Events) == 0 {
+		return ErrPolicyIsNotValid()
+	}
+
+	for _, event := range p.Events {
+		if !event.IsValid() {
+			return ErrPolicyIsNotValid()
+		}
+	}
+
+	if p.Target != nil && strings.TrimSpace(*p.Target) == "" {
+		p.Target = nil
+	}
+
+	if p.Target != nil {
+		if _, err := ParseExpression(*p.Target); err != nil {
+			return err
+		}
+	}
+
+	if p.Condition == nil {
+		return ErrExpressionIsNotValid()
+	}
+
+	if _, err := ParseExpression(*p.Condition); err != nil {
+		return err
+	}
+
+	if p.Active == nil {
+		p.Active = new(bool)
+		*p.Active = true
+	}
+
+	return nil
+}
+
+// IsActive returns if the policy is enforced, the inactive ones are only reported on the dry runs
+func (p *Policy) IsActive() bool {
+	return p.Active != nil && *p.Active
+}
+
+// Attributes models the attributes of a request by scope, read by the expressions as scope.name
+type Attributes map[string]map[string]interface{}
+
+// lookup returns the values of the attribute of the path, flattening the multi-valued attributes
+func (a Attributes) lookup(path string) []interface{} {
+	scope, name, _ := strings.Cut(path, ".")
+
+	value, ok := a[scope][name]
+	if !ok || value == nil {
+		return nil
+	}
+
+	if values, ok := value.([]interface{}); ok {
+		return values
+	}
+
+	return []interface{}{value}
+}
+
+// UserAttributes models the attributes of the user of a request
+type UserAttributes struct {
+	ID        *uuid.UUID
+	Email     *string
+	Level     *string
+	OTP       bool
+	Directory bool
+	Roles     []string
+}
+
+// ProjectAttributes models the attributes of the project of a token and of the participation of the user
+type ProjectAttributes struct {
+	ID          *uuid.UUID
+	Roles       []string
+	Permissions []string
+}
+
+// Request models a request evaluated by the policies, on the events or on the dry runs
+type Request struct {
+	Event     *Event     `json:"event" binding:"required"`
+	UserID    *uuid.UUID `json:"user_id" binding:"required"`
+	SessionID *uuid.UUID `json:"session_id,omitempty"`
+	Method    *string    `json:"method,omitempty"`
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+	ClientIP  *string    `json:"ip,omitempty"`
+	UserAgent *string    `json:"user_agent,omitempty"`
+	Time      *time.Time `json:"time,omitempty"`
+}
+
+// Validate checks the event and the user of the request, which is made now when the time is not informed
+func (r *Request) Validate() error {
+	if r.Event == nil || !r.Event.IsValid() || r.UserID == nil {
+		return ErrRequestIsNotValid()
+	}
+
+	if r.Time == nil {
+		now := time.Now()
+		r.Time = &now
+	}
+
+	return nil
+}
+
+// Attributes builds the attributes of the request, with the time in the location of the policies
+func (r *Request) Attributes(user *UserAttributes, sessions int64, project *ProjectAttributes, location *time.Location) Attributes {
+	at := r.Time.In(location)
+
+	attributes := Attributes{
+		ScopeUser: {
+			"id":        user.ID.String(),
+			"email":     value(user.Email),
+			"level":     value(user.Level),
+			"otp":       user.OTP,
+			"directory": user.Directory,
+			"roles":     list(user.Roles),
+		},
+		ScopeSession: {
+			"id":     nil,
+			"method": value(r.Method),
+			"active": float64(sessions),
+		},
+		ScopeRequest: {
+			"ip":         value(r.ClientIP),
+			"user_agent": value(r.UserAgent),
+			"time":       at.Format("15:04"),
+			"hour":       float64(at.Hour()),
+			"weekday":    strings.ToLower(at.Weekday().String()),
+			"date":       at.Format("2006-01-02"),
+		},
+		ScopeProject: {
+			"id":          nil,
+			"participant": false,
+			"roles":       list(nil),
+			"permissions": list(nil),
+		},
+	}
+
+	if r.SessionID != nil {
+		attributes[ScopeSession]["id"] = r.SessionID.String()
+	}
+
+	if project != nil {
+		attributes[ScopeProject]["id"] = project.ID.String()
+		attributes[ScopeProject]["participant"] = len(project.Roles) > 0
+		attributes[ScopeProject]["roles"] = list(project.Roles)
+		attributes[ScopeProject]["permissions"] = list(project.Permissions)
+	}
+
+	return attributes
+}
+
+func value(v *string) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func list(values []string) []interface{} {
+	items := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		items = append(items, v)
+	}
+	return items
+}
+
+// Result models the evaluation of a policy for a request
+type Result struct {
+	PolicyID *uuid.UUID `json:"policy_id"`
+	Name     *string    `json:"name"`
+	Active   bool       `json:"active"`
+	// Applies is if the target of the policy matches the request
+	Applies bool `json:"applies"`
+	// Satisfied is if the request matches the condition of the policy, or the policy does not apply to it
+	Satisfied bool    `json:"satisfied"`
+	Error     *string `json:"error,omitempty"`
+}
+
+// Decision models the decision of the policies for a request and how each policy was evaluated
+type Decision struct {
+	Allowed    bool       `json:"allowed"`
+	DeniedBy   *string    `json:"denied_by,omitempty"`
+	Results    []Result   `json:"policies"`
+	Attributes Attributes `json:"attributes,omitempty"`
+}
+
+// Evaluate evaluates the policies of the event, the request is denied by the first active policy
+// that applies to it and whose condition it does not match. The policies whose expressions
+// cannot be parsed deny the requests they are evaluated for
+func Evaluate(policies []Policy, event Event, attributes Attributes) *Decision {
+	decision := &Decision{Allowed: true, Results: make([]Result, 0, len(policies))}
+
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.hasEvent(event) {
+			continue
+		}
+
+		result := policy.evaluate(attributes)
+		decision.Results = append(decision.Results, result)
+
+		if result.Active && !result.Satisfied && decision.Allowed {
+			decision.Allowed, decision.DeniedBy = false, policy.Name
+		}
+	}
+
+	return decision
+}
+
+func (p *Policy) hasEvent(event Event) bool {
+	for _, e := range p.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) evaluate(attributes Attributes) Result {
+	result := Result{PolicyID: p.ID, Name: p.Name, Active: p.IsActive(), Applies: true, Satisfied: true}
+
+	if p.Target != nil {
+		target, err := ParseExpression(*p.Target)
+		if err != nil {
+			result.Satisfied, result.Error = false, new(string)
+			*result.Error = err.Error()
+			return result
+		}
+		result.Applies = target.Matches(attributes)
+	}
+
+	if !result.Applies {
+		return result
+	}
+
+	condition, err := ParseExpression(*p.Condition)
+	if err != nil {
+		result.Satisfied, result.Error = false, new(string)
+		*result.Error = err.Error()
+		return result
+	}
+
+	result.Satisfied = condition.Matches(attributes)
+	return result
+}
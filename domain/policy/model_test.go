@@ -0,0 +1,202 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func attributes() Attributes {
+	userID, projectID := uuid.New(), uuid.MustParse("5b1e3a63-4a4a-4d2b-a8e4-0f0a45f1d1a1")
+	request := &Request{
+		Event:     utils.Pointer(EventLogin),
+		UserID:    &userID,
+		Method:    utils.Pointer("password_otp"),
+		ClientIP:  utils.Pointer("10.1.2.3"),
+		UserAgent: utils.Pointer("Mozilla/5.0"),
+		Time:      utils.Pointer(time.Date(2023, 3, 6, 14, 30, 0, 0, time.UTC)),
+	}
+
+	return request.Attributes(&UserAttributes{
+		ID:    &userID,
+		Email: utils.Pointer("jane@powersso.io"),
+		Level: utils.Pointer("admin"),
+		OTP:   true,
+		Roles: []string{"admin", "support"},
+	}, 2, &ProjectAttributes{ID: &projectID, Roles: []string{"viewer"}}, time.UTC)
+}
+
+func TestParseExpression(t *testing.T) {
+	attributes := attributes()
+
+	for expression, expected := range map[string]bool{
+		`user.level eq "ADMIN"`: true,
+		`user.level eq "admin" and request.ip in ["10.0.0.0/8", "192.168.0.1"]`:     true,
+		`user.level eq "admin" and request.ip in ["172.16.0.0/12"]`:                 false,
+		`request.ip in "10.1.2.3"`:                                                  true,
+		`request.time ge "09:00" and request.time lt "18:00"`:                       true,
+		`request.weekday in ["saturday", "sunday"]`:                                 false,
+		`request.hour ge 9 and request.hour lt 14`:                                  false,
+		`user.roles eq "support"`:                                                   true,
+		`user.roles ne "support"`:                                                   false,
+		`not (user.otp eq true)`:                                                    false,
+		`project.id eq "5b1e3a63-4a4a-4d2b-a8e4-0f0a45f1d1a1" and user.otp eq true`: true,
+		`project.participant eq true or session.active gt 5`:                        true,
+		`session.id pr`:                    false,
+		`session.id eq null`:               true,
+		`session.method eq "password_otp"`: true,
+		`user.email ew "@powersso.io"`:     true,
+		`request.user_agent sw "curl"`:     false,
+	} {
+		parsed, err := ParseExpression(expression)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", expression, err)
+		}
+
+		if parsed.Matches(attributes) != expected {
+			t.Errorf("expected %q to match %v", expression, expected)
+		}
+	}
+}
+
+func TestParseExpressionInvalid(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`user.level`,
+		`user.level eq`,
+		`level eq "admin"`,
+		`device.os eq "linux"`,
+		`user.level xx "admin"`,
+		`user.level eq "admin" and`,
+		`(user.level eq "admin"`,
+		`request.ip in ["10.0.0.0/8"`,
+		`request.ip in ["10.0.0.0/8" "192.168.0.1"]`,
+		`user.level eq "admin`,
+	} {
+		if _, err := ParseExpression(expression); !isError(err, ErrExpressionIsNotValid()) {
+			t.Errorf("expected an error parsing %q, got %v", expression, err)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		policy := &Policy{
+			Name:      utils.Pointer("  Admins from the office "),
+			Events:    []Event{EventLogin},
+			Target:    utils.Pointer(" "),
+			Condition: utils.Pointer(`request.ip in ["10.0.0.0/8"]`),
+		}
+
+		if err := policy.Validate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if *policy.Name != "Admins from the office" || policy.Target != nil || !policy.IsActive() {
+			t.Errorf("unexpected policy: %+v", policy)
+		}
+	})
+
+	for name, policy := range map[string]*Policy{
+		"WithoutName":   {Events: []Event{EventLogin}, Condition: utils.Pointer(`user.otp eq true`)},
+		"WithoutEvents": {Name: utils.Pointer("2FA"), Condition: utils.Pointer(`user.otp eq true`)},
+		"UnknownEvent":  {Name: utils.Pointer("2FA"), Events: []Event{"logout"}, Condition: utils.Pointer(`user.otp eq true`)},
+	} {
+		t.Run("Error::"+name, func(t *testing.T) {
+			if err := policy.Validate(); !isError(err, ErrPolicyIsNotValid()) {
+				t.Errorf("expected the error of the policy, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Error::Expression", func(t *testing.T) {
+		policy := &Policy{Name: utils.Pointer("2FA"), Events: []Event{EventToken}, Target: utils.Pointer(`project.id`),
+			Condition: utils.Pointer(`user.otp eq true`)}
+		if err := policy.Validate(); !isError(err, ErrExpressionIsNotValid()) {
+			t.Errorf("expected the error of the expression, got %v", err)
+		}
+	})
+}
+
+func TestRequestAttributes(t *testing.T) {
+	attributes := attributes()
+
+	if attributes[ScopeRequest]["time"] != "14:30" || attributes[ScopeRequest]["weekday"] != "monday" ||
+		attributes[ScopeRequest]["hour"] != float64(14) || attributes[ScopeRequest]["date"] != "2023-03-06" {
+		t.Errorf("unexpected attributes of the request: %v", attributes[ScopeRequest])
+	}
+
+	if attributes[ScopeSession]["active"] != float64(2) || attributes[ScopeProject]["participant"] != true {
+		t.Errorf("unexpected attributes: %v", attributes)
+	}
+
+	location := time.FixedZone("UTC-3", -3*60*60)
+	request := &Request{Event: utils.Pointer(EventLogin), UserID: utils.Pointer(uuid.New())}
+	if err := request.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request.Time = utils.Pointer(time.Date(2023, 3, 6, 2, 0, 0, 0, time.UTC))
+	local := request.Attributes(&UserAttributes{ID: request.UserID}, 0, nil, location)
+	if local[ScopeRequest]["weekday"] != "sunday" || local[ScopeRequest]["time"] != "23:00" || local[ScopeProject]["id"] != nil {
+		t.Errorf("unexpected attributes in the location: %v", local)
+	}
+
+	if err := (&Request{Event: utils.Pointer(Event("logout")), UserID: request.UserID}).Validate(); !isError(err, ErrRequestIsNotValid()) {
+		t.Errorf("expected the error of the request, got %v", err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	attributes := attributes()
+
+	policies := []Policy{
+		{ID: utils.Pointer(uuid.New()), Name: utils.Pointer("Integrations in business hours"), Events: []Event{EventLogin},
+			Target: utils.Pointer(`user.level eq "integration"`), Condition: utils.Pointer(`request.hour ge 9 and request.hour lt 18`),
+			Active: utils.Pointer(true)},
+		{ID: utils.Pointer(uuid.New()), Name: utils.Pointer("Report only"), Events: []Event{EventLogin},
+			Condition: utils.Pointer(`request.weekday eq "sunday"`), Active: utils.Pointer(false)},
+		{ID: utils.Pointer(uuid.New()), Name: utils.Pointer("Admins from the office"), Events: []Event{EventLogin, EventToken},
+			Target: utils.Pointer(`user.level eq "admin"`), Condition: utils.Pointer(`request.ip in ["192.168.0.0/16"]`),
+			Active: utils.Pointer(true)},
+		{ID: utils.Pointer(uuid.New()), Name: utils.Pointer("Project requires 2FA"), Events: []Event{EventToken},
+			Condition: utils.Pointer(`user.otp eq true`), Active: utils.Pointer(true)},
+	}
+
+	decision := Evaluate(policies, EventLogin, attributes)
+	if decision.Allowed || *decision.DeniedBy != "Admins from the office" || len(decision.Results) != 3 {
+		t.Fatalf("unexpected decision: %+v", decision)
+	}
+
+	if decision.Results[0].Applies || !decision.Results[0].Satisfied {
+		t.Errorf("expected the policy of the integrations to not apply: %+v", decision.Results[0])
+	}
+
+	if decision.Results[1].Active || decision.Results[1].Satisfied {
+		t.Errorf("expected the inactive policy to be reported as not satisfied: %+v", decision.Results[1])
+	}
+
+	policies[2].Condition = utils.Pointer(`request.ip in ["10.0.0.0/8"]`)
+	if decision = Evaluate(policies, EventLogin, attributes); !decision.Allowed || decision.DeniedBy != nil {
+		t.Errorf("expected the request to be allowed: %+v", decision)
+	}
+
+	policies[3].Condition = utils.Pointer(`user.otp eq`)
+	if decision = Evaluate(policies, EventToken, attributes); decision.Allowed || decision.Results[1].Error == nil {
+		t.Errorf("expected the policy that cannot be parsed to deny: %+v", decision)
+	}
+}
+
+func isError(err error, expected *oops.Error) bool {
+	var handled *oops.Error
+	return errors.As(err, &handled) && handled.Message == expected.Message && handled.Code == expected.Code
+}
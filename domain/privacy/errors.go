@@ -0,0 +1,22 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package privacy
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrDeletionRequested creates and returns an error when the deletion of the account was already requested
+func ErrDeletionRequested() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_deletion_requested"), http.StatusConflict)
+}
+
+// ErrDeletionNotFound creates and returns an error when there is no pending deletion of the account
+func ErrDeletionNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_deletion_not_found"), http.StatusNotFound)
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package privacy
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/domain/audit"
+	"github.com/isaqueveras/powersso/domain/project"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// IPrivacy define an interface for data layer access methods
+type IPrivacy interface {
+	Sessions(userID *uuid.UUID, params *utils.Params) ([]Session, *bool, error)
+	Participations(userID *uuid.UUID, params *utils.Params) ([]Participation, *bool, error)
+	AuditEvents(userID *uuid.UUID, params *utils.Params) ([]audit.Event, *bool, error)
+	Deletion(userID *uuid.UUID) (*Deletion, error)
+	ScheduleDeletion(*Deletion) error
+	CancelDeletion(userID *uuid.UUID) error
+	DueDeletions(limit uint64) ([]uuid.UUID, error)
+	Anonymize(userID *uuid.UUID, data *Anonymized) ([]project.EndedParticipation, error)
+}
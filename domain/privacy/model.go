@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package privacy
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/domain/audit"
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/domain/identity"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// ExportPageSize is the number of rows read at once for each section of the export
+const ExportPageSize uint64 = 500
+
+// Values that replace the personal data of an erased user
+const (
+	AnonymizedFirstName string = "Deleted"
+	AnonymizedLastName  string = "User"
+	AnonymizedIP        string = "0.0.0.0"
+	AnonymizedUserAgent string = "anonymized"
+
+	anonymizedDomain string = "@anonymized.invalid"
+)
+
+// Export models the archive with the personal data of a user
+type Export struct {
+	GeneratedAt    *time.Time          `json:"generated_at"`
+	Profile        *auth.Profile       `json:"profile"`
+	Deletion       *Deletion           `json:"deletion,omitempty"`
+	Sessions       []Session           `json:"sessions"`
+	Participations []Participation     `json:"project_participations"`
+	AuditEvents    []audit.Event       `json:"audit_events"`
+	LockoutEvents  []auth.LockoutEntry `json:"lockout_events"`
+	Identities     []identity.Identity `json:"identities"`
+}
+
+// FileName returns the name of the file the archive is downloaded as
+func (e *Export) FileName() string {
+	return "powersso-export-" + e.Profile.ID.String() + ".json"
+}
+
+// Session models a session of the user in the export, including the ended ones
+type Session struct {
+	ID                *uuid.UUID `json:"id" sql:"id"`
+	IP                *string    `json:"ip" sql:"ip"`
+	UserAgent         *string    `json:"user_agent" sql:"user_agent"`
+	CreatedAt         *time.Time `json:"created_at" sql:"created_at"`
+	LastSeenAt        *time.Time `json:"last_seen_at" sql:"last_seen_at"`
+	ExpiresAt         *time.Time `json:"expires_at" sql:"expires_at"`
+	AbsoluteExpiresAt *time.Time `json:"absolute_expires_at" sql:"absolute_expires_at"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty" sql:"deleted_at"`
+}
+
+// Participation models a participation of the user in a project in the export, including the ended ones
+type Participation struct {
+	ProjectID     *uuid.UUID `json:"project_id" sql:"pp.project_id"`
+	ProjectName   *string    `json:"project_name" sql:"p.name"`
+	StartDate     *time.Time `json:"start_date" sql:"pp.start_date"`
+	DepartureDate *time.Time `json:"departure_date,omitempty" sql:"pp.departure_date"`
+	CreatedAt     *time.Time `json:"created_at" sql:"pp.created_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" sql:"pp.deleted_at"`
+}
+
+// Deletion models the request to delete the account of a user, which is erased after the scheduled date
+type Deletion struct {
+	UserID      *uuid.UUID `json:"user_id"`
+	RequestedBy *uuid.UUID `json:"requested_by,omitempty"`
+	WasActive   *bool      `json:"-"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// NewDeletion creates the deletion of the account scheduled after the grace period in seconds
+func NewDeletion(userID, requestedBy *uuid.UUID, wasActive bool, gracePeriod int64) *Deletion {
+	scheduledAt := time.Now().Add(time.Duration(gracePeriod) * time.Second)
+	return &Deletion{UserID: userID, RequestedBy: requestedBy, WasActive: &wasActive, ScheduledAt: &scheduledAt}
+}
+
+// Immediate returns if the account is erased at the time of the request, without a grace period
+func (d *Deletion) Immediate() bool {
+	return !d.ScheduledAt.After(time.Now())
+}
+
+// RequestedByUser returns if the users requested the deletion of their own account
+func (d *Deletion) RequestedByUser() bool {
+	return d.RequestedBy != nil && d.UserID != nil && *d.RequestedBy == *d.UserID
+}
+
+// DeleteAccount models the data users send to delete their own account
+type DeleteAccount struct {
+	UserID *uuid.UUID `json:"-"`
+	auth.Confirmation
+}
+
+// Prepare prepares the data to delete the account
+func (d *DeleteAccount) Prepare() {
+	d.Confirmation.Prepare()
+}
+
+// CancelDeletion models the token sent by email to cancel the deletion of an account
+type CancelDeletion struct {
+	Token *uuid.UUID `json:"token" binding:"required"`
+}
+
+// Anonymized models the values that replace the personal data of an erased user.
+// The password and the key are random, so no password matches the account anymore
+type Anonymized struct {
+	Email     string
+	FirstName string
+	LastName  string
+	Password  string
+	Key       string
+}
+
+// NewAnonymized creates the values that replace the personal data of the user
+func NewAnonymized(userID *uuid.UUID) *Anonymized {
+	return &Anonymized{
+		Email:     userID.String() + anonymizedDomain,
+		FirstName: AnonymizedFirstName,
+		LastName:  AnonymizedLastName,
+		Password:  utils.RandomString(60),
+		Key:       utils.RandomString(50),
+	}
+}
+
+// DeleteUser models the options of an administrator to delete the account of a user
+type DeleteUser struct {
+	// Immediate erases the personal data at the time of the request, without the grace period
+	Immediate bool `form:"immediate"`
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package privacy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDeletionImmediate(t *testing.T) {
+	userID := uuid.New()
+
+	if deletion := NewDeletion(&userID, &userID, true, 0); !deletion.Immediate() {
+		t.Error("expected the deletion without grace period to be immediate")
+	}
+
+	if deletion := NewDeletion(&userID, &userID, true, 3600); deletion.Immediate() {
+		t.Error("expected the deletion with grace period to be scheduled")
+	}
+}
+
+func TestDeletionRequestedByUser(t *testing.T) {
+	userID, adminID := uuid.New(), uuid.New()
+
+	if !NewDeletion(&userID, &userID, true, 3600).RequestedByUser() {
+		t.Error("expected the deletion to be requested by the user")
+	}
+
+	if NewDeletion(&userID, &adminID, true, 3600).RequestedByUser() {
+		t.Error("expected the deletion to be requested by the administrator")
+	}
+}
+
+func TestNewAnonymized(t *testing.T) {
+	userID := uuid.New()
+	data := NewAnonymized(&userID)
+
+	if !strings.HasPrefix(data.Email, userID.String()) || len(data.Email) > 64 {
+		t.Errorf("expected a unique email that fits the column, got %s", data.Email)
+	}
+
+	if data.FirstName != AnonymizedFirstName || data.LastName != AnonymizedLastName {
+		t.Error("expected the name to be replaced")
+	}
+
+	if other := NewAnonymized(&userID); other.Password == data.Password || other.Key == data.Key {
+		t.Error("expected a random password and key")
+	}
+}
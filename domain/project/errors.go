@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrProjectNotFound creates and returns an error when the project does not exist
+func ErrProjectNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_project_not_found"), http.StatusNotFound)
+}
+
+// ErrRoleNotFound creates and returns an error when the role does not exist in the project
+func ErrRoleNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_project_role_not_found"), http.StatusNotFound)
+}
+
+// ErrRoleExists creates and returns an error when a role with the name already exists in the project
+func ErrRoleExists() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_project_role_exists"), http.StatusConflict)
+}
+
+// ErrRoleNameIsNotValid creates and returns an error when the name of the role is not valid
+func ErrRoleNameIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_project_role_name_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrPermissionIsNotValid creates and returns an error when a permission of the project does not exist
+func ErrPermissionIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_project_permission_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrDefaultRole creates and returns an error when deleting or assigning the role given to all participants
+func ErrDefaultRole() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_project_default_role"), http.StatusBadRequest)
+}
+
+// ErrRoleNotAssigned creates and returns an error when the role is not assigned to the participant
+func ErrRoleNotAssigned() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_project_role_not_assigned"), http.StatusNotFound)
+}
+
+// ErrParticipantNotFound creates and returns an error when the user does not participate in the project
+func ErrParticipantNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_participant_not_found"), http.StatusNotFound)
+}
+
+// ErrParticipantExists creates and returns an error when the user already participates in the project
+func ErrParticipantExists() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_participant_exists"), http.StatusConflict)
+}
+
+// ErrParticipationDateIsNotValid creates and returns an error when the dates of the participation are not valid
+func ErrParticipationDateIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_participation_date_is_not_valid"), http.StatusBadRequest)
+}
@@ -4,7 +4,26 @@
 
 package project
 
+import "github.com/google/uuid"
+
 // IProject define an interface for data layer access methods
 type IProject interface {
-	Create(*CreateProject) error
+	Create(*CreateProject) (*uuid.UUID, error)
+	EndParticipations() ([]EndedParticipation, error)
+
+	Roles(projectID *uuid.UUID) ([]Role, error)
+	GetRole(projectID, roleID *uuid.UUID) (*Role, error)
+	CreateRole(*CreateRole) (*uuid.UUID, error)
+	UpdateRole(projectID, roleID *uuid.UUID, in *UpdateRole) error
+	DeleteRole(projectID, roleID *uuid.UUID) error
+
+	Participants(projectID *uuid.UUID) ([]ProjectParticipant, error)
+	AddParticipant(*AddParticipant) error
+	RemoveParticipant(projectID, userID *uuid.UUID) error
+	AssignRoles(projectID, userID, createdBy *uuid.UUID, roles []string) error
+	AssignRole(*Assignment) error
+	UnassignRole(*Assignment) error
+
+	HasPermission(projectID, userID *uuid.UUID, permission Permission) (bool, error)
+	Access(projectID, userID *uuid.UUID) (*Access, error)
 }
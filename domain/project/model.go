@@ -4,7 +4,11 @@
 
 package project
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // CreateProject models the data to create a project
 type CreateProject struct {
@@ -16,9 +20,17 @@ type CreateProject struct {
 	Slug         *string       `json:"slug,omitempty"`
 }
 
-// Participant models the data the a participant
+// Participant models the data the a participant, the default role of the project is given when no role is informed
 type Participant struct {
 	UserID        *string    `json:"user_id"`
 	StartDate     *time.Time `json:"start_date"`
 	DepartureDate *time.Time `json:"departure_date,omitempty"`
+	Roles         []string   `json:"roles,omitempty"`
+}
+
+// EndedParticipation models a participant removed from a project after the departure date
+type EndedParticipation struct {
+	ProjectID     *uuid.UUID
+	UserID        *uuid.UUID
+	DepartureDate *time.Time
 }
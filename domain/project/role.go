@@ -0,0 +1,216 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Permission set data type to an action the participants can be allowed to do in the project
+type Permission string
+
+const (
+	// PermissionParticipantRead allows to list the participants and the roles of the project
+	PermissionParticipantRead Permission = "participant:read"
+	// PermissionParticipantManage allows to add and remove the participants of the project
+	PermissionParticipantManage Permission = "participant:manage"
+	// PermissionRoleManage allows to manage the roles of the project and assign them to the participants
+	PermissionRoleManage Permission = "role:manage"
+)
+
+// IsValid returns if the permission exists
+func (p Permission) IsValid() bool {
+	switch p {
+	case PermissionParticipantRead, PermissionParticipantManage, PermissionRoleManage:
+		return true
+	}
+	return false
+}
+
+const (
+	// RoleOwner is the role of the participants that manage the project
+	RoleOwner = "owner"
+	// RoleMaintainer is the role of the participants that manage the team of the project
+	RoleMaintainer = "maintainer"
+	// RoleViewer is the default role, given to all participants of the project
+	RoleViewer = "viewer"
+)
+
+// maxRoleNameLength is the size of the names of the roles
+const maxRoleNameLength = 32
+
+// roleNamePattern is the format of the names of the roles
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Role models a set of permissions given to the participants of a project.
+// The default role of the project is given to all participants
+type Role struct {
+	ID          *uuid.UUID   `json:"id" sql:"id"`
+	ProjectID   *uuid.UUID   `json:"project_id" sql:"project_id"`
+	Name        *string      `json:"name" sql:"name"`
+	Description *string      `json:"description,omitempty" sql:"description"`
+	IsDefault   *bool        `json:"is_default" sql:"is_default"`
+	Permissions []Permission `json:"permissions" sql:"-"`
+	CreatedAt   *time.Time   `json:"created_at" sql:"created_at"`
+	UpdatedAt   *time.Time   `json:"updated_at" sql:"updated_at"`
+}
+
+// CreateRole models the data to create a role of a project
+type CreateRole struct {
+	ProjectID   *uuid.UUID   `json:"-"`
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	IsDefault   *bool        `json:"-"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// NewDefaultRoles returns the roles every project is created with
+func NewDefaultRoles(projectID *uuid.UUID) []CreateRole {
+	return []CreateRole{{
+		ProjectID:   projectID,
+		Name:        utils.Pointer(RoleOwner),
+		Description: utils.Pointer("Manages the roles and the participants of the project"),
+		IsDefault:   utils.Pointer(false),
+		Permissions: []Permission{PermissionParticipantRead, PermissionParticipantManage, PermissionRoleManage},
+	}, {
+		ProjectID:   projectID,
+		Name:        utils.Pointer(RoleMaintainer),
+		Description: utils.Pointer("Manages the participants of the project"),
+		IsDefault:   utils.Pointer(false),
+		Permissions: []Permission{PermissionParticipantRead, PermissionParticipantManage},
+	}, {
+		ProjectID:   projectID,
+		Name:        utils.Pointer(RoleViewer),
+		Description: utils.Pointer("Given to all participants of the project"),
+		IsDefault:   utils.Pointer(true),
+		Permissions: []Permission{PermissionParticipantRead},
+	}}
+}
+
+// Validate checks the data to create a role, removing the repeated permissions
+func (c *CreateRole) Validate() (err error) {
+	if c.Name == nil {
+		return ErrRoleNameIsNotValid()
+	}
+
+	name := strings.ToLower(strings.TrimSpace(*c.Name))
+	if len(name) > maxRoleNameLength || !roleNamePattern.MatchString(name) {
+		return ErrRoleNameIsNotValid()
+	}
+
+	c.Name = &name
+	c.Permissions, err = uniquePermissions(c.Permissions)
+	return
+}
+
+// UpdateRole models the data to update a role of a project, the permissions replace the ones of the role when informed
+type UpdateRole struct {
+	Description *string      `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// Validate checks the data to update a role, removing the repeated permissions
+func (u *UpdateRole) Validate() (err error) {
+	if u.Permissions == nil {
+		return nil
+	}
+
+	u.Permissions, err = uniquePermissions(u.Permissions)
+	return
+}
+
+func uniquePermissions(permissions []Permission) ([]Permission, error) {
+	var (
+		unique = make([]Permission, 0, len(permissions))
+		seen   = make(map[Permission]bool, len(permissions))
+	)
+
+	for _, permission := range permissions {
+		if !permission.IsValid() {
+			return nil, ErrPermissionIsNotValid()
+		}
+
+		if !seen[permission] {
+			seen[permission] = true
+			unique = append(unique, permission)
+		}
+	}
+
+	return unique, nil
+}
+
+// ProjectParticipant models an active participant of a project with the names of its roles
+type ProjectParticipant struct {
+	UserID        *uuid.UUID `json:"user_id" sql:"user_id"`
+	FirstName     *string    `json:"first_name" sql:"first_name"`
+	LastName      *string    `json:"last_name" sql:"last_name"`
+	Email         *string    `json:"email" sql:"email"`
+	StartDate     *time.Time `json:"start_date" sql:"start_date"`
+	DepartureDate *time.Time `json:"departure_date,omitempty" sql:"departure_date"`
+	Roles         []string   `json:"roles" sql:"-"`
+}
+
+// AddParticipant models the data to add a participant to a project
+type AddParticipant struct {
+	ProjectID     *uuid.UUID `json:"-"`
+	UserID        *uuid.UUID `json:"user_id"`
+	StartDate     *time.Time `json:"start_date"`
+	DepartureDate *time.Time `json:"departure_date,omitempty"`
+	Roles         []string   `json:"roles,omitempty"`
+	CreatedBy     *uuid.UUID `json:"-"`
+}
+
+// Validate checks the data to add a participant
+func (a *AddParticipant) Validate() error {
+	if a.UserID == nil {
+		return ErrParticipantNotFound()
+	}
+
+	if a.StartDate == nil || (a.DepartureDate != nil && a.DepartureDate.Before(*a.StartDate)) {
+		return ErrParticipationDateIsNotValid()
+	}
+
+	a.Roles = NormalizeRoles(a.Roles)
+	return nil
+}
+
+// NormalizeRoles returns the names of the roles in lowercase, without the repeated ones
+func NormalizeRoles(roles []string) []string {
+	var (
+		unique = make([]string, 0, len(roles))
+		seen   = make(map[string]bool, len(roles))
+	)
+
+	for _, role := range roles {
+		if role = strings.ToLower(strings.TrimSpace(role)); !seen[role] {
+			seen[role] = true
+			unique = append(unique, role)
+		}
+	}
+
+	return unique
+}
+
+// Assignment models a role of a project assigned to a participant
+type Assignment struct {
+	ProjectID *uuid.UUID `json:"project_id" sql:"-"`
+	RoleID    *uuid.UUID `json:"role_id" sql:"role_id"`
+	UserID    *uuid.UUID `json:"user_id" sql:"user_id"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" sql:"created_by"`
+	CreatedAt *time.Time `json:"created_at" sql:"created_at"`
+}
+
+// Access models the roles and the permissions of a participant in a project
+type Access struct {
+	ProjectID   *uuid.UUID   `json:"project_id"`
+	Roles       []string     `json:"roles"`
+	Permissions []Permission `json:"permissions"`
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func isError(err error, expected *oops.Error) bool {
+	var e *oops.Error
+	return errors.As(err, &e) && e.Message == expected.Message && e.Code == expected.Code
+}
+
+func TestNewDefaultRoles(t *testing.T) {
+	projectID := uuid.New()
+	roles := NewDefaultRoles(&projectID)
+
+	var defaults int
+	for _, role := range roles {
+		if *role.ProjectID != projectID {
+			t.Errorf("expected the role %s in the project", *role.Name)
+		}
+
+		if err := role.Validate(); err != nil {
+			t.Errorf("expected the role %s to be valid, got %v", *role.Name, err)
+		}
+
+		if *role.IsDefault {
+			defaults++
+			if *role.Name != RoleViewer {
+				t.Errorf("expected the viewer to be the default role, got %s", *role.Name)
+			}
+		}
+	}
+
+	if defaults != 1 {
+		t.Errorf("expected one default role, got %d", defaults)
+	}
+}
+
+func TestCreateRoleValidate(t *testing.T) {
+	in := &CreateRole{
+		Name:        utils.Pointer(" Reviewer "),
+		Permissions: []Permission{PermissionParticipantRead, PermissionParticipantRead},
+	}
+	if err := in.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *in.Name != "reviewer" || !reflect.DeepEqual(in.Permissions, []Permission{PermissionParticipantRead}) {
+		t.Errorf("unexpected role: %s %v", *in.Name, in.Permissions)
+	}
+
+	if err := (&CreateRole{Name: utils.Pointer("code reviewer")}).Validate(); !isError(err, ErrRoleNameIsNotValid()) {
+		t.Errorf("expected the name to be invalid, got %v", err)
+	}
+
+	in = &CreateRole{Name: utils.Pointer("reviewer"), Permissions: []Permission{"user:read"}}
+	if err := in.Validate(); !isError(err, ErrPermissionIsNotValid()) {
+		t.Errorf("expected the global permission to be invalid in the project, got %v", err)
+	}
+}
+
+func TestAddParticipantValidate(t *testing.T) {
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	in := &AddParticipant{UserID: utils.Pointer(uuid.New()), StartDate: &start, Roles: []string{"Owner ", "owner", "maintainer"}}
+	if err := in.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(in.Roles, []string{RoleOwner, RoleMaintainer}) {
+		t.Errorf("expected the repeated roles to be removed, got %v", in.Roles)
+	}
+
+	in = &AddParticipant{UserID: utils.Pointer(uuid.New())}
+	if err := in.Validate(); !isError(err, ErrParticipationDateIsNotValid()) {
+		t.Errorf("expected the participation without start date to be invalid, got %v", err)
+	}
+
+	in = &AddParticipant{UserID: utils.Pointer(uuid.New()), StartDate: &start, DepartureDate: utils.Pointer(start.AddDate(0, 0, -1))}
+	if err := in.Validate(); !isError(err, ErrParticipationDateIsNotValid()) {
+		t.Errorf("expected the departure before the start to be invalid, got %v", err)
+	}
+}
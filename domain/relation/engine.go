@@ -0,0 +1,214 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+// Engine answers the queries over the tuples, following the subject sets, the relations included
+// and the tuples to usersets of the namespaces. The relations already being followed are not followed
+// again, so the cycles of the tuples end the path instead of the query
+type Engine struct {
+	reader     IReader
+	maxDepth   int64
+	namespaces map[string]*Namespace
+}
+
+// NewEngine creates an engine that reads the tuples and the namespaces from the reader
+func NewEngine(reader IReader, maxDepth int64) *Engine {
+	return &Engine{reader: reader, maxDepth: maxDepth, namespaces: make(map[string]*Namespace)}
+}
+
+// relation returns the definition of the relation in the namespace of the object
+func (e *Engine) relation(objectType, name string) (*Relation, error) {
+	namespace, ok := e.namespaces[objectType]
+	if !ok {
+		var err error
+		if namespace, err = e.reader.GetNamespace(objectType); err != nil {
+			return nil, err
+		}
+		e.namespaces[objectType] = namespace
+	}
+	return namespace.Relation(name)
+}
+
+// path models the relations being followed by a query
+type path map[string]bool
+
+func (p path) enter(object *Object, relation string) bool {
+	key := object.String() + "#" + relation
+	if p[key] {
+		return false
+	}
+	p[key] = true
+	return true
+}
+
+func (p path) leave(object *Object, relation string) {
+	delete(p, object.String()+"#"+relation)
+}
+
+// Check answers if the subject has the relation with the object
+func (e *Engine) Check(in *Check) (bool, error) {
+	if _, err := e.relation(*in.Object.Type, *in.Relation); err != nil {
+		return false, err
+	}
+	return e.check(in.Object, *in.Relation, in.Subject, make(path))
+}
+
+func (e *Engine) check(object *Object, relation string, subject *Subject, visited path) (bool, error) {
+	if int64(len(visited)) >= e.maxDepth {
+		return false, ErrDepthExceeded()
+	}
+
+	definition, err := e.relation(*object.Type, relation)
+	if err != nil {
+		// the computed relations of the tuples to usersets may not exist in the namespace of every object
+		if err.Error() == ErrRelationNotFound().Error() {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !visited.enter(object, relation) {
+		return false, nil
+	}
+	defer visited.leave(object, relation)
+
+	subjects, err := e.reader.Subjects(object, relation)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range subjects {
+		if subjects[i].Equal(subject) {
+			return true, nil
+		}
+	}
+
+	for i := range subjects {
+		if !subjects[i].IsSet() {
+			continue
+		}
+
+		if allowed, err := e.check(subjects[i].Object(), *subjects[i].Relation, subject, visited); err != nil || allowed {
+			return allowed, err
+		}
+	}
+
+	for _, included := range definition.Includes {
+		if allowed, err := e.check(object, included, subject, visited); err != nil || allowed {
+			return allowed, err
+		}
+	}
+
+	for _, ttu := range definition.TupleToUserset {
+		var related []Subject
+		if related, err = e.reader.Subjects(object, ttu.Tupleset); err != nil {
+			return false, err
+		}
+
+		for i := range related {
+			if allowed, err := e.check(related[i].Object(), ttu.ComputedRelation, subject, visited); err != nil || allowed {
+				return allowed, err
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Expand returns the tree of the subjects that have the relation with the object. The subject sets
+// are returned in the leaves and can be expanded by other queries
+func (e *Engine) Expand(in *Expand) (*Tree, error) {
+	if _, err := e.relation(*in.Object.Type, *in.Relation); err != nil {
+		return nil, err
+	}
+	return e.expand(in.Object, *in.Relation, make(path))
+}
+
+func (e *Engine) expand(object *Object, relation string, visited path) (*Tree, error) {
+	if int64(len(visited)) >= e.maxDepth {
+		return nil, ErrDepthExceeded()
+	}
+
+	definition, err := e.relation(*object.Type, relation)
+	if err != nil {
+		if err.Error() == ErrRelationNotFound().Error() {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	tree := &Tree{Operation: OperationUnion, Object: object, Relation: &relation}
+	if !visited.enter(object, relation) {
+		return tree, nil
+	}
+	defer visited.leave(object, relation)
+
+	subjects, err := e.reader.Subjects(object, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	tree.Children = append(tree.Children, Tree{Operation: OperationLeaf, Object: object, Relation: &relation, Subjects: subjects})
+
+	for _, included := range definition.Includes {
+		var child *Tree
+		if child, err = e.expand(object, included, visited); err != nil {
+			return nil, err
+		}
+
+		if child != nil {
+			tree.Children = append(tree.Children, *child)
+		}
+	}
+
+	for _, ttu := range definition.TupleToUserset {
+		var related []Subject
+		if related, err = e.reader.Subjects(object, ttu.Tupleset); err != nil {
+			return nil, err
+		}
+
+		for i := range related {
+			var child *Tree
+			if child, err = e.expand(related[i].Object(), ttu.ComputedRelation, visited); err != nil {
+				return nil, err
+			}
+
+			if child != nil {
+				tree.Children = append(tree.Children, *child)
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+// ListObjects returns the identifiers of the objects of the type the subject has the relation with,
+// checking up to the limit of objects of the type
+func (e *Engine) ListObjects(in *ListObjects, limit int64) (*Objects, error) {
+	if _, err := e.relation(*in.ObjectType, *in.Relation); err != nil {
+		return nil, err
+	}
+
+	ids, err := e.reader.ObjectIDs(*in.ObjectType, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Objects{ObjectType: in.ObjectType, ObjectIDs: make([]string, 0)}
+	for i := range ids {
+		object := &Object{Type: in.ObjectType, ID: &ids[i]}
+
+		var allowed bool
+		if allowed, err = e.check(object, *in.Relation, in.Subject, make(path)); err != nil {
+			return nil, err
+		}
+
+		if allowed {
+			res.ObjectIDs = append(res.ObjectIDs, ids[i])
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// memoryReader keeps the namespaces and the tuples of the tests in memory
+type memoryReader struct {
+	namespaces map[string]*Namespace
+	tuples     []Tuple
+}
+
+func (m *memoryReader) GetNamespace(name string) (*Namespace, error) {
+	if namespace, ok := m.namespaces[name]; ok {
+		return namespace, nil
+	}
+	return nil, ErrNamespaceNotFound()
+}
+
+func (m *memoryReader) Subjects(object *Object, relation string) (subjects []Subject, _ error) {
+	for _, tuple := range m.tuples {
+		if tuple.Object.String() == object.String() && *tuple.Relation == relation {
+			subjects = append(subjects, *tuple.Subject)
+		}
+	}
+	return
+}
+
+func (m *memoryReader) ObjectIDs(objectType string, limit int64) (ids []string, _ error) {
+	seen := make(map[string]bool)
+	for _, tuple := range m.tuples {
+		if *tuple.Object.Type == objectType && !seen[*tuple.Object.ID] && int64(len(ids)) < limit {
+			seen[*tuple.Object.ID] = true
+			ids = append(ids, *tuple.Object.ID)
+		}
+	}
+	sort.Strings(ids)
+	return
+}
+
+func newReader(t *testing.T, tuples ...string) *memoryReader {
+	reader := &memoryReader{namespaces: map[string]*Namespace{
+		"user":  {Name: utils.Pointer("user")},
+		"group": {Name: utils.Pointer("group"), Relations: []Relation{{Name: "member"}}},
+		"folder": {Name: utils.Pointer("folder"), Relations: []Relation{
+			{Name: "parent"},
+			{Name: "owner"},
+			{Name: "viewer", Includes: []string{"owner"}, TupleToUserset: []TupleToUserset{{Tupleset: "parent", ComputedRelation: "viewer"}}},
+		}},
+		"document": {Name: utils.Pointer("document"), Relations: []Relation{
+			{Name: "parent"},
+			{Name: "editor"},
+			{Name: "viewer", Includes: []string{"editor"}, TupleToUserset: []TupleToUserset{{Tupleset: "parent", ComputedRelation: "viewer"}}},
+		}},
+	}}
+
+	for _, value := range tuples {
+		reader.tuples = append(reader.tuples, *mustTuple(t, value))
+	}
+
+	return reader
+}
+
+func mustTuple(t *testing.T, value string) *Tuple {
+	tuple, err := ParseTuple(value)
+	if err != nil {
+		t.Fatalf("unexpected tuple %s: %v", value, err)
+	}
+	return tuple
+}
+
+func check(t *testing.T, engine *Engine, object, relation, subject string) bool {
+	in := &Check{Relation: &relation}
+	in.Object, _ = ParseObject(object)
+	in.Subject, _ = ParseSubject(subject)
+
+	allowed, err := engine.Check(in)
+	if err != nil {
+		t.Fatalf("unexpected error checking %s#%s@%s: %v", object, relation, subject, err)
+	}
+	return allowed
+}
+
+func TestEngineCheck(t *testing.T) {
+	engine := NewEngine(newReader(t,
+		"group:eng#member@user:jane",
+		"group:eng#member@group:backend#member",
+		"group:backend#member@user:john",
+		"folder:root#owner@user:anna",
+		"folder:docs#parent@folder:root",
+		"folder:docs#viewer@group:eng#member",
+		"document:readme#parent@folder:docs",
+		"document:readme#editor@user:mark",
+	), 25)
+
+	cases := []struct {
+		object, relation, subject string
+		allowed                   bool
+	}{
+		{"document:readme", "editor", "user:mark", true},
+		{"document:readme", "viewer", "user:mark", true},
+		{"document:readme", "viewer", "user:jane", true},
+		{"document:readme", "viewer", "user:john", true},
+		{"document:readme", "viewer", "user:anna", true},
+		{"document:readme", "viewer", "group:eng#member", true},
+		{"document:readme", "editor", "user:jane", false},
+		{"document:readme", "viewer", "user:nobody", false},
+		{"folder:root", "viewer", "user:jane", false},
+	}
+
+	for _, c := range cases {
+		if allowed := check(t, engine, c.object, c.relation, c.subject); allowed != c.allowed {
+			t.Errorf("expected %s#%s@%s to be %v", c.object, c.relation, c.subject, c.allowed)
+		}
+	}
+}
+
+func TestEngineCheckCycles(t *testing.T) {
+	engine := NewEngine(newReader(t,
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+		"group:b#member@user:jane",
+		"folder:x#parent@folder:y",
+		"folder:y#parent@folder:x",
+	), 25)
+
+	if !check(t, engine, "group:a", "member", "user:jane") {
+		t.Error("expected the member of the cycle to be found")
+	}
+
+	if check(t, engine, "folder:x", "viewer", "user:jane") {
+		t.Error("expected the cycle of the folders to end without the relation")
+	}
+}
+
+func TestEngineCheckDepth(t *testing.T) {
+	engine := NewEngine(newReader(t,
+		"group:a#member@group:b#member",
+		"group:b#member@group:c#member",
+		"group:c#member@user:jane",
+	), 2)
+
+	in := &Check{Relation: utils.Pointer("member")}
+	in.Object, _ = ParseObject("group:a")
+	in.Subject, _ = ParseSubject("user:jane")
+
+	if _, err := engine.Check(in); !isError(err, ErrDepthExceeded()) {
+		t.Errorf("expected the depth to be exceeded, got %v", err)
+	}
+}
+
+func TestEngineCheckUndefinedRelation(t *testing.T) {
+	engine := NewEngine(newReader(t), 25)
+
+	in := &Check{Relation: utils.Pointer("admin")}
+	in.Object, _ = ParseObject("document:readme")
+	in.Subject, _ = ParseSubject("user:jane")
+
+	if _, err := engine.Check(in); !isError(err, ErrRelationNotFound()) {
+		t.Errorf("expected the relation to be undefined, got %v", err)
+	}
+
+	in.Object, _ = ParseObject("spreadsheet:budget")
+	if _, err := engine.Check(in); !isError(err, ErrNamespaceNotFound()) {
+		t.Errorf("expected the namespace to be undefined, got %v", err)
+	}
+}
+
+func TestEngineExpand(t *testing.T) {
+	engine := NewEngine(newReader(t,
+		"document:readme#parent@folder:docs",
+		"document:readme#editor@user:mark",
+		"document:readme#viewer@group:eng#member",
+		"folder:docs#owner@user:anna",
+	), 25)
+
+	in := &Expand{Relation: utils.Pointer("viewer")}
+	in.Object, _ = ParseObject("document:readme")
+
+	tree, err := engine.Expand(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tree.Operation != OperationUnion || len(tree.Children) != 3 {
+		t.Fatalf("expected the union of the viewers, the editors and the viewers of the parent, got %+v", tree)
+	}
+
+	if leaf := tree.Children[0]; leaf.Operation != OperationLeaf || len(leaf.Subjects) != 1 || leaf.Subjects[0].String() != "group:eng#member" {
+		t.Errorf("unexpected direct viewers: %+v", leaf)
+	}
+
+	if editors := tree.Children[1]; *editors.Relation != "editor" || editors.Children[0].Subjects[0].String() != "user:mark" {
+		t.Errorf("unexpected editors: %+v", editors)
+	}
+
+	parent := tree.Children[2]
+	if parent.Object.String() != "folder:docs" || *parent.Relation != "viewer" {
+		t.Fatalf("unexpected viewers of the parent: %+v", parent)
+	}
+
+	if owners := parent.Children[1]; *owners.Relation != "owner" || owners.Children[0].Subjects[0].String() != "user:anna" {
+		t.Errorf("unexpected owners of the parent: %+v", owners)
+	}
+}
+
+func TestEngineListObjects(t *testing.T) {
+	engine := NewEngine(newReader(t,
+		"document:readme#editor@user:jane",
+		"document:roadmap#parent@folder:docs",
+		"document:secret#editor@user:mark",
+		"folder:docs#owner@user:jane",
+	), 25)
+
+	in := &ListObjects{ObjectType: utils.Pointer("document"), Relation: utils.Pointer("viewer")}
+	in.Subject, _ = ParseSubject("user:jane")
+
+	res, err := engine.ListObjects(in, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(res.ObjectIDs, []string{"readme", "roadmap"}) {
+		t.Errorf("unexpected objects: %v", res.ObjectIDs)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrNamespaceNotFound creates and returns an error when the namespace does not exist
+func ErrNamespaceNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_namespace_not_found"), http.StatusNotFound)
+}
+
+// ErrNamespaceIsNotValid creates and returns an error when the definition of the namespace is not valid
+func ErrNamespaceIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_namespace_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrNamespaceInUse creates and returns an error when deleting a namespace with tuples
+func ErrNamespaceInUse() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_namespace_in_use"), http.StatusConflict)
+}
+
+// ErrRelationNotFound creates and returns an error when the relation is not defined in the namespace
+func ErrRelationNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_relation_not_found"), http.StatusBadRequest)
+}
+
+// ErrObjectIsNotValid creates and returns an error when the object is not written as type:id
+func ErrObjectIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_relation_object_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrSubjectIsNotValid creates and returns an error when the subject is not written as type:id or type:id#relation
+func ErrSubjectIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_relation_subject_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrNothingToWrite creates and returns an error when there are no tuples to write or delete
+func ErrNothingToWrite() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_relation_nothing_to_write"), http.StatusBadRequest)
+}
+
+// ErrDepthExceeded creates and returns an error when a query follows more relations than allowed
+func ErrDepthExceeded() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_relation_depth_exceeded"), http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import "github.com/google/uuid"
+
+// IReader define an interface for the data layer access methods used by the queries
+type IReader interface {
+	GetNamespace(name string) (*Namespace, error)
+	Subjects(object *Object, relation string) ([]Subject, error)
+	ObjectIDs(objectType string, limit int64) ([]string, error)
+}
+
+// IRelation define an interface for data layer access methods
+type IRelation interface {
+	IReader
+
+	Namespaces() ([]Namespace, error)
+	SaveNamespace(*Namespace) error
+	DeleteNamespace(name string) error
+	Relations(namespace string) ([]string, error)
+
+	Tuples(filter *TupleFilter, limit int64) ([]Tuple, error)
+	Write(in *Write, createdBy *uuid.UUID) error
+}
@@ -0,0 +1,351 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxNameLength is the size of the names of the namespaces and the relations
+	maxNameLength = 64
+	// maxIDLength is the size of the identifiers of the objects
+	maxIDLength = 255
+)
+
+// namePattern is the format of the names of the namespaces and the relations
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// idPattern is the format of the identifiers of the objects, which cannot hold the separators of the notation
+var idPattern = regexp.MustCompile(`^[^:#@\s]+$`)
+
+func isName(name string) bool {
+	return len(name) <= maxNameLength && namePattern.MatchString(name)
+}
+
+func isID(id string) bool {
+	return len(id) <= maxIDLength && idPattern.MatchString(id)
+}
+
+// Object models an object of a namespace, written as type:id
+type Object struct {
+	Type *string `json:"type"`
+	ID   *string `json:"id"`
+}
+
+// ParseObject parses an object written as type:id
+func ParseObject(value string) (*Object, error) {
+	kind, id, found := strings.Cut(value, ":")
+	if !found || !isName(kind) || !isID(id) {
+		return nil, ErrObjectIsNotValid()
+	}
+	return &Object{Type: &kind, ID: &id}, nil
+}
+
+// Validate checks the type and the identifier of the object
+func (o *Object) Validate() error {
+	if o == nil || o.Type == nil || o.ID == nil || !isName(*o.Type) || !isID(*o.ID) {
+		return ErrObjectIsNotValid()
+	}
+	return nil
+}
+
+func (o Object) String() string {
+	return *o.Type + ":" + *o.ID
+}
+
+// Subject models the subject of a relation, an object or the set of the subjects of a relation
+// of the object, written as type:id or type:id#relation
+type Subject struct {
+	Type     *string `json:"type"`
+	ID       *string `json:"id"`
+	Relation *string `json:"relation,omitempty"`
+}
+
+// ParseSubject parses a subject written as type:id or type:id#relation
+func ParseSubject(value string) (*Subject, error) {
+	value, relation, isSet := strings.Cut(value, "#")
+
+	object, err := ParseObject(value)
+	if err != nil {
+		return nil, ErrSubjectIsNotValid()
+	}
+
+	subject := &Subject{Type: object.Type, ID: object.ID}
+	if isSet {
+		if !isName(relation) {
+			return nil, ErrSubjectIsNotValid()
+		}
+		subject.Relation = &relation
+	}
+
+	return subject, nil
+}
+
+// Validate checks the object and the relation of the subject
+func (s *Subject) Validate() error {
+	if s == nil || (&Object{Type: s.Type, ID: s.ID}).Validate() != nil || (s.Relation != nil && !isName(*s.Relation)) {
+		return ErrSubjectIsNotValid()
+	}
+	return nil
+}
+
+// IsSet returns if the subject is the set of the subjects of a relation of the object
+func (s *Subject) IsSet() bool {
+	return s.Relation != nil
+}
+
+// Object returns the object of the subject
+func (s *Subject) Object() *Object {
+	return &Object{Type: s.Type, ID: s.ID}
+}
+
+// Equal returns if the subjects are the same
+func (s *Subject) Equal(other *Subject) bool {
+	return *s.Type == *other.Type && *s.ID == *other.ID &&
+		(s.Relation == nil) == (other.Relation == nil) && (s.Relation == nil || *s.Relation == *other.Relation)
+}
+
+func (s Subject) String() string {
+	if s.Relation != nil {
+		return *s.Type + ":" + *s.ID + "#" + *s.Relation
+	}
+	return *s.Type + ":" + *s.ID
+}
+
+// Tuple models a relation between an object and a subject, written as type:id#relation@subject
+type Tuple struct {
+	Object    *Object    `json:"object"`
+	Relation  *string    `json:"relation"`
+	Subject   *Subject   `json:"subject"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// ParseTuple parses a tuple written as type:id#relation@subject
+func ParseTuple(value string) (*Tuple, error) {
+	value, subject, found := strings.Cut(value, "@")
+	if !found {
+		return nil, ErrSubjectIsNotValid()
+	}
+
+	value, relation, found := strings.Cut(value, "#")
+	if !found || !isName(relation) {
+		return nil, ErrRelationNotFound()
+	}
+
+	tuple := &Tuple{Relation: &relation}
+
+	var err error
+	if tuple.Object, err = ParseObject(value); err != nil {
+		return nil, err
+	}
+
+	if tuple.Subject, err = ParseSubject(subject); err != nil {
+		return nil, err
+	}
+
+	return tuple, nil
+}
+
+// Validate checks the object, the relation and the subject of the tuple
+func (t *Tuple) Validate() error {
+	if err := t.Object.Validate(); err != nil {
+		return err
+	}
+
+	if t.Relation == nil || !isName(*t.Relation) {
+		return ErrRelationNotFound()
+	}
+
+	return t.Subject.Validate()
+}
+
+func (t Tuple) String() string {
+	return t.Object.String() + "#" + *t.Relation + "@" + t.Subject.String()
+}
+
+// TupleToUserset gives the relation to the subjects of the computed relation of the objects related by the tupleset,
+// like the viewers of the parent folder of a document
+type TupleToUserset struct {
+	Tupleset         string `json:"tupleset"`
+	ComputedRelation string `json:"computed_relation"`
+}
+
+// Relation models a relation of the objects of a namespace. Besides the subjects related directly,
+// the relation is given to the subjects of the relations included and of the tuples to usersets
+type Relation struct {
+	Name           string           `json:"name"`
+	Includes       []string         `json:"includes,omitempty"`
+	TupleToUserset []TupleToUserset `json:"tuple_to_userset,omitempty"`
+}
+
+// Namespace models the type of the objects and their relations
+type Namespace struct {
+	Name      *string    `json:"name"`
+	Relations []Relation `json:"relations"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the names of the namespace and of the relations, and that the relations
+// included and the tuplesets are relations of the namespace
+func (n *Namespace) Validate() error {
+	if n.Name == nil || !isName(*n.Name) {
+		return ErrNamespaceIsNotValid()
+	}
+
+	if n.Relations == nil {
+		n.Relations = make([]Relation, 0)
+	}
+
+	names := make(map[string]bool, len(n.Relations))
+	for _, relation := range n.Relations {
+		if !isName(relation.Name) || names[relation.Name] {
+			return ErrNamespaceIsNotValid()
+		}
+		names[relation.Name] = true
+	}
+
+	for _, relation := range n.Relations {
+		for _, included := range relation.Includes {
+			if !names[included] {
+				return ErrNamespaceIsNotValid()
+			}
+		}
+
+		for _, ttu := range relation.TupleToUserset {
+			if !names[ttu.Tupleset] || !isName(ttu.ComputedRelation) {
+				return ErrNamespaceIsNotValid()
+			}
+		}
+	}
+
+	return nil
+}
+
+// Relation returns the definition of the relation of the namespace
+func (n *Namespace) Relation(name string) (*Relation, error) {
+	for i := range n.Relations {
+		if n.Relations[i].Name == name {
+			return &n.Relations[i], nil
+		}
+	}
+	return nil, ErrRelationNotFound()
+}
+
+// Write models the tuples written and deleted together
+type Write struct {
+	Writes  []Tuple `json:"writes"`
+	Deletes []Tuple `json:"deletes"`
+}
+
+// Validate checks the tuples of the write
+func (w *Write) Validate() error {
+	if len(w.Writes) == 0 && len(w.Deletes) == 0 {
+		return ErrNothingToWrite()
+	}
+
+	for _, tuples := range [][]Tuple{w.Writes, w.Deletes} {
+		for i := range tuples {
+			if err := tuples[i].Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// TupleFilter models the filter of the tuples read, the fields not informed match any tuple
+type TupleFilter struct {
+	Object   *Object
+	Relation *string
+	Subject  *Subject
+}
+
+// Check models the question if the subject has the relation with the object
+type Check struct {
+	Object   *Object  `json:"object"`
+	Relation *string  `json:"relation"`
+	Subject  *Subject `json:"subject"`
+}
+
+// Validate checks the object, the relation and the subject of the question
+func (c *Check) Validate() error {
+	return (&Tuple{Object: c.Object, Relation: c.Relation, Subject: c.Subject}).Validate()
+}
+
+// CheckResult models the answer of the question if the subject has the relation with the object
+type CheckResult struct {
+	Allowed bool `json:"allowed"`
+}
+
+// Expand models the request of the tree of the subjects that have the relation with the object
+type Expand struct {
+	Object   *Object `json:"object"`
+	Relation *string `json:"relation"`
+}
+
+// Validate checks the object and the relation of the request
+func (e *Expand) Validate() error {
+	if err := e.Object.Validate(); err != nil {
+		return err
+	}
+
+	if e.Relation == nil || !isName(*e.Relation) {
+		return ErrRelationNotFound()
+	}
+
+	return nil
+}
+
+// Operations of the nodes of the tree of the subjects
+const (
+	// OperationUnion is the node whose subjects are the ones of any of its children
+	OperationUnion = "union"
+	// OperationLeaf is the node with the subjects related directly, whose sets can be expanded again
+	OperationLeaf = "leaf"
+)
+
+// Tree models the subjects that have a relation with an object, as the union of the subjects
+// related directly and the ones of the relations included and of the tuples to usersets
+type Tree struct {
+	Operation string    `json:"operation"`
+	Object    *Object   `json:"object"`
+	Relation  *string   `json:"relation"`
+	Subjects  []Subject `json:"subjects,omitempty"`
+	Children  []Tree    `json:"children,omitempty"`
+}
+
+// ListObjects models the request of the objects of the type the subject has the relation with
+type ListObjects struct {
+	ObjectType *string  `json:"object_type"`
+	Relation   *string  `json:"relation"`
+	Subject    *Subject `json:"subject"`
+}
+
+// Validate checks the type, the relation and the subject of the request
+func (l *ListObjects) Validate() error {
+	if l.ObjectType == nil || !isName(*l.ObjectType) {
+		return ErrObjectIsNotValid()
+	}
+
+	if l.Relation == nil || !isName(*l.Relation) {
+		return ErrRelationNotFound()
+	}
+
+	return l.Subject.Validate()
+}
+
+// Objects models the identifiers of the objects the subject has the relation with
+type Objects struct {
+	ObjectType *string  `json:"object_type"`
+	ObjectIDs  []string `json:"object_ids"`
+}
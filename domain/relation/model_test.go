@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func isError(err error, expected *oops.Error) bool {
+	var e *oops.Error
+	return errors.As(err, &e) && e.Message == expected.Message && e.Code == expected.Code
+}
+
+func TestParseTuple(t *testing.T) {
+	tuple, err := ParseTuple("document:readme#viewer@group:eng#member")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *tuple.Object.Type != "document" || *tuple.Object.ID != "readme" || *tuple.Relation != "viewer" {
+		t.Errorf("unexpected object of the tuple: %s", tuple)
+	}
+
+	if !tuple.Subject.IsSet() || tuple.Subject.String() != "group:eng#member" {
+		t.Errorf("unexpected subject of the tuple: %s", tuple.Subject)
+	}
+
+	cases := map[string]*oops.Error{
+		"document:readme#viewer":            ErrSubjectIsNotValid(),
+		"document:readme@user:jane":         ErrRelationNotFound(),
+		"document#viewer@user:jane":         ErrObjectIsNotValid(),
+		"Document:readme#viewer@user:jane":  ErrObjectIsNotValid(),
+		"document:readme#viewer@user":       ErrSubjectIsNotValid(),
+		"document:readme#viewer@user:jane#": ErrSubjectIsNotValid(),
+	}
+
+	for value, expected := range cases {
+		if _, err = ParseTuple(value); !isError(err, expected) {
+			t.Errorf("expected %s to fail with %s, got %v", value, expected.Message, err)
+		}
+	}
+}
+
+func TestSubjectEqual(t *testing.T) {
+	user, _ := ParseSubject("user:jane")
+	set, _ := ParseSubject("user:jane#self")
+
+	if !user.Equal(&Subject{Type: utils.Pointer("user"), ID: utils.Pointer("jane")}) {
+		t.Error("expected the subjects to be equal")
+	}
+
+	if user.Equal(set) || set.Equal(user) {
+		t.Error("expected the subject to differ from the set of the subject")
+	}
+}
+
+func TestNamespaceValidate(t *testing.T) {
+	namespace := &Namespace{Name: utils.Pointer("document"), Relations: []Relation{
+		{Name: "parent"},
+		{Name: "editor"},
+		{Name: "viewer", Includes: []string{"editor"}, TupleToUserset: []TupleToUserset{{Tupleset: "parent", ComputedRelation: "viewer"}}},
+	}}
+	if err := namespace.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := namespace.Relation("owner"); !isError(err, ErrRelationNotFound()) {
+		t.Errorf("expected the relation to be undefined, got %v", err)
+	}
+
+	invalid := []*Namespace{
+		{Name: utils.Pointer("Document")},
+		{Name: utils.Pointer("document"), Relations: []Relation{{Name: "viewer"}, {Name: "viewer"}}},
+		{Name: utils.Pointer("document"), Relations: []Relation{{Name: "viewer", Includes: []string{"editor"}}}},
+		{Name: utils.Pointer("document"), Relations: []Relation{{Name: "viewer", TupleToUserset: []TupleToUserset{{Tupleset: "parent", ComputedRelation: "viewer"}}}}},
+	}
+
+	for _, namespace := range invalid {
+		if err := namespace.Validate(); !isError(err, ErrNamespaceIsNotValid()) {
+			t.Errorf("expected the namespace %+v to be invalid, got %v", namespace, err)
+		}
+	}
+}
+
+func TestWriteValidate(t *testing.T) {
+	if err := (&Write{}).Validate(); !isError(err, ErrNothingToWrite()) {
+		t.Errorf("expected nothing to write, got %v", err)
+	}
+
+	in := &Write{Deletes: []Tuple{{Object: &Object{Type: utils.Pointer("document"), ID: utils.Pointer("readme")}, Relation: utils.Pointer("viewer")}}}
+	if err := in.Validate(); !isError(err, ErrSubjectIsNotValid()) {
+		t.Errorf("expected the tuple without subject to be invalid, got %v", err)
+	}
+}
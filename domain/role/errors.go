@@ -0,0 +1,42 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrRoleNotFound creates and returns an error when the role does not exist
+func ErrRoleNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_role_not_found"), http.StatusNotFound)
+}
+
+// ErrRoleExists creates and returns an error when a role with the name already exists
+func ErrRoleExists() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_role_exists"), http.StatusConflict)
+}
+
+// ErrNameIsNotValid creates and returns an error when the name of the role is not valid
+func ErrNameIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_role_name_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrPermissionIsNotValid creates and returns an error when a permission does not exist
+func ErrPermissionIsNotValid() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_role_permission_is_not_valid"), http.StatusBadRequest)
+}
+
+// ErrLevelRole creates and returns an error when deleting or assigning a role given to all users of a level
+func ErrLevelRole() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_role_of_level"), http.StatusBadRequest)
+}
+
+// ErrRoleNotAssigned creates and returns an error when the role is not assigned to the user
+func ErrRoleNotAssigned() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_role_not_assigned"), http.StatusNotFound)
+}
@@ -0,0 +1,21 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import "github.com/google/uuid"
+
+// IRole define an interface for data layer access methods
+type IRole interface {
+	List() ([]Role, error)
+	Get(roleID *uuid.UUID) (*Role, error)
+	Create(*CreateRole) (*uuid.UUID, error)
+	Update(roleID *uuid.UUID, in *UpdateRole) error
+	Delete(roleID *uuid.UUID) error
+	Permissions() ([]PermissionDetail, error)
+	HasPermission(userID *uuid.UUID, permission Permission) (bool, error)
+	UserRoles(userID *uuid.UUID) ([]Role, error)
+	Assign(*Assignment) error
+	Unassign(userID, roleID *uuid.UUID) error
+}
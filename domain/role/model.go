@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/domain/auth"
+)
+
+// Permission set data type to an action the users can be allowed to do
+type Permission string
+
+const (
+	// PermissionUserRead allows to list and read the users, their sessions and lockouts
+	PermissionUserRead Permission = "user:read"
+	// PermissionUserUpdate allows to update, enable, unlock and end the sessions of the users
+	PermissionUserUpdate Permission = "user:update"
+	// PermissionUserDelete allows to delete the users and cancel their deletions
+	PermissionUserDelete Permission = "user:delete"
+	// PermissionUserImport allows to import users in bulk
+	PermissionUserImport Permission = "user:import"
+	// PermissionUserExport allows to export the users and their personal data
+	PermissionUserExport Permission = "user:export"
+	// PermissionInvitationManage allows to invite users and revoke the invitations
+	PermissionInvitationManage Permission = "invitation:manage"
+	// PermissionProjectCreate allows to create projects
+	PermissionProjectCreate Permission = "project:create"
+	// PermissionWebhookManage allows to manage the webhooks of the projects and their deliveries
+	PermissionWebhookManage Permission = "webhook:manage"
+	// PermissionAuditRead allows to read and verify the audit log
+	PermissionAuditRead Permission = "audit:read"
+	// PermissionRoleManage allows to manage the roles and assign them to the users
+	PermissionRoleManage Permission = "role:manage"
+	// PermissionSCIMProvision allows to provision the users and the groups with SCIM
+	PermissionSCIMProvision Permission = "scim:provision"
+	// PermissionDebugPprof allows to access the profiling of the server
+	PermissionDebugPprof Permission = "debug:pprof"
+	// PermissionProjectManage allows to manage the roles and the participants of all projects
+	PermissionProjectManage Permission = "project:manage"
+	// PermissionTokenIntrospect allows to introspect the tokens of the users with the roles of the projects
+	PermissionTokenIntrospect Permission = "token:introspect"
+	// PermissionRelationManage allows to manage the namespaces and write the relationship tuples
+	PermissionRelationManage Permission = "relation:manage"
+	// PermissionRelationCheck allows to read the relationship tuples and check, expand and list the relations
+	PermissionRelationCheck Permission = "relation:check"
+	// PermissionPolicyManage allows to manage the conditional access policies and run them in dry run
+	PermissionPolicyManage Permission = "policy:manage"
+)
+
+// IsValid returns if the permission exists
+func (p Permission) IsValid() bool {
+	switch p {
+	case PermissionUserRead, PermissionUserUpdate, PermissionUserDelete, PermissionUserImport, PermissionUserExport,
+		PermissionInvitationManage, PermissionProjectCreate, PermissionWebhookManage, PermissionAuditRead,
+		PermissionRoleManage, PermissionSCIMProvision, PermissionDebugPprof, PermissionProjectManage, PermissionTokenIntrospect,
+		PermissionRelationManage, PermissionRelationCheck, PermissionPolicyManage:
+		return true
+	}
+	return false
+}
+
+// maxNameLength is the size of the names of the roles
+const maxNameLength = 32
+
+// namePattern is the format of the names of the roles
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Role models a set of permissions given to the users. The roles of a level are given to all users of the level
+type Role struct {
+	ID          *uuid.UUID   `json:"id" sql:"id"`
+	Name        *string      `json:"name" sql:"name"`
+	Description *string      `json:"description,omitempty" sql:"description"`
+	Level       *auth.Level  `json:"level,omitempty" sql:"level"`
+	Permissions []Permission `json:"permissions" sql:"-"`
+	CreatedAt   *time.Time   `json:"created_at" sql:"created_at"`
+	UpdatedAt   *time.Time   `json:"updated_at" sql:"updated_at"`
+}
+
+// IsLevel returns if the role is given to all users of a level
+func (r *Role) IsLevel() bool {
+	return r.Level != nil
+}
+
+// PermissionDetail models a permission with its description
+type PermissionDetail struct {
+	Name        *Permission `json:"name" sql:"name"`
+	Description *string     `json:"description" sql:"description"`
+}
+
+// CreateRole models the data to create a role
+type CreateRole struct {
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// Validate checks the data to create a role, removing the repeated permissions
+func (c *CreateRole) Validate() (err error) {
+	if c.Name == nil {
+		return ErrNameIsNotValid()
+	}
+
+	c.Name = normalizeName(*c.Name)
+	if len(*c.Name) > maxNameLength || !namePattern.MatchString(*c.Name) {
+		return ErrNameIsNotValid()
+	}
+
+	c.Permissions, err = uniquePermissions(c.Permissions)
+	return
+}
+
+// UpdateRole models the data to update a role, the permissions replace the ones of the role when informed
+type UpdateRole struct {
+	Description *string      `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// Validate checks the data to update a role, removing the repeated permissions
+func (u *UpdateRole) Validate() (err error) {
+	if u.Permissions == nil {
+		return nil
+	}
+
+	u.Permissions, err = uniquePermissions(u.Permissions)
+	return
+}
+
+// Assignment models a role assigned to a user
+type Assignment struct {
+	UserID    *uuid.UUID `json:"user_id" sql:"user_id"`
+	RoleID    *uuid.UUID `json:"role_id" sql:"role_id"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" sql:"created_by"`
+	CreatedAt *time.Time `json:"created_at" sql:"created_at"`
+}
+
+func normalizeName(name string) *string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return &name
+}
+
+func uniquePermissions(permissions []Permission) ([]Permission, error) {
+	var (
+		unique = make([]Permission, 0, len(permissions))
+		seen   = make(map[Permission]bool, len(permissions))
+	)
+
+	for _, permission := range permissions {
+		if !permission.IsValid() {
+			return nil, ErrPermissionIsNotValid()
+		}
+
+		if !seen[permission] {
+			seen[permission] = true
+			unique = append(unique, permission)
+		}
+	}
+
+	return unique, nil
+}
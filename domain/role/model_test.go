@@ -0,0 +1,80 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func isError(err error, expected *oops.Error) bool {
+	var e *oops.Error
+	return errors.As(err, &e) && e.Message == expected.Message && e.Code == expected.Code
+}
+
+func TestCreateRoleValidate(t *testing.T) {
+	in := &CreateRole{
+		Name:        utils.Pointer(" Support.Team "),
+		Permissions: []Permission{PermissionUserRead, PermissionAuditRead, PermissionUserRead},
+	}
+	if err := in.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *in.Name != "support.team" {
+		t.Errorf("expected the name to be normalized, got %s", *in.Name)
+	}
+
+	if !reflect.DeepEqual(in.Permissions, []Permission{PermissionUserRead, PermissionAuditRead}) {
+		t.Errorf("expected the repeated permissions to be removed, got %v", in.Permissions)
+	}
+
+	for _, name := range []string{"", "-support", "support team", "a23456789012345678901234567890123"} {
+		if err := (&CreateRole{Name: utils.Pointer(name)}).Validate(); !isError(err, ErrNameIsNotValid()) {
+			t.Errorf("expected the name %q to be invalid, got %v", name, err)
+		}
+	}
+
+	if err := (&CreateRole{}).Validate(); !isError(err, ErrNameIsNotValid()) {
+		t.Errorf("expected the role without name to be invalid, got %v", err)
+	}
+
+	in = &CreateRole{Name: utils.Pointer("support"), Permissions: []Permission{"user:fly"}}
+	if err := in.Validate(); !isError(err, ErrPermissionIsNotValid()) {
+		t.Errorf("expected the permission to be invalid, got %v", err)
+	}
+}
+
+func TestUpdateRoleValidate(t *testing.T) {
+	in := &UpdateRole{Description: utils.Pointer("Support team")}
+	if err := in.Validate(); err != nil || in.Permissions != nil {
+		t.Errorf("expected the permissions to be kept, got %v %v", in.Permissions, err)
+	}
+
+	in = &UpdateRole{Permissions: []Permission{}}
+	if err := in.Validate(); err != nil || in.Permissions == nil {
+		t.Errorf("expected the permissions to be cleared, got %v %v", in.Permissions, err)
+	}
+
+	in = &UpdateRole{Permissions: []Permission{PermissionRoleManage, "role:fly"}}
+	if err := in.Validate(); !isError(err, ErrPermissionIsNotValid()) {
+		t.Errorf("expected the permission to be invalid, got %v", err)
+	}
+}
+
+func TestRoleIsLevel(t *testing.T) {
+	if !(&Role{Level: utils.Pointer(auth.AdminLevel)}).IsLevel() {
+		t.Error("expected the role of a level")
+	}
+
+	if (&Role{Name: utils.Pointer("support")}).IsLevel() {
+		t.Error("expected a role that is not of a level")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Codes of the errors of the provisioning, used to find the scimType of the error responses
+const (
+	codeInvalidFilter int = 6001 + iota
+	codeInvalidPath
+	codeNoTarget
+	codeInvalidSyntax
+	codeInvalidValue
+	codeUniqueness
+	codeTooMany
+)
+
+// scimTypes are the types of the errors of the section 3.12 of the RFC 7644
+var scimTypes = map[int]string{
+	codeInvalidFilter: "invalidFilter",
+	codeInvalidPath:   "invalidPath",
+	codeNoTarget:      "noTarget",
+	codeInvalidSyntax: "invalidSyntax",
+	codeInvalidValue:  "invalidValue",
+	codeUniqueness:    "uniqueness",
+	codeTooMany:       "tooMany",
+}
+
+func newError(key string, code, status int) *oops.Error {
+	err := oops.NewError(i18n.Value("errors.handling."+key), status)
+	err.Code = code
+	return err
+}
+
+// ErrInvalidFilter creates and returns an error when the filter cannot be parsed or uses an attribute that cannot be filtered
+func ErrInvalidFilter() *oops.Error {
+	return newError("err_scim_invalid_filter", codeInvalidFilter, http.StatusBadRequest)
+}
+
+// ErrInvalidPath creates and returns an error when the path of a patch operation is not valid
+func ErrInvalidPath() *oops.Error {
+	return newError("err_scim_invalid_path", codeInvalidPath, http.StatusBadRequest)
+}
+
+// ErrNoTarget creates and returns an error when the path of a patch operation does not match any value
+func ErrNoTarget() *oops.Error {
+	return newError("err_scim_no_target", codeNoTarget, http.StatusBadRequest)
+}
+
+// ErrInvalidPatch creates and returns an error when a patch operation is not add, remove or replace or has no value
+func ErrInvalidPatch() *oops.Error {
+	return newError("err_scim_invalid_patch", codeInvalidSyntax, http.StatusBadRequest)
+}
+
+// ErrInvalidValue creates and returns an error when a value has the wrong type for the attribute
+func ErrInvalidValue() *oops.Error {
+	return newError("err_scim_invalid_value", codeInvalidValue, http.StatusBadRequest)
+}
+
+// ErrInvalidMember creates and returns an error when a member of a group is not a user
+func ErrInvalidMember() *oops.Error {
+	return newError("err_scim_invalid_member", codeInvalidValue, http.StatusBadRequest)
+}
+
+// ErrGroupNameIsNotValid creates and returns an error when the name of a group does not fit the name of a project
+func ErrGroupNameIsNotValid() *oops.Error {
+	return newError("err_scim_group_name_is_not_valid", codeInvalidValue, http.StatusBadRequest)
+}
+
+// ErrUserExists creates and returns an error when another account has the userName
+func ErrUserExists() *oops.Error {
+	return newError("err_user_exists", codeUniqueness, http.StatusConflict)
+}
+
+// ErrGroupNotFound creates and returns an error when the group does not exist
+func ErrGroupNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_scim_group_not_found"), http.StatusNotFound)
+}
+
+// ErrGroupDeletion creates and returns an error when a group is deleted, since the projects cannot be deleted
+func ErrGroupDeletion() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_scim_group_deletion"), http.StatusNotImplemented)
+}
+
+// ErrInvalidBulkOperation creates and returns an error when the method or the path of a bulk operation is not valid
+func ErrInvalidBulkOperation() *oops.Error {
+	return newError("err_scim_invalid_bulk_operation", codeInvalidSyntax, http.StatusBadRequest)
+}
+
+// ErrTooManyOperations creates and returns an error when a bulk request exceeds the limits of the configuration
+func ErrTooManyOperations() *oops.Error {
+	return newError("err_scim_too_many_operations", codeTooMany, http.StatusRequestEntityTooLarge)
+}
+
+// ErrorResponse models an error in the format of the section 3.12 of the RFC 7644
+type ErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// NewErrorResponse creates the response of an error, returning its status code
+func NewErrorResponse(err error) (int, *ErrorResponse) {
+	var handled *oops.Error
+	if !errors.As(oops.Err(err), &handled) {
+		handled = &oops.Error{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+	}
+
+	scimType, ok := scimTypes[handled.Code]
+	if !ok && handled.StatusCode == http.StatusBadRequest {
+		scimType = scimTypes[codeInvalidValue]
+	}
+
+	return handled.StatusCode, &ErrorResponse{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(handled.StatusCode),
+		ScimType: scimType,
+		Detail:   handled.Message,
+	}
+}
@@ -0,0 +1,502 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Operator set data type to the operators of the filters
+type Operator string
+
+const (
+	OperatorEq      Operator = "eq"
+	OperatorNe      Operator = "ne"
+	OperatorCo      Operator = "co"
+	OperatorSw      Operator = "sw"
+	OperatorEw      Operator = "ew"
+	OperatorGt      Operator = "gt"
+	OperatorGe      Operator = "ge"
+	OperatorLt      Operator = "lt"
+	OperatorLe      Operator = "le"
+	OperatorPresent Operator = "pr"
+	OperatorAnd     Operator = "and"
+	OperatorOr      Operator = "or"
+)
+
+// isComparison returns if the operator compares an attribute with a value
+func (o Operator) isComparison() bool {
+	switch o {
+	case OperatorEq, OperatorNe, OperatorCo, OperatorSw, OperatorEw, OperatorGt, OperatorGe, OperatorLt, OperatorLe:
+		return true
+	}
+	return false
+}
+
+// Filter is an expression of the filters of the lists and of the paths of the patches
+type Filter interface {
+	// Matches returns if the attributes of a resource match the expression
+	Matches(resource map[string]interface{}) bool
+}
+
+// Comparison models the comparison of an attribute with a value, the value is nil on the presence operator.
+// The path of the attribute is in lower case, since the names of the attributes are case insensitive
+type Comparison struct {
+	Path     string
+	Operator Operator
+	Value    interface{}
+}
+
+// Logical models the combination of two expressions with and or or
+type Logical struct {
+	Operator    Operator
+	Left, Right Filter
+}
+
+// Not models the negation of an expression
+type Not struct {
+	Filter Filter
+}
+
+// ValuePath models an expression on the values of a multi-valued attribute, like emails[type eq "work"]
+type ValuePath struct {
+	Path   string
+	Filter Filter
+}
+
+// ParseFilter parses a filter in the syntax of the section 3.4.2.2 of the RFC 7644
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.done() {
+		return nil, ErrInvalidFilter()
+	}
+
+	return expr, nil
+}
+
+// token models a word, a string or a bracket of the filter
+type token struct {
+	value  string
+	quoted bool
+}
+
+// tokenize splits the filter in words, strings and brackets
+func tokenize(filter string) (tokens []token, err error) {
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			tokens = append(tokens, token{value: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(filter) && filter[end] != '"'; end++ {
+				if filter[end] == '\\' {
+					end++
+				}
+			}
+
+			if end >= len(filter) {
+				return nil, ErrInvalidFilter()
+			}
+
+			var value string
+			if err = json.Unmarshal([]byte(filter[i:end+1]), &value); err != nil {
+				return nil, ErrInvalidFilter()
+			}
+
+			tokens = append(tokens, token{value: value, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(filter) && strings.IndexByte(" \t()[]\"", filter[end]) < 0 {
+				end++
+			}
+			tokens = append(tokens, token{value: filter[i:end]})
+			i = end
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, ErrInvalidFilter()
+	}
+
+	return
+}
+
+// parser reads the tokens with the precedence of the operators, from the lowest: or, and, not
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.position++
+	return t
+}
+
+// keyword returns if the next token is the keyword, consuming it
+func (p *parser) keyword(value string) bool {
+	if t := p.peek(); !t.quoted && strings.EqualFold(t.value, value) {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (Filter, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword(string(OperatorOr)) {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Operator: OperatorOr, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) and() (Filter, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword(string(OperatorAnd)) {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Operator: OperatorAnd, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) unary() (Filter, error) {
+	if p.keyword("not") {
+		if !p.keyword("(") {
+			return nil, ErrInvalidFilter()
+		}
+		return p.group(func(expr Filter) Filter { return &Not{Filter: expr} })
+	}
+
+	if p.keyword("(") {
+		return p.group(func(expr Filter) Filter { return expr })
+	}
+
+	return p.attribute()
+}
+
+// group reads the expression until the closing parenthesis
+func (p *parser) group(wrap func(Filter) Filter) (Filter, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.keyword(")") {
+		return nil, ErrInvalidFilter()
+	}
+
+	return wrap(expr), nil
+}
+
+// attribute reads a comparison or a value path
+func (p *parser) attribute() (Filter, error) {
+	name := p.next()
+	if name.quoted || !validPath(name.value) {
+		return nil, ErrInvalidFilter()
+	}
+	path := normalizePath(name.value)
+
+	if p.keyword("[") {
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.keyword("]") {
+			return nil, ErrInvalidFilter()
+		}
+		return &ValuePath{Path: path, Filter: expr}, nil
+	}
+
+	operator := Operator(strings.ToLower(p.next().value))
+	if operator == OperatorPresent {
+		return &Comparison{Path: path, Operator: operator}, nil
+	}
+
+	if !operator.isComparison() || p.done() {
+		return nil, ErrInvalidFilter()
+	}
+
+	value, err := literal(p.next())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comparison{Path: path, Operator: operator, Value: value}, nil
+}
+
+// literal converts the token of a value to a string, a boolean, a number or nil
+func literal(t token) (interface{}, error) {
+	if t.quoted {
+		return t.value, nil
+	}
+
+	switch strings.ToLower(t.value) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	number, err := strconv.ParseFloat(t.value, 64)
+	if err != nil {
+		return nil, ErrInvalidFilter()
+	}
+
+	return number, nil
+}
+
+// validPath returns if the value can be the name of an attribute, including the urn of the schema
+func validPath(value string) bool {
+	if value == "" || !unicode.IsLetter(rune(value[0])) {
+		return false
+	}
+
+	for _, c := range value {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && strings.IndexRune("_-.:$", c) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizePath removes the urn of the core schemas and converts the path to lower case
+func normalizePath(path string) string {
+	lower := strings.ToLower(path)
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		if prefix := strings.ToLower(schema) + ":"; strings.HasPrefix(lower, prefix) {
+			return lower[len(prefix):]
+		}
+	}
+	return lower
+}
+
+// Matches returns if the attribute, or any value of a multi-valued attribute, matches the comparison
+func (c *Comparison) Matches(resource map[string]interface{}) bool {
+	values := lookup(resource, c.Path)
+
+	if c.Operator == OperatorPresent {
+		for _, value := range values {
+			if !empty(value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if c.Operator == OperatorNe {
+		return !(&Comparison{Path: c.Path, Operator: OperatorEq, Value: c.Value}).Matches(resource)
+	}
+
+	for _, value := range values {
+		if compare(value, c.Operator, c.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches returns if the expressions match with and or or
+func (l *Logical) Matches(resource map[string]interface{}) bool {
+	if l.Operator == OperatorAnd {
+		return l.Left.Matches(resource) && l.Right.Matches(resource)
+	}
+	return l.Left.Matches(resource) || l.Right.Matches(resource)
+}
+
+// Matches returns if the expression does not match
+func (n *Not) Matches(resource map[string]interface{}) bool {
+	return !n.Filter.Matches(resource)
+}
+
+// Matches returns if a value of the multi-valued attribute matches the expression
+func (v *ValuePath) Matches(resource map[string]interface{}) bool {
+	key, ok := findKey(resource, v.Path)
+	if !ok {
+		return false
+	}
+
+	for _, value := range asList(resource[key]) {
+		if item, ok := value.(map[string]interface{}); ok && v.Filter.Matches(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the values of the attribute of the path, flattening the multi-valued attributes
+func lookup(resource map[string]interface{}, path string) []interface{} {
+	name, sub, _ := strings.Cut(path, ".")
+
+	key, ok := findKey(resource, name)
+	if !ok {
+		return nil
+	}
+
+	values := []interface{}{resource[key]}
+	if items, ok := resource[key].([]interface{}); ok {
+		values = items
+	}
+
+	if sub == "" {
+		var result []interface{}
+		for _, value := range values {
+			// the value of a multi-valued complex attribute is its sub-attribute value
+			if item, ok := value.(map[string]interface{}); ok {
+				if valueKey, ok := findKey(item, "value"); ok {
+					result = append(result, item[valueKey])
+					continue
+				}
+			}
+			result = append(result, value)
+		}
+		return result
+	}
+
+	var result []interface{}
+	for _, value := range values {
+		if item, ok := value.(map[string]interface{}); ok {
+			result = append(result, lookup(item, sub)...)
+		}
+	}
+
+	return result
+}
+
+// findKey finds the key of the attribute ignoring the case of the name
+func findKey(resource map[string]interface{}, name string) (string, bool) {
+	if _, ok := resource[name]; ok {
+		return name, true
+	}
+
+	for key := range resource {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+
+	return name, false
+}
+
+func empty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// compare compares a value of the resource with the value of the filter, the strings ignoring the case
+func compare(value interface{}, operator Operator, expected interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		text, ok := expected.(string)
+		if !ok {
+			return false
+		}
+
+		v, text = strings.ToLower(v), strings.ToLower(text)
+		switch operator {
+		case OperatorEq:
+			return v == text
+		case OperatorCo:
+			return strings.Contains(v, text)
+		case OperatorSw:
+			return strings.HasPrefix(v, text)
+		case OperatorEw:
+			return strings.HasSuffix(v, text)
+		case OperatorGt:
+			return v > text
+		case OperatorGe:
+			return v >= text
+		case OperatorLt:
+			return v < text
+		case OperatorLe:
+			return v <= text
+		}
+
+	case float64:
+		number, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+
+		switch operator {
+		case OperatorEq:
+			return v == number
+		case OperatorGt:
+			return v > number
+		case OperatorGe:
+			return v >= number
+		case OperatorLt:
+			return v < number
+		case OperatorLe:
+			return v <= number
+		}
+
+	case bool:
+		return operator == OperatorEq && v == expected
+
+	case nil:
+		return operator == OperatorEq && expected == nil
+	}
+
+	return false
+}
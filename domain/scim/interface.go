@@ -0,0 +1,21 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import "github.com/google/uuid"
+
+// ISCIM define an interface for data layer access methods
+type ISCIM interface {
+	Users(filter Filter, params *ListParams) ([]User, int64, error)
+	User(userID *uuid.UUID) (*User, error)
+	EmailExists(email *string, except *uuid.UUID) (bool, error)
+	UpdateUser(userID *uuid.UUID, in *Account) error
+	Groups(filter Filter, params *ListParams) ([]Group, int64, error)
+	Group(groupID *uuid.UUID, members bool) (*Group, error)
+	CreateGroup(in *Project, createdBy *uuid.UUID) (*uuid.UUID, error)
+	UpdateGroup(groupID *uuid.UUID, in *Project) error
+	AddMember(groupID, userID *uuid.UUID) error
+	RemoveMember(groupID, userID *uuid.UUID) error
+}
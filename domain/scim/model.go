@@ -0,0 +1,496 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/auth"
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Schemas of the resources and messages of the RFC 7643 and RFC 7644
+const (
+	SchemaUser                  string = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 string = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig string = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          string = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               string = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaBulkRequest           string = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	SchemaBulkResponse          string = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	SchemaError                 string = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Types and endpoints of the resources
+const (
+	ResourceUser  string = "User"
+	ResourceGroup string = "Group"
+
+	EndpointUsers  string = "Users"
+	EndpointGroups string = "Groups"
+)
+
+// maxGroupNameLength is the size of the name of the projects
+const maxGroupNameLength = 20
+
+// Meta models the metadata of a resource
+type Meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// User models a user of the core schema, mapped onto an account with the user level.
+// The userName is the email of the account and the password can only be written
+type User struct {
+	Schemas     []string   `json:"schemas"`
+	ID          *uuid.UUID `json:"id,omitempty"`
+	ExternalID  *string    `json:"externalId,omitempty"`
+	UserName    *string    `json:"userName,omitempty"`
+	Name        *Name      `json:"name,omitempty"`
+	DisplayName *string    `json:"displayName,omitempty"`
+	Emails      []Email    `json:"emails,omitempty"`
+	Active      *bool      `json:"active,omitempty"`
+	Locale      *string    `json:"locale,omitempty"`
+	Password    *string    `json:"password,omitempty"`
+	Groups      []Member   `json:"groups,omitempty"`
+	Meta        *Meta      `json:"meta,omitempty"`
+}
+
+// Name models the name of a user
+type Name struct {
+	Formatted  *string `json:"formatted,omitempty"`
+	GivenName  *string `json:"givenName,omitempty"`
+	FamilyName *string `json:"familyName,omitempty"`
+}
+
+// Email models an email of a user, the accounts have only the primary work email
+type Email struct {
+	Value   *string `json:"value,omitempty"`
+	Type    string  `json:"type,omitempty"`
+	Primary bool    `json:"primary,omitempty"`
+}
+
+// Member models a reference to a user in the members of a group, or to a group in the groups of a user
+type Member struct {
+	Value   *string `json:"value,omitempty"`
+	Display *string `json:"display,omitempty"`
+	Ref     *string `json:"$ref,omitempty"`
+}
+
+// Prepare fills the attributes derived from the account
+func (u *User) Prepare(baseURL string) {
+	u.Schemas, u.Password = []string{SchemaUser}, nil
+
+	if u.Name != nil && u.Name.GivenName != nil && u.Name.FamilyName != nil {
+		u.Name.Formatted = utils.Pointer(*u.Name.GivenName + " " + *u.Name.FamilyName)
+		u.DisplayName = u.Name.Formatted
+	}
+
+	u.Emails = []Email{{Value: u.UserName, Type: "work", Primary: true}}
+
+	if u.Locale != nil {
+		u.Locale = utils.Pointer(strings.ReplaceAll(*u.Locale, "_", "-"))
+	}
+
+	for i := range u.Groups {
+		u.Groups[i].Ref = utils.Pointer(baseURL + "/" + EndpointGroups + "/" + *u.Groups[i].Value)
+	}
+
+	if u.Meta == nil {
+		u.Meta = new(Meta)
+	}
+	u.Meta.ResourceType, u.Meta.Location = ResourceUser, baseURL+"/"+EndpointUsers+"/"+u.ID.String()
+}
+
+// Account creates the account of the user, with the same validation of the accounts created by the users.
+// Locales without translations are ignored, since the identity providers send the locale of any user
+func (u *User) Account() (*Account, error) {
+	account := &Account{ExternalID: u.ExternalID, Email: u.UserName, Active: u.Active, Password: u.Password}
+	if account.Email == nil {
+		account.Email = u.primaryEmail()
+	}
+
+	if u.Name != nil {
+		account.FirstName, account.LastName = u.Name.GivenName, u.Name.FamilyName
+	}
+
+	if account.Active == nil {
+		account.Active = utils.Pointer(true)
+	}
+
+	profile := &auth.CreateAccount{
+		FirstName: account.FirstName,
+		LastName:  account.LastName,
+		Email:     account.Email,
+		Password:  account.Password,
+	}
+
+	validate := profile.Validate
+	if account.Password == nil {
+		validate = profile.ValidateProfile
+	}
+
+	if err := validate(); err != nil {
+		return nil, err
+	}
+
+	account.Email = utils.Pointer(strings.ToLower(strings.TrimSpace(*account.Email)))
+
+	if u.Locale != nil {
+		if locale := strings.ReplaceAll(*u.Locale, "-", "_"); i18n.IsSupported(locale) {
+			account.Locale = &locale
+		}
+	}
+
+	return account, nil
+}
+
+// SyncEmail uses the primary email as the userName when a patch changed only the email
+func (u *User) SyncEmail(previous *User) {
+	if u.UserName != nil && previous.UserName != nil && *u.UserName != *previous.UserName {
+		return
+	}
+
+	if email := u.primaryEmail(); email != nil {
+		u.UserName = email
+	}
+}
+
+func (u *User) primaryEmail() *string {
+	for _, email := range u.Emails {
+		if email.Primary && email.Value != nil {
+			return email.Value
+		}
+	}
+
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+
+	return nil
+}
+
+// Account models the data of an account written by the provisioning. The locale and the
+// password are kept when not informed, the password is written with the key of its hash
+type Account struct {
+	ExternalID *string
+	Email      *string
+	FirstName  *string
+	LastName   *string
+	Active     *bool
+	Locale     *string
+	Password   *string
+	Key        *string
+}
+
+// Group models a group of the core schema, mapped onto a project. The members are the current participants
+type Group struct {
+	Schemas     []string   `json:"schemas"`
+	ID          *uuid.UUID `json:"id,omitempty"`
+	ExternalID  *string    `json:"externalId,omitempty"`
+	DisplayName *string    `json:"displayName,omitempty"`
+	Members     []Member   `json:"members,omitempty"`
+	Meta        *Meta      `json:"meta,omitempty"`
+}
+
+// Prepare fills the attributes derived from the project
+func (g *Group) Prepare(baseURL string) {
+	g.Schemas = []string{SchemaGroup}
+
+	for i := range g.Members {
+		g.Members[i].Ref = utils.Pointer(baseURL + "/" + EndpointUsers + "/" + *g.Members[i].Value)
+	}
+
+	if g.Meta == nil {
+		g.Meta = new(Meta)
+	}
+	g.Meta.ResourceType, g.Meta.Location = ResourceGroup, baseURL+"/"+EndpointGroups+"/"+g.ID.String()
+}
+
+// Project creates the project of the group, with the members without repetition
+func (g *Group) Project() (*Project, error) {
+	if g.DisplayName == nil {
+		return nil, ErrGroupNameIsNotValid()
+	}
+
+	name := strings.TrimSpace(*g.DisplayName)
+	if name == "" || len([]rune(name)) > maxGroupNameLength {
+		return nil, ErrGroupNameIsNotValid()
+	}
+
+	project := &Project{ExternalID: g.ExternalID, Name: &name, Members: make([]uuid.UUID, 0, len(g.Members))}
+
+	seen := make(map[uuid.UUID]bool, len(g.Members))
+	for _, member := range g.Members {
+		if member.Value == nil {
+			return nil, ErrInvalidMember()
+		}
+
+		userID, err := uuid.Parse(*member.Value)
+		if err != nil {
+			return nil, ErrInvalidMember()
+		}
+
+		if !seen[userID] {
+			seen[userID] = true
+			project.Members = append(project.Members, userID)
+		}
+	}
+
+	return project, nil
+}
+
+// Project models the data of a project written by the provisioning
+type Project struct {
+	ExternalID *string
+	Name       *string
+	Members    []uuid.UUID
+}
+
+// Changes returns the members added and removed in relation to the current members of the group
+func (p *Project) Changes(current []Member) (added, removed []uuid.UUID) {
+	members := make(map[uuid.UUID]bool, len(current))
+	for _, member := range current {
+		if userID, err := uuid.Parse(*member.Value); err == nil {
+			members[userID] = true
+		}
+	}
+
+	for _, userID := range p.Members {
+		if !members[userID] {
+			added = append(added, userID)
+		}
+		delete(members, userID)
+	}
+
+	for _, member := range current {
+		if userID, err := uuid.Parse(*member.Value); err == nil && members[userID] {
+			removed = append(removed, userID)
+		}
+	}
+
+	return
+}
+
+// ListParams models the parameters of the lists of resources, the first resource has the index one
+type ListParams struct {
+	Filter             *string `form:"filter"`
+	StartIndex         int64   `form:"startIndex"`
+	Count              *int64  `form:"count"`
+	ExcludedAttributes *string `form:"excludedAttributes"`
+}
+
+// Prepare parses the filter and limits the number of resources to the maximum of the configuration
+func (p *ListParams) Prepare(maxResults int64) (filter Filter, err error) {
+	if p.StartIndex < 1 {
+		p.StartIndex = 1
+	}
+
+	switch {
+	case p.Count == nil || *p.Count > maxResults:
+		p.Count = &maxResults
+	case *p.Count < 0:
+		p.Count = utils.Pointer[int64](0)
+	}
+
+	if p.Filter != nil && strings.TrimSpace(*p.Filter) != "" {
+		return ParseFilter(*p.Filter)
+	}
+
+	return nil, nil
+}
+
+// Excluded returns if the attribute must not be returned
+func (p *ListParams) Excluded(attribute string) bool {
+	if p.ExcludedAttributes == nil {
+		return false
+	}
+
+	for _, name := range strings.Split(*p.ExcludedAttributes, ",") {
+		if normalizePath(strings.TrimSpace(name)) == strings.ToLower(attribute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListResponse models a page of the resources that match the filter
+type ListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int64    `json:"startIndex"`
+	ItemsPerPage int64    `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+// NewListResponse creates a page of the resources
+func NewListResponse[T any](resources []T, total int64, params *ListParams) *ListResponse[T] {
+	if resources == nil {
+		resources = make([]T, 0)
+	}
+
+	return &ListResponse[T]{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   params.StartIndex,
+		ItemsPerPage: int64(len(resources)),
+		Resources:    resources,
+	}
+}
+
+// Methods of the bulk operations
+const (
+	MethodPost   string = "POST"
+	MethodPut    string = "PUT"
+	MethodPatch  string = "PATCH"
+	MethodDelete string = "DELETE"
+)
+
+// bulkIDPrefix is the prefix of the references to the resources created in the same bulk request
+const bulkIDPrefix = "bulkId:"
+
+// BulkRequest models a request with operations on several resources, section 3.7 of the RFC 7644
+type BulkRequest struct {
+	Schemas      []string        `json:"schemas"`
+	FailOnErrors *int            `json:"failOnErrors,omitempty"`
+	Operations   []BulkOperation `json:"Operations" binding:"required,min=1"`
+}
+
+// BulkOperation models an operation of a bulk request
+type BulkOperation struct {
+	Method string          `json:"method"`
+	BulkID *string         `json:"bulkId,omitempty"`
+	Path   string          `json:"path"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Resolve replaces the references to the resources created by the previous operations of the request
+func (o *BulkOperation) Resolve(created map[string]string) {
+	if !strings.Contains(o.Path, bulkIDPrefix) && !strings.Contains(string(o.Data), bulkIDPrefix) {
+		return
+	}
+
+	for bulkID, id := range created {
+		o.Path = strings.ReplaceAll(o.Path, bulkIDPrefix+bulkID, id)
+		o.Data = json.RawMessage(strings.ReplaceAll(string(o.Data), `"`+bulkIDPrefix+bulkID+`"`, strconv.Quote(id)))
+	}
+}
+
+// Endpoint returns the endpoint of the resources of the path of the operation
+func (o *BulkOperation) Endpoint() string {
+	endpoint, _, _ := strings.Cut(strings.Trim(o.Path, "/"), "/")
+	return endpoint
+}
+
+// Target validates the method and the path of the operation, returning the resource of the path.
+// The resource is informed on all methods but the creation, which needs the bulkId to be referenced
+func (o *BulkOperation) Target() (*uuid.UUID, error) {
+	o.Method = strings.ToUpper(o.Method)
+	if endpoint := o.Endpoint(); endpoint != EndpointUsers && endpoint != EndpointGroups {
+		return nil, ErrInvalidBulkOperation()
+	}
+
+	_, resource, _ := strings.Cut(strings.Trim(o.Path, "/"), "/")
+
+	switch o.Method {
+	case MethodPost:
+		if resource != "" || o.BulkID == nil || *o.BulkID == "" {
+			return nil, ErrInvalidBulkOperation()
+		}
+		return nil, nil
+
+	case MethodPut, MethodPatch, MethodDelete:
+		resourceID, err := uuid.Parse(resource)
+		if err != nil {
+			return nil, ErrInvalidBulkOperation()
+		}
+		return &resourceID, nil
+	}
+
+	return nil, ErrInvalidBulkOperation()
+}
+
+// BulkResponse models the results of the operations of a bulk request, in the order of the request
+type BulkResponse struct {
+	Schemas    []string     `json:"schemas"`
+	Operations []BulkResult `json:"Operations"`
+}
+
+// BulkResult models the result of an operation, with the error when it failed
+type BulkResult struct {
+	Method   string         `json:"method"`
+	BulkID   *string        `json:"bulkId,omitempty"`
+	Location string         `json:"location,omitempty"`
+	Status   string         `json:"status"`
+	Response *ErrorResponse `json:"response,omitempty"`
+}
+
+// NewBulkResponse creates the response of a bulk request
+func NewBulkResponse() *BulkResponse {
+	return &BulkResponse{Schemas: []string{SchemaBulkResponse}, Operations: make([]BulkResult, 0)}
+}
+
+// ServiceProviderConfig models the features of the provisioning, section 5 of the RFC 7643
+type ServiceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 Supported              `json:"patch"`
+	Bulk                  BulkSupported          `json:"bulk"`
+	Filter                FilterSupported        `json:"filter"`
+	ChangePassword        Supported              `json:"changePassword"`
+	Sort                  Supported              `json:"sort"`
+	ETag                  Supported              `json:"etag"`
+	AuthenticationSchemes []AuthenticationScheme `json:"authenticationSchemes"`
+}
+
+// Supported models if a feature is supported
+type Supported struct {
+	Supported bool `json:"supported"`
+}
+
+// BulkSupported models the limits of the bulk requests
+type BulkSupported struct {
+	Supported      bool  `json:"supported"`
+	MaxOperations  int   `json:"maxOperations"`
+	MaxPayloadSize int64 `json:"maxPayloadSize"`
+}
+
+// FilterSupported models the limit of the resources returned by the lists
+type FilterSupported struct {
+	Supported  bool  `json:"supported"`
+	MaxResults int64 `json:"maxResults"`
+}
+
+// AuthenticationScheme models how the clients authenticate
+type AuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// NewServiceProviderConfig creates the features of the provisioning with the limits of the configuration
+func NewServiceProviderConfig(cfg *config.SCIMConfig) *ServiceProviderConfig {
+	return &ServiceProviderConfig{
+		Schemas:        []string{SchemaServiceProviderConfig},
+		Patch:          Supported{Supported: true},
+		Bulk:           BulkSupported{Supported: true, MaxOperations: cfg.BulkMaxOperations, MaxPayloadSize: cfg.BulkMaxPayloadSize},
+		Filter:         FilterSupported{Supported: true, MaxResults: cfg.MaxResults},
+		ChangePassword: Supported{Supported: true},
+		AuthenticationSchemes: []AuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer Token",
+			Description: "Token of a session of a user with the integration level",
+		}},
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestParseFilter(t *testing.T) {
+	resource := map[string]interface{}{
+		"userName": "Jane@PowerSSO.io",
+		"active":   true,
+		"name":     map[string]interface{}{"givenName": "Jane", "familyName": "Doe"},
+		"emails":   []interface{}{map[string]interface{}{"value": "jane@powersso.io", "type": "work"}},
+		"meta":     map[string]interface{}{"lastModified": "2023-02-01T10:00:00Z"},
+	}
+
+	for filter, expected := range map[string]bool{
+		`userName eq "jane@powersso.io"`:                                           true,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "jane"`:            true,
+		`name.familyName co "oe" and active eq true`:                               true,
+		`name.familyName eq "Smith" or active eq false`:                            false,
+		`not (active eq false)`:                                                    true,
+		`emails[type eq "work" and value ew "@powersso.io"]`:                       true,
+		`emails[type eq "home"]`:                                                   false,
+		`externalId pr`:                                                            false,
+		`meta.lastModified gt "2023-01-01T00:00:00Z"`:                              true,
+		`userName ne "jane@powersso.io" or (active eq true and name.givenName pr)`: true,
+	} {
+		parsed, err := ParseFilter(filter)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", filter, err)
+		}
+
+		if parsed.Matches(resource) != expected {
+			t.Errorf("expected %q to match %v", filter, expected)
+		}
+	}
+}
+
+func TestParseFilterInvalid(t *testing.T) {
+	for _, filter := range []string{
+		`userName`,
+		`userName eq`,
+		`userName xx "jane"`,
+		`userName eq "jane" and`,
+		`(userName eq "jane"`,
+		`emails[type eq "work"`,
+		`userName eq "jane`,
+	} {
+		if _, err := ParseFilter(filter); err == nil {
+			t.Errorf("expected an error parsing %q", filter)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath(`emails[type eq "work"].value`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path.Attribute != "emails" || path.Sub != "value" || path.Filter == nil {
+		t.Errorf("unexpected path %+v", path)
+	}
+
+	if path, err = ParsePath("urn:ietf:params:scim:schemas:core:2.0:User:name.givenName"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path.Attribute != "name" || path.Sub != "givenname" {
+		t.Errorf("unexpected path %+v", path)
+	}
+
+	if _, err = ParsePath("emails[type eq"); err == nil {
+		t.Error("expected an error on an invalid path")
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	user := &User{
+		UserName: utils.Pointer("jane@powersso.io"),
+		Name:     &Name{GivenName: utils.Pointer("Jane"), FamilyName: utils.Pointer("Doe")},
+		Active:   utils.Pointer(true),
+	}
+	user.Emails = []Email{{Value: user.UserName, Type: "work", Primary: true}}
+
+	t.Run("PathlessWithStrings", func(t *testing.T) {
+		patched, err := Patch(user, &PatchRequest{Operations: []PatchOperation{
+			{Op: "Replace", Value: map[string]interface{}{"active": "False", "name.givenName": "Janet"}},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if *patched.Active || *patched.Name.GivenName != "Janet" || *patched.Name.FamilyName != "Doe" {
+			t.Errorf("unexpected patched user %+v", patched)
+		}
+
+		if !*user.Active {
+			t.Error("expected the original user to be kept")
+		}
+	})
+
+	t.Run("FilteredEmail", func(t *testing.T) {
+		patched, err := Patch(user, &PatchRequest{Operations: []PatchOperation{
+			{Op: "replace", Path: utils.Pointer(`emails[type eq "work"].value`), Value: "janet@powersso.io"},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if patched.SyncEmail(user); *patched.UserName != "janet@powersso.io" {
+			t.Errorf("expected the userName to follow the email, got %s", *patched.UserName)
+		}
+	})
+
+	t.Run("NoTarget", func(t *testing.T) {
+		_, err := Patch(user, &PatchRequest{Operations: []PatchOperation{
+			{Op: "replace", Path: utils.Pointer(`emails[type eq "home"].value`), Value: "jane@home.io"},
+		}})
+		if !isError(err, ErrNoTarget()) {
+			t.Errorf("expected the error noTarget, got %v", err)
+		}
+	})
+
+	t.Run("InvalidOperation", func(t *testing.T) {
+		_, err := Patch(user, &PatchRequest{Operations: []PatchOperation{{Op: "move", Path: utils.Pointer("active")}}})
+		if !isError(err, ErrInvalidPatch()) {
+			t.Errorf("expected the error invalidSyntax, got %v", err)
+		}
+	})
+}
+
+func TestPatchGroupMembers(t *testing.T) {
+	first, second, third := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	group := &Group{
+		DisplayName: utils.Pointer("Engineering"),
+		Members:     []Member{{Value: &first}, {Value: &second}},
+	}
+
+	patched, err := Patch(group, &PatchRequest{Operations: []PatchOperation{
+		{Op: "add", Path: utils.Pointer("members"), Value: []interface{}{map[string]interface{}{"value": third}}},
+		{Op: "remove", Path: utils.Pointer("members"), Value: []interface{}{map[string]interface{}{"value": first}}},
+		{Op: "remove", Path: utils.Pointer(`members[value eq "` + second + `"]`)},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(patched.Members) != 1 || *patched.Members[0].Value != third {
+		t.Errorf("expected only the member %s, got %+v", third, patched.Members)
+	}
+}
+
+func TestUserAccount(t *testing.T) {
+	user := &User{
+		UserName: utils.Pointer(" Jane@PowerSSO.io "),
+		Name:     &Name{GivenName: utils.Pointer("Jane"), FamilyName: utils.Pointer("Doe")},
+		Locale:   utils.Pointer("pt-BR"),
+	}
+
+	account, err := user.Account()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *account.Email != "jane@powersso.io" || !*account.Active || account.Locale == nil || *account.Locale != "pt_BR" {
+		t.Errorf("unexpected account %+v", account)
+	}
+
+	user.Locale = utils.Pointer("xx-YY")
+	if account, _ = user.Account(); account.Locale != nil {
+		t.Errorf("expected an unsupported locale to be ignored, got %s", *account.Locale)
+	}
+
+	user.UserName = utils.Pointer("jane")
+	if _, err = user.Account(); err == nil {
+		t.Error("expected an error on an invalid email")
+	}
+}
+
+func TestGroupProject(t *testing.T) {
+	kept, removed, added := uuid.New(), uuid.New(), uuid.New()
+
+	group := &Group{
+		DisplayName: utils.Pointer(" Engineering "),
+		Members:     []Member{{Value: utils.Pointer(kept.String())}, {Value: utils.Pointer(added.String())}, {Value: utils.Pointer(added.String())}},
+	}
+
+	project, err := group.Project()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *project.Name != "Engineering" || len(project.Members) != 2 {
+		t.Errorf("unexpected project %+v", project)
+	}
+
+	in, out := project.Changes([]Member{{Value: utils.Pointer(kept.String())}, {Value: utils.Pointer(removed.String())}})
+	if len(in) != 1 || in[0] != added || len(out) != 1 || out[0] != removed {
+		t.Errorf("unexpected changes, added %v and removed %v", in, out)
+	}
+
+	group.DisplayName = utils.Pointer("A name with more than twenty characters")
+	if _, err = group.Project(); !isError(err, ErrGroupNameIsNotValid()) {
+		t.Errorf("expected the error of the name, got %v", err)
+	}
+
+	group.DisplayName, group.Members = utils.Pointer("Engineering"), []Member{{Value: utils.Pointer("jane")}}
+	if _, err = group.Project(); !isError(err, ErrInvalidMember()) {
+		t.Errorf("expected the error of the member, got %v", err)
+	}
+}
+
+func TestListParamsPrepare(t *testing.T) {
+	params := &ListParams{Count: utils.Pointer[int64](500), ExcludedAttributes: utils.Pointer("members, meta")}
+	if _, err := params.Prepare(200); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if params.StartIndex != 1 || *params.Count != 200 {
+		t.Errorf("unexpected params %+v", params)
+	}
+
+	if !params.Excluded("members") || params.Excluded("displayName") {
+		t.Error("expected only the members and the meta to be excluded")
+	}
+
+	params.Filter = utils.Pointer("displayName eq")
+	if _, err := params.Prepare(200); !isError(err, ErrInvalidFilter()) {
+		t.Errorf("expected the error invalidFilter, got %v", err)
+	}
+}
+
+func TestBulkOperation(t *testing.T) {
+	userID := uuid.New()
+
+	create := &BulkOperation{Method: "post", BulkID: utils.Pointer("jane"), Path: "/Users"}
+	if target, err := create.Target(); err != nil || target != nil || create.Method != MethodPost {
+		t.Errorf("unexpected target %v of the creation: %v", target, err)
+	}
+
+	update := &BulkOperation{
+		Method: MethodPatch,
+		Path:   "/Groups/" + uuid.NewString(),
+		Data:   json.RawMessage(`{"Operations":[{"op":"add","path":"members","value":[{"value":"bulkId:jane"}]}]}`),
+	}
+	update.Resolve(map[string]string{"jane": userID.String()})
+
+	if string(update.Data) != `{"Operations":[{"op":"add","path":"members","value":[{"value":"`+userID.String()+`"}]}]}` {
+		t.Errorf("expected the bulkId to be resolved, got %s", update.Data)
+	}
+
+	if update.Endpoint() != EndpointGroups {
+		t.Errorf("expected the endpoint of the groups, got %s", update.Endpoint())
+	}
+
+	for _, operation := range []*BulkOperation{
+		{Method: MethodPost, Path: "/Users"},
+		{Method: MethodPut, Path: "/Users/jane"},
+		{Method: MethodDelete, Path: "/Schemas/" + userID.String()},
+		{Method: "GET", Path: "/Users/" + userID.String()},
+	} {
+		if _, err := operation.Target(); !isError(err, ErrInvalidBulkOperation()) {
+			t.Errorf("expected the operation %s %s to be invalid, got %v", operation.Method, operation.Path, err)
+		}
+	}
+}
+
+func TestNewErrorResponse(t *testing.T) {
+	status, res := NewErrorResponse(ErrUserExists())
+	if status != http.StatusConflict || res.Status != "409" || res.ScimType != "uniqueness" {
+		t.Errorf("unexpected response %d %+v", status, res)
+	}
+
+	if status, res = NewErrorResponse(ErrGroupNotFound()); status != http.StatusNotFound || res.ScimType != "" {
+		t.Errorf("unexpected response %d %+v", status, res)
+	}
+
+	if _, res = NewErrorResponse(ErrInvalidFilter()); res.ScimType != "invalidFilter" || res.Schemas[0] != SchemaError {
+		t.Errorf("unexpected response %+v", res)
+	}
+}
+
+// isError returns if the error is the error of the domain with the same message
+func isError(err error, expected *oops.Error) bool {
+	var handled *oops.Error
+	return errors.As(err, &handled) && handled.Message == expected.Message && handled.Code == expected.Code
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operations of the patch requests
+const (
+	PatchAdd     string = "add"
+	PatchReplace string = "replace"
+	PatchRemove  string = "remove"
+)
+
+// PatchRequest models the request to change some attributes of a resource, section 3.5.2 of the RFC 7644
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations" binding:"required,min=1"`
+}
+
+// PatchOperation models an operation of a patch request. The value of the operations without
+// a path is an object with the attributes to change
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  *string     `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Path models the target of a patch operation: attribute, attribute.sub, attribute[filter] or attribute[filter].sub
+type Path struct {
+	Attribute string
+	Filter    Filter
+	Sub       string
+}
+
+// ParsePath parses the path of a patch operation
+func ParsePath(value string) (*Path, error) {
+	value = strings.TrimSpace(value)
+
+	start := strings.IndexByte(value, '[')
+	if start < 0 {
+		if !validPath(value) {
+			return nil, ErrInvalidPath()
+		}
+
+		attribute, sub, _ := strings.Cut(normalizePath(value), ".")
+		return &Path{Attribute: attribute, Sub: sub}, nil
+	}
+
+	end := strings.LastIndexByte(value, ']')
+	if end < start || !validPath(value[:start]) {
+		return nil, ErrInvalidPath()
+	}
+
+	filter, err := ParseFilter(value[start+1 : end])
+	if err != nil {
+		return nil, ErrInvalidPath()
+	}
+
+	path := &Path{Attribute: normalizePath(value[:start]), Filter: filter}
+	if rest := value[end+1:]; rest != "" {
+		if !strings.HasPrefix(rest, ".") || !validPath(rest[1:]) {
+			return nil, ErrInvalidPath()
+		}
+		path.Sub = strings.ToLower(rest[1:])
+	}
+
+	return path, nil
+}
+
+// Patch applies the operations of the request in order to a copy of the resource, returning the copy
+func Patch[T any](resource *T, in *PatchRequest) (*T, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make(map[string]interface{})
+	if err = json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+
+	for i := range in.Operations {
+		if err = in.Operations[i].apply(attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	if data, err = json.Marshal(attributes); err != nil {
+		return nil, err
+	}
+
+	patched := new(T)
+	if err = json.Unmarshal(data, patched); err != nil {
+		return nil, ErrInvalidValue()
+	}
+
+	return patched, nil
+}
+
+// apply applies the operation to the attributes of a resource
+func (o *PatchOperation) apply(attributes map[string]interface{}) error {
+	op := strings.ToLower(o.Op)
+	if op != PatchAdd && op != PatchReplace && op != PatchRemove {
+		return ErrInvalidPatch()
+	}
+
+	if o.Path == nil || strings.TrimSpace(*o.Path) == "" {
+		if op == PatchRemove {
+			return ErrNoTarget()
+		}
+
+		values, ok := o.Value.(map[string]interface{})
+		if !ok {
+			return ErrInvalidPatch()
+		}
+
+		for name, value := range values {
+			path, err := ParsePath(name)
+			if err != nil {
+				return err
+			}
+
+			if err = path.set(attributes, op, value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	path, err := ParsePath(*o.Path)
+	if err != nil {
+		return err
+	}
+
+	if op != PatchRemove && o.Value == nil {
+		return ErrInvalidPatch()
+	}
+
+	return path.set(attributes, op, o.Value)
+}
+
+// set applies the operation on the target of the path
+func (p *Path) set(attributes map[string]interface{}, op string, value interface{}) error {
+	key, _ := findKey(attributes, p.Attribute)
+
+	if p.Filter == nil {
+		if p.Sub == "" {
+			return assign(attributes, key, op, value)
+		}
+
+		parent, ok := attributes[key].(map[string]interface{})
+		if !ok {
+			if op == PatchRemove {
+				return nil
+			}
+			parent = make(map[string]interface{})
+			attributes[key] = parent
+		}
+
+		subKey, _ := findKey(parent, p.Sub)
+		return assign(parent, subKey, op, value)
+	}
+
+	items, _ := attributes[key].([]interface{})
+	kept, matched := make([]interface{}, 0, len(items)), false
+
+	for _, item := range items {
+		values, ok := item.(map[string]interface{})
+		if !ok || !p.Filter.Matches(values) {
+			kept = append(kept, item)
+			continue
+		}
+		matched = true
+
+		switch {
+		case op == PatchRemove && p.Sub == "":
+			continue
+		case p.Sub == "":
+			replacement, ok := value.(map[string]interface{})
+			if !ok {
+				return ErrInvalidPatch()
+			}
+			merge(values, replacement)
+		default:
+			subKey, _ := findKey(values, p.Sub)
+			if err := assign(values, subKey, op, value); err != nil {
+				return err
+			}
+		}
+
+		kept = append(kept, values)
+	}
+
+	if !matched {
+		// adding to a value that does not exist creates it with the attributes compared by the filter
+		comparison, ok := p.Filter.(*Comparison)
+		if op != PatchAdd || !ok || comparison.Operator != OperatorEq || p.Sub == "" {
+			return ErrNoTarget()
+		}
+		kept = append(kept, map[string]interface{}{comparison.Path: comparison.Value, p.Sub: value})
+	}
+
+	attributes[key] = kept
+	return nil
+}
+
+// assign applies the operation on an attribute. Adding to a multi-valued attribute appends the values,
+// and removing with a value removes only the values informed, as sent by some identity providers
+func assign(attributes map[string]interface{}, key, op string, value interface{}) error {
+	current := attributes[key]
+
+	switch op {
+	case PatchRemove:
+		items, isList := current.([]interface{})
+		if value == nil || !isList {
+			delete(attributes, key)
+			return nil
+		}
+
+		removed := make(map[string]bool)
+		for _, item := range asList(value) {
+			removed[itemValue(item)] = true
+		}
+
+		kept := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			if !removed[itemValue(item)] {
+				kept = append(kept, item)
+			}
+		}
+		attributes[key] = kept
+
+	case PatchAdd:
+		if items, ok := current.([]interface{}); ok {
+			attributes[key] = append(items, asList(value)...)
+			return nil
+		}
+		fallthrough
+
+	default:
+		values, isMap := current.(map[string]interface{})
+		if replacement, ok := value.(map[string]interface{}); ok && isMap {
+			merge(values, replacement)
+			return nil
+		}
+		attributes[key] = coerce(current, value)
+	}
+
+	return nil
+}
+
+// merge sets the sub-attributes of the value on the complex attribute, keeping the others
+func merge(attributes, value map[string]interface{}) {
+	for name, sub := range value {
+		key, _ := findKey(attributes, name)
+		attributes[key] = coerce(attributes[key], sub)
+	}
+}
+
+// coerce converts the booleans sent as strings, like "False", to the type of the current value
+func coerce(current, value interface{}) interface{} {
+	if text, ok := value.(string); ok {
+		if _, isBool := current.(bool); isBool {
+			if converted, err := strconv.ParseBool(strings.ToLower(text)); err == nil {
+				return converted
+			}
+		}
+	}
+	return value
+}
+
+func asList(value interface{}) []interface{} {
+	if items, ok := value.([]interface{}); ok {
+		return items
+	}
+	return []interface{}{value}
+}
+
+// itemValue returns the value that identifies an item of a multi-valued attribute
+func itemValue(item interface{}) string {
+	if values, ok := item.(map[string]interface{}); ok {
+		if key, ok := findKey(values, "value"); ok {
+			item = values[key]
+		}
+	}
+	return strings.ToLower(fmt.Sprint(item))
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// ErrInvalidURL creates and returns an error when the address of the webhook is not a http(s) url
+func ErrInvalidURL() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_webhook_invalid_url"), http.StatusBadRequest)
+}
+
+// ErrInternalAddress creates and returns an error when the address of the webhook resolves to an internal network
+func ErrInternalAddress() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_webhook_internal_address"), http.StatusBadRequest)
+}
+
+// ErrInvalidEvent creates and returns an error when the webhook subscribes to an unknown event
+func ErrInvalidEvent() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_webhook_invalid_event"), http.StatusBadRequest)
+}
+
+// ErrWebhookNotFound creates and returns an error when the webhook does not exist in the project
+func ErrWebhookNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_webhook_not_found"), http.StatusNotFound)
+}
+
+// ErrDeliveryNotFound creates and returns an error when the delivery does not exist in the webhook
+func ErrDeliveryNotFound() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_webhook_delivery_not_found"), http.StatusNotFound)
+}
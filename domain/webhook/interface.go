@@ -0,0 +1,24 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// IWebhook define an interface for data layer access methods
+type IWebhook interface {
+	Create(*Webhook) (*uuid.UUID, error)
+	List(projectID *uuid.UUID) ([]Webhook, error)
+	Delete(projectID, webhookID *uuid.UUID) error
+	Enqueue(event *Event, payload []byte) error
+	Deliveries(projectID, webhookID *uuid.UUID, params *utils.Params) ([]Delivery, *bool, error)
+	Redeliver(projectID, webhookID, deliveryID *uuid.UUID) error
+	Claim(limit uint64, lease int64) ([]*Delivery, error)
+	Delivered(deliveryID *uuid.UUID, result *Result) error
+	Failed(*Delivery) error
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// EventType set data type to the lifecycle events sent to the webhooks
+type EventType string
+
+const (
+	// EventUserCreated is sent to all webhooks subscribed to it when a user account is created.
+	// As the user is not a participant of the projects yet, the event only carries the user id
+	EventUserCreated EventType = "user.created"
+	// EventUserDisabled is sent to the webhooks of the projects of the user when the account is disabled
+	EventUserDisabled EventType = "user.disabled"
+	// EventUserPasswordChanged is sent to the webhooks of the projects of the user when the password changes
+	EventUserPasswordChanged EventType = "user.password_changed"
+	// EventParticipantJoined is sent to the webhooks of the project when a user joins it
+	EventParticipantJoined EventType = "project.participant_joined"
+	// EventParticipantLeft is sent to the webhooks of the project when a user leaves it
+	EventParticipantLeft EventType = "project.participant_left"
+)
+
+// IsValid checks if the event type is one of the known events
+func (e EventType) IsValid() bool {
+	switch e {
+	case EventUserCreated, EventUserDisabled, EventUserPasswordChanged, EventParticipantJoined, EventParticipantLeft:
+		return true
+	}
+	return false
+}
+
+// Status set data type to the state of a delivery
+type Status string
+
+const (
+	// StatusPending is a delivery waiting for its next attempt
+	StatusPending Status = "pending"
+	// StatusDelivered is a delivery accepted by the webhook
+	StatusDelivered Status = "delivered"
+	// StatusDead is a delivery that failed all attempts and will only be sent again on redelivery
+	StatusDead Status = "dead"
+)
+
+// Headers sent with the deliveries
+const (
+	HeaderEvent     string = "X-PowerSSO-Event"
+	HeaderDelivery  string = "X-PowerSSO-Delivery"
+	HeaderTimestamp string = "X-PowerSSO-Timestamp"
+	HeaderSignature string = "X-PowerSSO-Signature"
+)
+
+// secretLength is the size of the secret generated to sign the payloads of a webhook
+const secretLength int = 40
+
+// lookupIP resolves the addresses of the host of a webhook
+var lookupIP = net.LookupIP
+
+// internalNetworks are the reserved ranges not covered by the methods of net.IP that must not be reached by the webhooks
+var internalNetworks = func() (networks []*net.IPNet) {
+	for _, cidr := range []string{"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4", "64:ff9b::/96"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return
+}()
+
+// IsInternalIP checks if the address belongs to the loopback, link-local, private or other reserved networks
+func IsInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckHost resolves the host of a webhook and checks that none of its addresses is internal
+func CheckHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if IsInternalIP(ip) {
+			return ErrInternalAddress()
+		}
+		return nil
+	}
+
+	ips, err := lookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return ErrInvalidURL()
+	}
+
+	for _, ip := range ips {
+		if IsInternalIP(ip) {
+			return ErrInternalAddress()
+		}
+	}
+
+	return nil
+}
+
+// Webhook models a subscription of a project to lifecycle events
+type Webhook struct {
+	ID        *uuid.UUID  `json:"id"`
+	ProjectID *uuid.UUID  `json:"project_id"`
+	URL       *string     `json:"url" binding:"required,url"`
+	Secret    *string     `json:"secret,omitempty"`
+	Events    []EventType `json:"events" binding:"required,min=1"`
+	Active    *bool       `json:"active"`
+	CreatedBy *uuid.UUID  `json:"created_by,omitempty"`
+	CreatedAt *time.Time  `json:"created_at"`
+}
+
+// Prepare validates the subscription and generates the secret used to sign the payloads
+func (w *Webhook) Prepare() error {
+	u, err := url.Parse(*w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL()
+	}
+
+	if err = CheckHost(u.Hostname()); err != nil {
+		return err
+	}
+
+	for _, event := range w.Events {
+		if !event.IsValid() {
+			return ErrInvalidEvent()
+		}
+	}
+
+	w.Secret = utils.Pointer(utils.RandomString(secretLength))
+	return nil
+}
+
+// Event models a lifecycle event to be sent to the subscribed webhooks
+type Event struct {
+	ID         uuid.UUID   `json:"id"`
+	Type       EventType   `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+
+	// ProjectID routes the event to the webhooks of the project
+	ProjectID *uuid.UUID `json:"-"`
+	// UserID routes the event to the webhooks of the projects of the user.
+	// Events without project and user are sent to all the subscribed webhooks
+	UserID *uuid.UUID `json:"-"`
+}
+
+// NewEvent creates an event sent to all the webhooks subscribed to it
+func NewEvent(kind EventType, data interface{}) *Event {
+	return &Event{ID: uuid.New(), Type: kind, OccurredAt: time.Now(), Data: data}
+}
+
+// NewUserEvent creates an event about a user, routed to the webhooks of the projects of the user
+func NewUserEvent(kind EventType, userID *uuid.UUID, data interface{}) *Event {
+	return &Event{ID: uuid.New(), Type: kind, OccurredAt: time.Now(), Data: data, UserID: userID}
+}
+
+// NewProjectEvent creates an event about a project, routed to the webhooks of the project
+func NewProjectEvent(kind EventType, projectID *uuid.UUID, data interface{}) *Event {
+	return &Event{ID: uuid.New(), Type: kind, OccurredAt: time.Now(), Data: data, ProjectID: projectID}
+}
+
+// Payload returns the body sent to the webhooks
+func (e *Event) Payload() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// UserData models the data of the events about a user
+type UserData struct {
+	UserID    *uuid.UUID `json:"user_id"`
+	Email     *string    `json:"email,omitempty"`
+	FirstName *string    `json:"first_name,omitempty"`
+	LastName  *string    `json:"last_name,omitempty"`
+}
+
+// ParticipantData models the data of the events about the participants of a project
+type ParticipantData struct {
+	ProjectID     *uuid.UUID `json:"project_id"`
+	UserID        *uuid.UUID `json:"user_id"`
+	StartDate     *time.Time `json:"start_date,omitempty"`
+	DepartureDate *time.Time `json:"departure_date,omitempty"`
+}
+
+// Delivery models an attempt to send an event to a webhook
+type Delivery struct {
+	ID             *uuid.UUID       `json:"id" sql:"id"`
+	WebhookID      *uuid.UUID       `json:"webhook_id" sql:"webhook_id"`
+	Event          *EventType       `json:"event" sql:"event"`
+	Payload        *json.RawMessage `json:"payload" sql:"payload"`
+	Status         *Status          `json:"status" sql:"status"`
+	Attempts       *int64           `json:"attempts" sql:"attempts"`
+	NextAttemptAt  *time.Time       `json:"next_attempt_at" sql:"next_attempt_at"`
+	LastStatusCode *int64           `json:"last_status_code,omitempty" sql:"last_status_code"`
+	LastError      *string          `json:"last_error,omitempty" sql:"last_error"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty" sql:"delivered_at"`
+	CreatedAt      *time.Time       `json:"created_at" sql:"created_at"`
+
+	URL    *string `json:"-" ignore:"true"`
+	Secret *string `json:"-" ignore:"true"`
+}
+
+// Sign returns the signature of the payload sent at the timestamp, computed with the secret of the webhook.
+// Receivers recompute it from the timestamp and signature headers to authenticate the delivery
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Result models the outcome of an attempt of a delivery
+type Result struct {
+	StatusCode *int64
+	Error      *string
+}
+
+// Succeeded returns if the webhook accepted the delivery
+func (r *Result) Succeeded() bool {
+	return r.Error == nil && r.StatusCode != nil && *r.StatusCode >= 200 && *r.StatusCode < 300
+}
+
+// Fail records the outcome of a failed attempt, scheduling the next attempt with exponential backoff
+// or moving the delivery to the dead-letter state when there are no attempts left
+func (d *Delivery) Fail(policy *config.WebhookConfig, result *Result) {
+	attempts := int64(1)
+	if d.Attempts != nil {
+		attempts = *d.Attempts + 1
+	}
+
+	d.Attempts, d.LastStatusCode, d.LastError = &attempts, result.StatusCode, result.Error
+	if attempts >= policy.MaxAttempts {
+		d.Status = utils.Pointer(StatusDead)
+		return
+	}
+
+	delay := float64(policy.BackoffBase) * math.Pow(2, float64(attempts-1))
+	if policy.BackoffMax > 0 && delay > float64(policy.BackoffMax) {
+		delay = float64(policy.BackoffMax)
+	}
+
+	d.Status = utils.Pointer(StatusPending)
+	d.NextAttemptAt = utils.Pointer(time.Now().Add(time.Duration(delay) * time.Second))
+}
+
+// Deliveries models a page of the deliveries of a webhook
+type Deliveries struct {
+	Deliveries []Delivery `json:"deliveries"`
+	Next       *bool      `json:"next"`
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"type":"user.created"}`)
+
+	// printf '1700000000.{"type":"user.created"}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=183b761865ab7e9c02fe7603937d181ce1482da954b1fecf912269e22500ac37"
+	if signature := Sign("secret", 1700000000, payload); signature != expected {
+		t.Errorf("expected %s, got %s", expected, signature)
+	}
+
+	if Sign("other", 1700000000, payload) == expected {
+		t.Error("expected a different signature for another secret")
+	}
+
+	if Sign("secret", 1700000001, payload) == expected {
+		t.Error("expected a different signature for another timestamp")
+	}
+}
+
+func TestDeliveryFail(t *testing.T) {
+	policy := &config.WebhookConfig{MaxAttempts: 4, BackoffBase: 10, BackoffMax: 30}
+	result := &Result{StatusCode: utils.Pointer(int64(500)), Error: utils.Pointer("500 Internal Server Error")}
+
+	delivery := &Delivery{Attempts: utils.Pointer(int64(0))}
+	for attempt, delay := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second} {
+		before := time.Now()
+		delivery.Fail(policy, result)
+
+		if *delivery.Status != StatusPending || *delivery.Attempts != int64(attempt+1) {
+			t.Fatalf("attempt %d: expected pending delivery, got %s after %d attempts", attempt+1, *delivery.Status, *delivery.Attempts)
+		}
+
+		if next := delivery.NextAttemptAt.Sub(before); next < delay || next > delay+time.Second {
+			t.Errorf("attempt %d: expected next attempt in %s, got %s", attempt+1, delay, next)
+		}
+	}
+
+	delivery.Fail(policy, result)
+	if *delivery.Status != StatusDead || *delivery.Attempts != 4 {
+		t.Errorf("expected dead delivery after 4 attempts, got %s after %d attempts", *delivery.Status, *delivery.Attempts)
+	}
+
+	if *delivery.LastStatusCode != 500 || *delivery.LastError != "500 Internal Server Error" {
+		t.Error("expected the outcome of the last attempt")
+	}
+}
+
+func TestResultSucceeded(t *testing.T) {
+	for code, succeeded := range map[int64]bool{200: true, 204: true, 301: false, 404: false, 503: false} {
+		if (&Result{StatusCode: &code}).Succeeded() != succeeded {
+			t.Errorf("expected status %d succeeded to be %v", code, succeeded)
+		}
+	}
+
+	if (&Result{Error: utils.Pointer("timeout")}).Succeeded() {
+		t.Error("expected a request error to fail")
+	}
+}
+
+func TestWebhookPrepare(t *testing.T) {
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "example.com":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "metadata.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("169.254.169.254")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { lookupIP = net.LookupIP }()
+
+	webhook := &Webhook{URL: utils.Pointer("https://example.com/hooks"), Events: []EventType{EventUserCreated}}
+	if err := webhook.Prepare(); err != nil || webhook.Secret == nil || len(*webhook.Secret) != secretLength {
+		t.Errorf("expected a secret for a valid webhook, got %v", err)
+	}
+
+	for _, webhook := range []*Webhook{
+		{URL: utils.Pointer("ftp://example.com"), Events: []EventType{EventUserCreated}},
+		{URL: utils.Pointer("https://example.com"), Events: []EventType{"user.deleted"}},
+		{URL: utils.Pointer("https://unknown.example.com"), Events: []EventType{EventUserCreated}},
+		{URL: utils.Pointer("https://metadata.example.com"), Events: []EventType{EventUserCreated}},
+		{URL: utils.Pointer("http://127.0.0.1:8080/hooks"), Events: []EventType{EventUserCreated}},
+		{URL: utils.Pointer("http://[::1]/hooks"), Events: []EventType{EventUserCreated}},
+	} {
+		if err := webhook.Prepare(); err == nil {
+			t.Errorf("expected an error for %s %v", *webhook.URL, webhook.Events)
+		}
+	}
+}
+
+func TestIsInternalIP(t *testing.T) {
+	for address, internal := range map[string]bool{
+		"93.184.216.34":   false,
+		"2606:2800::1":    false,
+		"127.0.0.1":       true,
+		"::1":             true,
+		"0.0.0.0":         true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.0.1":     true,
+		"169.254.169.254": true,
+		"100.64.0.1":      true,
+		"fd00::1":         true,
+		"fe80::1":         true,
+		"::ffff:10.0.0.1": true,
+	} {
+		if IsInternalIP(net.ParseIP(address)) != internal {
+			t.Errorf("expected internal of %s to be %v", address, internal)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/identity"
+)
+
+// ErrAuthorizationFailed is returned when the provider rejects the code or returns an invalid id token
+var ErrAuthorizationFailed = errors.New("federation: authorization failed")
+
+// Provider defines an interface for the external providers the users sign in with
+type Provider interface {
+	AuthCodeURL(ctx context.Context, stateID string, state *identity.State) (string, error)
+	Exchange(ctx context.Context, code string, state *identity.State) (*identity.Claims, error)
+}
+
+var (
+	mutex     sync.RWMutex
+	providers = map[string]Provider{}
+)
+
+// Setup initializes the providers of the configuration
+func Setup(cfg *config.Config) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	providers = make(map[string]Provider, len(cfg.OIDC.Providers))
+	for i := range cfg.OIDC.Providers {
+		providers[cfg.OIDC.Providers[i].Name] = NewOIDCProvider(&cfg.OIDC, &cfg.OIDC.Providers[i])
+	}
+}
+
+// SetProvider replaces the provider with the name
+func SetProvider(name string, provider Provider) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	providers[name] = provider
+}
+
+// Get returns the provider with the name
+func Get(name string) (Provider, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	provider, ok := providers[name]
+	if !ok {
+		return nil, identity.ErrProviderNotFound()
+	}
+	return provider, nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/identity"
+)
+
+// OIDCProvider signs in the users with an OpenID Connect provider, using the authorization code flow
+// with PKCE. The discovery document is fetched in the first sign in and kept while the server runs
+type OIDCProvider struct {
+	cfg         *config.OIDCProviderConfig
+	redirectURL string
+
+	mutex    sync.Mutex
+	provider *oidc.Provider
+}
+
+// NewOIDCProvider creates an OpenID Connect provider
+func NewOIDCProvider(cfg *config.OIDCConfig, provider *config.OIDCProviderConfig) *OIDCProvider {
+	return &OIDCProvider{cfg: provider, redirectURL: identity.RedirectURL(cfg, provider.Name)}
+}
+
+// AuthCodeURL returns the address the user is sent to sign in with the provider
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, stateID string, state *identity.State) (string, error) {
+	oauth, _, err := p.config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth.AuthCodeURL(stateID,
+		oidc.Nonce(*state.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", state.Challenge()),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// Exchange exchanges the code returned by the provider for the id token, returning its claims
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, state *identity.State) (*identity.Claims, error) {
+	oauth, verifier, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", *state.Verifier))
+	if err != nil {
+		var retrieve *oauth2.RetrieveError
+		if errors.As(err, &retrieve) {
+			return nil, ErrAuthorizationFailed
+		}
+		return nil, err
+	}
+
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrAuthorizationFailed
+	}
+
+	idToken, err := verifier.Verify(ctx, raw)
+	if err != nil || idToken.Nonce != *state.Nonce {
+		return nil, ErrAuthorizationFailed
+	}
+
+	claims := new(identity.Claims)
+	if err = idToken.Claims(claims); err != nil {
+		return nil, ErrAuthorizationFailed
+	}
+	claims.Subject = &idToken.Subject
+
+	return claims, nil
+}
+
+// config returns the OAuth 2 configuration and the verifier of the id tokens of the provider
+func (p *OIDCProvider) config(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.provider == nil {
+		provider, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.provider = provider
+	}
+
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     p.provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       scopes,
+	}, p.provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID}), nil
+}
@@ -0,0 +1,298 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/identity"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const (
+	stubClientID     = "powersso"
+	stubClientSecret = "client_secret"
+	stubKeyID        = "stub"
+)
+
+// stubGrant models an authorization given by the stub provider, redeemed once with the code
+type stubGrant struct {
+	challenge string
+	nonce     string
+	audience  string
+	claims    jwt.MapClaims
+}
+
+// stubProvider is a local OpenID Connect provider that serves the discovery document,
+// the keys and the token endpoint, issuing id tokens for the grants of the tests
+type stubProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	grants map[string]*stubGrant
+}
+
+func newStubProvider() (*stubProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	stub := &stubProvider{key: key, grants: make(map[string]*stubGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", stub.discovery)
+	mux.HandleFunc("/keys", stub.keys)
+	mux.HandleFunc("/token", stub.token)
+	stub.server = httptest.NewServer(mux)
+
+	return stub, nil
+}
+
+// authorize simulates the sign in of the user in the address returned by the provider, returning the code
+func (s *stubProvider) authorize(address string, grant *stubGrant) (string, error) {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsed.Query()
+	grant.challenge = query.Get("code_challenge")
+	if grant.nonce == "" {
+		grant.nonce = query.Get("nonce")
+	}
+	if grant.audience == "" {
+		grant.audience = query.Get("client_id")
+	}
+
+	code := utils.RandomString(16)
+
+	s.mutex.Lock()
+	s.grants[code] = grant
+	s.mutex.Unlock()
+
+	return code, nil
+}
+
+func (s *stubProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.server.URL,
+		"authorization_endpoint":                s.server.URL + "/authorize",
+		"token_endpoint":                        s.server.URL + "/token",
+		"jwks_uri":                              s.server.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (s *stubProvider) keys(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": stubKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, secret, _ := r.BasicAuth()
+	if clientID == "" {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != stubClientID || secret != stubClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mutex.Lock()
+	grant, ok := s.grants[r.PostForm.Get("code")]
+	delete(s.grants, r.PostForm.Get("code"))
+	s.mutex.Unlock()
+
+	sum := (&identity.State{Verifier: utils.Pointer(r.PostForm.Get("code_verifier"))}).Challenge()
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || grant.challenge != sum {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   s.server.URL,
+		"aud":   grant.audience,
+		"nonce": grant.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+	for name, value := range grant.claims {
+		claims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = stubKeyID
+
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": utils.RandomString(32),
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestOIDCProvider(t *testing.T) {
+	suite.Run(t, new(oidcSuite))
+}
+
+type oidcSuite struct {
+	stub     *stubProvider
+	provider *OIDCProvider
+	state    *identity.State
+
+	suite.Suite
+}
+
+func (s *oidcSuite) SetupSuite() {
+	var err error
+	s.stub, err = newStubProvider()
+	s.Require().NoError(err)
+}
+
+func (s *oidcSuite) TearDownSuite() {
+	s.stub.server.Close()
+}
+
+func (s *oidcSuite) SetupTest() {
+	s.provider = NewOIDCProvider(
+		&config.OIDCConfig{RedirectURL: "http://localhost:5000/v1/auth/oidc/{provider}/callback"},
+		&config.OIDCProviderConfig{
+			Name:         "stub",
+			Issuer:       s.stub.server.URL,
+			ClientID:     stubClientID,
+			ClientSecret: stubClientSecret,
+		},
+	)
+	s.state = identity.NewState("stub", identity.PurposeLogin, nil, time.Minute)
+}
+
+// signIn starts the sign in with the provider and returns the code of the grant
+func (s *oidcSuite) signIn(grant *stubGrant) string {
+	address, err := s.provider.AuthCodeURL(context.Background(), "state_id", s.state)
+	s.Require().NoError(err)
+
+	code, err := s.stub.authorize(address, grant)
+	s.Require().NoError(err)
+
+	return code
+}
+
+func (s *oidcSuite) TestShouldBuildAuthCodeURL() {
+	address, err := s.provider.AuthCodeURL(context.Background(), "state_id", s.state)
+	s.Require().NoError(err)
+
+	parsed, err := url.Parse(address)
+	s.Require().NoError(err)
+
+	query := parsed.Query()
+	s.Equal(s.stub.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	s.Equal("state_id", query.Get("state"))
+	s.Equal(*s.state.Nonce, query.Get("nonce"))
+	s.Equal(s.state.Challenge(), query.Get("code_challenge"))
+	s.Equal("S256", query.Get("code_challenge_method"))
+	s.Equal("http://localhost:5000/v1/auth/oidc/stub/callback", query.Get("redirect_uri"))
+	s.Equal("openid email profile", query.Get("scope"))
+}
+
+func (s *oidcSuite) TestShouldExchange() {
+	code := s.signIn(&stubGrant{claims: jwt.MapClaims{
+		"sub":            "248289761001",
+		"email":          "jane@powersso.io",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}})
+
+	claims, err := s.provider.Exchange(context.Background(), code, s.state)
+	s.Require().NoError(err)
+
+	s.Equal("248289761001", *claims.Subject)
+	s.Equal("jane@powersso.io", *claims.Email)
+	s.True(*claims.EmailVerified)
+	s.Equal("Jane", *claims.GivenName)
+	s.Equal("Doe", *claims.FamilyName)
+}
+
+func (s *oidcSuite) TestShouldNotExchangeCodeTwice() {
+	code := s.signIn(&stubGrant{claims: jwt.MapClaims{"sub": "248289761001"}})
+
+	_, err := s.provider.Exchange(context.Background(), code, s.state)
+	s.Require().NoError(err)
+
+	_, err = s.provider.Exchange(context.Background(), code, s.state)
+	s.Equal(ErrAuthorizationFailed, err)
+}
+
+func (s *oidcSuite) TestShouldRejectWrongVerifier() {
+	code := s.signIn(&stubGrant{claims: jwt.MapClaims{"sub": "248289761001"}})
+
+	s.state.Verifier = utils.Pointer(utils.RandomString(64))
+	_, err := s.provider.Exchange(context.Background(), code, s.state)
+	s.Equal(ErrAuthorizationFailed, err)
+}
+
+func (s *oidcSuite) TestShouldRejectWrongNonce() {
+	code := s.signIn(&stubGrant{nonce: "another_nonce", claims: jwt.MapClaims{"sub": "248289761001"}})
+
+	_, err := s.provider.Exchange(context.Background(), code, s.state)
+	s.Equal(ErrAuthorizationFailed, err)
+}
+
+func (s *oidcSuite) TestShouldRejectWrongAudience() {
+	code := s.signIn(&stubGrant{audience: "another_client", claims: jwt.MapClaims{"sub": "248289761001"}})
+
+	_, err := s.provider.Exchange(context.Background(), code, s.state)
+	s.Equal(ErrAuthorizationFailed, err)
+}
+
+func (s *oidcSuite) TestShouldFailWithUnavailableProvider() {
+	provider := NewOIDCProvider(&config.OIDCConfig{}, &config.OIDCProviderConfig{Name: "down", Issuer: "http://127.0.0.1:1"})
+
+	_, err := provider.AuthCodeURL(context.Background(), "state_id", s.state)
+	s.Error(err)
+	s.NotEqual(ErrAuthorizationFailed, err)
+}
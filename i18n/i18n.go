@@ -38,6 +38,11 @@ func Value(value string, args ...string) string {
 	return ling.Value(value, args...)
 }
 
+// IsSupported checks if the language has translations
+func IsSupported(lang string) bool {
+	return lang == PortugueseBR || lang == EnglishUS || lang == SpainES
+}
+
 func isValid(value string) bool {
 	return value != "" ||
 		(value == PortugueseBR ||
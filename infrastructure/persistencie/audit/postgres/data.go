@@ -0,0 +1,185 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/audit"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Audit is the implementation of transaction for the audit repository
+type Audit struct{ DB *database.Transaction }
+
+// Add records an event in the audit log
+func (pg *Audit) Add(event *domain.Event) error {
+	cols, vals, err := utils.FormatValuesInUp(event)
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Insert("audit_events").
+		Columns(cols...).
+		Values(vals...).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// List fetches the events of the audit log that match the filters
+func (pg *Audit) List(params *utils.Params) ([]domain.Event, *bool, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("audit_events").
+		OrderBy("created_at DESC")
+
+	for filter, column := range map[string]string{
+		domain.FilterActorID:   "actor_id",
+		domain.FilterTargetID:  "target_id",
+		domain.FilterAction:    "action",
+		domain.FilterOutcome:   "outcome",
+		domain.FilterRequestID: "request_id",
+	} {
+		if params.HasFilter(filter) {
+			query = query.Where(squirrel.Eq{column: params.Filters[filter]})
+		}
+	}
+
+	if params.HasFilter(domain.FilterFrom) {
+		query = query.Where(squirrel.GtOrEq{"created_at": params.Filters[domain.FilterFrom][0]})
+	}
+
+	if params.HasFilter(domain.FilterTo) {
+		query = query.Where(squirrel.Lt{"created_at": params.Filters[domain.FilterTo][0]})
+	}
+
+	return utils.MakePagination[domain.Event](&query, params)
+}
+
+// Last fetches the last link of the hash chain. When lock is true, the chain is locked until the end of
+// the transaction, so that the events are chained by a single transaction. The lock is only taken when the
+// events are chained, never when they are recorded
+func (pg *Audit) Last(lock bool) (link *domain.Link, err error) {
+	if lock {
+		if _, err = pg.DB.Builder.
+			Select("pg_advisory_xact_lock(hashtext('audit_events'))").
+			Exec(); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	link = new(domain.Link)
+	if err = pg.DB.Builder.
+		Select("seq, hash").
+		From("audit_events").
+		Where("seq IS NOT NULL").
+		OrderBy("seq DESC").
+		Limit(1).
+		Scan(&link.Seq, &link.Hash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Chain fetches the chained events after the position, in the order of the chain
+func (pg *Audit) Chain(afterSeq int64, limit uint64) (events []domain.Event, err error) {
+	params := &utils.Params{Limit: limit}
+	query := pg.DB.Builder.
+		Select().
+		From("audit_events").
+		Where("seq > ?", afterSeq).
+		OrderBy("seq")
+
+	if events, _, err = utils.MakePagination[domain.Event](&query, params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Unchained fetches the events that are not in the hash chain yet, in the order they were recorded
+func (pg *Audit) Unchained(limit uint64) (events []domain.Event, err error) {
+	params := &utils.Params{Limit: limit}
+	query := pg.DB.Builder.
+		Select().
+		From("audit_events").
+		Where("seq IS NULL").
+		OrderBy("created_at", "id")
+
+	if events, _, err = utils.MakePagination[domain.Event](&query, params); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Link records the position and the hash of an event in the hash chain
+func (pg *Audit) Link(event *domain.Event) error {
+	if _, err := pg.DB.Builder.
+		Update("audit_events").
+		Set("seq", event.Seq).
+		Set("prev_hash", event.PrevHash).
+		Set("hash", event.Hash).
+		Where("id = ? AND seq IS NULL", event.ID).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// AddCheckpoint records a checkpoint of the hash chain
+func (pg *Audit) AddCheckpoint(checkpoint *domain.Checkpoint) error {
+	cols, vals, err := utils.FormatValuesInUp(checkpoint)
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Insert("audit_checkpoints").
+		Columns(cols...).
+		Values(vals...).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Checkpoints fetches all checkpoints of the hash chain
+func (pg *Audit) Checkpoints() (checkpoints []domain.Checkpoint, err error) {
+	rows, err := pg.DB.Builder.
+		Select("id, seq, hash, signature, created_at").
+		From("audit_checkpoints").
+		OrderBy("seq").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var checkpoint domain.Checkpoint
+		if err = rows.Scan(&checkpoint.ID, &checkpoint.Seq, &checkpoint.Hash,
+			&checkpoint.Signature, &checkpoint.CreatedAt); err != nil {
+			return nil, oops.Err(err)
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return
+}
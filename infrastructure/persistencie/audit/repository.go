@@ -0,0 +1,61 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/audit"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/audit/postgres"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+var _ domain.IAudit = (*repoAudit)(nil)
+
+type repoAudit struct{ pg *infra.Audit }
+
+// NewAuditRepository creates a new repository
+func NewAuditRepository(tx *database.Transaction) domain.IAudit {
+	return &repoAudit{pg: &infra.Audit{DB: tx}}
+}
+
+// Add contains the flow to record an event in the audit log
+func (r *repoAudit) Add(event *domain.Event) error {
+	return r.pg.Add(event)
+}
+
+// List contains the flow to fetch the events of the audit log
+func (r *repoAudit) List(params *utils.Params) ([]domain.Event, *bool, error) {
+	return r.pg.List(params)
+}
+
+// Last contains the flow to fetch the last link of the hash chain
+func (r *repoAudit) Last(lock bool) (*domain.Link, error) {
+	return r.pg.Last(lock)
+}
+
+// Chain contains the flow to fetch the chained events in order
+func (r *repoAudit) Chain(afterSeq int64, limit uint64) ([]domain.Event, error) {
+	return r.pg.Chain(afterSeq, limit)
+}
+
+// Unchained contains the flow to fetch the events that are not in the hash chain yet
+func (r *repoAudit) Unchained(limit uint64) ([]domain.Event, error) {
+	return r.pg.Unchained(limit)
+}
+
+// Link contains the flow to record the position of an event in the hash chain
+func (r *repoAudit) Link(event *domain.Event) error {
+	return r.pg.Link(event)
+}
+
+// AddCheckpoint contains the flow to record a checkpoint of the hash chain
+func (r *repoAudit) AddCheckpoint(checkpoint *domain.Checkpoint) error {
+	return r.pg.AddCheckpoint(checkpoint)
+}
+
+// Checkpoints contains the flow to fetch the checkpoints of the hash chain
+func (r *repoAudit) Checkpoints() ([]domain.Checkpoint, error) {
+	return r.pg.Checkpoints()
+}
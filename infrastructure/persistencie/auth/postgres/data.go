@@ -2,6 +2,9 @@ package postgres
 
 import (
 	"database/sql"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -27,6 +30,15 @@ type (
 
 	// Flag is the implementation of transaction for the flag repository
 	Flag struct{ DB *database.Transaction }
+
+	// Lockout is the implementation of transaction for the lockout repository
+	Lockout struct{ DB *database.Transaction }
+
+	// Token is the implementation of transaction for the token repository
+	Token struct{ DB *database.Transaction }
+
+	// Directory is the implementation of transaction for the directory repository
+	Directory struct{ DB *database.Transaction }
 )
 
 // CreateAccount register the user in the database
@@ -48,14 +60,20 @@ func (pg *PGAuth) CreateAccount(input *domain.CreateAccount) (userID *uuid.UUID,
 	return
 }
 
-func (pg *PGAuth) AddAttempts(userID *uuid.UUID) (err error) {
-	if _, err = pg.DB.Builder.
+// AddAttempts adds a failed attempt to the user, restarting the count when the last failure is outside the window
+func (pg *PGAuth) AddAttempts(userID *uuid.UUID) (attempts *int64, err error) {
+	policy := config.Get().Lockout
+	if err = pg.DB.Builder.
 		Update("users").
-		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("attempts", squirrel.Expr(`CASE
+			WHEN attempts < ? AND last_failure + (? * INTERVAL '1 second') < NOW() THEN 1
+			ELSE attempts + 1
+		END`, policy.MaxAttempts, policy.Window)).
 		Set("last_failure", squirrel.Expr("NOW()")).
 		Where("id = ?", userID).
-		Exec(); err != nil && err != sql.ErrNoRows {
-		return oops.Err(err)
+		Suffix("RETURNING attempts").
+		Scan(&attempts); err != nil && err != sql.ErrNoRows {
+		return nil, oops.Err(err)
 	}
 
 	return
@@ -104,13 +122,18 @@ func (pg *User) GetUser(data *domain.User) (err error) {
 
 	if err = pg.DB.Builder.
 		Select(`id, email, password, first_name, last_name, flag, key, active, level, otp`).
-		Column("attempts >= 3 AND (last_failure + '5 minutes') >= NOW() AS blocked").
+		Column("locked_permanently OR COALESCE(locked_until >= NOW(), FALSE) AS blocked").
 		Column("(flag & ?) <> 0", domain.FlagOTPEnable).
 		Column("(flag & ?) <> 0", domain.FlagOTPSetup).
+		Columns("password_changed_at", "must_change_password").
+		Columns("attempts", "last_failure", "locked_until", "locked_permanently", "max_sessions", "ldap_dn").
 		From("users").
 		Where(cond).
 		Scan(&data.ID, &data.Email, &data.Password, &data.FirstName, &data.LastName, &data.Flag, &data.Key,
-			&data.Active, &data.Level, &data.OTPToken, &data.Blocked, &data.OTPEnable, &data.OTPSetUp); err != nil {
+			&data.Active, &data.Level, &data.OTPToken, &data.Blocked, &data.OTPEnable, &data.OTPSetUp,
+			&data.PasswordChangedAt, &data.MustChangePassword,
+			&data.Attempts, &data.LastFailure, &data.LockedUntil, &data.LockedPermanently, &data.MaxSessions,
+			&data.DirectoryDN); err != nil {
 		if err == sql.ErrNoRows {
 			return domain.ErrUserNotExists()
 		}
@@ -141,6 +164,9 @@ func (pg *User) ChangePassword(in *domain.ChangePassword) error {
 		Set("attempts", 0).
 		Set("key", in.Key).
 		Set("last_failure", squirrel.Expr("NULL")).
+		Set("locked_until", squirrel.Expr("NULL")).
+		Set("password_changed_at", squirrel.Expr("NOW()")).
+		Set("must_change_password", false).
 		Where(squirrel.Eq{"id": in.UserID, "active": true}).
 		Suffix("RETURNING id").
 		Scan(new(string)); err != nil {
@@ -150,6 +176,54 @@ func (pg *User) ChangePassword(in *domain.ChangePassword) error {
 	return nil
 }
 
+// ForcePasswordChange flags the user to change the password on the next login
+func (pg *User) ForcePasswordChange(userID *uuid.UUID) error {
+	if err := pg.DB.Builder.
+		Update("users").
+		Set("must_change_password", true).
+		Where(squirrel.Eq{"id": userID, "active": true}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// ForcePasswordChangeByLevel flags all users of a level to change the password on the next login
+func (pg *User) ForcePasswordChangeByLevel(level *domain.Level) (err error) {
+	if _, err = pg.DB.Builder.
+		Update("users").
+		Set("must_change_password", true).
+		Where(squirrel.Eq{"level": level, "active": true}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// SetMaxSessions changes the number of sessions the user can have open at the same time,
+// using the session policy of the level when it is nil
+func (pg *User) SetMaxSessions(userID *uuid.UUID, maxSessions *int64) error {
+	if err := pg.DB.Builder.
+		Update("users").
+		Set("max_sessions", maxSessions).
+		Where(squirrel.Eq{"id": userID}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
 func (pg *OTP) GetToken(userID *uuid.UUID) (userName, token *string, err error) {
 	if err = pg.DB.Builder.
 		Select("CONCAT('(',first_name,' ',last_name,')'), otp").
@@ -175,28 +249,18 @@ func (pg *OTP) SetToken(userID *uuid.UUID, secret *string) (err error) {
 }
 
 // Create add session of the user in database
-func (pg *Session) Create(userID *uuid.UUID, clientIP, userAgent *string) (sessionID *uuid.UUID, err error) {
+func (pg *Session) Create(userID *uuid.UUID, level *domain.Level, method *domain.AuthMethod, clientIP, userAgent *string) (sessionID *uuid.UUID, expiresAt *time.Time, err error) {
+	policy := config.Get().GetSessionPolicy(string(*level))
 	if err = pg.DB.Builder.
 		Insert("sessions").
-		Columns("user_id", "expires_at", "ip", "user_agent").
-		Values(userID, squirrel.Expr("NOW() + '15 minutes'"), clientIP, userAgent).
-		Suffix(`RETURNING "id"`).
-		Scan(&sessionID); err != nil {
-		return nil, oops.Err(err)
-	}
-
-	if _, err = pg.DB.Builder.
-		Update("sessions").
-		Set("deleted_at", squirrel.Expr("NOW()")).
-		Where(`id NOT IN (
-			SELECT id FROM sessions
-			WHERE user_id = ? AND deleted_at IS NULL
-			ORDER BY created_at DESC 
-			LIMIT ?
-		)`, userID, config.Get().Server.OpenSessionsPerUser).
-		Where("user_id = ?", userID).
-		Exec(); err != nil {
-		return nil, oops.Err(err)
+		Columns("user_id", "expires_at", "absolute_expires_at", "method", "ip", "user_agent").
+		Values(userID,
+			squirrel.Expr("NOW() + (LEAST(?, ?) * INTERVAL '1 second')", policy.IdleTimeout, policy.MaxLifetime),
+			squirrel.Expr("NOW() + (? * INTERVAL '1 second')", policy.MaxLifetime),
+			method, clientIP, userAgent).
+		Suffix(`RETURNING "id", expires_at`).
+		Scan(&sessionID, &expiresAt); err != nil {
+		return nil, nil, oops.Err(err)
 	}
 
 	if _, err = pg.DB.Builder.
@@ -204,9 +268,10 @@ func (pg *Session) Create(userID *uuid.UUID, clientIP, userAgent *string) (sessi
 		Set("attempts", 0).
 		Set("last_login", squirrel.Expr("NOW()")).
 		Set("last_failure", nil).
+		Set("locked_until", nil).
 		Where("id = ?", userID).
 		Exec(); err != nil && err != sql.ErrNoRows {
-		return nil, oops.Err(err)
+		return nil, nil, oops.Err(err)
 	}
 
 	return
@@ -226,6 +291,157 @@ func (pg *Session) Delete(ids ...*uuid.UUID) (err error) {
 	return
 }
 
+// DeleteByLevel delete all sessions of users with the level in database
+func (pg *Session) DeleteByLevel(level *domain.Level) (err error) {
+	if _, err = pg.DB.Builder.
+		Update("sessions").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where("deleted_at IS NULL").
+		Where("user_id IN (SELECT id FROM users WHERE level = ?)", level).
+		Exec(); err != nil && err != sql.ErrNoRows {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// List fetches the active sessions of the user in database
+func (pg *Session) List(userID *uuid.UUID) (sessions []*domain.ActiveSession, err error) {
+	rows, err := pg.DB.Builder.
+		Select("id, ip, user_agent, method, created_at, last_seen_at, expires_at").
+		From("sessions").
+		Where("user_id = ? AND deleted_at IS NULL AND expires_at > NOW()", userID).
+		OrderBy("last_seen_at DESC").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session := new(domain.ActiveSession)
+		if err = rows.Scan(&session.ID, &session.IP, &session.UserAgent, &session.Method, &session.CreatedAt,
+			&session.LastSeenAt, &session.ExpiresAt); err != nil {
+			return nil, oops.Err(err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return
+}
+
+// Recent fetches the devices and networks of the sessions created by the user in the last days,
+// including the sessions that have already ended
+func (pg *Session) Recent(userID *uuid.UUID, days int64) (sessions []*domain.ActiveSession, err error) {
+	rows, err := pg.DB.Builder.
+		Select("DISTINCT ip, user_agent").
+		From("sessions").
+		Where("user_id = ? AND created_at > NOW() - (? * INTERVAL '1 day')", userID, days).
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session := new(domain.ActiveSession)
+		if err = rows.Scan(&session.IP, &session.UserAgent); err != nil {
+			return nil, oops.Err(err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return
+}
+
+// Revoke ends an active session of the user in database
+func (pg *Session) Revoke(userID, sessionID *uuid.UUID) (err error) {
+	if err = pg.DB.Builder.
+		Update("sessions").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": sessionID, "user_id": userID, "deleted_at": nil}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrSessionNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// RevokeAll ends all active sessions of the user in database, except the informed one
+func (pg *Session) RevokeAll(userID *uuid.UUID, except *uuid.UUID) (err error) {
+	query := pg.DB.Builder.
+		Update("sessions").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil})
+
+	if except != nil {
+		query = query.Where(squirrel.NotEq{"id": except})
+	}
+
+	if _, err = query.Exec(); err != nil && err != sql.ErrNoRows {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Touch refreshes the idle timeout of the session, limited to its absolute lifetime,
+// returning false when the session has ended or expired
+func (pg *Session) Touch(sessionID *uuid.UUID, level *domain.Level) (active bool, err error) {
+	policy := config.Get().GetSessionPolicy(string(*level))
+	if err = pg.DB.Builder.
+		Update("sessions").
+		Set("last_seen_at", squirrel.Expr("NOW()")).
+		Set("expires_at", squirrel.Expr("LEAST(NOW() + (? * INTERVAL '1 second'), absolute_expires_at)", policy.IdleTimeout)).
+		Where(squirrel.Eq{"id": sessionID, "deleted_at": nil}).
+		Where("expires_at > NOW()").
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, oops.Err(err)
+	}
+
+	return true, nil
+}
+
+// Active returns if the session has not ended nor expired, without refreshing its idle timeout
+func (pg *Session) Active(sessionID *uuid.UUID) (active bool, err error) {
+	if err = pg.DB.Builder.
+		Select("id").
+		From("sessions").
+		Where(squirrel.Eq{"id": sessionID, "deleted_at": nil}).
+		Where("expires_at > NOW()").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, oops.Err(err)
+	}
+
+	return true, nil
+}
+
+// DeleteExpired ends the sessions whose idle timeout or absolute lifetime has passed
+func (pg *Session) DeleteExpired() (int64, error) {
+	result, err := pg.DB.Builder.
+		Update("sessions").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where("expires_at <= NOW()").
+		Exec()
+	if err != nil {
+		return 0, oops.Err(err)
+	}
+
+	return result.RowsAffected()
+}
+
 func (pg *Session) Get(userID *uuid.UUID) (sessions []*uuid.UUID, err error) {
 	query := pg.DB.Builder.Select("id").From("sessions").Where("user_id = ? AND deleted_at IS NULL", userID)
 
@@ -266,3 +482,409 @@ func (pg *Flag) Get(userID *uuid.UUID) (flag *int64, err error) {
 	}
 	return
 }
+
+// Get fetches the lockout status of a user
+func (pg *Lockout) Get(userID *uuid.UUID) (lockout *domain.Lockout, err error) {
+	lockout = &domain.Lockout{}
+	if err = pg.DB.Builder.
+		Select("id, attempts, last_failure, locked_until, locked_permanently").
+		Column("locked_permanently OR COALESCE(locked_until >= NOW(), FALSE) AS blocked").
+		From("users").
+		Where("id = ?", userID).
+		Scan(&lockout.UserID, &lockout.Attempts, &lockout.LastFailure, &lockout.LockedUntil,
+			&lockout.LockedPermanently, &lockout.Blocked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrUserNotExists()
+		}
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Events fetches the lockout history of a user
+func (pg *Lockout) Events(userID *uuid.UUID, params *utils.Params) ([]domain.LockoutEntry, *bool, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("lockout_events").
+		Where("user_id = ?", userID).
+		OrderBy("created_at DESC")
+
+	return utils.MakePagination[domain.LockoutEntry](&query, params)
+}
+
+// Lock applies a lock to the user account
+func (pg *Lockout) Lock(lock *domain.Lock) (err error) {
+	if _, err = pg.DB.Builder.
+		Update("users").
+		Set("locked_until", lock.Until).
+		Set("locked_permanently", lock.Permanent).
+		Where("id = ?", lock.UserID).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// Reset removes the failed attempts and the locks of the user account
+func (pg *Lockout) Reset(userID *uuid.UUID) (err error) {
+	if err = pg.DB.Builder.
+		Update("users").
+		Set("attempts", 0).
+		Set("last_failure", nil).
+		Set("locked_until", nil).
+		Set("locked_permanently", false).
+		Where("id = ?", userID).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// AddEvent records an event in the lockout history of a user
+func (pg *Lockout) AddEvent(entry *domain.LockoutEntry) (err error) {
+	if _, err = pg.DB.Builder.
+		Insert("lockout_events").
+		Columns("user_id", "event", "attempts", "locked_until", "ip", "user_agent", "created_by").
+		Values(entry.UserID, entry.Event, entry.Attempts, entry.LockedUntil, entry.IP, entry.UserAgent, entry.CreatedBy).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return
+}
+
+// ClearStale removes the failed attempts that have decayed from the unlocked users. The attempts below the
+// limit decay after the window, the ones that locked the account after the longest temporary lock
+func (pg *Lockout) ClearStale() (int64, error) {
+	policy := &config.Get().Lockout
+
+	window, _ := domain.AttemptsDecay(policy, 0)
+	stale := squirrel.Or{squirrel.Expr("last_failure + (? * INTERVAL '1 second') < NOW()", window)}
+
+	if policy.MaxAttempts > 0 {
+		stale = squirrel.Or{squirrel.And{
+			squirrel.Lt{"attempts": policy.MaxAttempts},
+			squirrel.Expr("last_failure + (? * INTERVAL '1 second') < NOW()", window),
+		}}
+
+		if decay, ok := domain.AttemptsDecay(policy, policy.MaxAttempts); ok {
+			stale = append(stale, squirrel.And{
+				squirrel.GtOrEq{"attempts": policy.MaxAttempts},
+				squirrel.Expr("last_failure + (? * INTERVAL '1 second') < NOW()", decay),
+			})
+		}
+	}
+
+	result, err := pg.DB.Builder.
+		Update("users").
+		Set("attempts", 0).
+		Where("attempts > 0").
+		Where(stale).
+		Where("COALESCE(locked_until < NOW(), TRUE)").
+		Where(squirrel.Eq{"locked_permanently": false}).
+		Exec()
+	if err != nil {
+		return 0, oops.Err(err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Create adds a single-use token of the user valid for the duration in seconds
+func (pg *Token) Create(userID *uuid.UUID, kind domain.TokenKind, duration int64) (tokenID *uuid.UUID, err error) {
+	return pg.CreateWithData(userID, kind, duration, nil)
+}
+
+// CreateWithData adds a single-use token that carries data to be used when the token is consumed
+func (pg *Token) CreateWithData(userID *uuid.UUID, kind domain.TokenKind, duration int64, data *string) (tokenID *uuid.UUID, err error) {
+	if err = pg.DB.Builder.
+		Insert("user_tokens").
+		Columns("user_id", "kind", "expires_at", "data").
+		Values(userID, kind, squirrel.Expr("NOW() + (? * INTERVAL '1 second')", duration), data).
+		Suffix(`RETURNING "id"`).
+		Scan(&tokenID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Use marks a valid token as used, returning the user of the token
+func (pg *Token) Use(tokenID *uuid.UUID, kind domain.TokenKind) (userID *uuid.UUID, err error) {
+	userID, _, err = pg.UseWithData(tokenID, kind)
+	return
+}
+
+// UseWithData marks a valid token as used, returning the user and the data of the token
+func (pg *Token) UseWithData(tokenID *uuid.UUID, kind domain.TokenKind) (userID *uuid.UUID, data *string, err error) {
+	if err = pg.DB.Builder.
+		Update("user_tokens").
+		Set("used", true).
+		Where(squirrel.Eq{"id": tokenID, "kind": kind, "used": false}).
+		Where("expires_at > NOW()").
+		Suffix(`RETURNING "user_id", "data"`).
+		Scan(&userID, &data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, domain.ErrTokenInvalid()
+		}
+		return nil, nil, oops.Err(err)
+	}
+
+	return
+}
+
+// DeleteExpired removes the tokens that were used or have expired
+func (pg *Token) DeleteExpired() (int64, error) {
+	result, err := pg.DB.Builder.
+		Delete("user_tokens").
+		Where("used OR expires_at <= NOW()").
+		Exec()
+	if err != nil {
+		return 0, oops.Err(err)
+	}
+
+	return result.RowsAffected()
+}
+
+// List fetches the users that match the filters, the most recent first
+func (pg *User) List(params *utils.Params) ([]domain.UserSummary, *bool, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("users").
+		OrderBy("created_at DESC", "id")
+
+	if params.HasFilter(domain.FilterUserLevel) {
+		query = query.Where(squirrel.Eq{"level": params.Filters[domain.FilterUserLevel]})
+	}
+
+	if params.HasFilter(domain.FilterUserActive) {
+		active, _ := strconv.ParseBool(params.Filters[domain.FilterUserActive][0])
+		query = query.Where(squirrel.Eq{"active": active})
+	}
+
+	if params.HasFilter(domain.FilterUserBlocked) {
+		blocked, _ := strconv.ParseBool(params.Filters[domain.FilterUserBlocked][0])
+		query = query.Where("(locked_permanently OR COALESCE(locked_until >= NOW(), FALSE)) = ?", blocked)
+	}
+
+	if params.HasFilter(domain.FilterUserCreatedFrom) {
+		query = query.Where(squirrel.GtOrEq{"created_at": params.Filters[domain.FilterUserCreatedFrom][0]})
+	}
+
+	if params.HasFilter(domain.FilterUserCreatedTo) {
+		query = query.Where(squirrel.Lt{"created_at": params.Filters[domain.FilterUserCreatedTo][0]})
+	}
+
+	if params.HasFilter(domain.FilterUserSearch) {
+		search := "%" + likeEscaper.Replace(strings.TrimSpace(params.Filters[domain.FilterUserSearch][0])) + "%"
+		query = query.Where(squirrel.Or{
+			squirrel.ILike{"email": search},
+			squirrel.ILike{"first_name || ' ' || last_name": search},
+		})
+	}
+
+	return utils.MakePagination[domain.UserSummary](&query, params)
+}
+
+// likeEscaper escapes the wildcards of a text searched with LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Summary fetches the data of a user shown to the administrators
+func (pg *User) Summary(userID *uuid.UUID) (*domain.UserSummary, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("users").
+		Where(squirrel.Eq{"id": userID})
+
+	users, _, err := utils.MakePagination[domain.UserSummary](&query, &utils.Params{Limit: 1})
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if len(users) == 0 {
+		return nil, domain.ErrUserNotExists()
+	}
+
+	return &users[0], nil
+}
+
+// Update changes the name and the level of a user
+func (pg *User) Update(userID *uuid.UUID, in *domain.UpdateUser) error {
+	query := pg.DB.Builder.
+		Update("users").
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": userID}).
+		Suffix("RETURNING id")
+
+	if in.FirstName != nil {
+		query = query.Set("first_name", in.FirstName)
+	}
+
+	if in.LastName != nil {
+		query = query.Set("last_name", in.LastName)
+	}
+
+	if in.Level != nil {
+		query = query.Set("level", in.Level)
+	}
+
+	if err := query.Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// EnableUser reactivates the account of a user, except the accounts whose personal data was erased
+func (pg *User) EnableUser(userID *uuid.UUID) error {
+	if err := pg.DB.Builder.
+		Update("users").
+		Set("active", true).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": userID, "anonymized_at": nil}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Profile fetches the data users see about themselves
+func (pg *User) Profile(userID *uuid.UUID) (profile *domain.Profile, err error) {
+	profile = new(domain.Profile)
+	if err = pg.DB.Builder.
+		Select("id, email, first_name, last_name, level, locale, password_changed_at, created_at").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		Scan(&profile.ID, &profile.Email, &profile.FirstName, &profile.LastName,
+			&profile.Level, &profile.Locale, &profile.PasswordChangedAt, &profile.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrUserNotExists()
+		}
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// UpdateProfile changes the name and the preferences of a user
+func (pg *User) UpdateProfile(userID *uuid.UUID, in *domain.UpdateProfile) error {
+	query := pg.DB.Builder.
+		Update("users").
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": userID}).
+		Suffix("RETURNING id")
+
+	if in.FirstName != nil {
+		query = query.Set("first_name", in.FirstName)
+	}
+
+	if in.LastName != nil {
+		query = query.Set("last_name", in.LastName)
+	}
+
+	if in.Locale != nil {
+		query = query.Set("locale", in.Locale)
+	}
+
+	if err := query.Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// ChangeEmail replaces the email of a user
+func (pg *User) ChangeEmail(userID *uuid.UUID, email *string) error {
+	if err := pg.DB.Builder.
+		Update("users").
+		Set("email", email).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": userID}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Sync writes the data of the directory on the account of a user
+func (pg *Directory) Sync(userID *uuid.UUID, in *domain.DirectoryAccount) error {
+	if err := pg.DB.Builder.
+		Update("users").
+		Set("first_name", in.FirstName).
+		Set("last_name", in.LastName).
+		Set("level", in.Level).
+		Set("ldap_dn", in.DN).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": userID}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// JoinProjects adds the user to the existing projects the user does not participate in,
+// returning the projects joined
+func (pg *Directory) JoinProjects(userID *uuid.UUID, projects []uuid.UUID) (joined []uuid.UUID, err error) {
+	if len(projects) == 0 {
+		return nil, nil
+	}
+
+	missing := squirrel.
+		Select().
+		Column("?::UUID", userID).
+		Column("p.id").
+		Column("CURRENT_DATE").
+		From("projects p").
+		Where(squirrel.Eq{"p.id": projects}).
+		Where(`NOT EXISTS (SELECT 1 FROM project_participants pp WHERE pp.project_id = p.id AND pp.user_id = ?
+			AND pp.deleted_at IS NULL AND COALESCE(pp.departure_date >= CURRENT_DATE, TRUE))`, userID)
+
+	rows, err := pg.DB.Builder.
+		Insert("project_participants").
+		Columns("user_id", "project_id", `"start_date"`).
+		Select(missing).
+		Suffix("RETURNING project_id").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID uuid.UUID
+		if err = rows.Scan(&projectID); err != nil {
+			return nil, oops.Err(err)
+		}
+		joined = append(joined, projectID)
+	}
+
+	return joined, rows.Err()
+}
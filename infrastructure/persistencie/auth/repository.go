@@ -5,26 +5,35 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	database "github.com/isaqueveras/powersso/database/postgres"
 	domain "github.com/isaqueveras/powersso/domain/auth"
 	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/auth/postgres"
+	"github.com/isaqueveras/powersso/utils"
 )
 
 var (
-	_ domain.IOTP     = (*repoOTP)(nil)
-	_ domain.IFlag    = (*repoFlag)(nil)
-	_ domain.IAuth    = (*repoAuth)(nil)
-	_ domain.ISession = (*repoSession)(nil)
-	_ domain.IUser    = (*repoUser)(nil)
+	_ domain.IOTP       = (*repoOTP)(nil)
+	_ domain.IFlag      = (*repoFlag)(nil)
+	_ domain.IAuth      = (*repoAuth)(nil)
+	_ domain.ISession   = (*repoSession)(nil)
+	_ domain.IUser      = (*repoUser)(nil)
+	_ domain.ILockout   = (*repoLockout)(nil)
+	_ domain.IToken     = (*repoToken)(nil)
+	_ domain.IDirectory = (*repoDirectory)(nil)
 )
 
 type (
-	repoOTP     struct{ pg *infra.OTP }
-	repoFlag    struct{ pg *infra.Flag }
-	repoAuth    struct{ pg *infra.PGAuth }
-	repoSession struct{ pg *infra.Session }
-	repoUser    struct{ pg *infra.User }
+	repoOTP       struct{ pg *infra.OTP }
+	repoFlag      struct{ pg *infra.Flag }
+	repoAuth      struct{ pg *infra.PGAuth }
+	repoSession   struct{ pg *infra.Session }
+	repoUser      struct{ pg *infra.User }
+	repoLockout   struct{ pg *infra.Lockout }
+	repoToken     struct{ pg *infra.Token }
+	repoDirectory struct{ pg *infra.Directory }
 )
 
 // NewAuthRepository creates a new repository
@@ -38,7 +47,7 @@ func (r *repoAuth) CreateAccount(data *domain.CreateAccount) (userID *uuid.UUID,
 }
 
 // AddAttempts contains the flow for the add number failed attempts
-func (r *repoAuth) AddAttempts(userID *uuid.UUID) error {
+func (r *repoAuth) AddAttempts(userID *uuid.UUID) (*int64, error) {
 	return r.pg.AddAttempts(userID)
 }
 
@@ -79,8 +88,8 @@ func NewSessionRepository(tx *database.Transaction) domain.ISession {
 }
 
 // Create create a new session for a user
-func (r *repoSession) Create(userID *uuid.UUID, clientIP, userAgent *string) (*uuid.UUID, error) {
-	return r.pg.Create(userID, clientIP, userAgent)
+func (r *repoSession) Create(userID *uuid.UUID, level *domain.Level, method *domain.AuthMethod, clientIP, userAgent *string) (*uuid.UUID, *time.Time, error) {
+	return r.pg.Create(userID, level, method, clientIP, userAgent)
 }
 
 // Delete delete a session for a user
@@ -92,6 +101,46 @@ func (r *repoSession) Get(userID *uuid.UUID) ([]*uuid.UUID, error) {
 	return r.pg.Get(userID)
 }
 
+// DeleteByLevel delete all sessions of users with the level
+func (r *repoSession) DeleteByLevel(level *domain.Level) error {
+	return r.pg.DeleteByLevel(level)
+}
+
+// List list the active sessions of a user
+func (r *repoSession) List(userID *uuid.UUID) ([]*domain.ActiveSession, error) {
+	return r.pg.List(userID)
+}
+
+// Revoke end an active session of a user
+func (r *repoSession) Revoke(userID, sessionID *uuid.UUID) error {
+	return r.pg.Revoke(userID, sessionID)
+}
+
+// RevokeAll end all active sessions of a user, except the informed one
+func (r *repoSession) RevokeAll(userID *uuid.UUID, except *uuid.UUID) error {
+	return r.pg.RevokeAll(userID, except)
+}
+
+// Touch refresh the idle timeout of a session that has not ended
+func (r *repoSession) Touch(sessionID *uuid.UUID, level *domain.Level) (bool, error) {
+	return r.pg.Touch(sessionID, level)
+}
+
+// Active manages the flow to check if a session has not ended nor expired
+func (r *repoSession) Active(sessionID *uuid.UUID) (bool, error) {
+	return r.pg.Active(sessionID)
+}
+
+// DeleteExpired manages the flow to end the expired sessions
+func (r *repoSession) DeleteExpired() (int64, error) {
+	return r.pg.DeleteExpired()
+}
+
+// Recent manages the flow for the devices and networks recently used by the user
+func (r *repoSession) Recent(userID *uuid.UUID, days int64) ([]*domain.ActiveSession, error) {
+	return r.pg.Recent(userID, days)
+}
+
 // NewUserRepository creates a new repository
 func NewUserRepository(tx *database.Transaction) domain.IUser {
 	return &repoUser{pg: &infra.User{DB: tx}}
@@ -116,3 +165,133 @@ func (r *repoUser) DisableUser(userUUID *uuid.UUID) error {
 func (r *repoUser) ChangePassword(in *domain.ChangePassword) error {
 	return r.pg.ChangePassword(in)
 }
+
+// ForcePasswordChange manages the flow to require a user to change the password
+func (r *repoUser) ForcePasswordChange(userID *uuid.UUID) error {
+	return r.pg.ForcePasswordChange(userID)
+}
+
+// ForcePasswordChangeByLevel manages the flow to require all users of a level to change the password
+func (r *repoUser) ForcePasswordChangeByLevel(level *domain.Level) error {
+	return r.pg.ForcePasswordChangeByLevel(level)
+}
+
+// NewLockoutRepository creates a new repository
+func NewLockoutRepository(tx *database.Transaction) domain.ILockout {
+	return &repoLockout{pg: &infra.Lockout{DB: tx}}
+}
+
+// Get manages the flow for the lockout status of a user
+func (r *repoLockout) Get(userID *uuid.UUID) (*domain.Lockout, error) {
+	return r.pg.Get(userID)
+}
+
+// Events manages the flow for the lockout history of a user
+func (r *repoLockout) Events(userID *uuid.UUID, params *utils.Params) ([]domain.LockoutEntry, *bool, error) {
+	return r.pg.Events(userID, params)
+}
+
+// Lock manages the flow to lock a user account
+func (r *repoLockout) Lock(lock *domain.Lock) error {
+	return r.pg.Lock(lock)
+}
+
+// Reset manages the flow to unlock a user account
+func (r *repoLockout) Reset(userID *uuid.UUID) error {
+	return r.pg.Reset(userID)
+}
+
+// AddEvent manages the flow to record a lockout event
+func (r *repoLockout) AddEvent(entry *domain.LockoutEntry) error {
+	return r.pg.AddEvent(entry)
+}
+
+// SetMaxSessions manages the flow to change the number of sessions a user can have open
+func (r *repoUser) SetMaxSessions(userID *uuid.UUID, maxSessions *int64) error {
+	return r.pg.SetMaxSessions(userID, maxSessions)
+}
+
+// ClearStale manages the flow to remove the expired failed attempts
+func (r *repoLockout) ClearStale() (int64, error) {
+	return r.pg.ClearStale()
+}
+
+// NewTokenRepository creates a new repository
+func NewTokenRepository(tx *database.Transaction) domain.IToken {
+	return &repoToken{pg: &infra.Token{DB: tx}}
+}
+
+// Create manages the flow to add a single-use token
+func (r *repoToken) Create(userID *uuid.UUID, kind domain.TokenKind, duration int64) (*uuid.UUID, error) {
+	return r.pg.Create(userID, kind, duration)
+}
+
+// Use manages the flow to consume a single-use token
+func (r *repoToken) Use(tokenID *uuid.UUID, kind domain.TokenKind) (*uuid.UUID, error) {
+	return r.pg.Use(tokenID, kind)
+}
+
+// CreateWithData manages the flow to add a single-use token that carries data
+func (r *repoToken) CreateWithData(userID *uuid.UUID, kind domain.TokenKind, duration int64, data *string) (*uuid.UUID, error) {
+	return r.pg.CreateWithData(userID, kind, duration, data)
+}
+
+// UseWithData manages the flow to consume a single-use token that carries data
+func (r *repoToken) UseWithData(tokenID *uuid.UUID, kind domain.TokenKind) (*uuid.UUID, *string, error) {
+	return r.pg.UseWithData(tokenID, kind)
+}
+
+// DeleteExpired manages the flow to remove the used and expired tokens
+func (r *repoToken) DeleteExpired() (int64, error) {
+	return r.pg.DeleteExpired()
+}
+
+// List manages the flow for the users that match the filters
+func (r *repoUser) List(params *utils.Params) ([]domain.UserSummary, *bool, error) {
+	return r.pg.List(params)
+}
+
+// Summary manages the flow for the data of a user shown to the administrators
+func (r *repoUser) Summary(userID *uuid.UUID) (*domain.UserSummary, error) {
+	return r.pg.Summary(userID)
+}
+
+// Update manages the flow to change the name and the level of a user
+func (r *repoUser) Update(userID *uuid.UUID, in *domain.UpdateUser) error {
+	return r.pg.Update(userID, in)
+}
+
+// EnableUser manages the flow to reactivate a user's account
+func (r *repoUser) EnableUser(userID *uuid.UUID) error {
+	return r.pg.EnableUser(userID)
+}
+
+// Profile manages the flow for the data users see about themselves
+func (r *repoUser) Profile(userID *uuid.UUID) (*domain.Profile, error) {
+	return r.pg.Profile(userID)
+}
+
+// UpdateProfile manages the flow to change the name and the preferences of a user
+func (r *repoUser) UpdateProfile(userID *uuid.UUID, in *domain.UpdateProfile) error {
+	return r.pg.UpdateProfile(userID, in)
+}
+
+// ChangeEmail manages the flow to replace the email of a user
+func (r *repoUser) ChangeEmail(userID *uuid.UUID, email *string) error {
+	return r.pg.ChangeEmail(userID, email)
+}
+
+// NewDirectoryRepository creates a new repository
+func NewDirectoryRepository(tx *database.Transaction) domain.IDirectory {
+	return &repoDirectory{pg: &infra.Directory{DB: tx}}
+}
+
+// Sync manages the flow to write the data of the directory on the account of a user
+func (r *repoDirectory) Sync(userID *uuid.UUID, in *domain.DirectoryAccount) error {
+	return r.pg.Sync(userID, in)
+}
+
+// JoinProjects manages the flow to add the user to the projects of the groups of the directory
+func (r *repoDirectory) JoinProjects(userID *uuid.UUID, projects []uuid.UUID) ([]uuid.UUID, error) {
+	return r.pg.JoinProjects(userID, projects)
+}
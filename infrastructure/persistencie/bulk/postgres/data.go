@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/Masterminds/squirrel"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/bulk"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Bulk is the implementation of transaction for the bulk repository
+type Bulk struct{ DB *database.Transaction }
+
+// Users reads the users that were not erased with their current participations in the projects,
+// calling each for every user as the rows are received, in the order the users were created
+func (pg *Bulk) Users(each func(*domain.ExportedUser) error) error {
+	rows, err := pg.DB.Builder.
+		Select("u.id, u.email, u.first_name, u.last_name, u.level, u.active, u.created_by, u.created_at").
+		Column(`COALESCE((
+			SELECT json_agg(json_build_object(
+				'project_id', pp.project_id,
+				'start_date', pp.start_date::TIMESTAMPTZ,
+				'departure_date', pp.departure_date::TIMESTAMPTZ
+			) ORDER BY pp.start_date)
+			FROM project_participants pp
+			WHERE pp.user_id = u.id AND pp.deleted_at IS NULL
+		), '[]')`).
+		From("users u").
+		Where(squirrel.Eq{"u.anonymized_at": nil}).
+		OrderBy("u.created_at", "u.id").
+		Query()
+	if err != nil {
+		return oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			user     domain.ExportedUser
+			projects []byte
+		)
+
+		if err = rows.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Level,
+			&user.Active, &user.CreatedBy, &user.CreatedAt, &projects); err != nil {
+			return oops.Err(err)
+		}
+
+		if err = json.Unmarshal(projects, &user.Projects); err != nil {
+			return oops.Err(err)
+		}
+
+		if err = each(&user); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
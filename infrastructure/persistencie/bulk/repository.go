@@ -0,0 +1,25 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package bulk
+
+import (
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/bulk"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/bulk/postgres"
+)
+
+var _ domain.IBulk = (*repoBulk)(nil)
+
+type repoBulk struct{ pg *infra.Bulk }
+
+// NewBulkRepository creates a new repository
+func NewBulkRepository(tx *database.Transaction) domain.IBulk {
+	return &repoBulk{pg: &infra.Bulk{DB: tx}}
+}
+
+// Users contains the flow to read the users to export
+func (r *repoBulk) Users(each func(*domain.ExportedUser) error) error {
+	return r.pg.Users(each)
+}
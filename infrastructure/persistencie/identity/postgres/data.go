@@ -0,0 +1,172 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/identity"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Identity is the implementation of transaction for the identity repository
+type Identity struct{ DB *database.Transaction }
+
+// State is the implementation of transaction for the state repository
+type State struct{ DB *database.Transaction }
+
+// Get fetches the identity with the subject in the provider
+func (pg *Identity) Get(provider, subject *string) (identity *domain.Identity, err error) {
+	identity = new(domain.Identity)
+	if err = pg.DB.Builder.
+		Select("id, user_id, provider, subject, email, created_at, last_login_at").
+		From("user_identities").
+		Where(squirrel.Eq{"provider": provider, "subject": subject}).
+		Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email,
+			&identity.CreatedAt, &identity.LastLoginAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrIdentityNotFound()
+		}
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Create links an identity to the user
+func (pg *Identity) Create(in *domain.Identity) (identityID *uuid.UUID, err error) {
+	if err = pg.DB.Builder.
+		Insert("user_identities").
+		Columns("user_id", "provider", "subject", "email").
+		Values(in.UserID, in.Provider, in.Subject, in.Email).
+		Suffix(`RETURNING "id"`).
+		Scan(&identityID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Touch records a sign in with the identity, keeping the last email given by the provider
+func (pg *Identity) Touch(identityID *uuid.UUID, email *string) error {
+	if _, err := pg.DB.Builder.
+		Update("user_identities").
+		Set("email", email).
+		Set("last_login_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": identityID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// List fetches the identities linked to the user, the oldest first
+func (pg *Identity) List(userID *uuid.UUID) (identities []domain.Identity, err error) {
+	rows, err := pg.DB.Builder.
+		Select("id, user_id, provider, subject, email, created_at, last_login_at").
+		From("user_identities").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at", "id").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	identities = make([]domain.Identity, 0)
+	for rows.Next() {
+		var identity domain.Identity
+		if err = rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email,
+			&identity.CreatedAt, &identity.LastLoginAt); err != nil {
+			return nil, oops.Err(err)
+		}
+		identities = append(identities, identity)
+	}
+
+	return
+}
+
+// Delete unlinks an identity of the user, returning the identity removed
+func (pg *Identity) Delete(userID, identityID *uuid.UUID) (identity *domain.Identity, err error) {
+	rows, err := pg.DB.Builder.
+		Delete("user_identities").
+		Where(squirrel.Eq{"id": identityID, "user_id": userID}).
+		Suffix("RETURNING id, user_id, provider, subject, email, created_at, last_login_at").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, domain.ErrIdentityNotFound()
+	}
+
+	identity = new(domain.Identity)
+	if err = rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email,
+		&identity.CreatedAt, &identity.LastLoginAt); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Create records the state of a sign in started with a provider
+func (pg *State) Create(in *domain.State) (stateID *uuid.UUID, err error) {
+	if err = pg.DB.Builder.
+		Insert("oidc_states").
+		Columns("provider", "purpose", "user_id", "nonce", "verifier", "binding", "expires_at").
+		Values(in.Provider, in.Purpose, in.UserID, in.Nonce, in.Verifier, in.Binding, in.ExpiresAt).
+		Suffix(`RETURNING "id"`).
+		Scan(&stateID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Use removes the state of a sign in with the provider that has not expired, returning the state
+func (pg *State) Use(stateID *uuid.UUID, provider *string) (state *domain.State, err error) {
+	rows, err := pg.DB.Builder.
+		Delete("oidc_states").
+		Where(squirrel.Eq{"id": stateID, "provider": provider}).
+		Where("expires_at > NOW()").
+		Suffix("RETURNING id, provider, purpose, user_id, nonce, verifier, binding, expires_at").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, domain.ErrStateInvalid()
+	}
+
+	state = new(domain.State)
+	if err = rows.Scan(&state.ID, &state.Provider, &state.Purpose, &state.UserID, &state.Nonce, &state.Verifier,
+		&state.Binding, &state.ExpiresAt); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// DeleteExpired removes the states of the sign ins that were not finished in time
+func (pg *State) DeleteExpired() (int64, error) {
+	result, err := pg.DB.Builder.
+		Delete("oidc_states").
+		Where("expires_at <= NOW()").
+		Exec()
+	if err != nil {
+		return 0, oops.Err(err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package identity
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/identity"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/identity/postgres"
+)
+
+var (
+	_ domain.IIdentity = (*repoIdentity)(nil)
+	_ domain.IState    = (*repoState)(nil)
+)
+
+type repoIdentity struct{ pg *infra.Identity }
+
+type repoState struct{ pg *infra.State }
+
+// NewIdentityRepository creates a new repository
+func NewIdentityRepository(tx *database.Transaction) domain.IIdentity {
+	return &repoIdentity{pg: &infra.Identity{DB: tx}}
+}
+
+// NewStateRepository creates a new repository
+func NewStateRepository(tx *database.Transaction) domain.IState {
+	return &repoState{pg: &infra.State{DB: tx}}
+}
+
+// Get contains the flow to fetch the identity with the subject in the provider
+func (r *repoIdentity) Get(provider, subject *string) (*domain.Identity, error) {
+	return r.pg.Get(provider, subject)
+}
+
+// Create contains the flow to link an identity to the user
+func (r *repoIdentity) Create(in *domain.Identity) (*uuid.UUID, error) {
+	return r.pg.Create(in)
+}
+
+// Touch contains the flow to record a sign in with the identity
+func (r *repoIdentity) Touch(identityID *uuid.UUID, email *string) error {
+	return r.pg.Touch(identityID, email)
+}
+
+// List contains the flow to fetch the identities linked to the user
+func (r *repoIdentity) List(userID *uuid.UUID) ([]domain.Identity, error) {
+	return r.pg.List(userID)
+}
+
+// Delete contains the flow to unlink an identity of the user
+func (r *repoIdentity) Delete(userID, identityID *uuid.UUID) (*domain.Identity, error) {
+	return r.pg.Delete(userID, identityID)
+}
+
+// Create contains the flow to record the state of a sign in
+func (r *repoState) Create(in *domain.State) (*uuid.UUID, error) {
+	return r.pg.Create(in)
+}
+
+// Use contains the flow to finish the state of a sign in
+func (r *repoState) Use(stateID *uuid.UUID, provider *string) (*domain.State, error) {
+	return r.pg.Use(stateID, provider)
+}
+
+// DeleteExpired contains the flow to remove the expired states
+func (r *repoState) DeleteExpired() (int64, error) {
+	return r.pg.DeleteExpired()
+}
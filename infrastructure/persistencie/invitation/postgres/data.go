@@ -0,0 +1,190 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/invitation"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Invitation is the implementation of transaction for the invitation repository
+type Invitation struct{ DB *database.Transaction }
+
+// pending is the condition of the invitations that can still be accepted
+var pending = squirrel.And{
+	squirrel.Eq{"accepted_at": nil, "revoked_at": nil},
+	squirrel.Expr("expires_at > NOW()"),
+}
+
+// Create records an invitation
+func (pg *Invitation) Create(in *domain.CreateInvitation) (invitationID *uuid.UUID, err error) {
+	if err = pg.DB.Builder.
+		Insert("invitations").
+		Columns("email", "first_name", "last_name", "level", "created_by", "expires_at").
+		Values(in.Email, in.FirstName, in.LastName, in.Level, in.CreatedBy, in.ExpiresAt).
+		Suffix(`RETURNING "id"`).
+		Scan(&invitationID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// AddProject adds a project the invitee joins when accepting the invitation
+func (pg *Invitation) AddProject(invitationID *uuid.UUID, project *domain.Project) error {
+	existing := squirrel.
+		Select().
+		Column("?::UUID", invitationID).
+		Column("id").
+		Column("?::DATE", project.StartDate).
+		Column("?::DATE", project.DepartureDate).
+		From("projects").
+		Where(squirrel.Eq{"id": project.ProjectID})
+
+	if err := pg.DB.Builder.
+		Insert("invitation_projects").
+		Columns("invitation_id", "project_id", `"start_date"`, "departure_date").
+		Select(existing).
+		Suffix("RETURNING project_id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrProjectNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// RevokePending revokes the pending invitations of the email, so that only the last one can be accepted
+func (pg *Invitation) RevokePending(email *string) error {
+	if _, err := pg.DB.Builder.
+		Update("invitations").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"email": email, "accepted_at": nil, "revoked_at": nil}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// List fetches the invitations that match the filters, the most recent first
+func (pg *Invitation) List(params *utils.Params) ([]domain.Invitation, *bool, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("invitations").
+		OrderBy("created_at DESC", "id")
+
+	if params.HasFilter(domain.FilterEmail) {
+		query = query.Where(squirrel.Eq{"email": params.Filters[domain.FilterEmail]})
+	}
+
+	if params.HasFilter(domain.FilterStatus) {
+		var status squirrel.Or
+		for _, value := range params.Filters[domain.FilterStatus] {
+			switch domain.Status(value) {
+			case domain.StatusPending:
+				status = append(status, pending)
+			case domain.StatusAccepted:
+				status = append(status, squirrel.NotEq{"accepted_at": nil})
+			case domain.StatusRevoked:
+				status = append(status, squirrel.NotEq{"revoked_at": nil})
+			case domain.StatusExpired:
+				status = append(status, squirrel.And{
+					squirrel.Eq{"accepted_at": nil, "revoked_at": nil},
+					squirrel.Expr("expires_at <= NOW()"),
+				})
+			}
+		}
+		query = query.Where(status)
+	}
+
+	return utils.MakePagination[domain.Invitation](&query, params)
+}
+
+// Revoke revokes a pending invitation
+func (pg *Invitation) Revoke(invitationID *uuid.UUID) error {
+	if err := pg.DB.Builder.
+		Update("invitations").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": invitationID}).
+		Where(pending).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrInvitationNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Use marks a pending invitation as accepted, returning the data of the invitation
+func (pg *Invitation) Use(invitationID *uuid.UUID) (invitation *domain.Invitation, err error) {
+	invitation = new(domain.Invitation)
+	if err = pg.DB.Builder.
+		Update("invitations").
+		Set("accepted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": invitationID}).
+		Where(pending).
+		Suffix(`RETURNING id, email, first_name, last_name, "level", created_by, expires_at, accepted_at, created_at`).
+		Scan(&invitation.ID, &invitation.Email, &invitation.FirstName, &invitation.LastName, &invitation.Level,
+			&invitation.CreatedBy, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrInvitationNotFound()
+		}
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Join links the user created with the invitation and adds the user to the projects of the invitation
+func (pg *Invitation) Join(invitationID, userID *uuid.UUID) (joined []domain.Participation, err error) {
+	if _, err = pg.DB.Builder.
+		Update("invitations").
+		Set("user_id", userID).
+		Where(squirrel.Eq{"id": invitationID}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	projects := squirrel.
+		Select().
+		Column("?::UUID", userID).
+		Columns("project_id", `"start_date"`, "departure_date").
+		From("invitation_projects").
+		Where(squirrel.Eq{"invitation_id": invitationID})
+
+	rows, err := pg.DB.Builder.
+		Insert("project_participants").
+		Columns("user_id", "project_id", `"start_date"`, "departure_date").
+		Select(projects).
+		Suffix(`RETURNING project_id, "start_date", departure_date`).
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var participation domain.Participation
+		if err = rows.Scan(&participation.ProjectID, &participation.StartDate, &participation.DepartureDate); err != nil {
+			return nil, oops.Err(err)
+		}
+		joined = append(joined, participation)
+	}
+
+	return joined, rows.Err()
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package invitation
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/invitation"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/invitation/postgres"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+var _ domain.IInvitation = (*repoInvitation)(nil)
+
+type repoInvitation struct{ pg *infra.Invitation }
+
+// NewInvitationRepository creates a new repository
+func NewInvitationRepository(tx *database.Transaction) domain.IInvitation {
+	return &repoInvitation{pg: &infra.Invitation{DB: tx}}
+}
+
+// Create contains the flow to record an invitation
+func (r *repoInvitation) Create(in *domain.CreateInvitation) (*uuid.UUID, error) {
+	return r.pg.Create(in)
+}
+
+// AddProject contains the flow to add a project the invitee joins when accepting the invitation
+func (r *repoInvitation) AddProject(invitationID *uuid.UUID, project *domain.Project) error {
+	return r.pg.AddProject(invitationID, project)
+}
+
+// RevokePending contains the flow to revoke the pending invitations of an email
+func (r *repoInvitation) RevokePending(email *string) error {
+	return r.pg.RevokePending(email)
+}
+
+// List contains the flow to fetch the invitations that match the filters
+func (r *repoInvitation) List(params *utils.Params) ([]domain.Invitation, *bool, error) {
+	return r.pg.List(params)
+}
+
+// Revoke contains the flow to revoke a pending invitation
+func (r *repoInvitation) Revoke(invitationID *uuid.UUID) error {
+	return r.pg.Revoke(invitationID)
+}
+
+// Use contains the flow to mark a pending invitation as accepted
+func (r *repoInvitation) Use(invitationID *uuid.UUID) (*domain.Invitation, error) {
+	return r.pg.Use(invitationID)
+}
+
+// Join contains the flow to add the user created with the invitation to the projects of the invitation
+func (r *repoInvitation) Join(invitationID, userID *uuid.UUID) ([]domain.Participation, error) {
+	return r.pg.Join(invitationID, userID)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/outbox"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Outbox is the implementation of transaction for the outbox repository
+type Outbox struct{ DB *database.Transaction }
+
+// Add writes a message in the outbox
+func (pg *Outbox) Add(message *domain.Message) error {
+	if _, err := pg.DB.Builder.
+		Insert("outbox").
+		Columns("id", "topic", "aggregate_type", "aggregate_id", "payload", "created_at").
+		Values(message.ID, message.Topic, message.AggregateType, message.AggregateID,
+			squirrel.Expr("?::JSONB", string(*message.Payload)), message.CreatedAt).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Claim takes the messages not yet published in the order they were written, marking them as claimed
+// for the lease in seconds. Nothing is claimed while the messages claimed by another relay are in their
+// lease, so that a message is never published before the ones written earlier
+func (pg *Outbox) Claim(limit uint64, lease int64) (messages []*domain.Message, err error) {
+	if _, err = pg.DB.Builder.
+		Select("pg_advisory_xact_lock(hashtext('outbox'))").
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	rows, err := pg.DB.Builder.
+		Select("id, topic, aggregate_type, aggregate_id, payload, attempts, created_at").
+		From("outbox").
+		Where(squirrel.Eq{"published_at": nil}).
+		Where("NOT EXISTS (SELECT 1 FROM outbox WHERE published_at IS NULL AND locked_until > NOW())").
+		OrderBy("created_at", "id").
+		Limit(limit).
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	ids := make([]*uuid.UUID, 0, limit)
+	for rows.Next() {
+		message := new(domain.Message)
+		if err = rows.Scan(&message.ID, &message.Topic, &message.AggregateType, &message.AggregateID,
+			&message.Payload, &message.Attempts, &message.CreatedAt); err != nil {
+			return nil, oops.Err(err)
+		}
+		messages, ids = append(messages, message), append(ids, message.ID)
+	}
+
+	if err = rows.Err(); err != nil || len(messages) == 0 {
+		return messages, err
+	}
+
+	if _, err = pg.DB.Builder.
+		Update("outbox").
+		Set("locked_until", squirrel.Expr("NOW() + (? * INTERVAL '1 second')", lease)).
+		Where(squirrel.Eq{"id": ids}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Published records that a message was published to the sink
+func (pg *Outbox) Published(messageID *uuid.UUID) error {
+	if _, err := pg.DB.Builder.
+		Update("outbox").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_error", nil).
+		Set("published_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": messageID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Failed records a failed attempt to publish a message, keeping it in the outbox
+func (pg *Outbox) Failed(messageID *uuid.UUID, reason string) error {
+	if _, err := pg.DB.Builder.
+		Update("outbox").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_error", reason).
+		Where(squirrel.Eq{"id": messageID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Release ends the lease of the claimed messages, so that the ones not published are claimed on the next run
+func (pg *Outbox) Release(messageIDs []*uuid.UUID) error {
+	if _, err := pg.DB.Builder.
+		Update("outbox").
+		Set("locked_until", nil).
+		Where(squirrel.Eq{"id": messageIDs}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
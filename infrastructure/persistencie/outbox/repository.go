@@ -0,0 +1,47 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/outbox"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/outbox/postgres"
+)
+
+var _ domain.IOutbox = (*repoOutbox)(nil)
+
+type repoOutbox struct{ pg *infra.Outbox }
+
+// NewOutboxRepository creates a new repository
+func NewOutboxRepository(tx *database.Transaction) domain.IOutbox {
+	return &repoOutbox{pg: &infra.Outbox{DB: tx}}
+}
+
+// Add contains the flow to write a message in the outbox
+func (r *repoOutbox) Add(message *domain.Message) error {
+	return r.pg.Add(message)
+}
+
+// Claim contains the flow to take the messages waiting to be published
+func (r *repoOutbox) Claim(limit uint64, lease int64) ([]*domain.Message, error) {
+	return r.pg.Claim(limit, lease)
+}
+
+// Published contains the flow to record that a message was published
+func (r *repoOutbox) Published(messageID *uuid.UUID) error {
+	return r.pg.Published(messageID)
+}
+
+// Failed contains the flow to record a failed attempt to publish a message
+func (r *repoOutbox) Failed(messageID *uuid.UUID, reason string) error {
+	return r.pg.Failed(messageID, reason)
+}
+
+// Release contains the flow to end the lease of the claimed messages
+func (r *repoOutbox) Release(messageIDs []*uuid.UUID) error {
+	return r.pg.Release(messageIDs)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/policy"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Policy is the implementation of transaction for the policy repository
+type Policy struct{ DB *database.Transaction }
+
+// events converts the events of the policy to the array of the column
+func events(policy *domain.Policy) squirrel.Sqlizer {
+	values := make([]string, 0, len(policy.Events))
+	for _, event := range policy.Events {
+		values = append(values, string(event))
+	}
+	return squirrel.Expr("string_to_array(?, ',')", strings.Join(values, ","))
+}
+
+// policies creates the query of the policies ordered by name
+func (pg *Policy) policies() squirrel.SelectBuilder {
+	return pg.DB.Builder.
+		Select(`id, "name", description, array_to_string(events, ','), target, "condition", active, created_by, created_at, updated_at`).
+		From("access_policies").
+		OrderBy(`LOWER("name")`)
+}
+
+func (pg *Policy) scanPolicies(query squirrel.SelectBuilder) (policies []domain.Policy, err error) {
+	rows, err := query.Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	policies = make([]domain.Policy, 0)
+	for rows.Next() {
+		var (
+			policy domain.Policy
+			events string
+		)
+
+		if err = rows.Scan(&policy.ID, &policy.Name, &policy.Description, &events, &policy.Target, &policy.Condition,
+			&policy.Active, &policy.CreatedBy, &policy.CreatedAt, &policy.UpdatedAt); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		for _, event := range strings.Split(events, ",") {
+			policy.Events = append(policy.Events, domain.Event(event))
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// List fetches the policies, only the ones of the event when informed
+func (pg *Policy) List(event *domain.Event) ([]domain.Policy, error) {
+	query := pg.policies()
+	if event != nil {
+		query = query.Where("? = ANY(events)", string(*event))
+	}
+	return pg.scanPolicies(query)
+}
+
+// Get fetches a policy
+func (pg *Policy) Get(policyID *uuid.UUID) (*domain.Policy, error) {
+	policies, err := pg.scanPolicies(pg.policies().Where(squirrel.Eq{"id": policyID}))
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if len(policies) == 0 {
+		return nil, domain.ErrPolicyNotFound()
+	}
+
+	return &policies[0], nil
+}
+
+// Create records a policy
+func (pg *Policy) Create(policy *domain.Policy) error {
+	if err := pg.DB.Builder.
+		Insert("access_policies").
+		Columns(`"name"`, "description", "events", "target", `"condition"`, "active", "created_by").
+		Values(policy.Name, policy.Description, events(policy), policy.Target, policy.Condition, policy.Active, policy.CreatedBy).
+		Suffix(`ON CONFLICT (LOWER("name")) DO NOTHING RETURNING id, created_at, updated_at`).
+		Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrPolicyExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Update replaces the name, the events, the expressions and the state of a policy
+func (pg *Policy) Update(policy *domain.Policy) error {
+	var exists bool
+	if err := pg.DB.Builder.
+		Select("COUNT(*) > 0").
+		From("access_policies").
+		Where(`LOWER("name") = LOWER(?)`, policy.Name).
+		Where(squirrel.NotEq{"id": policy.ID}).
+		Scan(&exists); err != nil {
+		return oops.Err(err)
+	}
+
+	if exists {
+		return domain.ErrPolicyExists()
+	}
+
+	if err := pg.DB.Builder.
+		Update("access_policies").
+		Set(`"name"`, policy.Name).
+		Set("description", policy.Description).
+		Set("events", events(policy)).
+		Set("target", policy.Target).
+		Set(`"condition"`, policy.Condition).
+		Set("active", policy.Active).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": policy.ID}).
+		Suffix("RETURNING created_by, created_at, updated_at").
+		Scan(&policy.CreatedBy, &policy.CreatedAt, &policy.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrPolicyNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Delete removes a policy
+func (pg *Policy) Delete(policyID *uuid.UUID) error {
+	result, err := pg.DB.Builder.
+		Delete("access_policies").
+		Where(squirrel.Eq{"id": policyID}).
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return domain.ErrPolicyNotFound()
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package policy
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/policy"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/policy/postgres"
+)
+
+var _ domain.IPolicy = (*repoPolicy)(nil)
+
+type repoPolicy struct{ pg *infra.Policy }
+
+// NewPolicyRepository creates a new repository
+func NewPolicyRepository(tx *database.Transaction) domain.IPolicy {
+	return &repoPolicy{pg: &infra.Policy{DB: tx}}
+}
+
+// List contains the flow to fetch the policies
+func (r *repoPolicy) List(event *domain.Event) ([]domain.Policy, error) { return r.pg.List(event) }
+
+// Get contains the flow to fetch a policy
+func (r *repoPolicy) Get(policyID *uuid.UUID) (*domain.Policy, error) { return r.pg.Get(policyID) }
+
+// Create contains the flow to record a policy
+func (r *repoPolicy) Create(policy *domain.Policy) error { return r.pg.Create(policy) }
+
+// Update contains the flow to replace a policy
+func (r *repoPolicy) Update(policy *domain.Policy) error { return r.pg.Update(policy) }
+
+// Delete contains the flow to remove a policy
+func (r *repoPolicy) Delete(policyID *uuid.UUID) error { return r.pg.Delete(policyID) }
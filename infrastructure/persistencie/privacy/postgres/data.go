@@ -0,0 +1,285 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/domain/audit"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/privacy"
+	"github.com/isaqueveras/powersso/domain/project"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Privacy is the implementation of transaction for the privacy repository
+type Privacy struct{ DB *database.Transaction }
+
+// Sessions fetches all sessions of a user, including the ended ones
+func (pg *Privacy) Sessions(userID *uuid.UUID, params *utils.Params) ([]domain.Session, *bool, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("sessions").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at DESC", "id")
+
+	return utils.MakePagination[domain.Session](&query, params)
+}
+
+// Participations fetches all participations of a user in the projects, including the ended ones
+func (pg *Privacy) Participations(userID *uuid.UUID, params *utils.Params) ([]domain.Participation, *bool, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("project_participants pp").
+		Join("projects p ON p.id = pp.project_id").
+		Where(squirrel.Eq{"pp.user_id": userID}).
+		OrderBy("pp.created_at DESC", "pp.project_id")
+
+	return utils.MakePagination[domain.Participation](&query, params)
+}
+
+// AuditEvents fetches the events of the audit log made by the user or about the user
+func (pg *Privacy) AuditEvents(userID *uuid.UUID, params *utils.Params) ([]audit.Event, *bool, error) {
+	query := pg.DB.Builder.
+		Select().
+		From("audit_events").
+		Where(squirrel.Or{
+			squirrel.Eq{"actor_id": userID},
+			squirrel.Eq{"target_type": audit.TargetUser, "target_id": userID.String()},
+		}).
+		OrderBy("created_at DESC", "id")
+
+	return utils.MakePagination[audit.Event](&query, params)
+}
+
+// Deletion fetches the pending deletion of the account of a user
+func (pg *Privacy) Deletion(userID *uuid.UUID) (deletion *domain.Deletion, err error) {
+	deletion = new(domain.Deletion)
+	if err = pg.DB.Builder.
+		Select("user_id, requested_by, was_active, scheduled_at, created_at").
+		From("account_deletions").
+		Where(squirrel.Eq{"user_id": userID, "completed_at": nil}).
+		Scan(&deletion.UserID, &deletion.RequestedBy, &deletion.WasActive,
+			&deletion.ScheduledAt, &deletion.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// ScheduleDeletion records the request to delete the account of a user and disables the account
+// until the deletion is cancelled. A user has a single deletion, so an erased account cannot be deleted again
+func (pg *Privacy) ScheduleDeletion(deletion *domain.Deletion) (err error) {
+	if err = pg.DB.Builder.
+		Insert("account_deletions").
+		Columns("user_id", "requested_by", "was_active", "scheduled_at").
+		Values(deletion.UserID, deletion.RequestedBy, deletion.WasActive, deletion.ScheduledAt).
+		Suffix("ON CONFLICT (user_id) DO NOTHING RETURNING created_at").
+		Scan(&deletion.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrDeletionRequested()
+		}
+		return oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Update("users").
+		Set("active", false).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": deletion.UserID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// CancelDeletion removes the pending deletion of the account of a user,
+// restoring the account to the state it had before the request
+func (pg *Privacy) CancelDeletion(userID *uuid.UUID) (err error) {
+	var wasActive bool
+	if err = pg.DB.Builder.
+		Select("was_active").
+		From("account_deletions").
+		Where(squirrel.Eq{"user_id": userID, "completed_at": nil}).
+		Suffix("FOR UPDATE").
+		Scan(&wasActive); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrDeletionNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Delete("account_deletions").
+		Where(squirrel.Eq{"user_id": userID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Update("users").
+		Set("active", wasActive).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": userID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// DueDeletions fetches the users whose grace period has passed, locking the deletions
+// so that concurrent runs of the job do not erase the same account
+func (pg *Privacy) DueDeletions(limit uint64) (users []uuid.UUID, err error) {
+	rows, err := pg.DB.Builder.
+		Select("user_id").
+		From("account_deletions").
+		Where(squirrel.Eq{"completed_at": nil}).
+		Where("scheduled_at <= NOW()").
+		OrderBy("scheduled_at").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uuid.UUID
+		if err = rows.Scan(&userID); err != nil {
+			return nil, oops.Err(err)
+		}
+		users = append(users, userID)
+	}
+
+	return users, rows.Err()
+}
+
+// Anonymize replaces the personal data of a user and removes the user from the projects.
+// The rows are kept so that the sessions, the projects created by the user and the participations
+// still reference a valid user, and the audit log is kept unchanged to preserve its hash chain
+func (pg *Privacy) Anonymize(userID *uuid.UUID, data *domain.Anonymized) (ended []project.EndedParticipation, err error) {
+	if err = pg.DB.Builder.
+		Update("users").
+		Set("email", data.Email).
+		Set("first_name", data.FirstName).
+		Set("last_name", data.LastName).
+		Set("password", data.Password).
+		Set("key", data.Key).
+		Set("otp", nil).
+		Set("flag", 0).
+		Set("active", false).
+		Set("locale", nil).
+		Set("max_sessions", nil).
+		Set("last_login", nil).
+		Set("last_failure", nil).
+		Set("attempts", 0).
+		Set("locked_until", nil).
+		Set("locked_permanently", false).
+		Set("must_change_password", false).
+		Set("anonymized_at", squirrel.Expr("NOW()")).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": userID, "anonymized_at": nil}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrUserNotExists()
+		}
+		return nil, oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Update("sessions").
+		Set("ip", domain.AnonymizedIP).
+		Set("user_agent", domain.AnonymizedUserAgent).
+		Set("deleted_at", squirrel.Expr("COALESCE(deleted_at, NOW())")).
+		Where(squirrel.Eq{"user_id": userID}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Update("lockout_events").
+		Set("ip", nil).
+		Set("user_agent", nil).
+		Where(squirrel.Eq{"user_id": userID}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Delete("user_tokens").
+		Where(squirrel.Eq{"user_id": userID}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	for _, table := range []string{"user_identities", "oidc_states", "user_roles", "participant_roles"} {
+		if _, err = pg.DB.Builder.
+			Delete(table).
+			Where(squirrel.Eq{"user_id": userID}).
+			Exec(); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	if _, err = pg.DB.Builder.
+		Delete("relation_tuples").
+		Where(squirrel.Or{
+			squirrel.Eq{"subject_type": "user", "subject_id": userID.String()},
+			squirrel.Eq{"object_type": "user", "object_id": userID.String()},
+		}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if ended, err = pg.endParticipations(userID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if _, err = pg.DB.Builder.
+		Update("account_deletions").
+		Set("completed_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"user_id": userID, "completed_at": nil}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// endParticipations removes the user from the projects the user still participates
+func (pg *Privacy) endParticipations(userID *uuid.UUID) (ended []project.EndedParticipation, err error) {
+	rows, err := pg.DB.Builder.
+		Update("project_participants").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		Suffix("RETURNING project_id, user_id, departure_date").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var participation project.EndedParticipation
+		if err = rows.Scan(&participation.ProjectID, &participation.UserID, &participation.DepartureDate); err != nil {
+			return nil, oops.Err(err)
+		}
+		ended = append(ended, participation)
+	}
+
+	return ended, rows.Err()
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package privacy
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/domain/audit"
+	domain "github.com/isaqueveras/powersso/domain/privacy"
+	"github.com/isaqueveras/powersso/domain/project"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/privacy/postgres"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+var _ domain.IPrivacy = (*repoPrivacy)(nil)
+
+type repoPrivacy struct{ pg *infra.Privacy }
+
+// NewPrivacyRepository creates a new repository
+func NewPrivacyRepository(tx *database.Transaction) domain.IPrivacy {
+	return &repoPrivacy{pg: &infra.Privacy{DB: tx}}
+}
+
+// Sessions contains the flow to fetch all sessions of a user
+func (r *repoPrivacy) Sessions(userID *uuid.UUID, params *utils.Params) ([]domain.Session, *bool, error) {
+	return r.pg.Sessions(userID, params)
+}
+
+// Participations contains the flow to fetch all participations of a user in the projects
+func (r *repoPrivacy) Participations(userID *uuid.UUID, params *utils.Params) ([]domain.Participation, *bool, error) {
+	return r.pg.Participations(userID, params)
+}
+
+// AuditEvents contains the flow to fetch the events of the audit log made by or about a user
+func (r *repoPrivacy) AuditEvents(userID *uuid.UUID, params *utils.Params) ([]audit.Event, *bool, error) {
+	return r.pg.AuditEvents(userID, params)
+}
+
+// Deletion contains the flow to fetch the pending deletion of the account of a user
+func (r *repoPrivacy) Deletion(userID *uuid.UUID) (*domain.Deletion, error) {
+	return r.pg.Deletion(userID)
+}
+
+// ScheduleDeletion contains the flow to record the request to delete the account of a user
+func (r *repoPrivacy) ScheduleDeletion(deletion *domain.Deletion) error {
+	return r.pg.ScheduleDeletion(deletion)
+}
+
+// CancelDeletion contains the flow to remove the pending deletion of the account of a user
+func (r *repoPrivacy) CancelDeletion(userID *uuid.UUID) error {
+	return r.pg.CancelDeletion(userID)
+}
+
+// DueDeletions contains the flow to fetch the users whose grace period has passed
+func (r *repoPrivacy) DueDeletions(limit uint64) ([]uuid.UUID, error) {
+	return r.pg.DueDeletions(limit)
+}
+
+// Anonymize contains the flow to replace the personal data of a user
+func (r *repoPrivacy) Anonymize(userID *uuid.UUID, data *domain.Anonymized) ([]project.EndedParticipation, error) {
+	return r.pg.Anonymize(userID, data)
+}
@@ -5,6 +5,12 @@
 package project
 
 import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
 	"github.com/isaqueveras/powersso/database/postgres"
 	"github.com/isaqueveras/powersso/domain/project"
 	"github.com/isaqueveras/powersso/oops"
@@ -16,15 +22,14 @@ type pg struct {
 }
 
 // Create contains the flow for create project in database
-func (pg *pg) create(input *project.CreateProject) (err error) {
-	var projectID *string
+func (pg *pg) create(input *project.CreateProject) (projectID *uuid.UUID, err error) {
 	if err = pg.DB.Builder.
 		Insert("projects").
 		Columns("created_by", "name", "description", "color", "slug").
 		Values(input.CreatedByID, input.Name, input.Description, input.Color, input.Slug).
 		Suffix(`RETURNING "id"`).
 		Scan(&projectID); err != nil {
-		return oops.Err(err)
+		return nil, oops.Err(err)
 	}
 
 	for _, value := range input.Participants {
@@ -34,9 +39,473 @@ func (pg *pg) create(input *project.CreateProject) (err error) {
 			Values(value.UserID, value.StartDate, value.DepartureDate, projectID).
 			Suffix(`RETURNING "user_id"`).
 			Scan(new(string)); err != nil {
-			return oops.Err(err)
+			return nil, oops.Err(err)
+		}
+	}
+
+	return
+}
+
+// endParticipations removes the participants whose departure date has passed
+func (pg *pg) endParticipations() (ended []project.EndedParticipation, err error) {
+	rows, err := pg.DB.Builder.
+		Update("project_participants").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where("departure_date < CURRENT_DATE").
+		Suffix("RETURNING project_id, user_id, departure_date").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var participation project.EndedParticipation
+		if err = rows.Scan(&participation.ProjectID, &participation.UserID, &participation.DepartureDate); err != nil {
+			return nil, oops.Err(err)
+		}
+		ended = append(ended, participation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, oops.Err(err)
+	}
+	rows.Close()
+
+	for _, participation := range ended {
+		if err = pg.removeRoles(participation.ProjectID, participation.UserID); err != nil {
+			return nil, oops.Err(err)
+		}
+	}
+
+	return ended, nil
+}
+
+// exists checks if the project exists
+func (pg *pg) exists(projectID *uuid.UUID) (err error) {
+	var exists bool
+	if err = pg.DB.Builder.
+		Select("COUNT(*) > 0").
+		From("projects").
+		Where(squirrel.Eq{"id": projectID}).
+		Scan(&exists); err != nil {
+		return oops.Err(err)
+	}
+
+	if !exists {
+		return project.ErrProjectNotFound()
+	}
+
+	return
+}
+
+// roles creates the query of the roles of the projects with their permissions
+func (pg *pg) roles() squirrel.SelectBuilder {
+	return pg.DB.Builder.
+		Select(`pr.id, pr.project_id, pr."name", pr.description, pr.is_default, pr.created_at, pr.updated_at`).
+		Column(`COALESCE((
+			SELECT json_agg(prp.permission ORDER BY prp.permission)
+			FROM project_role_permissions prp
+			WHERE prp.role_id = pr.id
+		), '[]')`).
+		From("project_roles pr").
+		OrderBy(`pr.is_default DESC`, `pr."name"`)
+}
+
+func (pg *pg) scanRoles(query squirrel.SelectBuilder) (roles []project.Role, err error) {
+	rows, err := query.Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	roles = make([]project.Role, 0)
+	for rows.Next() {
+		var (
+			role        project.Role
+			permissions []byte
+		)
+
+		if err = rows.Scan(&role.ID, &role.ProjectID, &role.Name, &role.Description, &role.IsDefault,
+			&role.CreatedAt, &role.UpdatedAt, &permissions); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		if err = json.Unmarshal(permissions, &role.Permissions); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// getRoles fetches the roles of the project, the default one first
+func (pg *pg) getRoles(projectID *uuid.UUID) ([]project.Role, error) {
+	if err := pg.exists(projectID); err != nil {
+		return nil, err
+	}
+	return pg.scanRoles(pg.roles().Where(squirrel.Eq{"pr.project_id": projectID}))
+}
+
+// getRole fetches a role of the project
+func (pg *pg) getRole(projectID, roleID *uuid.UUID) (*project.Role, error) {
+	roles, err := pg.scanRoles(pg.roles().Where(squirrel.Eq{"pr.project_id": projectID, "pr.id": roleID}))
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if len(roles) == 0 {
+		return nil, project.ErrRoleNotFound()
+	}
+
+	return &roles[0], nil
+}
+
+// createRole records a role of the project with its permissions
+func (pg *pg) createRole(in *project.CreateRole) (roleID *uuid.UUID, err error) {
+	if err = pg.exists(in.ProjectID); err != nil {
+		return nil, err
+	}
+
+	if err = pg.DB.Builder.
+		Insert("project_roles").
+		Columns("project_id", `"name"`, "description", "is_default").
+		Values(in.ProjectID, in.Name, in.Description, in.IsDefault != nil && *in.IsDefault).
+		Suffix(`ON CONFLICT (project_id, LOWER("name")) DO NOTHING RETURNING "id"`).
+		Scan(&roleID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, project.ErrRoleExists()
+		}
+		return nil, oops.Err(err)
+	}
+
+	if err = pg.setPermissions(roleID, in.Permissions); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// updateRole changes the description of a role of the project and replaces its permissions when informed
+func (pg *pg) updateRole(projectID, roleID *uuid.UUID, in *project.UpdateRole) error {
+	query := pg.DB.Builder.
+		Update("project_roles").
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": roleID, "project_id": projectID}).
+		Suffix("RETURNING id")
+
+	if in.Description != nil {
+		query = query.Set("description", in.Description)
+	}
+
+	if err := query.Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return project.ErrRoleNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	if in.Permissions == nil {
+		return nil
+	}
+
+	if _, err := pg.DB.Builder.
+		Delete("project_role_permissions").
+		Where(squirrel.Eq{"role_id": roleID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return pg.setPermissions(roleID, in.Permissions)
+}
+
+func (pg *pg) setPermissions(roleID *uuid.UUID, permissions []project.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	query := pg.DB.Builder.
+		Insert("project_role_permissions").
+		Columns("role_id", "permission")
+
+	for _, permission := range permissions {
+		query = query.Values(roleID, permission)
+	}
+
+	if _, err := query.Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// deleteRole removes a role of the project that is not the default one, the assignments are removed with it
+func (pg *pg) deleteRole(projectID, roleID *uuid.UUID) error {
+	result, err := pg.DB.Builder.
+		Delete("project_roles").
+		Where(squirrel.Eq{"id": roleID, "project_id": projectID, "is_default": false}).
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return project.ErrRoleNotFound()
+	}
+
+	return nil
+}
+
+// participants fetches the active participants of the project with the names of their roles
+func (pg *pg) participants(projectID *uuid.UUID) (participants []project.ProjectParticipant, err error) {
+	if err = pg.exists(projectID); err != nil {
+		return nil, err
+	}
+
+	rows, err := pg.DB.Builder.
+		Select("pp.user_id, u.first_name, u.last_name, u.email, pp.start_date, pp.departure_date").
+		Column(`COALESCE((
+			SELECT json_agg(pr."name" ORDER BY pr."name")
+			FROM project_roles pr
+			WHERE pr.project_id = pp.project_id
+			  AND (pr.is_default OR pr.id IN (SELECT par.role_id FROM participant_roles par WHERE par.user_id = pp.user_id))
+		), '[]')`).
+		From("project_participants pp").
+		Join("users u ON u.id = pp.user_id").
+		Where(squirrel.Eq{"pp.project_id": projectID, "pp.deleted_at": nil}).
+		OrderBy("u.first_name", "u.last_name").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	participants = make([]project.ProjectParticipant, 0)
+	for rows.Next() {
+		var (
+			participant project.ProjectParticipant
+			roles       []byte
+		)
+
+		if err = rows.Scan(&participant.UserID, &participant.FirstName, &participant.LastName, &participant.Email,
+			&participant.StartDate, &participant.DepartureDate, &roles); err != nil {
+			return nil, oops.Err(err)
 		}
+
+		if err = json.Unmarshal(roles, &participant.Roles); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		participants = append(participants, participant)
+	}
+
+	return participants, rows.Err()
+}
+
+// isParticipant is the condition of the active participation of the user in the project
+func isParticipant(projectID, userID *uuid.UUID) squirrel.Sqlizer {
+	return squirrel.Expr(`EXISTS (
+		SELECT 1 FROM project_participants pp
+		JOIN users u ON u.id = pp.user_id AND u.active
+		WHERE pp.project_id = ? AND pp.user_id = ? AND pp.deleted_at IS NULL)`, projectID, userID)
+}
+
+// addParticipant adds a user that does not participate in the project
+func (pg *pg) addParticipant(in *project.AddParticipant) error {
+	if err := pg.exists(in.ProjectID); err != nil {
+		return err
+	}
+
+	var participates bool
+	if err := pg.DB.Builder.
+		Select().
+		Column(isParticipant(in.ProjectID, in.UserID)).
+		Scan(&participates); err != nil {
+		return oops.Err(err)
+	}
+
+	if participates {
+		return project.ErrParticipantExists()
+	}
+
+	users := squirrel.
+		Select("u.id").
+		Column("?::UUID", in.ProjectID).
+		Column("?::DATE", in.StartDate).
+		Column("?::DATE", in.DepartureDate).
+		From("users u").
+		Where(squirrel.Eq{"u.id": in.UserID, "u.active": true})
+
+	if err := pg.DB.Builder.
+		Insert("project_participants").
+		Columns("user_id", "project_id", "start_date", "departure_date").
+		Select(users).
+		Suffix(`RETURNING "user_id"`).
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return project.ErrParticipantNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// removeParticipant ends the participation of the user in the project, removing the roles assigned to the user
+func (pg *pg) removeParticipant(projectID, userID *uuid.UUID) error {
+	result, err := pg.DB.Builder.
+		Update("project_participants").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"project_id": projectID, "user_id": userID, "deleted_at": nil}).
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if removed, _ := result.RowsAffected(); removed == 0 {
+		return project.ErrParticipantNotFound()
+	}
+
+	return pg.removeRoles(projectID, userID)
+}
+
+// removeRoles removes the roles of the project assigned to the user
+func (pg *pg) removeRoles(projectID, userID *uuid.UUID) error {
+	if _, err := pg.DB.Builder.
+		Delete("participant_roles").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where("role_id IN (SELECT id FROM project_roles WHERE project_id = ?)", projectID).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// assignRoles assigns the roles of the project with the names to the participant
+func (pg *pg) assignRoles(projectID, userID, createdBy *uuid.UUID, roles []string) error {
+	if len(roles) == 0 {
+		return nil
+	}
+
+	selected := squirrel.
+		Select("pr.id").
+		Column("?::UUID", userID).
+		Column("?::UUID", createdBy).
+		From("project_roles pr").
+		Where(squirrel.Eq{"pr.project_id": projectID, "pr.is_default": false, `pr."name"`: roles})
+
+	result, err := pg.DB.Builder.
+		Insert("participant_roles").
+		Columns("role_id", "user_id", "created_by").
+		Select(selected).
+		Suffix("ON CONFLICT (role_id, user_id) DO NOTHING").
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if assigned, _ := result.RowsAffected(); assigned != int64(len(roles)) {
+		return project.ErrRoleNotFound()
+	}
+
+	return nil
+}
+
+// assignRole assigns a role of the project to an active participant
+func (pg *pg) assignRole(in *project.Assignment) error {
+	participants := squirrel.
+		Select().
+		Column("?::UUID", in.RoleID).
+		Column("?::UUID", in.UserID).
+		Column("?::UUID", in.CreatedBy).
+		Where(isParticipant(in.ProjectID, in.UserID))
+
+	if err := pg.DB.Builder.
+		Insert("participant_roles").
+		Columns("role_id", "user_id", "created_by").
+		Select(participants).
+		Suffix("ON CONFLICT (role_id, user_id) DO UPDATE SET created_by = participant_roles.created_by RETURNING created_at").
+		Scan(&in.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return project.ErrParticipantNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// unassignRole removes a role of the project assigned to the participant
+func (pg *pg) unassignRole(in *project.Assignment) error {
+	result, err := pg.DB.Builder.
+		Delete("participant_roles").
+		Where(squirrel.Eq{"role_id": in.RoleID, "user_id": in.UserID}).
+		Where("role_id IN (SELECT id FROM project_roles WHERE project_id = ?)", in.ProjectID).
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return project.ErrRoleNotAssigned()
+	}
+
+	return nil
+}
+
+// participantRoles is the condition of the roles of the participant in the project,
+// the ones assigned and the default one, which are only given while the user participates
+func participantRoles(projectID, userID *uuid.UUID) squirrel.Sqlizer {
+	return squirrel.And{
+		squirrel.Eq{"pr.project_id": projectID},
+		isParticipant(projectID, userID),
+		squirrel.Or{
+			squirrel.Eq{"pr.is_default": true},
+			squirrel.Expr("pr.id IN (SELECT par.role_id FROM participant_roles par WHERE par.user_id = ?)", userID),
+		},
+	}
+}
+
+// hasPermission checks if a role of the participant has the permission in the project
+func (pg *pg) hasPermission(projectID, userID *uuid.UUID, permission project.Permission) (allowed bool, err error) {
+	if err = pg.DB.Builder.
+		Select("COUNT(*) > 0").
+		From("project_roles pr").
+		Join("project_role_permissions prp ON prp.role_id = pr.id").
+		Where(squirrel.Eq{"prp.permission": permission}).
+		Where(participantRoles(projectID, userID)).
+		Scan(&allowed); err != nil {
+		return false, oops.Err(err)
 	}
 
 	return
 }
+
+// access fetches the names of the roles and the permissions of the participant in the project
+func (pg *pg) access(projectID, userID *uuid.UUID) (*project.Access, error) {
+	roles, err := pg.scanRoles(pg.roles().Where(participantRoles(projectID, userID)))
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	var (
+		access = &project.Access{ProjectID: projectID, Roles: make([]string, 0), Permissions: make([]project.Permission, 0)}
+		seen   = make(map[project.Permission]bool)
+	)
+
+	for _, role := range roles {
+		access.Roles = append(access.Roles, *role.Name)
+		for _, permission := range role.Permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				access.Permissions = append(access.Permissions, permission)
+			}
+		}
+	}
+
+	return access, nil
+}
@@ -5,6 +5,8 @@
 package project
 
 import (
+	"github.com/google/uuid"
+
 	"github.com/isaqueveras/powersso/database/postgres"
 	"github.com/isaqueveras/powersso/domain/project"
 )
@@ -20,6 +22,76 @@ func New(transaction *postgres.Transaction) project.IProject {
 }
 
 // Create contains the flow for create project in database
-func (r *repository) Create(input *project.CreateProject) error {
+func (r *repository) Create(input *project.CreateProject) (*uuid.UUID, error) {
 	return r.pg.create(input)
 }
+
+// EndParticipations contains the flow for remove the participants whose departure date has passed
+func (r *repository) EndParticipations() ([]project.EndedParticipation, error) {
+	return r.pg.endParticipations()
+}
+
+// Roles contains the flow for fetch the roles of a project
+func (r *repository) Roles(projectID *uuid.UUID) ([]project.Role, error) {
+	return r.pg.getRoles(projectID)
+}
+
+// GetRole contains the flow for fetch a role of a project
+func (r *repository) GetRole(projectID, roleID *uuid.UUID) (*project.Role, error) {
+	return r.pg.getRole(projectID, roleID)
+}
+
+// CreateRole contains the flow for create a role of a project
+func (r *repository) CreateRole(in *project.CreateRole) (*uuid.UUID, error) {
+	return r.pg.createRole(in)
+}
+
+// UpdateRole contains the flow for update a role of a project
+func (r *repository) UpdateRole(projectID, roleID *uuid.UUID, in *project.UpdateRole) error {
+	return r.pg.updateRole(projectID, roleID, in)
+}
+
+// DeleteRole contains the flow for delete a role of a project
+func (r *repository) DeleteRole(projectID, roleID *uuid.UUID) error {
+	return r.pg.deleteRole(projectID, roleID)
+}
+
+// Participants contains the flow for fetch the participants of a project with their roles
+func (r *repository) Participants(projectID *uuid.UUID) ([]project.ProjectParticipant, error) {
+	return r.pg.participants(projectID)
+}
+
+// AddParticipant contains the flow for add a participant to a project
+func (r *repository) AddParticipant(in *project.AddParticipant) error {
+	return r.pg.addParticipant(in)
+}
+
+// RemoveParticipant contains the flow for remove a participant of a project
+func (r *repository) RemoveParticipant(projectID, userID *uuid.UUID) error {
+	return r.pg.removeParticipant(projectID, userID)
+}
+
+// AssignRoles contains the flow for assign the roles of a project with the names to a participant
+func (r *repository) AssignRoles(projectID, userID, createdBy *uuid.UUID, roles []string) error {
+	return r.pg.assignRoles(projectID, userID, createdBy, roles)
+}
+
+// AssignRole contains the flow for assign a role of a project to a participant
+func (r *repository) AssignRole(in *project.Assignment) error {
+	return r.pg.assignRole(in)
+}
+
+// UnassignRole contains the flow for remove a role of a project assigned to a participant
+func (r *repository) UnassignRole(in *project.Assignment) error {
+	return r.pg.unassignRole(in)
+}
+
+// HasPermission contains the flow for check if a role of the participant has the permission in the project
+func (r *repository) HasPermission(projectID, userID *uuid.UUID, permission project.Permission) (bool, error) {
+	return r.pg.hasPermission(projectID, userID, permission)
+}
+
+// Access contains the flow for fetch the roles and the permissions of a participant in a project
+func (r *repository) Access(projectID, userID *uuid.UUID) (*project.Access, error) {
+	return r.pg.access(projectID, userID)
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/relation"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Relation is the implementation of transaction for the relation repository
+type Relation struct{ DB *database.Transaction }
+
+// definition models the definition of a namespace stored as JSON
+type definition struct {
+	Relations []domain.Relation `json:"relations"`
+}
+
+func (pg *Relation) scanNamespaces(query squirrel.SelectBuilder) (namespaces []domain.Namespace, err error) {
+	rows, err := query.Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	namespaces = make([]domain.Namespace, 0)
+	for rows.Next() {
+		var (
+			namespace domain.Namespace
+			data      []byte
+			def       definition
+		)
+
+		if err = rows.Scan(&namespace.Name, &data, &namespace.CreatedAt, &namespace.UpdatedAt); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		if err = json.Unmarshal(data, &def); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		namespace.Relations = def.Relations
+		namespaces = append(namespaces, namespace)
+	}
+
+	return namespaces, rows.Err()
+}
+
+func (pg *Relation) namespaces() squirrel.SelectBuilder {
+	return pg.DB.Builder.
+		Select(`"name", "definition", created_at, updated_at`).
+		From("relation_namespaces").
+		OrderBy(`"name"`)
+}
+
+// Namespaces fetches the namespaces
+func (pg *Relation) Namespaces() ([]domain.Namespace, error) {
+	return pg.scanNamespaces(pg.namespaces())
+}
+
+// GetNamespace fetches a namespace
+func (pg *Relation) GetNamespace(name string) (*domain.Namespace, error) {
+	namespaces, err := pg.scanNamespaces(pg.namespaces().Where(squirrel.Eq{`"name"`: name}))
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if len(namespaces) == 0 {
+		return nil, domain.ErrNamespaceNotFound()
+	}
+
+	return &namespaces[0], nil
+}
+
+// SaveNamespace records the namespace or replaces the relations of the namespace that already exists
+func (pg *Relation) SaveNamespace(namespace *domain.Namespace) error {
+	data, err := json.Marshal(definition{Relations: namespace.Relations})
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if err = pg.DB.Builder.
+		Insert("relation_namespaces").
+		Columns(`"name"`, `"definition"`).
+		Values(namespace.Name, data).
+		Suffix(`ON CONFLICT ("name") DO UPDATE SET "definition" = EXCLUDED."definition", updated_at = NOW()
+			RETURNING created_at, updated_at`).
+		Scan(&namespace.CreatedAt, &namespace.UpdatedAt); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// DeleteNamespace removes a namespace without tuples
+func (pg *Relation) DeleteNamespace(name string) error {
+	var used bool
+	if err := pg.DB.Builder.
+		Select("COUNT(*) > 0").
+		From("relation_tuples").
+		Where(squirrel.Or{squirrel.Eq{"object_type": name}, squirrel.Eq{"subject_type": name}}).
+		Scan(&used); err != nil {
+		return oops.Err(err)
+	}
+
+	if used {
+		return domain.ErrNamespaceInUse()
+	}
+
+	result, err := pg.DB.Builder.
+		Delete("relation_namespaces").
+		Where(squirrel.Eq{`"name"`: name}).
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return domain.ErrNamespaceNotFound()
+	}
+
+	return nil
+}
+
+// Relations fetches the relations used by the tuples of the namespace, as relations of the objects or of the subject sets
+func (pg *Relation) Relations(namespace string) (relations []string, err error) {
+	rows, err := pg.DB.Builder.
+		Select("relation").
+		From("relation_tuples").
+		Where(squirrel.Eq{"object_type": namespace}).
+		Suffix(`UNION SELECT subject_relation FROM relation_tuples WHERE subject_type = ? AND subject_relation <> ''`, namespace).
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var relation string
+		if err = rows.Scan(&relation); err != nil {
+			return nil, oops.Err(err)
+		}
+		relations = append(relations, relation)
+	}
+
+	return relations, rows.Err()
+}
+
+// subjectRelation returns the relation of the subject as stored, empty for the subject itself
+func subjectRelation(subject *domain.Subject) string {
+	if subject.Relation == nil {
+		return ""
+	}
+	return *subject.Relation
+}
+
+// Subjects fetches the subjects related directly to the object by the relation
+func (pg *Relation) Subjects(object *domain.Object, relation string) (subjects []domain.Subject, err error) {
+	rows, err := pg.DB.Builder.
+		Select("subject_type, subject_id, NULLIF(subject_relation, '')").
+		From("relation_tuples").
+		Where(squirrel.Eq{"object_type": object.Type, "object_id": object.ID, "relation": relation}).
+		OrderBy("subject_type", "subject_id", "subject_relation").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var subject domain.Subject
+		if err = rows.Scan(&subject.Type, &subject.ID, &subject.Relation); err != nil {
+			return nil, oops.Err(err)
+		}
+		subjects = append(subjects, subject)
+	}
+
+	return subjects, rows.Err()
+}
+
+// ObjectIDs fetches the identifiers of the objects of the type that have tuples
+func (pg *Relation) ObjectIDs(objectType string, limit int64) (ids []string, err error) {
+	rows, err := pg.DB.Builder.
+		Select("DISTINCT object_id").
+		From("relation_tuples").
+		Where(squirrel.Eq{"object_type": objectType}).
+		OrderBy("object_id").
+		Limit(uint64(limit)).
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, oops.Err(err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// Tuples fetches the tuples that match the filter
+func (pg *Relation) Tuples(filter *domain.TupleFilter, limit int64) (tuples []domain.Tuple, err error) {
+	query := pg.DB.Builder.
+		Select("object_type, object_id, relation, subject_type, subject_id, NULLIF(subject_relation, ''), created_by, created_at").
+		From("relation_tuples").
+		OrderBy("object_type", "object_id", "relation", "subject_type", "subject_id", "subject_relation").
+		Limit(uint64(limit))
+
+	if filter.Object != nil {
+		query = query.Where(squirrel.Eq{"object_type": filter.Object.Type})
+		if filter.Object.ID != nil {
+			query = query.Where(squirrel.Eq{"object_id": filter.Object.ID})
+		}
+	}
+
+	if filter.Relation != nil {
+		query = query.Where(squirrel.Eq{"relation": filter.Relation})
+	}
+
+	if filter.Subject != nil {
+		query = query.Where(squirrel.Eq{
+			"subject_type":     filter.Subject.Type,
+			"subject_id":       filter.Subject.ID,
+			"subject_relation": subjectRelation(filter.Subject),
+		})
+	}
+
+	rows, err := query.Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	tuples = make([]domain.Tuple, 0)
+	for rows.Next() {
+		tuple := domain.Tuple{Object: new(domain.Object), Subject: new(domain.Subject)}
+		if err = rows.Scan(&tuple.Object.Type, &tuple.Object.ID, &tuple.Relation, &tuple.Subject.Type, &tuple.Subject.ID,
+			&tuple.Subject.Relation, &tuple.CreatedBy, &tuple.CreatedAt); err != nil {
+			return nil, oops.Err(err)
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	return tuples, rows.Err()
+}
+
+// Write deletes and then records the tuples, ignoring the ones that do not exist or already exist
+func (pg *Relation) Write(in *domain.Write, createdBy *uuid.UUID) error {
+	for _, tuple := range in.Deletes {
+		if _, err := pg.DB.Builder.
+			Delete("relation_tuples").
+			Where(squirrel.Eq{
+				"object_type":      tuple.Object.Type,
+				"object_id":        tuple.Object.ID,
+				"relation":         tuple.Relation,
+				"subject_type":     tuple.Subject.Type,
+				"subject_id":       tuple.Subject.ID,
+				"subject_relation": subjectRelation(tuple.Subject),
+			}).
+			Exec(); err != nil {
+			return oops.Err(err)
+		}
+	}
+
+	if len(in.Writes) == 0 {
+		return nil
+	}
+
+	query := pg.DB.Builder.
+		Insert("relation_tuples").
+		Columns("object_type", "object_id", "relation", "subject_type", "subject_id", "subject_relation", "created_by").
+		Suffix("ON CONFLICT DO NOTHING")
+
+	for _, tuple := range in.Writes {
+		query = query.Values(tuple.Object.Type, tuple.Object.ID, tuple.Relation, tuple.Subject.Type, tuple.Subject.ID,
+			subjectRelation(tuple.Subject), createdBy)
+	}
+
+	if _, err := query.Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
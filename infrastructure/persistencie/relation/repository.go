@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package relation
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/relation"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/relation/postgres"
+)
+
+var _ domain.IRelation = (*repoRelation)(nil)
+
+type repoRelation struct{ pg *infra.Relation }
+
+// NewRelationRepository creates a new repository
+func NewRelationRepository(tx *database.Transaction) domain.IRelation {
+	return &repoRelation{pg: &infra.Relation{DB: tx}}
+}
+
+// Namespaces contains the flow to fetch the namespaces
+func (r *repoRelation) Namespaces() ([]domain.Namespace, error) { return r.pg.Namespaces() }
+
+// GetNamespace contains the flow to fetch a namespace
+func (r *repoRelation) GetNamespace(name string) (*domain.Namespace, error) {
+	return r.pg.GetNamespace(name)
+}
+
+// SaveNamespace contains the flow to record a namespace
+func (r *repoRelation) SaveNamespace(namespace *domain.Namespace) error {
+	return r.pg.SaveNamespace(namespace)
+}
+
+// DeleteNamespace contains the flow to remove a namespace
+func (r *repoRelation) DeleteNamespace(name string) error { return r.pg.DeleteNamespace(name) }
+
+// Relations contains the flow to fetch the relations used by the tuples of a namespace
+func (r *repoRelation) Relations(namespace string) ([]string, error) {
+	return r.pg.Relations(namespace)
+}
+
+// Subjects contains the flow to fetch the subjects related directly to an object
+func (r *repoRelation) Subjects(object *domain.Object, relation string) ([]domain.Subject, error) {
+	return r.pg.Subjects(object, relation)
+}
+
+// ObjectIDs contains the flow to fetch the identifiers of the objects of a type
+func (r *repoRelation) ObjectIDs(objectType string, limit int64) ([]string, error) {
+	return r.pg.ObjectIDs(objectType, limit)
+}
+
+// Tuples contains the flow to fetch the tuples
+func (r *repoRelation) Tuples(filter *domain.TupleFilter, limit int64) ([]domain.Tuple, error) {
+	return r.pg.Tuples(filter, limit)
+}
+
+// Write contains the flow to record and delete tuples
+func (r *repoRelation) Write(in *domain.Write, createdBy *uuid.UUID) error {
+	return r.pg.Write(in, createdBy)
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// Role is the implementation of transaction for the role repository
+type Role struct{ DB *database.Transaction }
+
+// roles creates the query of the roles with their permissions
+func (pg *Role) roles() squirrel.SelectBuilder {
+	return pg.DB.Builder.
+		Select(`r.id, r."name", r.description, r."level", r.created_at, r.updated_at`).
+		Column(`COALESCE((
+			SELECT json_agg(rp.permission ORDER BY rp.permission)
+			FROM role_permissions rp
+			WHERE rp.role_id = r.id
+		), '[]')`).
+		From("roles r").
+		OrderBy(`r."level" NULLS LAST`, `r."name"`)
+}
+
+func (pg *Role) scanRoles(query squirrel.SelectBuilder) (roles []domain.Role, err error) {
+	rows, err := query.Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	roles = make([]domain.Role, 0)
+	for rows.Next() {
+		var (
+			role        domain.Role
+			permissions []byte
+		)
+
+		if err = rows.Scan(&role.ID, &role.Name, &role.Description, &role.Level, &role.CreatedAt,
+			&role.UpdatedAt, &permissions); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		if err = json.Unmarshal(permissions, &role.Permissions); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// List fetches the roles, the ones of the levels first
+func (pg *Role) List() ([]domain.Role, error) {
+	return pg.scanRoles(pg.roles())
+}
+
+// Get fetches a role
+func (pg *Role) Get(roleID *uuid.UUID) (*domain.Role, error) {
+	roles, err := pg.scanRoles(pg.roles().Where(squirrel.Eq{"r.id": roleID}))
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+
+	if len(roles) == 0 {
+		return nil, domain.ErrRoleNotFound()
+	}
+
+	return &roles[0], nil
+}
+
+// Create records a role with its permissions
+func (pg *Role) Create(in *domain.CreateRole) (roleID *uuid.UUID, err error) {
+	if err = pg.DB.Builder.
+		Insert("roles").
+		Columns(`"name"`, "description").
+		Values(in.Name, in.Description).
+		Suffix(`ON CONFLICT (LOWER("name")) DO NOTHING RETURNING "id"`).
+		Scan(&roleID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrRoleExists()
+		}
+		return nil, oops.Err(err)
+	}
+
+	if err = pg.setPermissions(roleID, in.Permissions); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// Update changes the description of a role and replaces its permissions when informed
+func (pg *Role) Update(roleID *uuid.UUID, in *domain.UpdateRole) error {
+	query := pg.DB.Builder.
+		Update("roles").
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": roleID}).
+		Suffix("RETURNING id")
+
+	if in.Description != nil {
+		query = query.Set("description", in.Description)
+	}
+
+	if err := query.Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrRoleNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	if in.Permissions == nil {
+		return nil
+	}
+
+	if _, err := pg.DB.Builder.
+		Delete("role_permissions").
+		Where(squirrel.Eq{"role_id": roleID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return pg.setPermissions(roleID, in.Permissions)
+}
+
+func (pg *Role) setPermissions(roleID *uuid.UUID, permissions []domain.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	query := pg.DB.Builder.
+		Insert("role_permissions").
+		Columns("role_id", "permission")
+
+	for _, permission := range permissions {
+		query = query.Values(roleID, permission)
+	}
+
+	if _, err := query.Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Delete removes a role that is not given to a level, the assignments of the role are removed with it
+func (pg *Role) Delete(roleID *uuid.UUID) error {
+	result, err := pg.DB.Builder.
+		Delete("roles").
+		Where(squirrel.Eq{"id": roleID, "level": nil}).
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return domain.ErrRoleNotFound()
+	}
+
+	return nil
+}
+
+// Permissions fetches the permissions that can be given to the roles
+func (pg *Role) Permissions() (permissions []domain.PermissionDetail, err error) {
+	rows, err := pg.DB.Builder.
+		Select(`"name", description`).
+		From("permissions").
+		OrderBy(`"name"`).
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission domain.PermissionDetail
+		if err = rows.Scan(&permission.Name, &permission.Description); err != nil {
+			return nil, oops.Err(err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+// userRoles is the condition of the roles of the user, the ones assigned and the one of the level of the user
+func userRoles(userID *uuid.UUID) squirrel.Sqlizer {
+	return squirrel.Or{
+		squirrel.Expr("r.id IN (SELECT ur.role_id FROM user_roles ur WHERE ur.user_id = ?)", userID),
+		squirrel.Expr(`r."level" = (SELECT u."level" FROM users u WHERE u.id = ? AND u.active)`, userID),
+	}
+}
+
+// HasPermission checks if a role of the user has the permission
+func (pg *Role) HasPermission(userID *uuid.UUID, permission domain.Permission) (allowed bool, err error) {
+	if err = pg.DB.Builder.
+		Select("COUNT(*) > 0").
+		From("roles r").
+		Join("role_permissions rp ON rp.role_id = r.id").
+		Where(squirrel.Eq{"rp.permission": permission}).
+		Where(userRoles(userID)).
+		Scan(&allowed); err != nil {
+		return false, oops.Err(err)
+	}
+
+	return
+}
+
+// UserRoles fetches the roles of the user, including the one of the level of the user
+func (pg *Role) UserRoles(userID *uuid.UUID) ([]domain.Role, error) {
+	return pg.scanRoles(pg.roles().Where(userRoles(userID)))
+}
+
+// Assign assigns a role that is not given to a level to the user
+func (pg *Role) Assign(in *domain.Assignment) error {
+	users := squirrel.
+		Select().
+		Column("u.id").
+		Column("r.id").
+		Column("?::UUID", in.CreatedBy).
+		From("users u, roles r").
+		Where(squirrel.Eq{"u.id": in.UserID, "r.id": in.RoleID})
+
+	if err := pg.DB.Builder.
+		Insert("user_roles").
+		Columns("user_id", "role_id", "created_by").
+		Select(users).
+		Suffix("ON CONFLICT (user_id, role_id) DO UPDATE SET created_by = user_roles.created_by RETURNING created_at").
+		Scan(&in.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return auth.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Unassign removes a role assigned to the user
+func (pg *Role) Unassign(userID, roleID *uuid.UUID) error {
+	result, err := pg.DB.Builder.
+		Delete("user_roles").
+		Where(squirrel.Eq{"user_id": userID, "role_id": roleID}).
+		Exec()
+	if err != nil {
+		return oops.Err(err)
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return domain.ErrRoleNotAssigned()
+	}
+
+	return nil
+}
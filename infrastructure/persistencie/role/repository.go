@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package role
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/role"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/role/postgres"
+)
+
+var _ domain.IRole = (*repoRole)(nil)
+
+type repoRole struct{ pg *infra.Role }
+
+// NewRoleRepository creates a new repository
+func NewRoleRepository(tx *database.Transaction) domain.IRole {
+	return &repoRole{pg: &infra.Role{DB: tx}}
+}
+
+// List contains the flow to fetch the roles
+func (r *repoRole) List() ([]domain.Role, error) {
+	return r.pg.List()
+}
+
+// Get contains the flow to fetch a role
+func (r *repoRole) Get(roleID *uuid.UUID) (*domain.Role, error) {
+	return r.pg.Get(roleID)
+}
+
+// Create contains the flow to record a role with its permissions
+func (r *repoRole) Create(in *domain.CreateRole) (*uuid.UUID, error) {
+	return r.pg.Create(in)
+}
+
+// Update contains the flow to update a role
+func (r *repoRole) Update(roleID *uuid.UUID, in *domain.UpdateRole) error {
+	return r.pg.Update(roleID, in)
+}
+
+// Delete contains the flow to remove a role
+func (r *repoRole) Delete(roleID *uuid.UUID) error {
+	return r.pg.Delete(roleID)
+}
+
+// Permissions contains the flow to fetch the permissions that can be given to the roles
+func (r *repoRole) Permissions() ([]domain.PermissionDetail, error) {
+	return r.pg.Permissions()
+}
+
+// HasPermission contains the flow to check if a role of the user has the permission
+func (r *repoRole) HasPermission(userID *uuid.UUID, permission domain.Permission) (bool, error) {
+	return r.pg.HasPermission(userID, permission)
+}
+
+// UserRoles contains the flow to fetch the roles of the user
+func (r *repoRole) UserRoles(userID *uuid.UUID) ([]domain.Role, error) {
+	return r.pg.UserRoles(userID)
+}
+
+// Assign contains the flow to assign a role to the user
+func (r *repoRole) Assign(in *domain.Assignment) error {
+	return r.pg.Assign(in)
+}
+
+// Unassign contains the flow to remove a role assigned to the user
+func (r *repoRole) Unassign(userID, roleID *uuid.UUID) error {
+	return r.pg.Unassign(userID, roleID)
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domain "github.com/isaqueveras/powersso/domain/scim"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// SCIM is the implementation of transaction for the scim repository
+type SCIM struct{ DB *database.Transaction }
+
+// provisioned are the accounts managed by the provisioning, the erased accounts are not found
+var provisioned = squirrel.Eq{"u.level": auth.UserLevel, "u.anonymized_at": nil}
+
+// Users fetches a page of the users that match the filter, with the total of users that match it
+func (pg *SCIM) Users(filter domain.Filter, params *domain.ListParams) (users []domain.User, total int64, err error) {
+	count := pg.DB.Builder.Select("COUNT(*)").From("users u").Where(provisioned)
+	query := pg.users(!params.Excluded("groups"))
+
+	if filter != nil {
+		condition, args, err := where(filter, userAttributes, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		count, query = count.Where(condition, args...), query.Where(condition, args...)
+	}
+
+	if err = count.Scan(&total); err != nil {
+		return nil, 0, oops.Err(err)
+	}
+
+	if *params.Count == 0 {
+		return
+	}
+
+	users, err = pg.scanUsers(query.
+		OrderBy("u.created_at", "u.id").
+		Offset(uint64(params.StartIndex - 1)).
+		Limit(uint64(*params.Count)))
+
+	return
+}
+
+// User fetches a user managed by the provisioning
+func (pg *SCIM) User(userID *uuid.UUID) (*domain.User, error) {
+	users, err := pg.scanUsers(pg.users(true).Where(squirrel.Eq{"u.id": userID}))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(users) == 0 {
+		return nil, auth.ErrUserNotExists()
+	}
+
+	return &users[0], nil
+}
+
+// users creates the query of the users with their current projects
+func (pg *SCIM) users(groups bool) squirrel.SelectBuilder {
+	projects := "'[]'"
+	if groups {
+		projects = `COALESCE((
+			SELECT json_agg(json_build_object('value', p.id::TEXT, 'display', p.name) ORDER BY p.name)
+			FROM project_participants pp
+			JOIN projects p ON p.id = pp.project_id
+			WHERE pp.user_id = u.id AND pp.deleted_at IS NULL
+		), '[]')`
+	}
+
+	return pg.DB.Builder.
+		Select("u.id, u.external_id, u.email, u.first_name, u.last_name, u.active, u.locale").
+		Column("u.created_at, COALESCE(u.updated_at, u.created_at)").
+		Column(projects).
+		From("users u").
+		Where(provisioned)
+}
+
+func (pg *SCIM) scanUsers(query squirrel.SelectBuilder) (users []domain.User, err error) {
+	rows, err := query.Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			user   = domain.User{Name: new(domain.Name), Meta: new(domain.Meta)}
+			groups []byte
+		)
+
+		if err = rows.Scan(&user.ID, &user.ExternalID, &user.UserName, &user.Name.GivenName, &user.Name.FamilyName,
+			&user.Active, &user.Locale, &user.Meta.Created, &user.Meta.LastModified, &groups); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		if err = json.Unmarshal(groups, &user.Groups); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// EmailExists checks if an account other than the user has the email, including the accounts of any level
+func (pg *SCIM) EmailExists(email *string, except *uuid.UUID) (exists bool, err error) {
+	query := pg.DB.Builder.
+		Select("COUNT(id) > 0").
+		From("users").
+		Where(squirrel.Eq{"email": email})
+
+	if except != nil {
+		query = query.Where(squirrel.NotEq{"id": except})
+	}
+
+	if err = query.Scan(&exists); err != nil {
+		return false, oops.Err(err)
+	}
+
+	return
+}
+
+// UpdateUser writes the account of a user managed by the provisioning
+func (pg *SCIM) UpdateUser(userID *uuid.UUID, in *domain.Account) error {
+	query := pg.DB.Builder.
+		Update("users u").
+		Set("external_id", in.ExternalID).
+		Set("email", in.Email).
+		Set("first_name", in.FirstName).
+		Set("last_name", in.LastName).
+		Set("active", in.Active).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"u.id": userID}).
+		Where(provisioned).
+		Suffix("RETURNING u.id")
+
+	if in.Locale != nil {
+		query = query.Set("locale", in.Locale)
+	}
+
+	if in.Password != nil {
+		query = query.
+			Set("password", in.Password).
+			Set("key", in.Key).
+			Set("attempts", 0).
+			Set("password_changed_at", squirrel.Expr("NOW()")).
+			Set("must_change_password", false)
+	}
+
+	if err := query.Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return auth.ErrUserNotExists()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Groups fetches a page of the groups that match the filter, with the total of groups that match it
+func (pg *SCIM) Groups(filter domain.Filter, params *domain.ListParams) (groups []domain.Group, total int64, err error) {
+	count := pg.DB.Builder.Select("COUNT(*)").From("projects p")
+	query := pg.groups(!params.Excluded("members"))
+
+	if filter != nil {
+		condition, args, err := where(filter, groupAttributes, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		count, query = count.Where(condition, args...), query.Where(condition, args...)
+	}
+
+	if err = count.Scan(&total); err != nil {
+		return nil, 0, oops.Err(err)
+	}
+
+	if *params.Count == 0 {
+		return
+	}
+
+	groups, err = pg.scanGroups(query.
+		OrderBy("p.created_at", "p.id").
+		Offset(uint64(params.StartIndex - 1)).
+		Limit(uint64(*params.Count)))
+
+	return
+}
+
+// Group fetches the group of a project, the members are not fetched when they are excluded
+func (pg *SCIM) Group(groupID *uuid.UUID, members bool) (*domain.Group, error) {
+	groups, err := pg.scanGroups(pg.groups(members).Where(squirrel.Eq{"p.id": groupID}))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, domain.ErrGroupNotFound()
+	}
+
+	return &groups[0], nil
+}
+
+// groups creates the query of the projects with their current participants managed by the provisioning
+func (pg *SCIM) groups(members bool) squirrel.SelectBuilder {
+	participants := "'[]'"
+	if members {
+		participants = `COALESCE((
+			SELECT json_agg(json_build_object('value', u.id::TEXT, 'display', u.first_name || ' ' || u.last_name)
+				ORDER BY u.first_name, u.last_name)
+			FROM project_participants pp
+			JOIN users u ON u.id = pp.user_id
+			WHERE pp.project_id = p.id AND pp.deleted_at IS NULL AND u.level = 'user' AND u.anonymized_at IS NULL
+		), '[]')`
+	}
+
+	return pg.DB.Builder.
+		Select("p.id, p.external_id, p.name, p.created_at, COALESCE(p.updated_at, p.created_at)").
+		Column(participants).
+		From("projects p")
+}
+
+func (pg *SCIM) scanGroups(query squirrel.SelectBuilder) (groups []domain.Group, err error) {
+	rows, err := query.Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			group   = domain.Group{Meta: new(domain.Meta)}
+			members []byte
+		)
+
+		if err = rows.Scan(&group.ID, &group.ExternalID, &group.DisplayName,
+			&group.Meta.Created, &group.Meta.LastModified, &members); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		if err = json.Unmarshal(members, &group.Members); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+// CreateGroup creates the project of a group, the projects created by the provisioning have no redirect address
+func (pg *SCIM) CreateGroup(in *domain.Project, createdBy *uuid.UUID) (groupID *uuid.UUID, err error) {
+	if err = pg.DB.Builder.
+		Insert("projects").
+		Columns("created_by", "name", "external_id", "uri_redirect").
+		Values(createdBy, in.Name, in.ExternalID, "").
+		Suffix(`RETURNING "id"`).
+		Scan(&groupID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// UpdateGroup writes the project of a group
+func (pg *SCIM) UpdateGroup(groupID *uuid.UUID, in *domain.Project) error {
+	if err := pg.DB.Builder.
+		Update("projects").
+		Set("name", in.Name).
+		Set("external_id", in.ExternalID).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": groupID}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrGroupNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// AddMember adds a user managed by the provisioning as participant of a project from the current date
+func (pg *SCIM) AddMember(groupID, userID *uuid.UUID) error {
+	user := squirrel.
+		Select().
+		Column("?::UUID", groupID).
+		Column("u.id").
+		Column("CURRENT_DATE").
+		From("users u").
+		Where(squirrel.Eq{"u.id": userID}).
+		Where(provisioned)
+
+	if err := pg.DB.Builder.
+		Insert("project_participants").
+		Columns("project_id", "user_id", `"start_date"`).
+		Select(user).
+		Suffix("RETURNING user_id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrInvalidMember()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// RemoveMember ends the current participation of a user in a project at the current date,
+// or at the start date of the participations that have not started
+func (pg *SCIM) RemoveMember(groupID, userID *uuid.UUID) error {
+	if _, err := pg.DB.Builder.
+		Update("project_participants").
+		Set("departure_date", squirrel.Expr(`GREATEST("start_date", CURRENT_DATE)`)).
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"project_id": groupID, "user_id": userID, "deleted_at": nil}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
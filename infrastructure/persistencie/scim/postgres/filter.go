@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	domain "github.com/isaqueveras/powersso/domain/scim"
+)
+
+// kind set data type to the type of the column of an attribute
+type kind int
+
+const (
+	kindText kind = iota
+	kindBool
+	kindTime
+)
+
+// attribute models the column of an attribute that can be filtered. The attributes of
+// the participations are compared inside the exists condition
+type attribute struct {
+	column string
+	kind   kind
+	exists string
+}
+
+const (
+	userName  = "u.first_name || ' ' || u.last_name"
+	userGroup = "EXISTS (SELECT 1 FROM project_participants pp WHERE pp.user_id = u.id AND pp.deleted_at IS NULL AND %s)"
+	member    = "EXISTS (SELECT 1 FROM project_participants pp JOIN users mu ON mu.id = pp.user_id " +
+		"WHERE pp.project_id = p.id AND pp.deleted_at IS NULL AND mu.level = 'user' AND mu.anonymized_at IS NULL AND %s)"
+)
+
+// userAttributes are the attributes of the users that can be filtered
+var userAttributes = map[string]attribute{
+	"id":                {column: "u.id::TEXT"},
+	"externalid":        {column: "u.external_id"},
+	"username":          {column: "u.email"},
+	"emails":            {column: "u.email"},
+	"emails.value":      {column: "u.email"},
+	"emails.type":       {column: "'work'"},
+	"emails.primary":    {column: "TRUE", kind: kindBool},
+	"name.givenname":    {column: "u.first_name"},
+	"name.familyname":   {column: "u.last_name"},
+	"name.formatted":    {column: userName},
+	"displayname":       {column: userName},
+	"active":            {column: "u.active", kind: kindBool},
+	"locale":            {column: "REPLACE(u.locale, '_', '-')"},
+	"groups":            {column: "pp.project_id::TEXT", exists: userGroup},
+	"groups.value":      {column: "pp.project_id::TEXT", exists: userGroup},
+	"meta.created":      {column: "u.created_at", kind: kindTime},
+	"meta.lastmodified": {column: "COALESCE(u.updated_at, u.created_at)", kind: kindTime},
+}
+
+// groupAttributes are the attributes of the groups that can be filtered
+var groupAttributes = map[string]attribute{
+	"id":                {column: "p.id::TEXT"},
+	"externalid":        {column: "p.external_id"},
+	"displayname":       {column: "p.name"},
+	"members":           {column: "mu.id::TEXT", exists: member},
+	"members.value":     {column: "mu.id::TEXT", exists: member},
+	"members.display":   {column: "mu.first_name || ' ' || mu.last_name", exists: member},
+	"meta.created":      {column: "p.created_at", kind: kindTime},
+	"meta.lastmodified": {column: "COALESCE(p.updated_at, p.created_at)", kind: kindTime},
+}
+
+// operators are the sql operators of the comparisons with the same meaning in sql
+var operators = map[domain.Operator]string{
+	domain.OperatorEq: "=",
+	domain.OperatorGt: ">",
+	domain.OperatorGe: ">=",
+	domain.OperatorLt: "<",
+	domain.OperatorLe: "<=",
+}
+
+// where converts the filter to a condition on the columns of the attributes. The prefix
+// is the path of the multi-valued attribute of the value paths, like emails[type eq "work"]
+func where(filter domain.Filter, attributes map[string]attribute, prefix string) (string, []interface{}, error) {
+	switch f := filter.(type) {
+	case *domain.Logical:
+		left, leftArgs, err := where(f.Left, attributes, prefix)
+		if err != nil {
+			return "", nil, err
+		}
+
+		right, rightArgs, err := where(f.Right, attributes, prefix)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return "(" + left + " " + strings.ToUpper(string(f.Operator)) + " " + right + ")", append(leftArgs, rightArgs...), nil
+
+	case *domain.Not:
+		condition, args, err := where(f.Filter, attributes, prefix)
+		return "NOT COALESCE(" + condition + ", FALSE)", args, err
+
+	case *domain.ValuePath:
+		return where(f.Filter, attributes, prefix+f.Path+".")
+
+	case *domain.Comparison:
+		attr, ok := attributes[prefix+f.Path]
+		if !ok {
+			return "", nil, domain.ErrInvalidFilter()
+		}
+
+		if f.Operator == domain.OperatorNe {
+			condition, args, err := where(&domain.Comparison{Path: f.Path, Operator: domain.OperatorEq, Value: f.Value}, attributes, prefix)
+			return "NOT COALESCE(" + condition + ", FALSE)", args, err
+		}
+
+		condition, args, err := attr.compare(f.Operator, f.Value)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if attr.exists != "" {
+			condition = fmt.Sprintf(attr.exists, condition)
+		}
+
+		return condition, args, nil
+	}
+
+	return "", nil, domain.ErrInvalidFilter()
+}
+
+// compare converts a comparison to sql according to the type of the column, the texts ignoring the case
+func (a attribute) compare(operator domain.Operator, value interface{}) (string, []interface{}, error) {
+	if operator == domain.OperatorPresent {
+		return a.column + " IS NOT NULL", nil, nil
+	}
+
+	if value == nil {
+		if operator != domain.OperatorEq {
+			return "", nil, domain.ErrInvalidFilter()
+		}
+		return a.column + " IS NULL", nil, nil
+	}
+
+	switch a.kind {
+	case kindBool:
+		if _, ok := value.(bool); !ok || operator != domain.OperatorEq {
+			return "", nil, domain.ErrInvalidFilter()
+		}
+		return a.column + " = ?", []interface{}{value}, nil
+
+	case kindTime:
+		text, _ := value.(string)
+		date, err := time.Parse(time.RFC3339, text)
+		if sql, ok := operators[operator]; ok && err == nil {
+			return a.column + " " + sql + " ?", []interface{}{date}, nil
+		}
+		return "", nil, domain.ErrInvalidFilter()
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return "", nil, domain.ErrInvalidFilter()
+	}
+
+	switch operator {
+	case domain.OperatorCo:
+		return a.column + " ILIKE ?", []interface{}{"%" + escapeLike(text) + "%"}, nil
+	case domain.OperatorSw:
+		return a.column + " ILIKE ?", []interface{}{escapeLike(text) + "%"}, nil
+	case domain.OperatorEw:
+		return a.column + " ILIKE ?", []interface{}{"%" + escapeLike(text)}, nil
+	}
+
+	return "LOWER(" + a.column + ") " + operators[operator] + " LOWER(?)", []interface{}{text}, nil
+}
+
+// escapeLike escapes the wildcards of the patterns of like
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"reflect"
+	"testing"
+
+	domain "github.com/isaqueveras/powersso/domain/scim"
+)
+
+func TestWhere(t *testing.T) {
+	for filter, expected := range map[string]struct {
+		sql  string
+		args []interface{}
+	}{
+		`userName eq "Jane@PowerSSO.io"`: {
+			sql:  "LOWER(u.email) = LOWER(?)",
+			args: []interface{}{"Jane@PowerSSO.io"},
+		},
+		`name.familyName sw "d_e" and active eq true`: {
+			sql:  "(u.last_name ILIKE ? AND u.active = ?)",
+			args: []interface{}{`d\_e%`, true},
+		},
+		`not (externalId pr) or emails[type eq "work" and value co "@powersso"]`: {
+			sql:  "(NOT COALESCE(u.external_id IS NOT NULL, FALSE) OR (LOWER('work') = LOWER(?) AND u.email ILIKE ?))",
+			args: []interface{}{"work", "%@powersso%"},
+		},
+		`groups.value ne "1a6b2a3c-8f5e-4b7a-9d1e-2f3a4b5c6d7e"`: {
+			sql: "NOT COALESCE(EXISTS (SELECT 1 FROM project_participants pp WHERE pp.user_id = u.id AND pp.deleted_at IS NULL " +
+				"AND LOWER(pp.project_id::TEXT) = LOWER(?)), FALSE)",
+			args: []interface{}{"1a6b2a3c-8f5e-4b7a-9d1e-2f3a4b5c6d7e"},
+		},
+	} {
+		parsed, err := domain.ParseFilter(filter)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", filter, err)
+		}
+
+		sql, args, err := where(parsed, userAttributes, "")
+		if err != nil {
+			t.Fatalf("unexpected error converting %q: %v", filter, err)
+		}
+
+		if sql != expected.sql || !reflect.DeepEqual(args, expected.args) {
+			t.Errorf("unexpected condition of %q: %s %v", filter, sql, args)
+		}
+	}
+}
+
+func TestWhereInvalid(t *testing.T) {
+	for _, filter := range []string{
+		`password eq "secret"`,
+		`active gt true`,
+		`meta.created gt "yesterday"`,
+		`userName eq 10`,
+	} {
+		parsed, err := domain.ParseFilter(filter)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", filter, err)
+		}
+
+		if _, _, err = where(parsed, userAttributes, ""); err == nil {
+			t.Errorf("expected an error converting %q", filter)
+		}
+	}
+}
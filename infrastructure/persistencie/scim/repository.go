@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/scim"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/scim/postgres"
+)
+
+var _ domain.ISCIM = (*repoSCIM)(nil)
+
+type repoSCIM struct{ pg *infra.SCIM }
+
+// NewSCIMRepository creates a new repository
+func NewSCIMRepository(tx *database.Transaction) domain.ISCIM {
+	return &repoSCIM{pg: &infra.SCIM{DB: tx}}
+}
+
+// Users contains the flow to fetch a page of the users that match the filter
+func (r *repoSCIM) Users(filter domain.Filter, params *domain.ListParams) ([]domain.User, int64, error) {
+	return r.pg.Users(filter, params)
+}
+
+// User contains the flow to fetch a user
+func (r *repoSCIM) User(userID *uuid.UUID) (*domain.User, error) {
+	return r.pg.User(userID)
+}
+
+// EmailExists contains the flow to check if another account has the email
+func (r *repoSCIM) EmailExists(email *string, except *uuid.UUID) (bool, error) {
+	return r.pg.EmailExists(email, except)
+}
+
+// UpdateUser contains the flow to write the account of a user
+func (r *repoSCIM) UpdateUser(userID *uuid.UUID, in *domain.Account) error {
+	return r.pg.UpdateUser(userID, in)
+}
+
+// Groups contains the flow to fetch a page of the groups that match the filter
+func (r *repoSCIM) Groups(filter domain.Filter, params *domain.ListParams) ([]domain.Group, int64, error) {
+	return r.pg.Groups(filter, params)
+}
+
+// Group contains the flow to fetch a group
+func (r *repoSCIM) Group(groupID *uuid.UUID, members bool) (*domain.Group, error) {
+	return r.pg.Group(groupID, members)
+}
+
+// CreateGroup contains the flow to create the project of a group
+func (r *repoSCIM) CreateGroup(in *domain.Project, createdBy *uuid.UUID) (*uuid.UUID, error) {
+	return r.pg.CreateGroup(in, createdBy)
+}
+
+// UpdateGroup contains the flow to write the project of a group
+func (r *repoSCIM) UpdateGroup(groupID *uuid.UUID, in *domain.Project) error {
+	return r.pg.UpdateGroup(groupID, in)
+}
+
+// AddMember contains the flow to add a user as participant of the project of a group
+func (r *repoSCIM) AddMember(groupID, userID *uuid.UUID) error {
+	return r.pg.AddMember(groupID, userID)
+}
+
+// RemoveMember contains the flow to end the participation of a user in the project of a group
+func (r *repoSCIM) RemoveMember(groupID, userID *uuid.UUID) error {
+	return r.pg.RemoveMember(groupID, userID)
+}
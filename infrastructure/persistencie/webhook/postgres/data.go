@@ -0,0 +1,249 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/webhook"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// Webhook is the implementation of transaction for the webhook repository
+type Webhook struct{ DB *database.Transaction }
+
+// Create subscribes a webhook to the events of a project
+func (pg *Webhook) Create(webhook *domain.Webhook) (webhookID *uuid.UUID, err error) {
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, string(event))
+	}
+
+	if err = pg.DB.Builder.
+		Insert("webhooks").
+		Columns("project_id", "url", "secret", "events", "created_by").
+		Values(webhook.ProjectID, webhook.URL, webhook.Secret,
+			squirrel.Expr("string_to_array(?, ',')", strings.Join(events, ",")), webhook.CreatedBy).
+		Suffix(`RETURNING "id"`).
+		Scan(&webhookID); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return
+}
+
+// List fetches the webhooks of a project
+func (pg *Webhook) List(projectID *uuid.UUID) (webhooks []domain.Webhook, err error) {
+	rows, err := pg.DB.Builder.
+		Select("id, project_id, url, array_to_string(events, ','), active, created_by, created_at").
+		From("webhooks").
+		Where(squirrel.Eq{"project_id": projectID, "deleted_at": nil}).
+		OrderBy("created_at").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			webhook domain.Webhook
+			events  string
+		)
+
+		if err = rows.Scan(&webhook.ID, &webhook.ProjectID, &webhook.URL, &events,
+			&webhook.Active, &webhook.CreatedBy, &webhook.CreatedAt); err != nil {
+			return nil, oops.Err(err)
+		}
+
+		for _, event := range strings.Split(events, ",") {
+			webhook.Events = append(webhook.Events, domain.EventType(event))
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// Delete removes a webhook of a project, stopping the deliveries of the events
+func (pg *Webhook) Delete(projectID, webhookID *uuid.UUID) error {
+	if err := pg.DB.Builder.
+		Update("webhooks").
+		Set("active", false).
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": webhookID, "project_id": projectID, "deleted_at": nil}).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrWebhookNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Enqueue schedules a delivery of the event to each active webhook subscribed to it
+func (pg *Webhook) Enqueue(event *domain.Event, payload []byte) error {
+	subscribed := squirrel.
+		Select("id").
+		Column("?::VARCHAR", event.Type).
+		Column("?::JSONB", string(payload)).
+		From("webhooks").
+		Where(squirrel.Eq{"active": true, "deleted_at": nil}).
+		Where("? = ANY(events)", event.Type)
+
+	switch {
+	case event.ProjectID != nil:
+		subscribed = subscribed.Where(squirrel.Eq{"project_id": event.ProjectID})
+	case event.UserID != nil:
+		subscribed = subscribed.Where(`project_id IN (
+			SELECT project_id FROM project_participants WHERE user_id = ? AND deleted_at IS NULL)`, event.UserID)
+	}
+
+	if _, err := pg.DB.Builder.
+		Insert("webhook_deliveries").
+		Columns("webhook_id", `"event"`, "payload").
+		Select(subscribed).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Deliveries fetches the deliveries of a webhook of the project, the most recent first
+func (pg *Webhook) Deliveries(projectID, webhookID *uuid.UUID, params *utils.Params) ([]domain.Delivery, *bool, error) {
+	var exists bool
+	if err := pg.DB.Builder.
+		Select("COUNT(id) > 0").
+		From("webhooks").
+		Where(squirrel.Eq{"id": webhookID, "project_id": projectID, "deleted_at": nil}).
+		Scan(&exists); err != nil {
+		return nil, nil, oops.Err(err)
+	}
+
+	if !exists {
+		return nil, nil, domain.ErrWebhookNotFound()
+	}
+
+	query := pg.DB.Builder.
+		Select().
+		From("webhook_deliveries").
+		Where(squirrel.Eq{"webhook_id": webhookID}).
+		OrderBy("created_at DESC")
+
+	return utils.MakePagination[domain.Delivery](&query, params)
+}
+
+// Redeliver schedules a delivery of the webhook to be sent again, restarting the attempts
+func (pg *Webhook) Redeliver(projectID, webhookID, deliveryID *uuid.UUID) error {
+	if err := pg.DB.Builder.
+		Update("webhook_deliveries").
+		Set("status", domain.StatusPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", squirrel.Expr("NOW()")).
+		Set("delivered_at", nil).
+		Where(squirrel.Eq{"id": deliveryID, "webhook_id": webhookID}).
+		Where("webhook_id IN (SELECT id FROM webhooks WHERE project_id = ? AND deleted_at IS NULL)", projectID).
+		Suffix("RETURNING id").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrDeliveryNotFound()
+		}
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Claim fetches the pending deliveries whose next attempt has come and postpones their next attempt
+// by the lease, so that once the transaction is committed they are not sent again by another run
+// while they are in flight. Deliveries locked by another transaction are skipped
+func (pg *Webhook) Claim(limit uint64, lease int64) (deliveries []*domain.Delivery, err error) {
+	rows, err := pg.DB.Builder.
+		Select("d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret").
+		From("webhook_deliveries d").
+		Join("webhooks w ON w.id = d.webhook_id").
+		Where(squirrel.Eq{"d.status": domain.StatusPending, "w.active": true, "w.deleted_at": nil}).
+		Where("d.next_attempt_at <= NOW()").
+		OrderBy("d.next_attempt_at").
+		Limit(limit).
+		Suffix("FOR UPDATE OF d SKIP LOCKED").
+		Query()
+	if err != nil {
+		return nil, oops.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery := new(domain.Delivery)
+		if err = rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload,
+			&delivery.Attempts, &delivery.URL, &delivery.Secret); err != nil {
+			return nil, oops.Err(err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+
+	ids := make([]*uuid.UUID, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+
+	if _, err = pg.DB.Builder.
+		Update("webhook_deliveries").
+		Set("next_attempt_at", squirrel.Expr("NOW() + (? * INTERVAL '1 second')", lease)).
+		Where(squirrel.Eq{"id": ids}).
+		Exec(); err != nil {
+		return nil, oops.Err(err)
+	}
+
+	return deliveries, nil
+}
+
+// Delivered records that a delivery was accepted by the webhook
+func (pg *Webhook) Delivered(deliveryID *uuid.UUID, result *domain.Result) error {
+	if _, err := pg.DB.Builder.
+		Update("webhook_deliveries").
+		Set("status", domain.StatusDelivered).
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_status_code", result.StatusCode).
+		Set("last_error", nil).
+		Set("delivered_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": deliveryID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
+
+// Failed records a failed attempt of a delivery and when the next attempt happens
+func (pg *Webhook) Failed(delivery *domain.Delivery) error {
+	if _, err := pg.DB.Builder.
+		Update("webhook_deliveries").
+		Set("status", delivery.Status).
+		Set("attempts", delivery.Attempts).
+		Set("next_attempt_at", squirrel.Expr("COALESCE(?, next_attempt_at)", delivery.NextAttemptAt)).
+		Set("last_status_code", delivery.LastStatusCode).
+		Set("last_error", delivery.LastError).
+		Where(squirrel.Eq{"id": delivery.ID}).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return nil
+}
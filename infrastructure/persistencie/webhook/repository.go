@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"github.com/google/uuid"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	domain "github.com/isaqueveras/powersso/domain/webhook"
+	infra "github.com/isaqueveras/powersso/infrastructure/persistencie/webhook/postgres"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+var _ domain.IWebhook = (*repoWebhook)(nil)
+
+type repoWebhook struct{ pg *infra.Webhook }
+
+// NewWebhookRepository creates a new repository
+func NewWebhookRepository(tx *database.Transaction) domain.IWebhook {
+	return &repoWebhook{pg: &infra.Webhook{DB: tx}}
+}
+
+// Create contains the flow to subscribe a webhook to the events of a project
+func (r *repoWebhook) Create(webhook *domain.Webhook) (*uuid.UUID, error) {
+	return r.pg.Create(webhook)
+}
+
+// List contains the flow to fetch the webhooks of a project
+func (r *repoWebhook) List(projectID *uuid.UUID) ([]domain.Webhook, error) {
+	return r.pg.List(projectID)
+}
+
+// Delete contains the flow to remove a webhook of a project
+func (r *repoWebhook) Delete(projectID, webhookID *uuid.UUID) error {
+	return r.pg.Delete(projectID, webhookID)
+}
+
+// Enqueue contains the flow to schedule the deliveries of an event to the subscribed webhooks
+func (r *repoWebhook) Enqueue(event *domain.Event, payload []byte) error {
+	return r.pg.Enqueue(event, payload)
+}
+
+// Deliveries contains the flow to fetch the deliveries of a webhook
+func (r *repoWebhook) Deliveries(projectID, webhookID *uuid.UUID, params *utils.Params) ([]domain.Delivery, *bool, error) {
+	return r.pg.Deliveries(projectID, webhookID, params)
+}
+
+// Redeliver contains the flow to schedule a delivery to be sent again
+func (r *repoWebhook) Redeliver(projectID, webhookID, deliveryID *uuid.UUID) error {
+	return r.pg.Redeliver(projectID, webhookID, deliveryID)
+}
+
+// Claim contains the flow to fetch the deliveries waiting for an attempt and mark them as in flight
+func (r *repoWebhook) Claim(limit uint64, lease int64) ([]*domain.Delivery, error) {
+	return r.pg.Claim(limit, lease)
+}
+
+// Delivered contains the flow to record that a delivery was accepted by the webhook
+func (r *repoWebhook) Delivered(deliveryID *uuid.UUID, result *domain.Result) error {
+	return r.pg.Delivered(deliveryID, result)
+}
+
+// Failed contains the flow to record a failed attempt of a delivery
+func (r *repoWebhook) Failed(delivery *domain.Delivery) error {
+	return r.pg.Failed(delivery)
+}
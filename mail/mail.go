@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package mail
+
+import (
+	"context"
+	"log"
+
+	"github.com/isaqueveras/powersso/config"
+)
+
+const (
+	// BackendSMTP sends the messages through a SMTP server
+	BackendSMTP string = "smtp"
+	// BackendLog writes the messages in the log, useful in development
+	BackendLog string = "log"
+)
+
+// Message models an email sent to a user
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender defines an interface for the backends that deliver the messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+var sender Sender
+
+// Setup initializes the sender used to deliver the messages
+func Setup(cfg *config.Config) {
+	if !cfg.Mail.Enabled {
+		sender = nil
+		return
+	}
+
+	switch cfg.Mail.Backend {
+	case BackendSMTP:
+		sender = NewSMTPSender(&cfg.Mail)
+	default:
+		sender = NewLogSender()
+	}
+}
+
+// SetSender replaces the sender used to deliver the messages
+func SetSender(s Sender) {
+	sender = s
+}
+
+// Enabled returns if the messages are delivered
+func Enabled() bool {
+	return sender != nil
+}
+
+// Send delivers the message, doing nothing when the mail is not enabled
+func Send(ctx context.Context, msg *Message) error {
+	if sender == nil {
+		return nil
+	}
+	return sender.Send(ctx, msg)
+}
+
+// SendAsync delivers the messages without blocking the caller, logging the failures
+func SendAsync(msgs ...*Message) {
+	if sender == nil {
+		return
+	}
+
+	go func() {
+		for _, msg := range msgs {
+			if err := sender.Send(context.Background(), msg); err != nil {
+				log.Println("Error while sending the mail: ", err)
+			}
+		}
+	}()
+}
+
+// Link returns the address of a path of the application to be used in the messages
+func Link(path string) string {
+	return config.Get().Mail.BaseURL + path
+}
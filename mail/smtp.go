@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+
+	"github.com/isaqueveras/powersso/config"
+)
+
+// SMTPSender delivers the messages through a SMTP server
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPSender creates a new sender for the SMTP server of the configuration
+func NewSMTPSender(cfg *config.MailConfig) *SMTPSender {
+	s := &SMTPSender{addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), from: cfg.From}
+	if cfg.Username != "" {
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return s
+}
+
+// Send delivers the message to the SMTP server
+func (s *SMTPSender) Send(_ context.Context, msg *Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, s.format(msg))
+}
+
+// format builds the headers and the body of the message
+func (s *SMTPSender) format(msg *Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n\r\n")
+	b.WriteString(msg.Body)
+	return []byte(b.String())
+}
+
+// LogSender writes the messages in the log instead of delivering them
+type LogSender struct{}
+
+// NewLogSender creates a new sender that writes in the log
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send writes the message in the log
+func (l *LogSender) Send(_ context.Context, msg *Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
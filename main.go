@@ -5,12 +5,22 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os/signal"
+	"syscall"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/isaqueveras/powersso/config"
 	"github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/database/redis"
+	"github.com/isaqueveras/powersso/directory"
+	"github.com/isaqueveras/powersso/federation"
+	"github.com/isaqueveras/powersso/mail"
+	"github.com/isaqueveras/powersso/publisher"
+	"github.com/isaqueveras/powersso/ratelimit"
+	"github.com/isaqueveras/powersso/scheduler"
 	"github.com/isaqueveras/powersso/scripts"
 	"github.com/isaqueveras/powersso/server"
 	"github.com/isaqueveras/powersso/utils"
@@ -28,10 +38,28 @@ func main() {
 	postgres.OpenConnections(cfg)
 	defer postgres.CloseConnections()
 
+	redis.OpenConnection(cfg)
+	defer redis.CloseConnection()
+
+	ratelimit.Setup(cfg)
+	mail.Setup(cfg)
+	directory.Setup(cfg)
+	federation.Setup(cfg)
+	publisher.Setup(cfg)
+
 	scripts.Init(logg)
 
+	// the context is cancelled on the shutdown, stopping the gRPC server and the jobs with the HTTP server
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	group := &errgroup.Group{}
-	server := server.NewServer(cfg, logg, group)
+	server := server.NewServer(ctx, cfg, logg, group)
+
+	if cfg.Scheduler.Enabled {
+		jobs := scheduler.Setup(cfg, logg)
+		group.Go(func() error { return jobs.Run(ctx) })
+	}
 
 	if err := server.ServerHTTP(); err != nil {
 		logg.Fatal("Error while serving the server HTTP: ", err)
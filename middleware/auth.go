@@ -1,13 +1,21 @@
 package middleware
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	app "github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/project"
+	"github.com/isaqueveras/powersso/application/role"
 	"github.com/isaqueveras/powersso/config"
 	"github.com/isaqueveras/powersso/domain/auth"
+	domainProject "github.com/isaqueveras/powersso/domain/project"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/oops"
 	"github.com/isaqueveras/powersso/tokens"
 )
 
@@ -28,8 +36,9 @@ func GetSession(ctx *gin.Context) *Session {
 	}
 
 	value := session.(jwt.MapClaims)
+	sessionID, _ := value["SessionID"].(string)
 	return &Session{
-		SessionID: value["SessionID"].(string),
+		SessionID: sessionID,
 		UserID:    value["UserID"].(string),
 		UserLevel: value["UserLevel"].(string),
 		FirstName: value["FirstName"].(string),
@@ -45,7 +54,56 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
-		if claims := tokens.ParseJWT(token[7:], config.Get().GetSecrets()); claims != nil {
+		claims := tokens.ParseJWT(token[7:], config.Get().GetSecrets())
+		if claims == nil || claims["Scope"] != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		sessionID, err := uuid.Parse(fmt.Sprint(claims["SessionID"]))
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		level := auth.Level(fmt.Sprint(claims["UserLevel"]))
+		active, err := app.CheckSession(ctx, &sessionID, &level)
+		if err != nil {
+			oops.Handling(ctx, err)
+			return
+		}
+
+		if !active {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		ctx.Set("UID", claims["UserID"])
+		ctx.Set("SESSION", claims)
+	}
+}
+
+// AuthChangePassword is a middleware that only accepts tokens issued to users who must change their password
+func AuthChangePassword() gin.HandlerFunc {
+	return authScope(tokens.ScopeChangePassword)
+}
+
+// AuthOTP is a middleware that only accepts tokens issued to users who signed in with a provider
+// and must inform the code of the 2FA
+func AuthOTP() gin.HandlerFunc {
+	return authScope(tokens.ScopeOTP)
+}
+
+// authScope is a middleware that only accepts the tokens of the scope
+func authScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var token string
+		if token = ctx.GetHeader("Authorization"); token == "" || len(token) < 30 {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		if claims := tokens.ParseJWT(token[7:], config.Get().GetSecrets()); claims != nil && claims["Scope"] == scope {
 			ctx.Set("UID", claims["UserID"])
 			ctx.Set("SESSION", claims)
 			return
@@ -55,15 +113,71 @@ func Auth() gin.HandlerFunc {
 	}
 }
 
-// OnlyAdmin check if the user is an administrator
-func OnlyAdmin() gin.HandlerFunc {
+// RequirePermission checks if a role of the user has the permission, the roles of the level of
+// the user included. It replaces the checks of the levels, like the administrators only routes
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := GetSession(ctx)
+		if session == nil {
+			return
+		}
+
+		userID, err := uuid.Parse(session.UserID)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		allowed, err := role.HasPermission(ctx, &userID, domainRole.Permission(permission))
+		if err != nil {
+			oops.Handling(ctx, err)
+			return
+		}
+
+		if !allowed {
+			log.Printf("WARNING: user (%v - %v) tried to access route without the permission %v", session.UserID, session.FirstName, permission)
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireProjectPermission checks if a role of the user in the project of the route has the permission, the default
+// role of the project included. The users allowed to manage all projects are always allowed
+func RequireProjectPermission(permission string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		if GetSession(ctx).UserLevel != string(auth.AdminLevel) {
-			session := GetSession(ctx)
-			log.Printf("WARNING: user (%v - %v) tried to access user tried to access route for administrators only", session.UserID, session.FirstName)
+		session := GetSession(ctx)
+		if session == nil {
+			return
+		}
+
+		userID, err := uuid.Parse(session.UserID)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		projectID, err := uuid.Parse(ctx.Param("project_id"))
+		if err != nil {
+			oops.Handling(ctx, err)
+			return
+		}
+
+		allowed, err := project.HasPermission(ctx, &projectID, &userID, domainProject.Permission(permission))
+		if err != nil {
+			oops.Handling(ctx, err)
+			return
+		}
+
+		if !allowed {
+			log.Printf("WARNING: user (%v - %v) tried to access project (%v) without the permission %v",
+				session.UserID, session.FirstName, projectID, permission)
 			ctx.AbortWithStatus(http.StatusForbidden)
 			return
 		}
+
 		ctx.Next()
 	}
 }
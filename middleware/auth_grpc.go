@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	app "github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/role"
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/domain/auth"
+	domainRole "github.com/isaqueveras/powersso/domain/role"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/tokens"
+)
+
+// AuthorizeGRPC checks the token of the authorization metadata of the call like Auth, and if a role
+// of the user has the permission like RequirePermission, for the services that are not public
+func AuthorizeGRPC(ctx context.Context, permission string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+
+	claims := tokens.ParseJWT(strings.TrimPrefix(values[0], "Bearer "), config.Get().GetSecrets())
+	if claims == nil || claims["Scope"] != nil {
+		return status.Error(codes.Unauthenticated, "invalid authorization token")
+	}
+
+	sessionID, err := uuid.Parse(fmt.Sprint(claims["SessionID"]))
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid authorization token")
+	}
+
+	level := auth.Level(fmt.Sprint(claims["UserLevel"]))
+	active, err := app.CheckSession(ctx, &sessionID, &level)
+	if err != nil {
+		return oops.HandlingGRPC(err)
+	}
+
+	if !active {
+		return status.Error(codes.Unauthenticated, "session is not active")
+	}
+
+	userID, err := uuid.Parse(fmt.Sprint(claims["UserID"]))
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid authorization token")
+	}
+
+	allowed, err := role.HasPermission(ctx, &userID, domainRole.Permission(permission))
+	if err != nil {
+		return oops.HandlingGRPC(err)
+	}
+
+	if !allowed {
+		log.Printf("WARNING: user (%v - %v) tried to call service without the permission %v", userID, claims["FirstName"], permission)
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	return nil
+}
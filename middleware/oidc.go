@@ -0,0 +1,42 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcBindingCookie is the cookie that binds a sign in with an external provider to the browser that started it
+	oidcBindingCookie string = "powersso_oidc_binding"
+	// oidcCallbackPath is the path of the callbacks of the external providers, the only one the cookie is sent to
+	oidcCallbackPath string = "/v1/auth/oidc"
+)
+
+// BindOIDCState keeps the binding of the sign in with an external provider in a cookie of the browser.
+// The cookie is sent back when the provider redirects the browser to the callback
+func BindOIDCState(ctx *gin.Context, binding *string) {
+	if binding == nil {
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcBindingCookie, *binding, 0, oidcCallbackPath, "", true, true)
+}
+
+// OIDCStateBinding returns the binding of the sign in sent by the browser, removing the cookie
+func OIDCStateBinding(ctx *gin.Context) *string {
+	binding, err := ctx.Cookie(oidcBindingCookie)
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcBindingCookie, "", -1, oidcCallbackPath, "", true, true)
+
+	if err != nil || binding == "" {
+		return nil
+	}
+	return &binding
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/oops"
+	"github.com/isaqueveras/powersso/ratelimit"
+)
+
+// maxEmailBodySize is the size of the largest body read to find the email of the request,
+// the larger bodies are not limited by the email
+const maxEmailBodySize int64 = 4 << 10
+
+// RateLimit limits the requests to the route by client IP and by email sent in the request
+func RateLimit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !ratelimit.Enabled() {
+			ctx.Next()
+			return
+		}
+
+		keys := rateLimitKeys(ctx.FullPath(), ctx.ClientIP(), requestEmail(ctx))
+
+		if wait, limited := takeRateLimit(ctx, keys); limited {
+			ctx.Header("Retry-After", strconv.FormatInt(ratelimit.RetryAfterSeconds(wait), 10))
+			oops.Handling(ctx, ratelimit.ErrTooManyRequests())
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RateLimitGRPC is a request interceptor that limits the requests to the method by client IP and by email
+func RateLimitGRPC() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !ratelimit.Enabled() {
+			return handler(ctx, req)
+		}
+
+		var clientIP, email string
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			if clientIP, _, _ = net.SplitHostPort(p.Addr.String()); clientIP == "" {
+				clientIP = p.Addr.String()
+			}
+		}
+
+		if in, ok := req.(interface{ GetEmail() string }); ok {
+			email = normalizeEmail(in.GetEmail())
+		}
+
+		if wait, limited := takeRateLimit(ctx, rateLimitKeys(info.FullMethod, clientIP, email)); limited {
+			retryAfter := strconv.FormatInt(ratelimit.RetryAfterSeconds(wait), 10)
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+			return nil, status.Error(codes.ResourceExhausted, ratelimit.ErrTooManyRequests().Message)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitKeys returns the keys and limits of the rate limit for a request. The limits are applied by
+// client, so that a client exceeding them does not prevent the requests of the others to the route
+func rateLimitKeys(route, clientIP, email string) (keys []ratelimit.Key) {
+	cfg := config.Get().RateLimit
+
+	keys = append(keys, ratelimit.Key{Name: "ip:" + route + ":" + clientIP, Limit: ratelimit.NewLimit(cfg.IP)})
+	if email != "" {
+		keys = append(keys, ratelimit.Key{Name: "email:" + route + ":" + email, Limit: ratelimit.NewLimit(cfg.Email)})
+	}
+
+	return
+}
+
+// takeRateLimit takes a token from all keys when none of them is exceeded, otherwise returns the longest
+// waiting time without spending any token. Requests are allowed when the limits cannot be applied
+func takeRateLimit(ctx context.Context, keys []ratelimit.Key) (wait time.Duration, limited bool) {
+	allowed, wait, err := ratelimit.Take(ctx, keys...)
+	if err != nil {
+		log.Printf("WARNING: unable to apply rate limit on keys (%v): %v", keys, err)
+		return 0, false
+	}
+
+	return wait, !allowed
+}
+
+// requestEmail returns the email sent in the query or in the JSON body of the request
+func requestEmail(ctx *gin.Context) string {
+	if email := ctx.Query("email"); email != "" {
+		return normalizeEmail(email)
+	}
+
+	if ctx.Request.Body == nil || (ctx.ContentType() != "" && !strings.Contains(ctx.ContentType(), "json")) {
+		return ""
+	}
+
+	// the bytes read are given back to the handler, including the ones past the limit
+	read := new(bytes.Buffer)
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, io.NopCloser(io.TeeReader(ctx.Request.Body, read)), maxEmailBodySize))
+	ctx.Request.Body = io.NopCloser(io.MultiReader(read, ctx.Request.Body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+
+	_ = json.Unmarshal(body, &payload)
+	return normalizeEmail(payload.Email)
+}
+
+// normalizeEmail returns the email in the format stored in the database
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
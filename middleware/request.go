@@ -19,6 +19,14 @@ func RequestIdentifier() gin.HandlerFunc {
 	}
 }
 
+// RequestMetadata inject the address and the user agent of the client in all contexts for the audit log
+func RequestMetadata() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("CLIENT_IP", ctx.ClientIP())
+		ctx.Set("USER_AGENT", ctx.Request.UserAgent())
+	}
+}
+
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", config.Get().Server.AccessControlAllowOrigin)
@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users
+	DROP COLUMN IF EXISTS password_changed_at,
+	DROP COLUMN IF EXISTS must_change_password;
@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users
+	ADD COLUMN password_changed_at 	TIMESTAMP WITH TIME ZONE 	NOT NULL DEFAULT NOW(),
+	ADD COLUMN must_change_password BOOLEAN 									NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP TABLE IF EXISTS "lockout_events" CASCADE;
+
+ALTER TABLE users
+	DROP COLUMN IF EXISTS locked_until,
+	DROP COLUMN IF EXISTS locked_permanently,
+	ALTER COLUMN last_failure TYPE TIMESTAMP;
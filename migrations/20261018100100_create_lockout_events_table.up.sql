@@ -0,0 +1,22 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users
+	ALTER COLUMN last_failure TYPE TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN locked_until 				TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN locked_permanently 	BOOLEAN 									NOT NULL DEFAULT FALSE;
+
+CREATE TABLE lockout_events (
+	id						UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	user_id				UUID NOT NULL REFERENCES users (id),
+	"event"				VARCHAR(20) NOT NULL,
+	attempts			INTEGER NOT NULL,
+	locked_until	TIMESTAMP WITH TIME ZONE,
+	ip						VARCHAR,
+	user_agent		VARCHAR,
+	created_by		UUID REFERENCES users (id),
+	created_at		TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX lockout_events_user_id_idx ON public.lockout_events (user_id, created_at DESC);
@@ -0,0 +1,9 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE UNLOGGED TABLE rate_limits (
+	"key"				VARCHAR PRIMARY KEY,
+	tokens			DOUBLE PRECISION NOT NULL,
+	updated_at	TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
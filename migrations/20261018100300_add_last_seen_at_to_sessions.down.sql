@@ -0,0 +1,6 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP INDEX IF EXISTS sessions_user_id_idx;
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE "sessions" ADD COLUMN last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX sessions_user_id_idx ON public.sessions (user_id) WHERE deleted_at IS NULL;
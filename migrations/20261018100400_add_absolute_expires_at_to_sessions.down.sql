@@ -0,0 +1,5 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS absolute_expires_at;
@@ -0,0 +1,9 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE "sessions" ADD COLUMN absolute_expires_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE "sessions" SET absolute_expires_at = expires_at;
+
+ALTER TABLE "sessions" ALTER COLUMN absolute_expires_at SET NOT NULL;
@@ -0,0 +1,10 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE scheduled_jobs (
+	"name"				VARCHAR(50) PRIMARY KEY,
+	next_run_at		TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_run_at		TIMESTAMP WITH TIME ZONE,
+	last_error		VARCHAR
+);
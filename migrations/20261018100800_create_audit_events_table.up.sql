@@ -0,0 +1,21 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE audit_events (
+	id						UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	"action"			VARCHAR(30) NOT NULL,
+	outcome				VARCHAR(10) NOT NULL,
+	actor_id			UUID REFERENCES users (id),
+	target_type		VARCHAR(20),
+	target_id			VARCHAR,
+	reason				VARCHAR,
+	ip						VARCHAR,
+	user_agent		VARCHAR,
+	request_id		VARCHAR,
+	created_at		TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_events_created_at_idx ON public.audit_events (created_at DESC);
+CREATE INDEX audit_events_actor_id_idx ON public.audit_events (actor_id, created_at DESC);
+CREATE INDEX audit_events_target_idx ON public.audit_events (target_type, target_id, created_at DESC);
@@ -0,0 +1,10 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP TABLE IF EXISTS audit_checkpoints;
+
+ALTER TABLE audit_events
+	DROP COLUMN IF EXISTS seq,
+	DROP COLUMN IF EXISTS prev_hash,
+	DROP COLUMN IF EXISTS hash;
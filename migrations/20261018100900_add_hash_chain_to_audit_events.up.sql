@@ -0,0 +1,18 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE audit_events
+	ADD COLUMN seq					BIGINT UNIQUE,
+	ADD COLUMN prev_hash		VARCHAR(64),
+	ADD COLUMN hash					VARCHAR(64);
+
+CREATE TABLE audit_checkpoints (
+	id						UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	seq						BIGINT NOT NULL,
+	hash					VARCHAR(64) NOT NULL,
+	signature			VARCHAR,
+	created_at		TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_checkpoints_seq_idx ON public.audit_checkpoints (seq);
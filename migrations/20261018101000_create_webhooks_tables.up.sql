@@ -0,0 +1,34 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE webhooks (
+	id						UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	project_id		UUID NOT NULL REFERENCES projects (id),
+	url						VARCHAR(500) NOT NULL CHECK ( url <> '' ),
+	secret				VARCHAR(100) NOT NULL,
+	events				VARCHAR[] NOT NULL,
+	active				BOOLEAN NOT NULL DEFAULT TRUE,
+	created_by		UUID REFERENCES users (id),
+	created_at		TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	deleted_at		TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX webhooks_project_id_idx ON public.webhooks (project_id);
+
+CREATE TABLE webhook_deliveries (
+	id								UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	webhook_id				UUID NOT NULL REFERENCES webhooks (id),
+	"event"						VARCHAR(50) NOT NULL,
+	payload						JSONB NOT NULL,
+	status						VARCHAR(10) NOT NULL DEFAULT 'pending',
+	attempts					INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at		TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_status_code	INTEGER,
+	last_error				VARCHAR,
+	delivered_at			TIMESTAMP WITH TIME ZONE,
+	created_at				TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON public.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON public.webhook_deliveries (webhook_id, created_at DESC);
@@ -0,0 +1,17 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE outbox (
+	id								UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	topic							VARCHAR(50) NOT NULL,
+	aggregate_type		VARCHAR(30) NOT NULL,
+	aggregate_id			VARCHAR(100) NOT NULL,
+	payload						JSONB NOT NULL,
+	attempts					INTEGER NOT NULL DEFAULT 0,
+	last_error				VARCHAR,
+	created_at				TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	published_at			TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX outbox_pending_idx ON public.outbox (created_at) WHERE published_at IS NULL;
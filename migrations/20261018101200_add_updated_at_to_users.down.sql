@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP INDEX IF EXISTS users_created_at_idx;
+
+ALTER TABLE users DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX users_created_at_idx ON public.users (created_at DESC);
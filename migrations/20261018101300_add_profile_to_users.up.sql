@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users ADD COLUMN locale VARCHAR(10);
+
+ALTER TABLE user_tokens ADD COLUMN data VARCHAR(255);
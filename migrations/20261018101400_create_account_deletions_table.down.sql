@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP TABLE IF EXISTS account_deletions;
+
+ALTER TABLE users DROP COLUMN IF EXISTS anonymized_at;
@@ -0,0 +1,16 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users ADD COLUMN anonymized_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE account_deletions (
+	user_id					UUID PRIMARY KEY REFERENCES users (id),
+	requested_by		UUID REFERENCES users (id),
+	was_active			BOOLEAN NOT NULL,
+	scheduled_at		TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	completed_at		TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX account_deletions_pending_idx ON public.account_deletions (scheduled_at) WHERE completed_at IS NULL;
@@ -0,0 +1,6 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP TABLE IF EXISTS invitation_projects;
+DROP TABLE IF EXISTS invitations;
@@ -0,0 +1,28 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE invitations (
+	id							UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	email						VARCHAR(64) NOT NULL CHECK ( email <> '' ),
+	first_name			VARCHAR(20) NOT NULL CHECK ( first_name <> '' ),
+	last_name				VARCHAR(32) NOT NULL CHECK ( last_name <> '' ),
+	"level"					"level" NOT NULL DEFAULT 'user',
+	created_by			UUID NOT NULL REFERENCES users (id),
+	user_id					UUID REFERENCES users (id),
+	expires_at			TIMESTAMP WITH TIME ZONE NOT NULL,
+	accepted_at			TIMESTAMP WITH TIME ZONE,
+	revoked_at			TIMESTAMP WITH TIME ZONE,
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX invitations_pending_email_idx ON public.invitations (email) WHERE accepted_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX invitations_created_at_idx ON public.invitations (created_at);
+
+CREATE TABLE invitation_projects (
+	invitation_id		UUID NOT NULL REFERENCES invitations (id),
+	project_id			UUID NOT NULL REFERENCES projects (id),
+	"start_date"		DATE NOT NULL,
+	departure_date	DATE,
+	PRIMARY KEY (invitation_id, project_id)
+);
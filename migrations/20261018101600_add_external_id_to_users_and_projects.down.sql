@@ -0,0 +1,9 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP INDEX IF EXISTS project_participants_project_id_idx;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS external_id;
+
+ALTER TABLE users DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,13 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users ADD COLUMN external_id VARCHAR(255);
+
+ALTER TABLE projects ADD COLUMN external_id VARCHAR(255);
+
+CREATE INDEX users_external_id_idx ON public.users (external_id) WHERE external_id IS NOT NULL;
+
+CREATE INDEX projects_external_id_idx ON public.projects (external_id) WHERE external_id IS NOT NULL;
+
+CREATE INDEX project_participants_project_id_idx ON public.project_participants (project_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE users ADD COLUMN ldap_dn TEXT;
+
+CREATE UNIQUE INDEX users_ldap_dn_idx ON users (LOWER(ldap_dn)) WHERE ldap_dn IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE user_identities (
+	id							UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	user_id					UUID NOT NULL REFERENCES users (id),
+	provider				VARCHAR(32) NOT NULL CHECK ( provider <> '' ),
+	subject					VARCHAR(255) NOT NULL CHECK ( subject <> '' ),
+	email						VARCHAR(64),
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_login_at		TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX user_identities_provider_subject_idx ON public.user_identities (provider, subject);
+CREATE INDEX user_identities_user_id_idx ON public.user_identities (user_id);
+
+CREATE TABLE oidc_states (
+	id							UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	provider				VARCHAR(32) NOT NULL,
+	user_id					UUID REFERENCES users (id),
+	nonce						VARCHAR(64) NOT NULL,
+	verifier				VARCHAR(128) NOT NULL,
+	expires_at			TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX oidc_states_expires_at_idx ON public.oidc_states (expires_at);
@@ -0,0 +1,61 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE permissions (
+	"name"					VARCHAR(64) PRIMARY KEY,
+	description			TEXT NOT NULL
+);
+
+CREATE TABLE roles (
+	id							UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	"name"					VARCHAR(32) NOT NULL CHECK ( "name" <> '' ),
+	description			TEXT,
+	"level"					"level" UNIQUE,
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX roles_name_idx ON public.roles (LOWER("name"));
+
+CREATE TABLE role_permissions (
+	role_id					UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+	permission			VARCHAR(64) NOT NULL REFERENCES permissions ("name"),
+	PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE user_roles (
+	user_id					UUID NOT NULL REFERENCES users (id),
+	role_id					UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+	created_by			UUID REFERENCES users (id),
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_id_idx ON public.user_roles (role_id);
+
+INSERT INTO permissions ("name", description) VALUES
+	('user:read', 'List and read the users, their sessions and lockouts'),
+	('user:update', 'Update, enable, unlock and end the sessions of the users'),
+	('user:delete', 'Delete the users and cancel their deletions'),
+	('user:import', 'Import users in bulk'),
+	('user:export', 'Export the users and their personal data'),
+	('invitation:manage', 'Invite users and revoke the invitations'),
+	('project:create', 'Create projects'),
+	('webhook:manage', 'Manage the webhooks of the projects and their deliveries'),
+	('audit:read', 'Read and verify the audit log'),
+	('role:manage', 'Manage the roles and assign them to the users'),
+	('scim:provision', 'Provision the users and the groups with SCIM'),
+	('debug:pprof', 'Access the profiling of the server');
+
+-- the roles of the levels are given to all users of the level, keeping the access of the levels
+INSERT INTO roles ("name", description, "level") VALUES
+	('user', 'Given to all users of the user level', 'user'),
+	('admin', 'Given to all users of the admin level', 'admin'),
+	('integration', 'Given to all users of the integration level', 'integration');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p."name" FROM roles r, permissions p WHERE r."level" = 'admin' AND p."name" <> 'scim:provision';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'scim:provision' FROM roles r WHERE r."level" = 'integration';
@@ -0,0 +1,10 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP TABLE IF EXISTS participant_roles;
+DROP TABLE IF EXISTS project_role_permissions;
+DROP TABLE IF EXISTS project_roles;
+
+DELETE FROM role_permissions WHERE permission IN ('project:manage', 'token:introspect');
+DELETE FROM permissions WHERE "name" IN ('project:manage', 'token:introspect');
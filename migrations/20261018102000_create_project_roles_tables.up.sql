@@ -0,0 +1,71 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE project_roles (
+	id							UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	project_id			UUID NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+	"name"					VARCHAR(32) NOT NULL CHECK ( "name" <> '' ),
+	description			TEXT,
+	is_default			BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX project_roles_name_idx ON public.project_roles (project_id, LOWER("name"));
+CREATE UNIQUE INDEX project_roles_default_idx ON public.project_roles (project_id) WHERE is_default;
+
+CREATE TABLE project_role_permissions (
+	role_id					UUID NOT NULL REFERENCES project_roles (id) ON DELETE CASCADE,
+	permission			VARCHAR(64) NOT NULL,
+	PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE participant_roles (
+	role_id					UUID NOT NULL REFERENCES project_roles (id) ON DELETE CASCADE,
+	user_id					UUID NOT NULL REFERENCES users (id),
+	created_by			UUID REFERENCES users (id),
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (role_id, user_id)
+);
+
+CREATE INDEX participant_roles_user_id_idx ON public.participant_roles (user_id);
+
+INSERT INTO permissions ("name", description) VALUES
+	('project:manage', 'Manage the roles and the participants of all projects'),
+	('token:introspect', 'Introspect the tokens of the users with the roles of the projects');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p."name" FROM roles r, permissions p
+WHERE r."level" = 'admin' AND p."name" IN ('project:manage', 'token:introspect');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'token:introspect' FROM roles r WHERE r."level" = 'integration';
+
+-- the default role is given to all participants of the project, keeping the access of the participants
+INSERT INTO project_roles (project_id, "name", description, is_default)
+SELECT p.id, r."name", r.description, r.is_default
+FROM projects p, (VALUES
+	('owner', 'Manages the roles and the participants of the project', FALSE),
+	('maintainer', 'Manages the participants of the project', FALSE),
+	('viewer', 'Given to all participants of the project', TRUE)
+) AS r ("name", description, is_default);
+
+INSERT INTO project_role_permissions (role_id, permission)
+SELECT pr.id, p.permission
+FROM project_roles pr
+JOIN (VALUES
+	('owner', 'participant:read'),
+	('owner', 'participant:manage'),
+	('owner', 'role:manage'),
+	('maintainer', 'participant:read'),
+	('maintainer', 'participant:manage'),
+	('viewer', 'participant:read')
+) AS p ("role", permission) ON p."role" = pr."name";
+
+-- the creators that participate in their projects become the owners
+INSERT INTO participant_roles (role_id, user_id)
+SELECT DISTINCT pr.id, p.created_by
+FROM projects p
+JOIN project_roles pr ON pr.project_id = p.id AND pr."name" = 'owner'
+JOIN project_participants pp ON pp.project_id = p.id AND pp.user_id = p.created_by AND pp.deleted_at IS NULL;
@@ -0,0 +1,9 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DROP TABLE IF EXISTS relation_tuples;
+DROP TABLE IF EXISTS relation_namespaces;
+
+DELETE FROM role_permissions WHERE permission IN ('relation:manage', 'relation:check');
+DELETE FROM permissions WHERE "name" IN ('relation:manage', 'relation:check');
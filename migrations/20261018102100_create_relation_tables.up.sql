@@ -0,0 +1,41 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE relation_namespaces (
+	"name"						VARCHAR(64) PRIMARY KEY,
+	"definition"			JSONB NOT NULL,
+	created_at				TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at				TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE relation_tuples (
+	object_type				VARCHAR(64) NOT NULL REFERENCES relation_namespaces ("name"),
+	object_id					VARCHAR(255) NOT NULL,
+	relation					VARCHAR(64) NOT NULL,
+	subject_type			VARCHAR(64) NOT NULL REFERENCES relation_namespaces ("name"),
+	subject_id				VARCHAR(255) NOT NULL,
+	-- the empty relation is the subject itself, instead of the set of the subjects of a relation
+	subject_relation	VARCHAR(64) NOT NULL DEFAULT '',
+	created_by				UUID REFERENCES users (id),
+	created_at				TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (object_type, object_id, relation, subject_type, subject_id, subject_relation)
+);
+
+CREATE INDEX relation_tuples_subject_idx ON public.relation_tuples (subject_type, subject_id);
+
+INSERT INTO relation_namespaces ("name", "definition") VALUES
+	('user', '{"relations": []}'),
+	('project', '{"relations": [
+		{"name": "owner"},
+		{"name": "maintainer", "includes": ["owner"]},
+		{"name": "viewer", "includes": ["maintainer"]}
+	]}');
+
+INSERT INTO permissions ("name", description) VALUES
+	('relation:manage', 'Manage the namespaces and write the relationship tuples'),
+	('relation:check', 'Read the relationship tuples and check, expand and list the relations');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p."name" FROM roles r, permissions p
+WHERE r."level" IN ('admin', 'integration') AND p."name" IN ('relation:manage', 'relation:check');
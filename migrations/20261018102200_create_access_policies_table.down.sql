@@ -0,0 +1,8 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+DELETE FROM role_permissions WHERE permission = 'policy:manage';
+DELETE FROM permissions WHERE "name" = 'policy:manage';
+
+DROP TABLE IF EXISTS access_policies;
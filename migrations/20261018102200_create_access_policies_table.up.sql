@@ -0,0 +1,24 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE TABLE access_policies (
+	id							UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	"name"					VARCHAR(64) NOT NULL CHECK ( "name" <> '' ),
+	description			TEXT,
+	events					VARCHAR[] NOT NULL,
+	target					TEXT,
+	"condition"			TEXT NOT NULL,
+	active					BOOLEAN NOT NULL DEFAULT TRUE,
+	created_by			UUID REFERENCES users (id),
+	created_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at			TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX access_policies_name_idx ON public.access_policies (LOWER("name"));
+
+INSERT INTO permissions ("name", description) VALUES
+	('policy:manage', 'Manage the conditional access policies and run them in dry run');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'policy:manage' FROM roles r WHERE r."level" = 'admin';
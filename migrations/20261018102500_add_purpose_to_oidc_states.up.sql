@@ -0,0 +1,7 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE oidc_states ADD COLUMN purpose VARCHAR(10) NOT NULL DEFAULT 'login';
+
+UPDATE oidc_states SET purpose = 'link' WHERE user_id IS NOT NULL;
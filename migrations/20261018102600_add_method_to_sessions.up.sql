@@ -0,0 +1,6 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+-- the sessions opened before have no method, as it is not known how they were authenticated
+ALTER TABLE "sessions" ADD COLUMN method VARCHAR(20);
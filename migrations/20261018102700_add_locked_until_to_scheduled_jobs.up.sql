@@ -0,0 +1,5 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+ALTER TABLE scheduled_jobs ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,5 @@
+-- Copyright (c) 2022 Isaque Veras
+-- Use of this source code is governed by MIT style
+-- license that can be found in the LICENSE file.
+
+CREATE INDEX audit_events_unchained_idx ON public.audit_events (created_at, id) WHERE seq IS NULL;
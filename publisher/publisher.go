@@ -0,0 +1,61 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package publisher
+
+import (
+	"context"
+	"time"
+
+	"github.com/isaqueveras/powersso/config"
+)
+
+const (
+	// SinkMemory keeps the messages in memory, useful in tests
+	SinkMemory string = "memory"
+	// SinkLog writes the messages in the log, useful in development
+	SinkLog string = "log"
+)
+
+// Message models an event published to the sink
+type Message struct {
+	ID string
+	// Topic is where the message is published, such as a subject or a topic of a broker
+	Topic string
+	// Key groups the messages of the same aggregate, such as a partition key of a broker
+	Key       string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// Sink defines an interface for the backends that receive the published messages.
+// A message can be published more than once, so the consumers must be idempotent
+type Sink interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+var sink Sink
+
+// Setup initializes the sink that receives the published messages
+func Setup(cfg *config.Config) {
+	switch cfg.Outbox.Sink {
+	case SinkMemory:
+		sink = NewMemorySink()
+	default:
+		sink = NewLogSink()
+	}
+}
+
+// SetSink replaces the sink that receives the published messages
+func SetSink(s Sink) {
+	sink = s
+}
+
+// Publish sends the message to the sink
+func Publish(ctx context.Context, msg *Message) error {
+	if sink == nil {
+		return errNoSink
+	}
+	return sink.Publish(ctx, msg)
+}
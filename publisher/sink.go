@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package publisher
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+var errNoSink = errors.New("publisher: sink not configured")
+
+// memoryCapacity is the number of messages kept by the memory sink, which drops the oldest ones
+const memoryCapacity = 1000
+
+// MemorySink keeps the last published messages in memory
+type MemorySink struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemorySink creates a new sink that keeps the messages in memory
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Publish keeps the message in memory, dropping the oldest message when the sink is full
+func (m *MemorySink) Publish(_ context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) == memoryCapacity {
+		m.messages = append(m.messages[:0], m.messages[1:]...)
+	}
+
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// Messages returns the published messages in the order they were received
+func (m *MemorySink) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// LogSink writes the published messages in the log
+type LogSink struct{}
+
+// NewLogSink creates a new sink that writes in the log
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+// Publish writes the message in the log without the payload, which has the personal data of the users
+func (l *LogSink) Publish(_ context.Context, msg *Message) error {
+	log.Printf("Event %s on %s (%s)", msg.ID, msg.Topic, msg.Key)
+	return nil
+}
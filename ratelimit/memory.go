@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the interval to remove the buckets that are already full
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the token buckets in the memory of the instance
+type MemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	limits    map[string]Limit
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates a new store in memory
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		limits:  make(map[string]Limit),
+		now:     time.Now,
+	}
+}
+
+// Take removes a token from the buckets of the keys when all of them have a token
+func (m *MemoryStore) Take(_ context.Context, keys []Key) (bool, time.Duration, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	buckets := make([]*bucket, len(keys))
+	for i, key := range keys {
+		b, ok := m.buckets[key.Name]
+		if !ok {
+			b = &bucket{tokens: key.Limit.Burst, updatedAt: now}
+			m.buckets[key.Name] = b
+		}
+		m.limits[key.Name] = key.Limit
+		buckets[i] = b
+	}
+
+	allowed, retryAfter := take(buckets, keys, now)
+	return allowed, retryAfter, nil
+}
+
+// sweep removes the buckets that would already be full, as they are equivalent to new buckets
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if !now.Before(b.fullAt(m.limits[key])) {
+			delete(m.buckets, key)
+			delete(m.limits, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/isaqueveras/powersso/config"
+)
+
+func TestMemoryStore(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		now   = time.Now()
+		limit = NewLimit(config.RateLimitRule{Requests: 2, Period: 10, Burst: 3})
+		store = NewMemoryStore()
+	)
+	store.now = func() time.Time { return now }
+
+	t.Run("ConsumeBurst", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if allowed, _, _ := store.Take(ctx, []Key{{Name: "ip:login:127.0.0.1", Limit: limit}}); !allowed {
+				t.Fatalf("request %d should be allowed", i+1)
+			}
+		}
+
+		allowed, retryAfter, _ := store.Take(ctx, []Key{{Name: "ip:login:127.0.0.1", Limit: limit}})
+		if allowed {
+			t.Fatal("request after the burst should be limited")
+		}
+
+		if retryAfter != 5*time.Second {
+			t.Errorf("expected retry after 5s, got %v", retryAfter)
+		}
+	})
+
+	t.Run("IndependentKeys", func(t *testing.T) {
+		if allowed, _, _ := store.Take(ctx, []Key{{Name: "ip:login:10.0.0.1", Limit: limit}}); !allowed {
+			t.Error("another key should have its own bucket")
+		}
+	})
+
+	t.Run("WithoutSpendingOnDenial", func(t *testing.T) {
+		keys := []Key{{Name: "email:login:user@powersso.io", Limit: limit}, {Name: "ip:login:127.0.0.1", Limit: limit}}
+		if allowed, _, _ := store.Take(ctx, keys); allowed {
+			t.Fatal("request should be limited by the exceeded key")
+		}
+
+		if b := store.buckets["email:login:user@powersso.io"]; b.tokens != limit.Burst {
+			t.Errorf("expected no token to be spent, got %v tokens", b.tokens)
+		}
+	})
+
+	t.Run("Refill", func(t *testing.T) {
+		now = now.Add(5 * time.Second)
+		if allowed, _, _ := store.Take(ctx, []Key{{Name: "ip:login:127.0.0.1", Limit: limit}}); !allowed {
+			t.Fatal("request should be allowed after the refill")
+		}
+
+		if allowed, _, _ := store.Take(ctx, []Key{{Name: "ip:login:127.0.0.1", Limit: limit}}); allowed {
+			t.Error("only one token should have been refilled")
+		}
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		_, _, _ = store.Take(ctx, []Key{{Name: "ip:login:10.0.0.2", Limit: limit}})
+		if _, ok := store.buckets["ip:login:127.0.0.1"]; ok {
+			t.Error("full buckets should be removed")
+		}
+	})
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	for wait, expected := range map[time.Duration]int64{
+		0:                       1,
+		300 * time.Millisecond:  1,
+		time.Second:             1,
+		1500 * time.Millisecond: 2,
+	} {
+		if got := RetryAfterSeconds(wait); got != expected {
+			t.Errorf("RetryAfterSeconds(%v) = %d, expected %d", wait, got, expected)
+		}
+	}
+}
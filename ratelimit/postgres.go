@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// PostgresStore keeps the token buckets in the database, shared by all instances
+type PostgresStore struct{}
+
+// NewPostgresStore creates a new store in the database
+func NewPostgresStore() *PostgresStore {
+	return &PostgresStore{}
+}
+
+// Take removes a token from the buckets of the keys when all of them have a token,
+// locking the rows in the order of the keys until the transaction ends
+func (p *PostgresStore) Take(ctx context.Context, keys []Key) (allowed bool, retryAfter time.Duration, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return false, 0, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	names := make([]string, 0, len(keys))
+	insert := tx.Builder.Insert("rate_limits").Columns("key", "tokens")
+	for _, key := range keys {
+		names = append(names, key.Name)
+		insert = insert.Values(key.Name, key.Limit.Burst)
+	}
+
+	if _, err = insert.Suffix("ON CONFLICT (key) DO NOTHING").Exec(); err != nil {
+		return false, 0, oops.Err(err)
+	}
+
+	rows, err := tx.Builder.
+		Select("key, tokens, updated_at, NOW()").
+		From("rate_limits").
+		Where(squirrel.Eq{"key": names}).
+		OrderBy("key").
+		Suffix("FOR UPDATE").
+		Query()
+	if err != nil {
+		return false, 0, oops.Err(err)
+	}
+	defer rows.Close()
+
+	var (
+		stored = make(map[string]*bucket, len(keys))
+		now    time.Time
+	)
+
+	for rows.Next() {
+		var (
+			key string
+			b   = new(bucket)
+		)
+
+		if err = rows.Scan(&key, &b.tokens, &b.updatedAt, &now); err != nil {
+			return false, 0, oops.Err(err)
+		}
+		stored[key] = b
+	}
+
+	if err = rows.Err(); err != nil {
+		return false, 0, oops.Err(err)
+	}
+
+	buckets := make([]*bucket, len(keys))
+	for i, key := range keys {
+		if buckets[i] = stored[key.Name]; buckets[i] == nil {
+			return false, 0, oops.Err(sql.ErrNoRows)
+		}
+	}
+
+	allowed, retryAfter = take(buckets, keys, now)
+	for i, key := range keys {
+		if _, err = tx.Builder.
+			Update("rate_limits").
+			Set("tokens", buckets[i].tokens).
+			Set("updated_at", buckets[i].updatedAt).
+			Set("expires_at", buckets[i].fullAt(key.Limit)).
+			Where("key = ?", key.Name).
+			Exec(); err != nil {
+			return false, 0, oops.Err(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, 0, oops.Err(err)
+	}
+
+	return
+}
+
+// Purge removes the buckets that are already full, as they are equivalent to new buckets
+func (p *PostgresStore) Purge(ctx context.Context) (deleted int64, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return 0, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Builder.
+		Delete("rate_limits").
+		Where("expires_at < NOW()").
+		Exec()
+	if err != nil {
+		return 0, oops.Err(err)
+	}
+
+	if deleted, err = result.RowsAffected(); err != nil {
+		return 0, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, oops.Err(err)
+	}
+
+	return
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/database/redis"
+	"github.com/isaqueveras/powersso/i18n"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+const (
+	// BackendMemory stores the limits in the memory of the instance
+	BackendMemory string = "memory"
+	// BackendPostgres stores the limits in the database shared by all instances
+	BackendPostgres string = "postgres"
+	// BackendRedis stores the limits in redis shared by all instances
+	BackendRedis string = "redis"
+)
+
+// Store defines an interface for the backends that keep the token buckets
+type Store interface {
+	// Take removes a token from the buckets of the keys only when all of them have a token, returning
+	// false and the longest time to wait for the missing tokens otherwise, without spending any token
+	Take(ctx context.Context, keys []Key) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// Key models a token bucket and the limit applied to it
+type Key struct {
+	Name  string
+	Limit Limit
+}
+
+// Limit models the capacity and refill rate of a token bucket
+type Limit struct {
+	// Rate is the number of tokens added to the bucket per second
+	Rate float64
+	// Burst is the capacity of the bucket
+	Burst float64
+}
+
+// NewLimit creates a limit from a rule of the configuration
+func NewLimit(rule config.RateLimitRule) Limit {
+	limit := Limit{Burst: float64(rule.Burst)}
+	if rule.Period > 0 {
+		limit.Rate = float64(rule.Requests) / float64(rule.Period)
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = float64(rule.Requests)
+	}
+	return limit
+}
+
+// IsZero returns if the limit is not configured
+func (l Limit) IsZero() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+var store Store
+
+// Setup initializes the store used by the limiter
+func Setup(cfg *config.Config) {
+	if !cfg.RateLimit.Enabled {
+		store = nil
+		return
+	}
+
+	switch cfg.RateLimit.Backend {
+	case BackendPostgres:
+		store = NewPostgresStore()
+	case BackendRedis:
+		if !redis.Enabled() {
+			log.Fatal("Unable to use the redis backend for the rate limit: redis is not enabled")
+		}
+		store = NewRedisStore(redis.Client())
+	default:
+		store = NewMemoryStore()
+	}
+}
+
+// SetStore replaces the store used by the limiter
+func SetStore(s Store) {
+	store = s
+}
+
+// Enabled returns if the limiter has a store configured
+func Enabled() bool {
+	return store != nil
+}
+
+// Take removes a token from the buckets of the keys in the configured store when all of them have a token.
+// Requests are always allowed when the limiter is not configured, and keys without a limit are ignored
+func Take(ctx context.Context, keys ...Key) (bool, time.Duration, error) {
+	limited := make([]Key, 0, len(keys))
+	for _, key := range keys {
+		if !key.Limit.IsZero() {
+			limited = append(limited, key)
+		}
+	}
+
+	if store == nil || len(limited) == 0 {
+		return true, 0, nil
+	}
+	return store.Take(ctx, limited)
+}
+
+// RetryAfterSeconds returns the value of the Retry-After header for the waiting time
+func RetryAfterSeconds(wait time.Duration) int64 {
+	return int64(math.Max(1, math.Ceil(wait.Seconds())))
+}
+
+// Purge removes the buckets that are already full from the configured store, for the stores that do not expire them
+func Purge(ctx context.Context) (err error) {
+	if p, ok := store.(*PostgresStore); ok {
+		_, err = p.Purge(ctx)
+	}
+	return
+}
+
+// ErrTooManyRequests creates and returns an error when the rate limit is exceeded
+func ErrTooManyRequests() *oops.Error {
+	return oops.NewError(i18n.Value("errors.handling.err_too_many_requests"), http.StatusTooManyRequests)
+}
+
+// bucket models the state of a token bucket
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// refill adds to the bucket the tokens of the time elapsed since its last update
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(limit.Burst, b.tokens+elapsed*limit.Rate)
+	}
+	b.updatedAt = now
+}
+
+// wait returns the time to wait for a token, zero when the bucket has one
+func (b *bucket) wait(limit Limit) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+}
+
+// fullAt returns when the bucket is refilled to its capacity, from then on it is equivalent to a new bucket
+func (b *bucket) fullAt(limit Limit) time.Time {
+	return b.updatedAt.Add(time.Duration((limit.Burst - b.tokens) / limit.Rate * float64(time.Second)))
+}
+
+// take refills the buckets and removes a token from each of them only when all of them have one,
+// otherwise returns the longest time to wait for the missing tokens
+func take(buckets []*bucket, keys []Key, now time.Time) (bool, time.Duration) {
+	var retryAfter time.Duration
+	for i, b := range buckets {
+		b.refill(keys[i].Limit, now)
+		if wait := b.wait(keys[i].Limit); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+
+	for _, b := range buckets {
+		b.tokens--
+	}
+
+	return true, 0
+}
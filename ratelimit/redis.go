@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// keyPrefix is the prefix of the rate limit keys in redis
+const keyPrefix = "powersso:ratelimit:"
+
+// takeScript refills the buckets and takes a token from each of them atomically when all of them have one,
+// using the clock of the redis server. The limits of the keys are sent in pairs of rate and burst
+var takeScript = redis.NewScript(`
+local now = redis.call('TIME')
+now = tonumber(now[1]) + tonumber(now[2]) / 1000000
+
+local tokens, wait = {}, 0
+for i, key in ipairs(KEYS) do
+	local rate, burst = tonumber(ARGV[i * 2 - 1]), tonumber(ARGV[i * 2])
+	local bucket = redis.call('HMGET', key, 'tokens', 'updated_at')
+	local available, updated = tonumber(bucket[1]), tonumber(bucket[2])
+	if available == nil then
+		available, updated = burst, now
+	end
+
+	tokens[i] = math.min(burst, available + math.max(0, now - updated) * rate)
+	if tokens[i] < 1 then
+		wait = math.max(wait, (1 - tokens[i]) / rate)
+	end
+end
+
+local allowed = 0
+if wait == 0 then
+	allowed = 1
+end
+
+for i, key in ipairs(KEYS) do
+	local rate, burst = tonumber(ARGV[i * 2 - 1]), tonumber(ARGV[i * 2])
+	redis.call('HSET', key, 'tokens', tostring(tokens[i] - allowed), 'updated_at', tostring(now))
+	redis.call('EXPIRE', key, math.ceil(burst / rate) + 1)
+end
+
+return {allowed, tostring(wait)}
+`)
+
+// RedisStore keeps the token buckets in redis, shared by all instances
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a new store in redis
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Take removes a token from the buckets of the keys when all of them have a token
+func (r *RedisStore) Take(ctx context.Context, keys []Key) (bool, time.Duration, error) {
+	var (
+		names  = make([]string, 0, len(keys))
+		limits = make([]interface{}, 0, len(keys)*2)
+	)
+
+	for _, key := range keys {
+		names = append(names, keyPrefix+key.Name)
+		limits = append(limits, key.Limit.Rate, key.Limit.Burst)
+	}
+
+	res, err := takeScript.Run(ctx, r.client, names, limits...).Slice()
+	if err != nil {
+		return false, 0, oops.Err(err)
+	}
+
+	allowed, _ := res[0].(int64)
+	wait, _ := res[1].(string)
+
+	var seconds float64
+	if seconds, err = strconv.ParseFloat(wait, 64); err != nil {
+		return false, 0, oops.Err(err)
+	}
+
+	return allowed == 1, time.Duration(math.Ceil(seconds * float64(time.Second))), nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/isaqueveras/powersso/config"
+)
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.SetTime(time.Now())
+
+	var (
+		ctx   = context.Background()
+		limit = NewLimit(config.RateLimitRule{Requests: 1, Period: 2, Burst: 2})
+		store = NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.Take(ctx, []Key{{Name: "email:login:user@powersso.io", Limit: limit}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+
+	allowed, retryAfter, err := store.Take(ctx, []Key{{Name: "email:login:user@powersso.io", Limit: limit}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if allowed {
+		t.Fatal("request after the burst should be limited")
+	}
+
+	if retryAfter <= time.Second || retryAfter > 2*time.Second {
+		t.Errorf("expected retry after up to 2s, got %v", retryAfter)
+	}
+
+	if ttl := server.TTL(keyPrefix + "email:login:user@powersso.io"); ttl <= 0 {
+		t.Error("the bucket should expire")
+	}
+
+	keys := []Key{{Name: "ip:login:127.0.0.1", Limit: limit}, {Name: "email:login:user@powersso.io", Limit: limit}}
+	if allowed, _, err = store.Take(ctx, keys); err != nil || allowed {
+		t.Fatalf("request should be limited by the exceeded key, got %v", err)
+	}
+
+	if tokens := server.HGet(keyPrefix+"ip:login:127.0.0.1", "tokens"); tokens != "2" {
+		t.Errorf("expected no token to be spent, got %v tokens", tokens)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/isaqueveras/powersso/application/audit"
+	"github.com/isaqueveras/powersso/application/auth"
+	"github.com/isaqueveras/powersso/application/identity"
+	"github.com/isaqueveras/powersso/application/outbox"
+	"github.com/isaqueveras/powersso/application/privacy"
+	"github.com/isaqueveras/powersso/application/project"
+	"github.com/isaqueveras/powersso/application/webhook"
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/ratelimit"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+const (
+	// JobPurgeExpiredSessions ends the sessions that have expired
+	JobPurgeExpiredSessions string = "purge_expired_sessions"
+	// JobClearStaleAttempts removes the failed login attempts that have decayed
+	JobClearStaleAttempts string = "clear_stale_attempts"
+	// JobExpireUserTokens removes the activation and reset tokens that were used or have expired
+	JobExpireUserTokens string = "expire_user_tokens"
+	// JobEndProjectParticipations removes the participants whose departure date has passed
+	JobEndProjectParticipations string = "end_project_participations"
+	// JobChainAuditEvents adds the events recorded in the audit log to its hash chain
+	JobChainAuditEvents string = "chain_audit_events"
+	// JobAuditCheckpoint records a checkpoint of the hash chain of the audit log
+	JobAuditCheckpoint string = "audit_checkpoint"
+	// JobDeliverWebhooks sends the pending deliveries of the webhooks
+	JobDeliverWebhooks string = "deliver_webhooks"
+	// JobRelayOutbox publishes the events written in the outbox
+	JobRelayOutbox string = "relay_outbox"
+	// JobEraseAccounts erases the personal data of the accounts whose deletion grace period has passed
+	JobEraseAccounts string = "erase_accounts"
+	// JobExpireOIDCStates removes the sign ins with external providers that were not finished in time
+	JobExpireOIDCStates string = "expire_oidc_states"
+	// JobPurgeRateLimits removes the rate limit buckets that were refilled to their capacity
+	JobPurgeRateLimits string = "purge_rate_limits"
+)
+
+// Setup creates the scheduler with the maintenance jobs of the configuration
+func Setup(cfg *config.Config, logg *utils.Logger) *Scheduler {
+	interval := func(name string) time.Duration {
+		return time.Duration(cfg.Scheduler.Jobs[name]) * time.Second
+	}
+
+	scheduler := New(logg, NewPostgresLocker(), time.Duration(cfg.Scheduler.Tick)*time.Second)
+	scheduler.Register(&Job{Name: JobPurgeExpiredSessions, Interval: interval(JobPurgeExpiredSessions), Run: auth.PurgeExpiredSessions})
+	scheduler.Register(&Job{Name: JobClearStaleAttempts, Interval: interval(JobClearStaleAttempts), Run: auth.ClearStaleAttempts})
+	scheduler.Register(&Job{Name: JobExpireUserTokens, Interval: interval(JobExpireUserTokens), Run: auth.ExpireTokens})
+	scheduler.Register(&Job{Name: JobEndProjectParticipations, Interval: interval(JobEndProjectParticipations), Run: project.EndParticipations})
+	scheduler.Register(&Job{Name: JobChainAuditEvents, Interval: interval(JobChainAuditEvents), Run: audit.Sequence})
+	scheduler.Register(&Job{Name: JobAuditCheckpoint, Interval: interval(JobAuditCheckpoint), Run: audit.Checkpoint})
+	scheduler.Register(&Job{Name: JobDeliverWebhooks, Interval: interval(JobDeliverWebhooks), Run: webhook.Deliver})
+	scheduler.Register(&Job{Name: JobRelayOutbox, Interval: interval(JobRelayOutbox), Run: outbox.Relay})
+	scheduler.Register(&Job{Name: JobEraseAccounts, Interval: interval(JobEraseAccounts), Run: privacy.Erase})
+	scheduler.Register(&Job{Name: JobExpireOIDCStates, Interval: interval(JobExpireOIDCStates), Run: identity.ExpireStates})
+	scheduler.Register(&Job{Name: JobPurgeRateLimits, Interval: interval(JobPurgeRateLimits), Run: ratelimit.Purge})
+
+	return scheduler
+}
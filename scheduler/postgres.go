@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+
+	database "github.com/isaqueveras/powersso/database/postgres"
+	"github.com/isaqueveras/powersso/oops"
+)
+
+// PostgresLocker keeps the next run of the jobs in the database. The instance that runs a job claims it
+// with a lease, so that no transaction is kept open while the job runs and the job is run again by another
+// instance when the lease expires without being released, as when the instance stops
+type PostgresLocker struct{}
+
+// NewPostgresLocker creates a new locker in the database
+func NewPostgresLocker() *PostgresLocker {
+	return &PostgresLocker{}
+}
+
+// RunLocked runs the job when it is due and it is not claimed by another instance. The job is cancelled
+// when it runs for longer than the lease, which is the interval of the job
+func (p *PostgresLocker) RunLocked(ctx context.Context, job *Job) (ran bool, err error) {
+	if err = p.register(ctx, job); err != nil {
+		return false, oops.Err(err)
+	}
+
+	var claimed bool
+	if claimed, err = p.claim(ctx, job); err != nil || !claimed {
+		return false, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Interval)
+	defer cancel()
+
+	var lastError *string
+	if runErr := job.Run(runCtx); runErr != nil {
+		lastError, err = new(string), runErr
+		*lastError = runErr.Error()
+	}
+
+	// the job is released even when the application is shutting down, otherwise it would only
+	// run again after the lease expires
+	if errRelease := p.release(context.Background(), job, lastError); errRelease != nil {
+		return true, oops.Err(errRelease)
+	}
+
+	return true, err
+}
+
+// claim takes the lease of the job when it is due and the lease of another instance is not valid
+func (p *PostgresLocker) claim(ctx context.Context, job *Job) (claimed bool, err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return false, oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if err = tx.Builder.
+		Update("scheduled_jobs").
+		Set("locked_until", squirrel.Expr("NOW() + (? * INTERVAL '1 second')", int64(job.Interval.Seconds()))).
+		Where("name = ? AND next_run_at <= NOW()", job.Name).
+		Where("(locked_until IS NULL OR locked_until <= NOW())").
+		Suffix("RETURNING name").
+		Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, oops.Err(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, oops.Err(err)
+	}
+
+	return true, nil
+}
+
+// release schedules the next run of the job and ends the lease
+func (p *PostgresLocker) release(ctx context.Context, job *Job, lastError *string) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Builder.
+		Update("scheduled_jobs").
+		Set("last_run_at", squirrel.Expr("NOW()")).
+		Set("next_run_at", squirrel.Expr("NOW() + (? * INTERVAL '1 second')", int64(job.Interval.Seconds()))).
+		Set("last_error", lastError).
+		Set("locked_until", nil).
+		Where("name = ?", job.Name).
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return tx.Commit()
+}
+
+// register adds the row of the job when it does not exist yet
+func (p *PostgresLocker) register(ctx context.Context, job *Job) (err error) {
+	var tx *database.Transaction
+	if tx, err = database.NewTransaction(ctx, false); err != nil {
+		return oops.Err(err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Builder.
+		Insert("scheduled_jobs").
+		Columns("name").
+		Values(job.Name).
+		Suffix("ON CONFLICT (name) DO NOTHING").
+		Exec(); err != nil {
+		return oops.Err(err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// defaultTick is the time between the checks for jobs to run when it is not configured
+const defaultTick = 30 * time.Second
+
+// Job models a task that runs periodically
+type Job struct {
+	// Name identifies the job between the instances
+	Name string
+	// Interval is the time between two runs of the job
+	Interval time.Duration
+	// Run executes the job
+	Run func(ctx context.Context) error
+}
+
+// Locker defines an interface to prevent several instances from running the same job
+type Locker interface {
+	// RunLocked runs the job when it is due and no other instance is running it,
+	// returning false when the job was skipped
+	RunLocked(ctx context.Context, job *Job) (ran bool, err error)
+}
+
+// Scheduler runs the registered jobs periodically
+type Scheduler struct {
+	jobs   []*Job
+	tick   time.Duration
+	locker Locker
+	logg   *utils.Logger
+}
+
+// New creates a new scheduler that checks for jobs to run at each tick
+func New(logg *utils.Logger, locker Locker, tick time.Duration) *Scheduler {
+	if tick <= 0 {
+		tick = defaultTick
+	}
+	return &Scheduler{tick: tick, locker: locker, logg: logg}
+}
+
+// Register adds a job to the scheduler, ignoring jobs without interval
+func (s *Scheduler) Register(job *Job) {
+	if job.Interval <= 0 {
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Run runs the due jobs at each tick until the context is done
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+
+	for {
+		s.runDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue runs the jobs that are due, logging the failures so that one job does not stop the others
+func (s *Scheduler) runDue(ctx context.Context) {
+	for _, job := range s.jobs {
+		started := time.Now()
+		ran, err := s.locker.RunLocked(ctx, job)
+		if err != nil {
+			s.logg.Errorf("Error while running the job %s: %v", job.Name, err)
+			continue
+		}
+
+		if ran {
+			s.logg.Infof("Job %s finished in %v", job.Name, time.Since(started))
+		}
+	}
+}
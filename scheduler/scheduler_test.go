@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Isaque Veras
+// Use of this source code is governed by MIT style
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/isaqueveras/powersso/config"
+	"github.com/isaqueveras/powersso/utils"
+)
+
+// memoryLocker keeps the next run of the jobs in memory, like a single instance
+type memoryLocker struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func (m *memoryLocker) RunLocked(ctx context.Context, job *Job) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if time.Now().Before(m.next[job.Name]) {
+		return false, nil
+	}
+
+	m.next[job.Name] = time.Now().Add(job.Interval)
+	return true, job.Run(ctx)
+}
+
+func TestScheduler(t *testing.T) {
+	logg := utils.NewLogger(&config.Config{})
+	logg.InitLogger()
+
+	var (
+		locker           = &memoryLocker{next: map[string]time.Time{}}
+		scheduler        = New(logg, locker, 10*time.Millisecond)
+		fast, slow, fail int64
+	)
+
+	scheduler.Register(&Job{Name: "fast", Interval: 20 * time.Millisecond, Run: func(context.Context) error {
+		atomic.AddInt64(&fast, 1)
+		return nil
+	}})
+	scheduler.Register(&Job{Name: "slow", Interval: time.Hour, Run: func(context.Context) error {
+		atomic.AddInt64(&slow, 1)
+		return nil
+	}})
+	scheduler.Register(&Job{Name: "fail", Interval: 20 * time.Millisecond, Run: func(context.Context) error {
+		atomic.AddInt64(&fail, 1)
+		return errors.New("failure")
+	}})
+	scheduler.Register(&Job{Name: "disabled", Run: func(context.Context) error {
+		t.Error("expected the job without interval not to run")
+		return nil
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := scheduler.Run(ctx); err != nil {
+		t.Fatalf("expected the scheduler to stop without error, got %v", err)
+	}
+
+	if runs := atomic.LoadInt64(&fast); runs < 2 {
+		t.Errorf("expected the job to run at each interval, got %d runs", runs)
+	}
+
+	if runs := atomic.LoadInt64(&slow); runs != 1 {
+		t.Errorf("expected the job to run once before its interval, got %d runs", runs)
+	}
+
+	if runs := atomic.LoadInt64(&fail); runs < 2 {
+		t.Errorf("expected the failing job to keep running, got %d runs", runs)
+	}
+}
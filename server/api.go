@@ -5,6 +5,8 @@
 package server
 
 import (
+	"context"
+
 	"golang.org/x/sync/errgroup"
 
 	"github.com/isaqueveras/powersso/config"
@@ -18,12 +20,14 @@ const (
 
 // Server struct
 type Server struct {
+	// ctx is cancelled on the shutdown of the application
+	ctx   context.Context
 	cfg   *config.Config
 	logg  *utils.Logger
 	group *errgroup.Group
 }
 
 // NewServer new server constructor
-func NewServer(cfg *config.Config, logg *utils.Logger, group *errgroup.Group) *Server {
-	return &Server{cfg: cfg, logg: logg, group: group}
+func NewServer(ctx context.Context, cfg *config.Config, logg *utils.Logger, group *errgroup.Group) *Server {
+	return &Server{ctx: ctx, cfg: cfg, logg: logg, group: group}
 }
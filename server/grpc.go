@@ -6,9 +6,6 @@ package server
 
 import (
 	"net"
-	"os"
-	"os/signal"
-	"syscall"
 
 	grpcMiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpcRecovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
@@ -16,6 +13,7 @@ import (
 	"google.golang.org/grpc/reflection"
 
 	"github.com/isaqueveras/powersso/delivery/grpc/auth"
+	"github.com/isaqueveras/powersso/delivery/grpc/relation"
 	"github.com/isaqueveras/powersso/middleware"
 	"github.com/isaqueveras/powersso/oops"
 	"github.com/isaqueveras/powersso/utils"
@@ -33,6 +31,7 @@ func (s *Server) ServerGRPC() (err error) {
 			gogrpc.UnaryInterceptor(
 				grpcMiddleware.ChainUnaryServer(
 					middleware.GRPCZap(),
+					middleware.RateLimitGRPC(),
 					grpcRecovery.UnaryServerInterceptor(
 						grpcRecovery.WithRecoveryHandler(utils.PanicRecovery),
 					),
@@ -48,12 +47,8 @@ func (s *Server) ServerGRPC() (err error) {
 	}
 
 	go func(server *gogrpc.Server) {
-		signalOS := make(chan os.Signal, 1)
-		signal.Notify(signalOS, syscall.SIGINT, syscall.SIGTERM)
-		for range signalOS {
-			server.GracefulStop()
-			return
-		}
+		<-s.ctx.Done()
+		server.GracefulStop()
 	}(serverGRPC)
 
 	// TODO: use address in config file
@@ -62,6 +57,7 @@ func (s *Server) ServerGRPC() (err error) {
 	}
 
 	auth.RegisterAuthenticationServer(serverGRPC, &auth.Server{})
+	relation.RegisterRelationServer(serverGRPC, &relation.Server{})
 
 	s.group.Go(func() error {
 		return serverGRPC.Serve(listen)
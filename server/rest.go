@@ -12,8 +12,18 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/isaqueveras/endless"
 
+	"github.com/isaqueveras/powersso/delivery/http/audit"
 	"github.com/isaqueveras/powersso/delivery/http/auth"
+	"github.com/isaqueveras/powersso/delivery/http/health"
+	"github.com/isaqueveras/powersso/delivery/http/identity"
+	"github.com/isaqueveras/powersso/delivery/http/invitation"
+	"github.com/isaqueveras/powersso/delivery/http/me"
+	"github.com/isaqueveras/powersso/delivery/http/policy"
 	"github.com/isaqueveras/powersso/delivery/http/project"
+	"github.com/isaqueveras/powersso/delivery/http/relation"
+	"github.com/isaqueveras/powersso/delivery/http/role"
+	"github.com/isaqueveras/powersso/delivery/http/scim"
+	"github.com/isaqueveras/powersso/delivery/http/webhook"
 	"github.com/isaqueveras/powersso/middleware"
 )
 
@@ -32,15 +42,31 @@ func (s *Server) ServerHTTP() (err error) {
 		middleware.CORS(),
 		middleware.VersionInfo(),
 		middleware.RequestIdentifier(),
+		middleware.RequestMetadata(),
 		middleware.RecoveryWithZap(s.logg.ZapLogger(), true),
 		middleware.GinZap(s.logg.ZapLogger(), *s.cfg),
 		middleware.SetupI18n(),
 	)
 
+	health.Router(router.Group("health"))
+
 	v1 := router.Group("v1")
 	auth.Router(v1.Group("auth"))
 	auth.RouterAuthorization(v1.Group("auth", middleware.Auth()))
+	auth.RouterChangePassword(v1.Group("auth", middleware.AuthChangePassword()))
+	identity.Router(v1.Group("auth/oidc"))
+	me.Router(v1.Group("me"))
+	me.RouterAuthorization(v1.Group("me", middleware.Auth()))
+	invitation.Router(v1.Group("invitation"))
+	invitation.RouterAuthorization(v1.Group("invitation", middleware.Auth()))
 	project.RouterAuthorization(v1.Group("project", middleware.Auth()))
+	webhook.RouterAuthorization(v1.Group("project/:project_id/webhooks", middleware.Auth()))
+	audit.RouterAuthorization(v1.Group("audit", middleware.Auth()))
+	role.RouterAuthorization(v1.Group("role", middleware.Auth()))
+	relation.RouterAuthorization(v1.Group("relation", middleware.Auth()))
+	policy.RouterAuthorization(v1.Group("policy", middleware.Auth()))
+
+	scim.RouterAuthorization(router.Group("scim/v2", middleware.Auth(), middleware.RequirePermission("scim:provision")))
 
 	endless.DefaultReadTimeOut = s.cfg.Server.ReadTimeout * time.Second
 	endless.DefaultWriteTimeOut = s.cfg.Server.WriteTimeout * time.Second
@@ -60,7 +86,7 @@ func (s *Server) ServerHTTP() (err error) {
 
 func (s *Server) routerDebugPProf(router *gin.Engine) {
 	r := router.Group("debug/pprof")
-	r.Use(middleware.Auth(), middleware.OnlyAdmin())
+	r.Use(middleware.Auth(), middleware.RequirePermission("debug:pprof"))
 	r.GET("/", func(c *gin.Context) { pprof.Index(c.Writer, c.Request) })
 	r.GET("/cmdline", func(c *gin.Context) { pprof.Cmdline(c.Writer, c.Request) })
 	r.GET("/profile", func(c *gin.Context) { pprof.Profile(c.Writer, c.Request) })
@@ -12,6 +12,14 @@ import (
 	"github.com/isaqueveras/powersso/utils"
 )
 
+const (
+	// ScopeChangePassword is the scope of the tokens that can only be used to change the password
+	ScopeChangePassword = "change_password"
+	// ScopeOTP is the scope of the tokens that can only be used to inform the code of the 2FA
+	// after signing in with a provider
+	ScopeOTP = "otp"
+)
+
 // NewAuthToken generates and returns a new authentication token
 func NewAuthToken(user *auth.User, sessionID *uuid.UUID) (*string, error) {
 	claims := jwt.MapClaims{
@@ -21,6 +29,38 @@ func NewAuthToken(user *auth.User, sessionID *uuid.UUID) (*string, error) {
 		"FirstName": user.FirstName,
 	}
 
-	token, err := NewToken(claims, user.GetUserLevel(&config.Get().SecretsTokens), config.Get().SecretsDuration)
+	cfg := config.Get()
+	duration := cfg.GetSessionPolicy(string(*user.Level)).MaxLifetime
+
+	token, err := NewToken(claims, user.GetUserLevel(&cfg.SecretsTokens), duration)
+	return utils.Pointer(token), err
+}
+
+// NewChangePasswordToken generates and returns a token that only allows the user to change the password
+func NewChangePasswordToken(user *auth.User) (*string, error) {
+	claims := jwt.MapClaims{
+		"UserID":    user.ID,
+		"UserLevel": user.Level,
+		"FirstName": user.FirstName,
+		"Scope":     ScopeChangePassword,
+	}
+
+	cfg := config.Get()
+	token, err := NewToken(claims, user.GetUserLevel(&cfg.SecretsTokens), cfg.PasswordPolicy.ChangeTokenDuration)
+	return utils.Pointer(token), err
+}
+
+// NewOTPToken generates and returns a token that only allows the user authenticated by a provider to
+// inform the code of the 2FA, valid for the time the user has to sign in with the provider
+func NewOTPToken(user *auth.User) (*string, error) {
+	claims := jwt.MapClaims{
+		"UserID":    user.ID,
+		"UserLevel": user.Level,
+		"FirstName": user.FirstName,
+		"Scope":     ScopeOTP,
+	}
+
+	cfg := config.Get()
+	token, err := NewToken(claims, user.GetUserLevel(&cfg.SecretsTokens), cfg.OIDC.StateDuration)
 	return utils.Pointer(token), err
 }